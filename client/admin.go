@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// QuoteViews — цитата с числом показов за окно отчёта.
+type QuoteViews struct {
+	Quote
+	Views int64 `json:"views"`
+}
+
+// AuthorViews — автор с суммарным числом показов его цитат.
+type AuthorViews struct {
+	Author string `json:"author"`
+	Views  int64  `json:"views"`
+}
+
+// HourlyRequests — число запросов за час, всего и по маршрутам.
+type HourlyRequests struct {
+	Hour   time.Time        `json:"hour"`
+	Total  int64            `json:"total"`
+	Routes map[string]int64 `json:"routes"`
+}
+
+// Analytics — сводка показов за окно: популярные цитаты и авторы, объём запросов по часам
+// и цитаты, которые не показывались ни разу.
+type Analytics struct {
+	From        time.Time        `json:"from"`
+	To          time.Time        `json:"to"`
+	TopQuotes   []QuoteViews     `json:"top_quotes"`
+	TopAuthors  []AuthorViews    `json:"top_authors"`
+	Requests    []HourlyRequests `json:"requests"`
+	NeverServed []Quote          `json:"never_served"`
+}
+
+// AnalyticsOptions задаёт отчёт о показах. Нулевые значения — значения сервера: 7 дней и 10 записей.
+type AnalyticsOptions struct {
+	Window time.Duration // окно отчёта
+	Limit  int           // размер топов от 1 до 100
+}
+
+// Analytics возвращает отчёт о показах. Требует области admin.
+func (c *Client) Analytics(ctx context.Context, opts AnalyticsOptions) (Analytics, error) {
+	var report Analytics
+	err := c.do(ctx, get("/admin/analytics", windowQuery(opts.Window, opts.Limit), &report))
+	return report, err
+}
+
+// SetScore задаёт оценку редактора, определяющую вес цитаты при взвешенном выборе. Требует области admin.
+func (c *Client) SetScore(ctx context.Context, id, score int) error {
+	return c.do(ctx, put("/admin/quotes/"+strconv.Itoa(id)+"/score", map[string]int{"score": score}, nil))
+}
+
+// PinDaily закрепляет цитату id за датой date в формате YYYY-MM-DD. Требует области admin.
+func (c *Client) PinDaily(ctx context.Context, date string, id int) error {
+	return c.do(ctx, put("/admin/daily/"+date, map[string]int{"id": id}, nil))
+}
+
+// UnpinDaily снимает закрепление с даты и возвращает автоматический выбор. Требует области admin.
+func (c *Client) UnpinDaily(ctx context.Context, date string) error {
+	return c.do(ctx, del("/admin/daily/"+date, nil))
+}
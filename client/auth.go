@@ -0,0 +1,35 @@
+package client
+
+import "net/http"
+
+// Authenticator добавляет учётные данные к запросу перед каждой попыткой, в том числе повторной.
+type Authenticator interface {
+	Authorize(req *http.Request) error
+}
+
+// AuthFunc позволяет использовать функцию как Authenticator.
+type AuthFunc func(req *http.Request) error
+
+// Authorize вызывает f(req).
+func (f AuthFunc) Authorize(req *http.Request) error { return f(req) }
+
+// APIKey передаёт ключ API или JWT в заголовке Authorization: Bearer.
+func APIKey(key string) Authenticator {
+	return AuthFunc(func(req *http.Request) error {
+		req.Header.Set("Authorization", "Bearer "+key)
+		return nil
+	})
+}
+
+// BearerToken получает токен у source перед каждым запросом, например чтобы обновлять истекающие JWT.
+// Ошибка source прерывает запрос без обращения к сервису.
+func BearerToken(source func(req *http.Request) (string, error)) Authenticator {
+	return AuthFunc(func(req *http.Request) error {
+		token, err := source(req)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	})
+}
@@ -0,0 +1,163 @@
+// Package client — типизированный клиент HTTP API сервиса цитат для Go-программ и утилиты quotes.
+//
+// Методы клиента повторяют эндпоинты сервиса, принимают context.Context и возвращают ошибки сервера
+// как *Error, которые сравниваются с ErrNotFound, ErrQuotaExceeded и другими через errors.Is.
+// Идемпотентные запросы повторяются с экспоненциальной паузой при сетевых ошибках и ответах
+// 429, 502, 503 и 504, см. RetryPolicy.
+//
+//	c, err := client.New("https://quotes.example.com", client.Options{Auth: client.APIKey(key)})
+//	for q, err := range c.All(ctx, client.ListOptions{Author: "Чехов"}) { ... }
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Options задаёт необязательные параметры клиента.
+type Options struct {
+	Auth       Authenticator // учётные данные запросов, например APIKey; nil — без авторизации
+	Workspace  string        // рабочее пространство; запросы идут с префиксом /w/{workspace}
+	HTTPClient *http.Client  // по умолчанию http.DefaultClient; для mTLS задайте свой Transport
+	UserAgent  string        // по умолчанию quotes-client
+	Retry      RetryPolicy   // повторы идемпотентных запросов; нулевое значение — DefaultRetryPolicy
+}
+
+// Client выполняет запросы к API одного развёртывания сервиса. Безопасен для параллельного использования.
+type Client struct {
+	base *url.URL
+	opts Options
+}
+
+// New создаёт клиента для сервиса с адресом baseURL, например https://quotes.example.com.
+func New(baseURL string, opts Options) (*Client, error) {
+	base, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, err
+	}
+	if base.Scheme != "http" && base.Scheme != "https" || base.Host == "" {
+		return nil, fmt.Errorf("адрес сервиса должен быть http(s)://хост, получено %q", baseURL)
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+	if opts.UserAgent == "" {
+		opts.UserAgent = "quotes-client"
+	}
+	if opts.Retry == (RetryPolicy{}) {
+		opts.Retry = DefaultRetryPolicy
+	}
+	return &Client{base: base, opts: opts}, nil
+}
+
+// call описывает один вызов API.
+type call struct {
+	method string
+	path   string // путь без префикса пространства
+	query  url.Values
+	in     any  // тело запроса в JSON; nil — без тела
+	out    any  // куда декодировать JSON-ответ; nil — ответ не читается
+	retry  bool // вызов идемпотентен и его можно повторить
+}
+
+// get, put и del — идемпотентные вызовы; post повторяется только с retry.
+func get(path string, query url.Values, out any) call {
+	return call{method: http.MethodGet, path: path, query: query, out: out, retry: true}
+}
+
+func put(path string, in, out any) call {
+	return call{method: http.MethodPut, path: path, in: in, out: out, retry: true}
+}
+
+func del(path string, out any) call {
+	return call{method: http.MethodDelete, path: path, out: out, retry: true}
+}
+
+// do выполняет вызов, повторяя его по политике c.opts.Retry, если он идемпотентен.
+func (c *Client) do(ctx context.Context, cl call) error {
+	var body []byte
+	if cl.in != nil {
+		var err error
+		if body, err = json.Marshal(cl.in); err != nil {
+			return err
+		}
+	}
+	for attempt := 1; ; attempt++ {
+		err := c.once(ctx, cl, body)
+		if err == nil || !cl.retry || attempt >= c.opts.Retry.MaxAttempts || ctx.Err() != nil {
+			return err
+		}
+		wait, ok := c.opts.Retry.backoff(attempt, err)
+		if !ok {
+			return err
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// once выполняет одну попытку вызова. Ответ со статусом 4xx или 5xx возвращается как *Error.
+func (c *Client) once(ctx context.Context, cl call, body []byte) error {
+	u := *c.base
+	path := cl.path
+	if c.opts.Workspace != "" {
+		path = "/w/" + url.PathEscape(c.opts.Workspace) + path
+	}
+	u.Path += path
+	u.RawQuery = cl.query.Encode()
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, cl.method, u.String(), r)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.opts.UserAgent)
+	if c.opts.Auth != nil {
+		if err := c.opts.Auth.Authorize(req); err != nil {
+			return fmt.Errorf("авторизация запроса: %w", err)
+		}
+	}
+	resp, err := c.opts.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return newError(resp)
+	}
+	if cl.out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(cl.out); err != nil {
+		return fmt.Errorf("разбор ответа %s %s: %w", cl.method, path, err)
+	}
+	return nil
+}
+
+// StatusCode возвращает HTTP-статус ошибки сервера или 0, если err не *Error.
+func StatusCode(err error) int {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.StatusCode
+	}
+	return 0
+}
@@ -0,0 +1,420 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"Test_Project_Brand_Scout/internal/auth"
+	"Test_Project_Brand_Scout/internal/features"
+	"Test_Project_Brand_Scout/internal/quotes"
+)
+
+// testServer — настоящий маршрутизатор сервиса с хранилищем в памяти.
+type testServer struct {
+	*httptest.Server
+	keys     *auth.KeyManager
+	requests atomic.Int32 // число запросов к маршрутизатору
+}
+
+// newServer запускает маршрутизатор. При withAuth запросы требуют ключей API из keys.
+// wrap, если задан, оборачивает маршрутизатор, например чтобы имитировать сбои.
+func newServer(t *testing.T, withAuth bool, wrap func(http.Handler) http.Handler, opts ...quotes.Option) *testServer {
+	t.Helper()
+	repo := quotes.NewMemoryRepository()
+	s := &testServer{keys: auth.NewKeyManager(repo.(auth.KeyStore))}
+	var authn auth.Authenticator
+	if withAuth {
+		authn = s.keys
+	}
+	r := mux.NewRouter()
+	quotes.RegisterHandlers(r, quotes.NewService(repo, opts...), authn)
+	var h http.Handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		s.requests.Add(1)
+		r.ServeHTTP(w, req)
+	})
+	if wrap != nil {
+		h = wrap(h)
+	}
+	s.Server = httptest.NewServer(h)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// token выдаёт ключ API с областями scopes.
+func (s *testServer) token(t *testing.T, scopes ...string) string {
+	t.Helper()
+	_, token, err := s.keys.Issue("test", "", scopes)
+	if err != nil {
+		t.Fatalf("ошибка выдачи ключа: %v", err)
+	}
+	return token
+}
+
+func newClient(t *testing.T, url string, opts Options) *Client {
+	t.Helper()
+	c, err := New(url, opts)
+	if err != nil {
+		t.Fatalf("ошибка создания клиента: %v", err)
+	}
+	return c
+}
+
+// TestClientQuotes проверяет создание, получение, списки, случайную цитату и удаление
+func TestClientQuotes(t *testing.T) {
+	srv := newServer(t, false, nil)
+	c := newClient(t, srv.URL+"/", Options{})
+	ctx := context.Background()
+
+	if _, err := c.Random(ctx, RandomOptions{}); !errors.Is(err, ErrEmpty) || errors.Is(err, ErrNotFound) {
+		t.Errorf("в пустом хранилище ожидается ErrEmpty, получено %v", err)
+	}
+	id1, err := c.Create(ctx, "Толстой", "первая")
+	if err != nil || id1 != 1 {
+		t.Fatalf("ожидается id=1, получено %d, %v", id1, err)
+	}
+	id2, _ := c.Create(ctx, "Чехов", "вторая")
+	if _, err := c.Create(ctx, "", "без автора"); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("ожидается ErrInvalidInput для пустого автора, получено %v", err)
+	}
+
+	q, err := c.Get(ctx, id2)
+	if err != nil || q.Author != "Чехов" || q.Text != "вторая" {
+		t.Errorf("неожиданная цитата: %+v, %v", q, err)
+	}
+	if list, err := c.List(ctx, ListOptions{}); err != nil || len(list) != 2 {
+		t.Errorf("ожидается 2 цитаты, получено %v, %v", list, err)
+	}
+	if list, err := c.List(ctx, ListOptions{After: id1}); err != nil || len(list) != 1 || list[0].ID != id2 {
+		t.Errorf("ожидается только цитата %d, получено %v, %v", id2, list, err)
+	}
+	if list, err := c.List(ctx, ListOptions{Author: "Толстой"}); err != nil || len(list) != 1 || list[0].ID != id1 {
+		t.Errorf("ожидается цитата Толстого, получено %v, %v", list, err)
+	}
+	seed := int64(42)
+	a, _ := c.Random(ctx, RandomOptions{Seed: &seed})
+	b, _ := c.Random(ctx, RandomOptions{Seed: &seed, Weighted: true})
+	if a.ID == 0 || b.ID == 0 {
+		t.Errorf("ожидаются случайные цитаты, получено %d и %d", a.ID, b.ID)
+	}
+
+	if err := c.Delete(ctx, id1); err != nil {
+		t.Fatalf("ошибка удаления: %v", err)
+	}
+	_, err = c.Get(ctx, id1)
+	var apiErr *Error
+	if !errors.Is(err, ErrNotFound) || !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("ожидается *Error 404, совпадающая с ErrNotFound, получено %v", err)
+	}
+}
+
+// TestClientReactionsAdmin проверяет лайки, оценки, цитату дня, популярные цитаты и административные вызовы
+func TestClientReactionsAdmin(t *testing.T) {
+	srv := newServer(t, true, nil)
+	ctx := context.Background()
+	admin := newClient(t, srv.URL, Options{Auth: APIKey(srv.token(t, auth.ScopeRead, auth.ScopeWrite, auth.ScopeAdmin))})
+	reader := newClient(t, srv.URL, Options{Auth: APIKey(srv.token(t, auth.ScopeRead))})
+	id, _ := admin.Create(ctx, "A", "первая")
+	admin.Create(ctx, "B", "вторая")
+
+	if q, err := reader.Like(ctx, id); err != nil || q.Likes != 1 {
+		t.Errorf("ожидается 1 лайк, получено %+v, %v", q, err)
+	}
+	if q, _ := reader.Like(ctx, id); q.Likes != 1 {
+		t.Errorf("повторный лайк не должен учитываться: %d", q.Likes)
+	}
+	if q, err := reader.Rate(ctx, id, 4); err != nil || q.Ratings != 1 || q.Rating != 4 {
+		t.Errorf("ожидается оценка 4, получено %+v, %v", q, err)
+	}
+	if _, err := reader.Rate(ctx, id, 6); !errors.Is(err, ErrInvalidRating) {
+		t.Errorf("ожидается ErrInvalidRating, получено %v", err)
+	}
+	if popular, err := reader.Popular(ctx, PopularOptions{Window: 24 * time.Hour, Limit: 1}); err != nil || len(popular) != 1 || popular[0].ID != id || popular[0].Popularity <= 0 {
+		t.Errorf("ожидается популярная цитата %d, получено %+v, %v", id, popular, err)
+	}
+	if q, err := reader.Unlike(ctx, id); err != nil || q.Likes != 0 {
+		t.Errorf("ожидается снятый лайк, получено %+v, %v", q, err)
+	}
+
+	if err := reader.SetScore(ctx, id, 5); !errors.Is(err, ErrForbidden) {
+		t.Errorf("без области admin ожидается ErrForbidden, получено %v", err)
+	}
+	if err := admin.SetScore(ctx, id, -1); !errors.Is(err, ErrInvalidScore) {
+		t.Errorf("ожидается ErrInvalidScore, получено %v", err)
+	}
+	if err := admin.SetScore(ctx, id, 5); err != nil {
+		t.Errorf("ошибка установки оценки: %v", err)
+	}
+	if q, _ := reader.Get(ctx, id); q.Score != 5 {
+		t.Errorf("ожидается оценка редактора 5, получено %d", q.Score)
+	}
+
+	loc, _ := time.LoadLocation("Europe/Moscow")
+	today := time.Now().In(loc).Format("2006-01-02")
+	if err := admin.PinDaily(ctx, today, id); err != nil {
+		t.Fatalf("ошибка закрепления: %v", err)
+	}
+	if d, err := reader.Daily(ctx, loc); err != nil || !d.Pinned || d.ID != id || d.Date != today {
+		t.Errorf("ожидается закреплённая цитата %d на %s, получено %+v, %v", id, today, d, err)
+	}
+	if err := admin.PinDaily(ctx, "вчера", id); !errors.Is(err, ErrInvalidDate) {
+		t.Errorf("ожидается ErrInvalidDate, получено %v", err)
+	}
+	if err := admin.UnpinDaily(ctx, today); err != nil {
+		t.Errorf("ошибка снятия закрепления: %v", err)
+	}
+	if d, err := reader.Daily(ctx, nil); err != nil || d.Pinned {
+		t.Errorf("ожидается автоматическая цитата дня, получено %+v, %v", d, err)
+	}
+
+	report, err := admin.Analytics(ctx, AnalyticsOptions{Window: time.Hour, Limit: 5})
+	if err != nil || len(report.TopQuotes) == 0 || report.TopQuotes[0].Views == 0 || len(report.Requests) == 0 {
+		t.Errorf("ожидается отчёт с показами и запросами, получено %+v, %v", report, err)
+	}
+}
+
+// TestClientAuth проверяет ключи API, источник токенов, рабочие пространства и квоты
+func TestClientAuth(t *testing.T) {
+	srv := newServer(t, true, nil, quotes.WithQuotas(0, map[string]int{"small": 1}))
+	ctx := context.Background()
+	token := srv.token(t, auth.ScopeRead, auth.ScopeWrite)
+
+	if _, err := newClient(t, srv.URL, Options{}).List(ctx, ListOptions{}); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("без ключа ожидается ErrUnauthorized, получено %v", err)
+	}
+	reader := newClient(t, srv.URL, Options{Auth: APIKey(srv.token(t, auth.ScopeRead))})
+	if _, err := reader.Create(ctx, "A", "q"); !errors.Is(err, ErrForbidden) || errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("ключу только для чтения ожидается ErrForbidden, получено %v", err)
+	}
+
+	var calls atomic.Int32
+	team := newClient(t, srv.URL, Options{Workspace: "team-a", Auth: BearerToken(func(*http.Request) (string, error) {
+		calls.Add(1)
+		return token, nil
+	})})
+	id, err := team.Create(ctx, "A", "q")
+	if err != nil {
+		t.Fatalf("ошибка создания в пространстве: %v", err)
+	}
+	if q, err := team.Get(ctx, id); err != nil || q.Tenant != "team-a" || calls.Load() != 2 {
+		t.Errorf("ожидается цитата пространства team-a и токен на каждый запрос, получено %+v, %v, %d", q, err, calls.Load())
+	}
+	if list, _ := newClient(t, srv.URL, Options{Auth: APIKey(token)}).List(ctx, ListOptions{}); len(list) != 0 {
+		t.Errorf("цитата пространства не должна попасть в пространство по умолчанию: %v", list)
+	}
+
+	failing := newClient(t, srv.URL, Options{Auth: BearerToken(func(*http.Request) (string, error) {
+		return "", errors.New("нет токена")
+	})})
+	before := srv.requests.Load()
+	if _, err := failing.Get(ctx, id); err == nil || srv.requests.Load() != before {
+		t.Errorf("ошибка источника токенов должна прерывать запрос до отправки: %v", err)
+	}
+
+	small := newClient(t, srv.URL, Options{Auth: APIKey(token), Workspace: "small"})
+	small.Create(ctx, "A", "1")
+	if _, err := small.Create(ctx, "A", "2"); !errors.Is(err, ErrQuotaExceeded) || errors.Is(err, ErrForbidden) {
+		t.Errorf("ожидается ErrQuotaExceeded, получено %v", err)
+	}
+	if _, err := newClient(t, srv.URL, Options{Auth: APIKey(token), Workspace: "Team A"}).List(ctx, ListOptions{}); !errors.Is(err, ErrInvalidTenant) {
+		t.Errorf("ожидается ErrInvalidTenant, получено %v", err)
+	}
+}
+
+// TestClientPagination проверяет постраничный обход и досрочную остановку итератора
+func TestClientPagination(t *testing.T) {
+	srv := newServer(t, false, nil)
+	c := newClient(t, srv.URL, Options{})
+	ctx := context.Background()
+	for i := 1; i <= 25; i++ {
+		author := "A"
+		if i%5 == 0 {
+			author = "B"
+		}
+		c.Create(ctx, author, fmt.Sprintf("цитата %d", i))
+	}
+
+	before := srv.requests.Load()
+	var ids []int
+	for q, err := range c.All(ctx, ListOptions{Limit: 10}) {
+		if err != nil {
+			t.Fatalf("ошибка обхода: %v", err)
+		}
+		ids = append(ids, q.ID)
+	}
+	if len(ids) != 25 || ids[0] != 1 || ids[24] != 25 || srv.requests.Load()-before != 3 {
+		t.Errorf("ожидается 25 цитат по порядку за 3 запроса, получено %v за %d", ids, srv.requests.Load()-before)
+	}
+
+	var authors []int
+	for q, err := range c.All(ctx, ListOptions{Author: "B", Limit: 2}) {
+		if err != nil || q.Author != "B" {
+			t.Fatalf("неожиданная цитата %+v, %v", q, err)
+		}
+		authors = append(authors, q.ID)
+	}
+	if fmt.Sprint(authors) != "[5 10 15 20 25]" {
+		t.Errorf("ожидаются все цитаты автора B, получено %v", authors)
+	}
+
+	before = srv.requests.Load()
+	n := 0
+	for range c.All(ctx, ListOptions{Limit: 10}) {
+		if n++; n == 3 {
+			break
+		}
+	}
+	if srv.requests.Load()-before != 1 {
+		t.Errorf("после break не должно быть запросов следующих страниц, получено %d", srv.requests.Load()-before)
+	}
+
+	for _, err := range c.All(ctx, ListOptions{Limit: 5000}) {
+		if !errors.Is(err, &Error{StatusCode: http.StatusBadRequest}) {
+			t.Errorf("ожидается ошибка 400 для слишком большой страницы, получено %v", err)
+		}
+	}
+}
+
+// TestClientRetry проверяет повторы идемпотентных запросов и отказ от повторов
+func TestClientRetry(t *testing.T) {
+	var failures atomic.Int32
+	flaky := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if failures.Add(-1) >= 0 {
+				http.Error(w, "перегрузка", http.StatusServiceUnavailable)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	srv := newServer(t, false, flaky)
+	fast := RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+	c := newClient(t, srv.URL, Options{Retry: fast})
+	ctx := context.Background()
+
+	failures.Store(1)
+	if _, err := c.Create(ctx, "A", "q"); !errors.Is(err, &Error{StatusCode: http.StatusServiceUnavailable}) {
+		t.Fatalf("создание не должно повторяться, получено %v", err)
+	}
+	id, _ := c.Create(ctx, "A", "q")
+
+	failures.Store(2)
+	if q, err := c.Get(ctx, id); err != nil || q.ID != id || srv.requests.Load() != 2 {
+		t.Errorf("ожидается успех с третьей попытки, получено %+v, %v", q, err)
+	}
+	failures.Store(3)
+	if _, err := c.Get(ctx, id); StatusCode(err) != http.StatusServiceUnavailable || failures.Load() != 0 {
+		t.Errorf("после 3 попыток ожидается 503, получено %v", err)
+	}
+	failures.Store(0)
+
+	once := newClient(t, srv.URL, Options{Retry: RetryPolicy{MaxAttempts: 1}})
+	failures.Store(1)
+	if _, err := once.Get(ctx, id); StatusCode(err) != http.StatusServiceUnavailable {
+		t.Errorf("с MaxAttempts 1 повторов быть не должно, получено %v", err)
+	}
+	failures.Store(0)
+
+	down := newClient(t, "http://127.0.0.1:1", Options{Retry: fast})
+	start := time.Now()
+	if _, err := down.Get(ctx, id); StatusCode(err) != 0 || time.Since(start) > time.Second {
+		t.Errorf("ожидается сетевая ошибка после быстрых повторов, получено %v за %v", err, time.Since(start))
+	}
+
+	slow := newClient(t, srv.URL, Options{Retry: RetryPolicy{MaxAttempts: 3, MinBackoff: time.Hour, MaxBackoff: time.Hour}})
+	failures.Store(1)
+	ctx2, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := slow.Get(ctx2, id); StatusCode(err) != http.StatusServiceUnavailable {
+		t.Errorf("отмена контекста во время паузы должна вернуть последнюю ошибку, получено %v", err)
+	}
+}
+
+// TestClientReadOnly проверяет, что ответ режима только для чтения с долгим Retry-After не повторяется
+func TestClientReadOnly(t *testing.T) {
+	flags := features.New([]string{features.ReadOnly})
+	srv := newServer(t, false, flags.Middleware)
+	c := newClient(t, srv.URL, Options{})
+	ctx := context.Background()
+	start := time.Now()
+	_, err := c.Rate(ctx, 1, 5)
+	var apiErr *Error
+	if !errors.Is(err, ErrReadOnly) || !errors.As(err, &apiErr) || apiErr.RetryAfter != time.Minute || time.Since(start) > time.Second {
+		t.Errorf("ожидается ErrReadOnly с Retry-After 60s без повторов, получено %v", err)
+	}
+	if _, err := c.List(ctx, ListOptions{}); err != nil {
+		t.Errorf("чтение должно работать в режиме только для чтения: %v", err)
+	}
+}
+
+// TestClientWatch проверяет, что Watch выдаёт только новые цитаты в порядке добавления
+func TestClientWatch(t *testing.T) {
+	srv := newServer(t, false, nil)
+	c := newClient(t, srv.URL, Options{})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	old, _ := c.Create(ctx, "A", "старая")
+
+	got := make(chan Quote)
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Watch(ctx, old, 10*time.Millisecond, func(q Quote) error {
+			got <- q
+			return nil
+		})
+	}()
+	for _, text := range []string{"новая 1", "новая 2"} {
+		c.Create(ctx, "B", text)
+		select {
+		case q := <-got:
+			if q.Text != text {
+				t.Errorf("ожидается %q, получено %q", text, q.Text)
+			}
+		case <-ctx.Done():
+			t.Fatal("новая цитата не получена")
+		}
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("после отмены ожидается context.Canceled, получено %v", err)
+	}
+}
+
+// TestErrorsMatchServer проверяет, что тексты ошибок клиента совпадают с ошибками сервера
+func TestErrorsMatchServer(t *testing.T) {
+	cases := []struct {
+		client *Error
+		server error
+	}{
+		{ErrInvalidInput, quotes.ErrInvalidInput},
+		{ErrInvalidScore, quotes.ErrInvalidScore},
+		{ErrInvalidRating, quotes.ErrInvalidRating},
+		{ErrInvalidDate, quotes.ErrInvalidDate},
+		{ErrInvalidTenant, quotes.ErrInvalidTenant},
+		{ErrForbidden, auth.ErrForbidden},
+		{ErrQuotaExceeded, quotes.ErrQuotaExceeded},
+		{ErrNotFound, quotes.ErrNotFound},
+		{ErrEmpty, quotes.ErrEmpty},
+	}
+	for _, c := range cases {
+		if c.client.Message != c.server.Error() {
+			t.Errorf("текст ошибки клиента %q не совпадает с сервером %q", c.client.Message, c.server.Error())
+		}
+	}
+}
+
+// TestNew проверяет отказ для некорректного адреса сервиса
+func TestNew(t *testing.T) {
+	for _, url := range []string{"", "localhost:8080", "ftp://host", "http://"} {
+		if _, err := New(url, Options{}); err == nil {
+			t.Errorf("ожидается ошибка для адреса %q", url)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Error — ответ сервера со статусом ошибки.
+type Error struct {
+	StatusCode int
+	Message    string        // текст ошибки из тела ответа
+	RequestID  string        // X-Request-ID ответа для поиска запроса в логах сервера
+	RetryAfter time.Duration // пауза из заголовка Retry-After, если сервер её назвал
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// Is сопоставляет ошибку с ошибками сервера ErrNotFound, ErrQuotaExceeded и т.п.: статус должен
+// совпадать, а текст — если он задан у target.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.StatusCode == e.StatusCode && (t.Message == "" || t.Message == e.Message)
+}
+
+// Ошибки сервера. Сервер сообщает об ошибке статусом и текстом, поэтому ответ сопоставляется
+// с ними по обоим: errors.Is(err, client.ErrEmpty) отличает пустое хранилище от ненайденной цитаты.
+var (
+	ErrInvalidInput  = &Error{StatusCode: http.StatusBadRequest, Message: "Поля не должны быть пустыми (Автор и цитата)"}
+	ErrInvalidScore  = &Error{StatusCode: http.StatusBadRequest, Message: "оценка не может быть отрицательной"}
+	ErrInvalidRating = &Error{StatusCode: http.StatusBadRequest, Message: "оценка должна быть от 1 до 5"}
+	ErrInvalidDate   = &Error{StatusCode: http.StatusBadRequest, Message: "дата должна быть в формате YYYY-MM-DD"}
+	ErrInvalidTenant = &Error{StatusCode: http.StatusBadRequest, Message: "некорректное имя рабочего пространства: допустимы a-z, 0-9, - и _, до 64 символов"}
+	ErrUnauthorized  = &Error{StatusCode: http.StatusUnauthorized} // нет ключа или он недействителен
+	ErrForbidden     = &Error{StatusCode: http.StatusForbidden, Message: "недостаточно прав"}
+	ErrQuotaExceeded = &Error{StatusCode: http.StatusForbidden, Message: "превышена квота цитат рабочего пространства"}
+	ErrNotFound      = &Error{StatusCode: http.StatusNotFound, Message: "нет такой цитаты"}
+	ErrEmpty         = &Error{StatusCode: http.StatusNotFound, Message: "нету доступных цитат"}
+	ErrRateLimited   = &Error{StatusCode: http.StatusTooManyRequests} // превышено ограничение запросов
+	ErrReadOnly      = &Error{StatusCode: http.StatusServiceUnavailable, Message: "сервис временно работает только на чтение"}
+)
+
+// newError читает ответ с ошибкой.
+func newError(resp *http.Response) *Error {
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	e := &Error{
+		StatusCode: resp.StatusCode,
+		Message:    strings.TrimSpace(string(msg)),
+		RequestID:  resp.Header.Get("X-Request-ID"),
+	}
+	if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && s >= 0 {
+		e.RetryAfter = time.Duration(s) * time.Second
+	}
+	return e
+}
@@ -0,0 +1,221 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Quote — цитата в ответах API.
+type Quote struct {
+	ID      int     `json:"id"`
+	Tenant  string  `json:"tenant,omitempty"`
+	Author  string  `json:"author"`
+	Text    string  `json:"quote"`
+	Score   int     `json:"score,omitempty"`
+	Likes   int     `json:"likes,omitempty"`
+	Ratings int     `json:"ratings,omitempty"` // число оценок
+	Rating  float64 `json:"rating,omitempty"`  // средняя оценка от 1 до 5
+}
+
+// DailyQuote — цитата дня; Pinned означает ручной выбор редактора.
+type DailyQuote struct {
+	Date   string `json:"date"`
+	Pinned bool   `json:"pinned"`
+	Quote
+}
+
+// PopularQuote — цитата с популярностью, учитывающей затухание реакций во времени.
+type PopularQuote struct {
+	Quote
+	Popularity float64 `json:"popularity"`
+}
+
+// quotePath возвращает путь цитаты id с необязательным суффиксом.
+func quotePath(id int, suffix string) string {
+	return "/quotes/" + strconv.Itoa(id) + suffix
+}
+
+// Create добавляет цитату и возвращает её ID. Вызов не повторяется: повтор мог бы создать дубликат.
+func (c *Client) Create(ctx context.Context, author, text string) (int, error) {
+	var res struct {
+		ID int `json:"id"`
+	}
+	err := c.do(ctx, call{method: http.MethodPost, path: "/quotes", in: Quote{Author: author, Text: text}, out: &res})
+	return res.ID, err
+}
+
+// Get возвращает цитату по ID.
+func (c *Client) Get(ctx context.Context, id int) (Quote, error) {
+	var q Quote
+	err := c.do(ctx, get(quotePath(id, ""), nil, &q))
+	return q, err
+}
+
+// Delete удаляет цитату по ID. Если ответ на первую попытку потерян, повтор вернёт ErrNotFound.
+func (c *Client) Delete(ctx context.Context, id int) error {
+	return c.do(ctx, del(quotePath(id, ""), nil))
+}
+
+// ListOptions задаёт выборку списка цитат.
+type ListOptions struct {
+	Author string // только цитаты автора
+	After  int    // только цитаты с ID больше After
+	Limit  int    // размер страницы от 1 до 1000; 0 — List возвращает весь список, All — страницы по 100
+}
+
+// List возвращает одну страницу цитат в порядке ID. Следующая страница начинается после ID
+// последней цитаты; All обходит страницы сам.
+func (c *Client) List(ctx context.Context, opts ListOptions) ([]Quote, error) {
+	query := url.Values{}
+	if opts.Author != "" {
+		query.Set("author", opts.Author)
+	}
+	if opts.After > 0 {
+		query.Set("after", strconv.Itoa(opts.After))
+	}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	var list []Quote
+	err := c.do(ctx, get("/quotes", query, &list))
+	return list, err
+}
+
+// All обходит все цитаты выборки постранично. Ошибка запроса страницы выдаётся последним элементом.
+//
+//	for q, err := range c.All(ctx, client.ListOptions{}) {
+//		if err != nil { return err }
+//		...
+//	}
+func (c *Client) All(ctx context.Context, opts ListOptions) iter.Seq2[Quote, error] {
+	if opts.Limit == 0 {
+		opts.Limit = 100
+	}
+	return func(yield func(Quote, error) bool) {
+		for {
+			page, err := c.List(ctx, opts)
+			if err != nil {
+				yield(Quote{}, err)
+				return
+			}
+			for _, q := range page {
+				if !yield(q, nil) {
+					return
+				}
+			}
+			if len(page) < opts.Limit {
+				return
+			}
+			opts.After = page[len(page)-1].ID
+		}
+	}
+}
+
+// Watch опрашивает сервис с интервалом interval и вызывает fn для каждой цитаты с ID больше after
+// в порядке добавления. Возвращает ошибку запроса или fn либо ошибку ctx после его отмены.
+// Временные сбои сглаживаются повторами клиента, см. RetryPolicy.
+func (c *Client) Watch(ctx context.Context, after int, interval time.Duration, fn func(Quote) error) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for q, err := range c.All(ctx, ListOptions{After: after}) {
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				return err
+			}
+			if err := fn(q); err != nil {
+				return err
+			}
+			after = q.ID
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RandomOptions задаёт способ выбора случайной цитаты.
+type RandomOptions struct {
+	Seed     *int64 // фиксированный seed для воспроизводимого выбора
+	Weighted bool   // выбирать пропорционально весу цитаты
+}
+
+// Random возвращает случайную цитату; в пустом пространстве — ErrEmpty.
+func (c *Client) Random(ctx context.Context, opts RandomOptions) (Quote, error) {
+	query := url.Values{}
+	if opts.Seed != nil {
+		query.Set("seed", strconv.FormatInt(*opts.Seed, 10))
+	}
+	if opts.Weighted {
+		query.Set("weighted", "true")
+	}
+	var q Quote
+	err := c.do(ctx, get("/quotes/random", query, &q))
+	return q, err
+}
+
+// Daily возвращает цитату дня для текущей даты в часовом поясе loc; nil — UTC.
+func (c *Client) Daily(ctx context.Context, loc *time.Location) (DailyQuote, error) {
+	var query url.Values
+	if loc != nil {
+		query = url.Values{"tz": {loc.String()}}
+	}
+	var q DailyQuote
+	err := c.do(ctx, get("/quotes/daily", query, &q))
+	return q, err
+}
+
+// PopularOptions задаёт рейтинг популярных цитат. Нулевые значения — значения сервера: 7 дней и 10 цитат.
+type PopularOptions struct {
+	Window time.Duration // окно, за которое учитываются реакции
+	Limit  int           // число цитат от 1 до 100
+}
+
+// Popular возвращает самые популярные цитаты.
+func (c *Client) Popular(ctx context.Context, opts PopularOptions) ([]PopularQuote, error) {
+	var list []PopularQuote
+	err := c.do(ctx, get("/quotes/popular", windowQuery(opts.Window, opts.Limit), &list))
+	return list, err
+}
+
+// Like ставит лайк цитате от имени клиента и возвращает цитату с обновлёнными счётчиками.
+// Сервер учитывает лайк один раз на клиента, поэтому вызов повторяется, как идемпотентный.
+func (c *Client) Like(ctx context.Context, id int) (Quote, error) {
+	var q Quote
+	err := c.do(ctx, call{method: http.MethodPost, path: quotePath(id, "/like"), out: &q, retry: true})
+	return q, err
+}
+
+// Unlike снимает лайк клиента с цитаты.
+func (c *Client) Unlike(ctx context.Context, id int) (Quote, error) {
+	var q Quote
+	err := c.do(ctx, del(quotePath(id, "/like"), &q))
+	return q, err
+}
+
+// Rate оценивает цитату от 1 до 5 звёзд; повторная оценка клиента заменяет предыдущую.
+func (c *Client) Rate(ctx context.Context, id, stars int) (Quote, error) {
+	var q Quote
+	err := c.do(ctx, put(quotePath(id, "/rating"), map[string]int{"stars": stars}, &q))
+	return q, err
+}
+
+// windowQuery собирает параметры window и limit; нулевые значения не передаются.
+func windowQuery(window time.Duration, limit int) url.Values {
+	query := url.Values{}
+	if window > 0 {
+		query.Set("window", window.String())
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	return query
+}
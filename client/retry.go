@@ -0,0 +1,46 @@
+package client
+
+import (
+	"errors"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy задаёт повторы идемпотентных запросов: GET, PUT, DELETE и лайков, которые сервер
+// учитывает один раз на клиента. Создание цитаты не повторяется, чтобы не получить дубликат.
+// Повторяются сетевые ошибки и ответы 429, 502, 503 и 504. Пауза растёт вдвое от MinBackoff
+// до MaxBackoff со случайным разбросом; Retry-After ответа заменяет её, а если он больше MaxBackoff,
+// ошибка возвращается сразу — например, в режиме только для чтения сервер просит подождать минуту.
+type RetryPolicy struct {
+	MaxAttempts int           // число попыток, включая первую; 1 отключает повторы
+	MinBackoff  time.Duration // пауза перед первым повтором
+	MaxBackoff  time.Duration // наибольшая пауза
+}
+
+// DefaultRetryPolicy используется, если Options.Retry не задан.
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 3, MinBackoff: 100 * time.Millisecond, MaxBackoff: 2 * time.Second}
+
+// backoff возвращает паузу перед повтором после неудачной попытки attempt или false, если err не повторяется.
+func (p RetryPolicy) backoff(attempt int, err error) (time.Duration, bool) {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		default:
+			return 0, false
+		}
+		if apiErr.RetryAfter > 0 {
+			return apiErr.RetryAfter, apiErr.RetryAfter <= p.MaxBackoff
+		}
+	} else if netErr := net.Error(nil); !errors.As(err, &netErr) {
+		return 0, false
+	}
+	d := p.MinBackoff << (attempt - 1)
+	if d > p.MaxBackoff || d <= 0 {
+		d = p.MaxBackoff
+	}
+	// Половина паузы случайна, чтобы клиенты после общего сбоя не повторяли запросы одновременно
+	return d/2 + rand.N(d/2+1), true
+}
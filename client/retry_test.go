@@ -0,0 +1,36 @@
+package client
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestBackoff проверяет рост паузы, её границы, Retry-After и ошибки, которые не повторяются
+func TestBackoff(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 5, MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	unavailable := &Error{StatusCode: http.StatusServiceUnavailable}
+	for attempt, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 4: 800 * time.Millisecond, 5: time.Second, 70: time.Second} {
+		d, ok := p.backoff(attempt, unavailable)
+		if !ok || d < want/2 || d > want {
+			t.Errorf("попытка %d: ожидается пауза от %v до %v, получено %v, %v", attempt, want/2, want, d, ok)
+		}
+	}
+
+	if d, ok := p.backoff(1, &Error{StatusCode: http.StatusTooManyRequests, RetryAfter: 700 * time.Millisecond}); !ok || d != 700*time.Millisecond {
+		t.Errorf("ожидается пауза из Retry-After, получено %v, %v", d, ok)
+	}
+	if _, ok := p.backoff(1, &Error{StatusCode: http.StatusServiceUnavailable, RetryAfter: time.Minute}); ok {
+		t.Error("Retry-After длиннее MaxBackoff не должен выжидаться")
+	}
+	if _, ok := p.backoff(1, &net.OpError{Op: "dial", Err: errors.New("connection refused")}); !ok {
+		t.Error("сетевая ошибка должна повторяться")
+	}
+	for _, err := range []error{ErrNotFound, &Error{StatusCode: http.StatusInternalServerError}, errors.New("разбор ответа")} {
+		if _, ok := p.backoff(1, err); ok {
+			t.Errorf("ошибка %v не должна повторяться", err)
+		}
+	}
+}
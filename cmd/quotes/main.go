@@ -0,0 +1,397 @@
+// Команда quotes работает с цитатами через HTTP API любого развёртывания сервиса: добавляет, выводит,
+// удаляет цитаты и следит за новыми. Адрес сервиса и ключ API берутся из профиля в файле настроек,
+// переменных окружения QUOTES_CLI_URL, QUOTES_CLI_API_KEY, QUOTES_CLI_WORKSPACE или флагов.
+package main
+
+import (
+	"Test_Project_Brand_Scout/client"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Коды завершения процесса.
+const (
+	exitOK          = 0
+	exitError       = 1 // прочие ошибки, в том числе 5xx сервера
+	exitUsage       = 2 // неверные аргументы
+	exitNotFound    = 3 // цитата не найдена или цитат нет
+	exitAuth        = 4 // нет ключа, ключ недействителен или не хватает прав
+	exitUnavailable = 5 // сервис недоступен, перегружен или в режиме только для чтения
+)
+
+// usageError — ошибка в аргументах команды.
+type usageError struct{ msg string }
+
+func (e usageError) Error() string { return e.msg }
+
+// command — подкоманда quotes.
+type command struct {
+	name    string
+	args    string // краткое описание аргументов для справки
+	summary string
+	run     func(c *cli, args []string) error
+}
+
+// commands — подкоманды в порядке вывода справки.
+var commands = []command{
+	{"add", "ТЕКСТ --author АВТОР", "добавить цитату", runAdd},
+	{"random", "[--seed N] [--weighted]", "показать случайную цитату", runRandom},
+	{"ls", "[--author АВТОР]", "вывести цитаты, все или одного автора", runList},
+	{"get", "ID", "показать цитату", runGet},
+	{"rm", "ID...", "удалить цитаты", runRemove},
+	{"watch", "[--interval 2s] [--since ID]", "выводить новые цитаты по мере добавления", runWatch},
+	{"profile", "ls | set ИМЯ --url URL [--api-key KEY] [--workspace WS] | use ИМЯ | rm ИМЯ", "управлять профилями подключения", runProfile},
+}
+
+// globals — флаги, общие для всех подкоманд.
+type globals struct {
+	config    string
+	profile   string
+	url       string
+	apiKey    string
+	workspace string
+	output    string
+	timeout   time.Duration
+}
+
+// register добавляет общие флаги в fs; значения по умолчанию — уже разобранные значения.
+func (g *globals) register(fs *flag.FlagSet) {
+	fs.StringVar(&g.config, "config", g.config, "файл профилей; по умолчанию $QUOTES_CLI_CONFIG или ~/.config/quotes/config.yaml")
+	fs.StringVar(&g.profile, "profile", g.profile, "профиль подключения; по умолчанию $QUOTES_CLI_PROFILE или текущий профиль")
+	fs.StringVar(&g.url, "url", g.url, "адрес сервиса вместо адреса из профиля")
+	fs.StringVar(&g.apiKey, "api-key", g.apiKey, "ключ API вместо ключа из профиля")
+	fs.StringVar(&g.workspace, "workspace", g.workspace, "рабочее пространство вместо пространства из профиля")
+	fs.StringVar(&g.output, "o", g.output, "формат вывода: table, json или plain")
+	fs.DurationVar(&g.timeout, "timeout", g.timeout, "ограничение времени одного запроса")
+}
+
+// cli — общее состояние подкоманд.
+type cli struct {
+	ctx            context.Context
+	g              globals
+	stdout, stderr io.Writer
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+// run выполняет команду и возвращает код завершения процесса.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	c := &cli{ctx: ctx, stdout: stdout, stderr: stderr, g: globals{output: "table", timeout: 10 * time.Second}}
+	fs := flag.NewFlagSet("quotes", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() { usage(stderr, fs) }
+	c.g.register(fs)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if fs.NArg() == 0 {
+		usage(stderr, fs)
+		return exitUsage
+	}
+	name := fs.Arg(0)
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		err := cmd.run(c, fs.Args()[1:])
+		if err == nil || errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		var uerr usageError
+		if errors.As(err, &uerr) {
+			fmt.Fprintln(stderr, uerr.msg)
+			fmt.Fprintf(stderr, "использование: quotes %s %s\n", cmd.name, cmd.args)
+			return exitUsage
+		}
+		fmt.Fprintf(stderr, "quotes %s: %v\n", cmd.name, err)
+		return exitCode(err)
+	}
+	fmt.Fprintf(stderr, "неизвестная команда %q\n", name)
+	usage(stderr, fs)
+	return exitUsage
+}
+
+// exitCode выбирает код завершения по ошибке.
+func exitCode(err error) int {
+	var netErr net.Error
+	switch status := client.StatusCode(err); {
+	case status == http.StatusNotFound:
+		return exitNotFound
+	case status == http.StatusUnauthorized, status == http.StatusForbidden:
+		return exitAuth
+	case status == http.StatusTooManyRequests, status == http.StatusServiceUnavailable,
+		status == http.StatusBadGateway, status == http.StatusGatewayTimeout:
+		return exitUnavailable
+	case status == 0 && errors.As(err, &netErr):
+		return exitUnavailable
+	default:
+		return exitError
+	}
+}
+
+// usage выводит список команд и общих флагов.
+func usage(w io.Writer, fs *flag.FlagSet) {
+	fmt.Fprintln(w, "использование: quotes [флаги] КОМАНДА [аргументы]")
+	fmt.Fprintln(w, "\nКоманды:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w, "\nФлаги (допустимы и после команды):")
+	fs.SetOutput(w)
+	fs.PrintDefaults()
+}
+
+// flags создаёт набор флагов подкоманды с общими флагами.
+func (c *cli) flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("quotes "+name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	c.g.register(fs)
+	return fs
+}
+
+// parse разбирает флаги подкоманды вперемешку с позиционными аргументами, как в
+// quotes add "текст" --author X, и возвращает позиционные аргументы. После -- флаги не разбираются.
+func (c *cli) parse(fs *flag.FlagSet, args []string) ([]string, error) {
+	var pos []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, usageError{err.Error()}
+		}
+		rest := fs.Args()
+		if n := len(args) - len(rest); n > 0 && args[n-1] == "--" {
+			pos = append(pos, rest...)
+			rest = nil
+		}
+		if len(rest) == 0 {
+			break
+		}
+		pos = append(pos, rest[0])
+		args = rest[1:]
+	}
+	switch c.g.output {
+	case outputTable, outputJSON, outputPlain:
+	default:
+		return nil, usageError{fmt.Sprintf("неизвестный формат вывода %q (ожидается table, json или plain)", c.g.output)}
+	}
+	return pos, nil
+}
+
+// client создаёт клиента API по профилю и флагам.
+func (c *cli) client() (*client.Client, error) {
+	p, err := c.resolveProfile()
+	if err != nil {
+		return nil, err
+	}
+	opts := client.Options{
+		Workspace:  p.Workspace,
+		HTTPClient: &http.Client{Timeout: c.g.timeout},
+		UserAgent:  "quotes-cli",
+	}
+	if p.APIKey != "" {
+		opts.Auth = client.APIKey(p.APIKey)
+	}
+	return client.New(p.URL, opts)
+}
+
+// parseID разбирает ID цитаты из аргумента.
+func parseID(s string) (int, error) {
+	id, err := strconv.Atoi(s)
+	if err != nil || id <= 0 {
+		return 0, usageError{"некорректный ID цитаты: " + s}
+	}
+	return id, nil
+}
+
+func runAdd(c *cli, args []string) error {
+	fs := c.flags("add")
+	author := fs.String("author", "", "автор цитаты")
+	pos, err := c.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) == 0 {
+		return usageError{"ожидается текст цитаты"}
+	}
+	if *author == "" {
+		return usageError{"ожидается --author"}
+	}
+	api, err := c.client()
+	if err != nil {
+		return err
+	}
+	q := client.Quote{Author: *author, Text: strings.Join(pos, " ")}
+	if q.ID, err = api.Create(c.ctx, q.Author, q.Text); err != nil {
+		return err
+	}
+	return c.printQuote(q)
+}
+
+func runRandom(c *cli, args []string) error {
+	fs := c.flags("random")
+	seed := fs.String("seed", "", "seed для воспроизводимого выбора")
+	weighted := fs.Bool("weighted", false, "выбирать пропорционально весу цитаты")
+	pos, err := c.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) > 0 {
+		return usageError{"лишние аргументы: " + strings.Join(pos, " ")}
+	}
+	opts := client.RandomOptions{Weighted: *weighted}
+	if *seed != "" {
+		n, err := strconv.ParseInt(*seed, 10, 64)
+		if err != nil {
+			return usageError{"некорректный seed: " + *seed}
+		}
+		opts.Seed = &n
+	}
+	api, err := c.client()
+	if err != nil {
+		return err
+	}
+	q, err := api.Random(c.ctx, opts)
+	if err != nil {
+		return err
+	}
+	return c.printQuote(q)
+}
+
+func runList(c *cli, args []string) error {
+	fs := c.flags("ls")
+	author := fs.String("author", "", "только цитаты автора")
+	pos, err := c.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) > 0 {
+		return usageError{"лишние аргументы: " + strings.Join(pos, " ")}
+	}
+	api, err := c.client()
+	if err != nil {
+		return err
+	}
+	list := []client.Quote{}
+	for q, err := range api.All(c.ctx, client.ListOptions{Author: *author}) {
+		if err != nil {
+			return err
+		}
+		list = append(list, q)
+	}
+	return c.printQuotes(list)
+}
+
+func runGet(c *cli, args []string) error {
+	fs := c.flags("get")
+	pos, err := c.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) != 1 {
+		return usageError{"ожидается ID цитаты"}
+	}
+	id, err := parseID(pos[0])
+	if err != nil {
+		return err
+	}
+	api, err := c.client()
+	if err != nil {
+		return err
+	}
+	q, err := api.Get(c.ctx, id)
+	if err != nil {
+		return err
+	}
+	return c.printQuote(q)
+}
+
+// runRemove удаляет цитаты по очереди и останавливается на первой ошибке.
+func runRemove(c *cli, args []string) error {
+	fs := c.flags("rm")
+	pos, err := c.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) == 0 {
+		return usageError{"ожидается ID цитаты"}
+	}
+	ids := make([]int, len(pos))
+	for i, s := range pos {
+		if ids[i], err = parseID(s); err != nil {
+			return err
+		}
+	}
+	api, err := c.client()
+	if err != nil {
+		return err
+	}
+	deleted := []int{}
+	for _, id := range ids {
+		if err := api.Delete(c.ctx, id); err != nil {
+			if len(deleted) > 0 {
+				c.printDeleted(deleted)
+			}
+			return fmt.Errorf("цитата %d: %w", id, err)
+		}
+		deleted = append(deleted, id)
+	}
+	return c.printDeleted(deleted)
+}
+
+// runWatch выводит новые цитаты до прерывания. Без --since выводятся только цитаты,
+// добавленные после запуска.
+func runWatch(c *cli, args []string) error {
+	fs := c.flags("watch")
+	interval := fs.Duration("interval", 2*time.Second, "интервал опроса сервиса")
+	since := fs.Int("since", -1, "выводить цитаты с ID больше заданного")
+	pos, err := c.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) > 0 {
+		return usageError{"лишние аргументы: " + strings.Join(pos, " ")}
+	}
+	if *interval <= 0 {
+		return usageError{"интервал должен быть положительным"}
+	}
+	api, err := c.client()
+	if err != nil {
+		return err
+	}
+	after := *since
+	if after < 0 {
+		after = 0
+		for q, err := range api.All(c.ctx, client.ListOptions{}) {
+			if err != nil {
+				return err
+			}
+			after = q.ID
+		}
+	}
+	err = api.Watch(c.ctx, after, *interval, c.printStreamed)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
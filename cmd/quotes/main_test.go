@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"Test_Project_Brand_Scout/internal/auth"
+	"Test_Project_Brand_Scout/internal/config"
+	"Test_Project_Brand_Scout/internal/quotes"
+)
+
+// testEnv — сервер с хранилищем в памяти, требующий ключей API, и файл профилей во временном каталоге.
+type testEnv struct {
+	srv    *httptest.Server
+	keys   *auth.KeyManager
+	config string
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	for _, name := range []string{"CONFIG", "PROFILE", "URL", "API_KEY", "WORKSPACE"} {
+		t.Setenv(envPrefix+name, "")
+	}
+	repo := quotes.NewMemoryRepository()
+	keys := auth.NewKeyManager(repo.(auth.KeyStore))
+	r := mux.NewRouter()
+	quotes.RegisterHandlers(r, quotes.NewService(repo), keys)
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return &testEnv{srv: srv, keys: keys, config: filepath.Join(t.TempDir(), "config.yaml")}
+}
+
+// token выдаёт ключ API с областями scopes.
+func (e *testEnv) token(t *testing.T, scopes ...string) string {
+	_, token, err := e.keys.Issue("cli", "", scopes)
+	if err != nil {
+		t.Fatalf("ошибка выдачи ключа: %v", err)
+	}
+	return token
+}
+
+// exec выполняет команду с файлом профилей окружения и возвращает код завершения и вывод.
+func (e *testEnv) exec(ctx context.Context, args ...string) (int, string, string) {
+	var out, errOut bytes.Buffer
+	code := run(ctx, append([]string{"--config", e.config}, args...), &out, &errOut)
+	return code, out.String(), errOut.String()
+}
+
+func (e *testEnv) mustExec(t *testing.T, args ...string) string {
+	t.Helper()
+	code, out, errOut := e.exec(context.Background(), args...)
+	if code != exitOK {
+		t.Fatalf("%v: ожидается код 0, получен %d: %s", args, code, errOut)
+	}
+	return out
+}
+
+// TestCommands проверяет add, ls, get, random и rm в разных форматах вывода
+func TestCommands(t *testing.T) {
+	e := newTestEnv(t)
+	e.mustExec(t, "profile", "set", "local", "--url", e.srv.URL, "--api-key", e.token(t, auth.ScopeRead, auth.ScopeWrite))
+
+	out := e.mustExec(t, "add", "Краткость — сестра таланта", "--author", "Чехов", "-o", "plain")
+	if out != "1\tЧехов\tКраткость — сестра таланта\n" {
+		t.Errorf("неожиданный вывод add: %q", out)
+	}
+	e.mustExec(t, "add", "--author", "Толстой", "Все", "счастливые", "семьи")
+
+	out = e.mustExec(t, "ls")
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "ID") || !strings.Contains(lines[2], "Все счастливые семьи") {
+		t.Errorf("ожидается таблица с заголовком и двумя цитатами, получено:\n%s", out)
+	}
+	var list []map[string]any
+	out = e.mustExec(t, "-o", "json", "ls", "--author", "Толстой")
+	if err := json.Unmarshal([]byte(out), &list); err != nil || len(list) != 1 || list[0]["id"] != float64(2) {
+		t.Errorf("ожидается JSON с цитатой Толстого, получено %s", out)
+	}
+	if out := e.mustExec(t, "ls", "--author", "Пушкин", "-o", "json"); strings.TrimSpace(out) != "[]" {
+		t.Errorf("ожидается пустой массив, получено %s", out)
+	}
+	if out := e.mustExec(t, "get", "2", "-o", "plain"); !strings.HasPrefix(out, "2\tТолстой\t") {
+		t.Errorf("неожиданный вывод get: %q", out)
+	}
+	if out := e.mustExec(t, "random", "--seed", "1", "-o", "plain"); strings.Count(out, "\n") != 1 {
+		t.Errorf("ожидается одна цитата, получено %q", out)
+	}
+
+	out = e.mustExec(t, "rm", "1", "2", "-o", "json")
+	if !strings.Contains(out, `"deleted"`) {
+		t.Errorf("ожидается список удалённых цитат, получено %s", out)
+	}
+	if code, _, _ := e.exec(context.Background(), "random"); code != exitNotFound {
+		t.Errorf("без цитат ожидается код %d, получен %d", exitNotFound, code)
+	}
+	if code, _, _ := e.exec(context.Background(), "rm", "1"); code != exitNotFound {
+		t.Errorf("для удалённой цитаты ожидается код %d, получен %d", exitNotFound, code)
+	}
+}
+
+// TestExitCodes проверяет коды завершения для ошибок аргументов, авторизации и недоступного сервиса
+func TestExitCodes(t *testing.T) {
+	e := newTestEnv(t)
+	reader := e.token(t, auth.ScopeRead)
+	ctx := context.Background()
+	cases := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"без команды", nil, exitUsage},
+		{"неизвестная команда", []string{"vacuum"}, exitUsage},
+		{"add без автора", []string{"add", "текст"}, exitUsage},
+		{"некорректный ID", []string{"rm", "abc"}, exitUsage},
+		{"неизвестный формат", []string{"ls", "-o", "xml"}, exitUsage},
+		{"неизвестный профиль", []string{"--profile", "prod", "ls"}, exitUsage},
+		{"без ключа", []string{"--url", e.srv.URL, "ls"}, exitAuth},
+		{"нет прав", []string{"--url", e.srv.URL, "--api-key", reader, "add", "q", "--author", "A"}, exitAuth},
+		{"сервис недоступен", []string{"--url", "http://127.0.0.1:1", "ls"}, exitUnavailable},
+	}
+	for _, c := range cases {
+		if code, _, errOut := e.exec(ctx, c.args...); code != c.want {
+			t.Errorf("%s: ожидается код %d, получен %d: %s", c.name, c.want, code, errOut)
+		}
+	}
+}
+
+// TestProfiles проверяет сохранение, выбор профилей и приоритет окружения и флагов
+func TestProfiles(t *testing.T) {
+	e := newTestEnv(t)
+	token := e.token(t, auth.ScopeRead, auth.ScopeWrite)
+	e.mustExec(t, "profile", "set", "broken", "--url", "http://127.0.0.1:1")
+	e.mustExec(t, "profile", "set", "team", "--url", e.srv.URL, "--api-key", token, "--workspace", "team-a")
+	if fi, err := os.Stat(e.config); err != nil || fi.Mode().Perm() != 0o600 {
+		t.Errorf("файл профилей с ключами должен иметь права 0600: %v, %v", fi, err)
+	}
+
+	// Текущим остаётся первый сохранённый профиль
+	if code, _, _ := e.exec(context.Background(), "ls"); code != exitUnavailable {
+		t.Errorf("ожидается обращение к профилю broken, получен код %d", code)
+	}
+	e.mustExec(t, "--profile", "team", "add", "q", "--author", "A")
+	t.Setenv("QUOTES_CLI_PROFILE", "team")
+	out := e.mustExec(t, "get", "1", "-o", "json")
+	if !strings.Contains(out, `"tenant": "team-a"`) {
+		t.Errorf("ожидается цитата пространства из профиля, получено %s", out)
+	}
+	t.Setenv("QUOTES_CLI_URL", "http://127.0.0.1:1")
+	if code, _, _ := e.exec(context.Background(), "ls"); code != exitUnavailable {
+		t.Errorf("QUOTES_CLI_URL должен переопределять адрес профиля, получен код %d", code)
+	}
+	e.mustExec(t, "ls", "--url", e.srv.URL)
+	t.Setenv("QUOTES_CLI_URL", "")
+	t.Setenv("QUOTES_CLI_PROFILE", "")
+
+	e.mustExec(t, "profile", "use", "team")
+	var list []profileInfo
+	out = e.mustExec(t, "profile", "ls", "-o", "json")
+	if err := json.Unmarshal([]byte(out), &list); err != nil || len(list) != 2 || !list[1].Current || !list[1].HasKey {
+		t.Errorf("ожидается текущий профиль team с ключом, получено %s", out)
+	}
+	if strings.Contains(out, token) {
+		t.Error("profile ls не должен выводить ключ")
+	}
+	e.mustExec(t, "profile", "rm", "broken")
+	if code, _, _ := e.exec(context.Background(), "profile", "use", "broken"); code != exitError {
+		t.Errorf("для удалённого профиля ожидается код %d, получен %d", exitError, code)
+	}
+}
+
+// syncBuffer — буфер вывода, который можно читать, пока команда пишет в него из другой горутины.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// TestEnvPrefix проверяет, что переменные клиента не мешают загрузке конфигурации сервиса в том же окружении
+func TestEnvPrefix(t *testing.T) {
+	if envPrefix != config.CLIEnvPrefix {
+		t.Fatalf("префикс переменных клиента %s не совпадает с пропускаемым сервисом %s", envPrefix, config.CLIEnvPrefix)
+	}
+	for _, name := range []string{"CONFIG", "PROFILE", "URL", "API_KEY", "WORKSPACE"} {
+		t.Setenv(envPrefix+name, "x")
+	}
+	if _, _, err := config.Load(nil); err != nil {
+		t.Fatalf("переменные клиента не должны ломать конфигурацию сервиса: %v", err)
+	}
+}
+
+// TestWatch проверяет, что watch выводит цитаты после --since в JSON Lines и завершается с кодом 0 при отмене
+func TestWatch(t *testing.T) {
+	e := newTestEnv(t)
+	e.mustExec(t, "profile", "set", "local", "--url", e.srv.URL, "--api-key", e.token(t, auth.ScopeRead, auth.ScopeWrite))
+	e.mustExec(t, "add", "старая", "--author", "A")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var out, errOut syncBuffer
+	done := make(chan int)
+	go func() {
+		done <- run(ctx, []string{"--config", e.config, "watch", "--since", "1", "--interval", "10ms", "-o", "json"}, &out, &errOut)
+	}()
+	e.mustExec(t, "add", "новая", "--author", "B")
+	for deadline := time.Now().Add(5 * time.Second); !strings.Contains(out.String(), "новая") && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if code := <-done; code != exitOK {
+		t.Fatalf("после прерывания ожидается код 0, получен %d: %s", code, errOut.String())
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	var q map[string]any
+	if len(lines) != 1 || json.Unmarshal([]byte(lines[0]), &q) != nil || q["quote"] != "новая" {
+		t.Errorf("ожидается одна новая цитата в JSON Lines, получено %q", out.String())
+	}
+}
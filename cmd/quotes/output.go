@@ -0,0 +1,86 @@
+package main
+
+import (
+	"Test_Project_Brand_Scout/client"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+// Форматы вывода.
+const (
+	outputTable = "table" // выровненная таблица с заголовком
+	outputJSON  = "json"  // JSON, как в ответах API
+	outputPlain = "plain" // ID, автор и текст через табуляцию, без заголовка; удобно для cut и awk
+)
+
+// printQuotes выводит список цитат.
+func (c *cli) printQuotes(list []client.Quote) error {
+	switch c.g.output {
+	case outputJSON:
+		if list == nil {
+			list = []client.Quote{}
+		}
+		return c.printJSON(list)
+	case outputPlain:
+		for _, q := range list {
+			c.printPlain(q)
+		}
+		return nil
+	default:
+		tw := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tАВТОР\tЦИТАТА")
+		for _, q := range list {
+			fmt.Fprintf(tw, "%d\t%s\t%s\n", q.ID, oneLine(q.Author), oneLine(q.Text))
+		}
+		return tw.Flush()
+	}
+}
+
+// printQuote выводит одну цитату.
+func (c *cli) printQuote(q client.Quote) error {
+	if c.g.output == outputJSON {
+		return c.printJSON(q)
+	}
+	return c.printQuotes([]client.Quote{q})
+}
+
+// printStreamed выводит цитату из watch: в JSON по одному объекту в строке, иначе строкой plain.
+func (c *cli) printStreamed(q client.Quote) error {
+	if c.g.output == outputJSON {
+		return json.NewEncoder(c.stdout).Encode(q)
+	}
+	c.printPlain(q)
+	return nil
+}
+
+// printDeleted сообщает об удалённых цитатах; в формате plain ничего не выводит.
+func (c *cli) printDeleted(ids []int) error {
+	switch c.g.output {
+	case outputJSON:
+		return c.printJSON(map[string][]int{"deleted": ids})
+	case outputPlain:
+		return nil
+	default:
+		for _, id := range ids {
+			fmt.Fprintf(c.stdout, "Цитата %d удалена\n", id)
+		}
+		return nil
+	}
+}
+
+func (c *cli) printPlain(q client.Quote) {
+	fmt.Fprintf(c.stdout, "%d\t%s\t%s\n", q.ID, oneLine(q.Author), oneLine(q.Text))
+}
+
+func (c *cli) printJSON(v any) error {
+	enc := json.NewEncoder(c.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// oneLine заменяет переводы строк и табуляции пробелами, чтобы цитата занимала одну строку вывода.
+func oneLine(s string) string {
+	return strings.NewReplacer("\r\n", " ", "\n", " ", "\t", " ").Replace(s)
+}
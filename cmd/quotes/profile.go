@@ -0,0 +1,240 @@
+package main
+
+import (
+	"Test_Project_Brand_Scout/client"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultURL — адрес сервиса, если он не задан ни в профиле, ни в окружении, ни флагом.
+const defaultURL = "http://localhost:8080"
+
+// profile — параметры подключения к одному развёртыванию.
+type profile struct {
+	URL       string `yaml:"url" json:"url"`
+	APIKey    string `yaml:"api_key,omitempty" json:"-"`
+	Workspace string `yaml:"workspace,omitempty" json:"workspace,omitempty"`
+}
+
+// profileFile — файл профилей:
+//
+//	current: prod
+//	profiles:
+//	  prod:
+//	    url: https://quotes.example.com
+//	    api_key: qk_...
+//	    workspace: team-a
+type profileFile struct {
+	Current  string             `yaml:"current,omitempty"`
+	Profiles map[string]profile `yaml:"profiles"`
+}
+
+// envPrefix — префикс переменных окружения клиента. Он отличается от префикса сервиса QUOTES_,
+// чтобы переменные клиента не принимались сервисом за его параметры.
+const envPrefix = "QUOTES_CLI_"
+
+// configPath возвращает путь к файлу профилей: флаг --config, затем QUOTES_CLI_CONFIG,
+// затем quotes/config.yaml в каталоге настроек пользователя.
+func (c *cli) configPath() (string, error) {
+	if c.g.config != "" {
+		return c.g.config, nil
+	}
+	if path := os.Getenv(envPrefix + "CONFIG"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "quotes", "config.yaml"), nil
+}
+
+// loadProfiles читает файл профилей; отсутствующий файл равносилен пустому.
+func (c *cli) loadProfiles() (*profileFile, string, error) {
+	path, err := c.configPath()
+	if err != nil {
+		return nil, "", err
+	}
+	pf := &profileFile{}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, "", err
+	}
+	if err := yaml.Unmarshal(data, pf); err != nil {
+		return nil, "", fmt.Errorf("%s: %w", path, err)
+	}
+	if pf.Profiles == nil {
+		pf.Profiles = make(map[string]profile)
+	}
+	return pf, path, nil
+}
+
+// save записывает файл профилей с правами 0600: в нём хранятся ключи API.
+func (pf *profileFile) save(path string) error {
+	data, err := yaml.Marshal(pf)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// resolveProfile собирает параметры подключения. Профиль выбирается флагом --profile, затем QUOTES_CLI_PROFILE,
+// затем полем current файла; поверх него применяются QUOTES_CLI_URL, QUOTES_CLI_API_KEY, QUOTES_CLI_WORKSPACE
+// и флаги.
+func (c *cli) resolveProfile() (profile, error) {
+	pf, path, err := c.loadProfiles()
+	if err != nil {
+		return profile{}, err
+	}
+	name, explicit := c.g.profile, true
+	if name == "" {
+		name = os.Getenv(envPrefix + "PROFILE")
+	}
+	if name == "" {
+		name, explicit = pf.Current, false
+	}
+	p, ok := pf.Profiles[name]
+	if !ok && explicit {
+		return profile{}, usageError{fmt.Sprintf("профиль %q не найден в %s", name, path)}
+	}
+	for _, o := range []struct {
+		dst        *string
+		env, value string
+	}{
+		{&p.URL, envPrefix + "URL", c.g.url},
+		{&p.APIKey, envPrefix + "API_KEY", c.g.apiKey},
+		{&p.Workspace, envPrefix + "WORKSPACE", c.g.workspace},
+	} {
+		if v := os.Getenv(o.env); v != "" {
+			*o.dst = v
+		}
+		if o.value != "" {
+			*o.dst = o.value
+		}
+	}
+	if p.URL == "" {
+		p.URL = defaultURL
+	}
+	return p, nil
+}
+
+// profileInfo — профиль в выводе profile ls; сам ключ не выводится.
+type profileInfo struct {
+	Name string `json:"name"`
+	profile
+	HasKey  bool `json:"has_key"`
+	Current bool `json:"current"`
+}
+
+// runProfile показывает, сохраняет, выбирает и удаляет профили.
+func runProfile(c *cli, args []string) error {
+	if len(args) == 0 {
+		return usageError{"ожидается ls, set, use или rm"}
+	}
+	sub, args := args[0], args[1:]
+	fs := c.flags("profile " + sub)
+	pos, err := c.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	pf, path, err := c.loadProfiles()
+	if err != nil {
+		return err
+	}
+
+	switch sub {
+	case "ls":
+		if len(pos) > 0 {
+			return usageError{"лишние аргументы: " + strings.Join(pos, " ")}
+		}
+		names := make([]string, 0, len(pf.Profiles))
+		for name := range pf.Profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		list := make([]profileInfo, 0, len(names))
+		for _, name := range names {
+			p := pf.Profiles[name]
+			list = append(list, profileInfo{Name: name, profile: p, HasKey: p.APIKey != "", Current: name == pf.Current})
+		}
+		switch c.g.output {
+		case outputJSON:
+			return c.printJSON(list)
+		case outputPlain:
+			for _, p := range list {
+				fmt.Fprintf(c.stdout, "%s\t%s\t%s\n", p.Name, p.URL, p.Workspace)
+			}
+			return nil
+		default:
+			tw := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "\tПРОФИЛЬ\tАДРЕС\tПРОСТРАНСТВО\tКЛЮЧ")
+			for _, p := range list {
+				mark, key := "", "нет"
+				if p.Current {
+					mark = "*"
+				}
+				if p.HasKey {
+					key = "есть"
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", mark, p.Name, p.URL, p.Workspace, key)
+			}
+			return tw.Flush()
+		}
+	case "set":
+		if len(pos) != 1 {
+			return usageError{"ожидается имя профиля"}
+		}
+		// Значения берутся из общих флагов --url, --api-key и --workspace; неуказанные остаются прежними
+		p := pf.Profiles[pos[0]]
+		for _, o := range []struct{ dst, value *string }{
+			{&p.URL, &c.g.url}, {&p.APIKey, &c.g.apiKey}, {&p.Workspace, &c.g.workspace},
+		} {
+			if *o.value != "" {
+				*o.dst = *o.value
+			}
+		}
+		if p.URL == "" {
+			return usageError{"ожидается --url"}
+		}
+		if _, err := client.New(p.URL, client.Options{}); err != nil {
+			return usageError{err.Error()}
+		}
+		pf.Profiles[pos[0]] = p
+		if pf.Current == "" {
+			pf.Current = pos[0]
+		}
+		if err := pf.save(path); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stderr, "Профиль %s сохранён в %s\n", pos[0], path)
+		return nil
+	case "use", "rm":
+		if len(pos) != 1 {
+			return usageError{"ожидается имя профиля"}
+		}
+		if _, ok := pf.Profiles[pos[0]]; !ok {
+			return fmt.Errorf("профиль %q не найден в %s", pos[0], path)
+		}
+		if sub == "use" {
+			pf.Current = pos[0]
+		} else {
+			delete(pf.Profiles, pos[0])
+			if pf.Current == pos[0] {
+				pf.Current = ""
+			}
+		}
+		return pf.save(path)
+	default:
+		return usageError{fmt.Sprintf("неизвестная команда profile %s", sub)}
+	}
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"Test_Project_Brand_Scout/internal/quotes"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Форматы импорта и экспорта.
+const (
+	formatJSON  = "json"  // массив цитат, как отдаёт GET /quotes
+	formatJSONL = "jsonl" // одна цитата в строке
+	formatCSV   = "csv"   // таблица с заголовком author,quote,score
+)
+
+// csvHeader — столбцы CSV; при импорте столбец score необязателен.
+var csvHeader = []string{"author", "quote", "score"}
+
+// detectFormat возвращает формат из флага или по расширению файла; по умолчанию json.
+func detectFormat(flagValue, path string) (string, error) {
+	format := flagValue
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+		if format == "ndjson" {
+			format = formatJSONL
+		}
+	}
+	switch format {
+	case formatJSON, formatJSONL, formatCSV:
+		return format, nil
+	case "":
+		return formatJSON, nil
+	default:
+		return "", usageError{fmt.Sprintf("неизвестный формат %q (ожидается json, jsonl или csv)", format)}
+	}
+}
+
+// readQuotes разбирает цитаты из r в формате format. Ошибка указывает номер записи.
+func readQuotes(r io.Reader, format string) ([]quotes.Quote, error) {
+	var list []quotes.Quote
+	switch format {
+	case formatJSON:
+		if err := json.NewDecoder(r).Decode(&list); err != nil {
+			return nil, fmt.Errorf("разбор JSON: %w", err)
+		}
+	case formatJSONL:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 1<<20)
+		for line := 1; scanner.Scan(); line++ {
+			if strings.TrimSpace(scanner.Text()) == "" {
+				continue
+			}
+			var q quotes.Quote
+			if err := json.Unmarshal(scanner.Bytes(), &q); err != nil {
+				return nil, fmt.Errorf("строка %d: %w", line, err)
+			}
+			list = append(list, q)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	case formatCSV:
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		header, err := cr.Read()
+		if err != nil {
+			return nil, fmt.Errorf("чтение заголовка CSV: %w", err)
+		}
+		cols := make(map[string]int)
+		for i, name := range header {
+			cols[strings.ToLower(strings.TrimSpace(name))] = i
+		}
+		ai, aok := cols["author"]
+		qi, qok := cols["quote"]
+		if !aok || !qok {
+			return nil, errors.New("в заголовке CSV нужны столбцы author и quote")
+		}
+		si, sok := cols["score"]
+		for line := 2; ; line++ {
+			rec, err := cr.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			if ai >= len(rec) || qi >= len(rec) {
+				return nil, fmt.Errorf("строка %d: не хватает столбцов", line)
+			}
+			q := quotes.Quote{Author: rec[ai], Text: rec[qi]}
+			if sok && si < len(rec) && strings.TrimSpace(rec[si]) != "" {
+				if q.Score, err = strconv.Atoi(strings.TrimSpace(rec[si])); err != nil {
+					return nil, fmt.Errorf("строка %d: некорректная оценка %q", line, rec[si])
+				}
+			}
+			list = append(list, q)
+		}
+	}
+	for i, q := range list {
+		if strings.TrimSpace(q.Author) == "" || strings.TrimSpace(q.Text) == "" {
+			return nil, fmt.Errorf("запись %d: нужны автор и текст цитаты", i+1)
+		}
+	}
+	return list, nil
+}
+
+// writeQuotes записывает цитаты в w в формате format.
+func writeQuotes(w io.Writer, list []quotes.Quote, format string) error {
+	switch format {
+	case formatJSONL:
+		enc := json.NewEncoder(w)
+		for _, q := range list {
+			if err := enc.Encode(q); err != nil {
+				return err
+			}
+		}
+		return nil
+	case formatCSV:
+		cw := csv.NewWriter(w)
+		cw.Write(csvHeader)
+		for _, q := range list {
+			cw.Write([]string{q.Author, q.Text, strconv.Itoa(q.Score)})
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		if list == nil {
+			list = []quotes.Quote{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(list)
+	}
+}
+
+// runImport загружает цитаты в пространство. Идентификаторы из файла не сохраняются: цитаты получают
+// новые ID. С --skip-duplicates пропускаются цитаты, которые уже есть у того же автора.
+func runImport(c *ctl, args []string) error {
+	fs := c.flags("import")
+	format := fs.String("format", "", "формат: json, jsonl или csv; по умолчанию по расширению файла")
+	ws := fs.String("tenant", quotes.DefaultTenant, "рабочее пространство")
+	skip := fs.Bool("skip-duplicates", false, "пропускать цитаты, совпадающие по автору и тексту с уже сохранёнными")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return usageError{"ожидается не больше одного файла"}
+	}
+	name, err := tenant(*ws)
+	if err != nil {
+		return err
+	}
+	in, path := io.Reader(os.Stdin), ""
+	if fs.NArg() == 1 && fs.Arg(0) != "-" {
+		path = fs.Arg(0)
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	fmtName, err := detectFormat(*format, path)
+	if err != nil {
+		return err
+	}
+	list, err := readQuotes(in, fmtName)
+	if err != nil {
+		return err
+	}
+
+	base, err := c.open()
+	if err != nil {
+		return err
+	}
+	defer base.Close()
+	repo := base.Tenant(name)
+	seen := make(map[[2]string]bool)
+	if *skip {
+		existing, err := repo.GetAll()
+		if err != nil {
+			return err
+		}
+		for _, q := range existing {
+			seen[[2]string{q.Author, q.Text}] = true
+		}
+	}
+	res := struct {
+		Imported int `json:"imported"`
+		Skipped  int `json:"skipped"`
+	}{}
+	for _, q := range list {
+		key := [2]string{q.Author, q.Text}
+		if *skip && seen[key] {
+			res.Skipped++
+			continue
+		}
+		id, err := repo.Create(quotes.Quote{Author: q.Author, Text: q.Text})
+		if err != nil {
+			return fmt.Errorf("импортировано %d, ошибка на цитате %q: %w", res.Imported, q.Text, err)
+		}
+		if q.Score != 0 {
+			if err := repo.SetScore(id, q.Score); err != nil {
+				return err
+			}
+		}
+		seen[key] = true
+		res.Imported++
+	}
+	return c.print(res, fmt.Sprintf("Импортировано цитат: %d, пропущено повторов: %d", res.Imported, res.Skipped))
+}
+
+// runExport выгружает цитаты пространства в файл или stdout. Флаг --json не влияет на формат выгрузки.
+func runExport(c *ctl, args []string) error {
+	fs := c.flags("export")
+	format := fs.String("format", "", "формат: json, jsonl или csv; по умолчанию по расширению файла")
+	ws := fs.String("tenant", quotes.DefaultTenant, "рабочее пространство")
+	out := fs.String("o", "", "файл для выгрузки; по умолчанию stdout")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usageError{"лишние аргументы: " + strings.Join(fs.Args(), " ")}
+	}
+	name, err := tenant(*ws)
+	if err != nil {
+		return err
+	}
+	fmtName, err := detectFormat(*format, *out)
+	if err != nil {
+		return err
+	}
+	base, err := c.open()
+	if err != nil {
+		return err
+	}
+	defer base.Close()
+	list, err := base.Tenant(name).GetAll()
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		return writeQuotes(c.stdout, list, fmtName)
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err := writeQuotes(f, list, fmtName); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stderr, "Выгружено цитат: %d в %s\n", len(list), *out)
+	return nil
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"Test_Project_Brand_Scout/internal/backup"
+	"Test_Project_Brand_Scout/internal/quotes"
+	"Test_Project_Brand_Scout/internal/sqlitedriver"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// runBackup сохраняет копию базы в новый файл и её контрольную сумму в FILE.sha256;
+// сервер при этом может продолжать работу.
+func runBackup(c *ctl, args []string) error {
+	fs := c.flags("backup")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usageError{"ожидается путь к файлу копии"}
+	}
+	if c.cfg.DBMode != "sqlite" {
+		return errors.New("резервные копии делаются только при DB_MODE=sqlite")
+	}
+	repo, err := c.openSQLite()
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+	path := fs.Arg(0)
+	if err := repo.Backup(context.Background(), path); err != nil {
+		return err
+	}
+	sum, err := backup.WriteChecksum(path)
+	if err != nil {
+		return err
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	res := struct {
+		Path   string `json:"path"`
+		Bytes  int64  `json:"bytes"`
+		SHA256 string `json:"sha256"`
+	}{path, fi.Size(), sum}
+	return c.print(res, fmt.Sprintf("Копия базы сохранена в %s (%d байт)", path, res.Bytes))
+}
+
+// runRestore заменяет базу копией после проверки контрольной суммы, целостности и версии схемы.
+// Копия без файла контрольной суммы восстанавливается с предупреждением.
+// Файл подменяется атомарным переименованием; журналы WAL прежней базы удаляются.
+func runRestore(c *ctl, args []string) error {
+	fs := c.flags("restore")
+	force := fs.Bool("force", false, "заменить существующую базу")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usageError{"ожидается путь к файлу копии"}
+	}
+	if c.cfg.DBMode != "sqlite" {
+		return errors.New("восстановление возможно только при DB_MODE=sqlite")
+	}
+	src, dst := fs.Arg(0), c.cfg.DBPath
+	switch err := backup.VerifyChecksum(src); {
+	case errors.Is(err, backup.ErrNoChecksum):
+		fmt.Fprintf(c.stderr, "Предупреждение: у копии %s нет файла контрольной суммы, проверяется только структура базы\n", src)
+	case err != nil:
+		return fmt.Errorf("копия не прошла проверку: %w", err)
+	}
+	d, err := sqlitedriver.Lookup(c.cfg.SQLiteDriver)
+	if err != nil {
+		return err
+	}
+	version, err := quotes.VerifySQLiteFile(context.Background(), src, quotes.WithSQLDriver(d))
+	if err != nil {
+		return fmt.Errorf("копия не прошла проверку: %w", err)
+	}
+	if _, err := os.Stat(dst); err == nil && !*force {
+		return fmt.Errorf("база %s существует; остановите сервер и повторите с --force", dst)
+	}
+	if err := copyFile(src, dst); err != nil {
+		return err
+	}
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(dst + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	res := struct {
+		Path          string `json:"path"`
+		SchemaVersion int    `json:"schema_version"`
+	}{dst, version}
+	return c.print(res, fmt.Sprintf("База %s восстановлена из %s (версия схемы %d)", dst, src, version))
+}
+
+// copyFile копирует src во временный файл рядом с dst и переименовывает его в dst.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmp, err := os.CreateTemp(filepath.Dir(dst), filepath.Base(dst)+".restore-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, in); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
+
+// runMigrate показывает версию схемы и применяет недостающие миграции. С --status база не меняется.
+func runMigrate(c *ctl, args []string) error {
+	fs := c.flags("migrate")
+	status := fs.Bool("status", false, "только показать версию схемы")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	if c.cfg.DBMode != "sqlite" {
+		return errors.New("миграции применяются только при DB_MODE=sqlite")
+	}
+	d, err := sqlitedriver.Lookup(c.cfg.SQLiteDriver)
+	if err != nil {
+		return err
+	}
+	db, err := d.Open(c.cfg.DBPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	ctx := context.Background()
+	from, latest, err := quotes.SQLiteSchemaVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+	res := struct {
+		From    int  `json:"from"`
+		To      int  `json:"to"`
+		Latest  int  `json:"latest"`
+		Applied bool `json:"applied"`
+	}{From: from, To: from, Latest: latest}
+	if !*status && from < latest {
+		if err := quotes.MigrateSQLite(db); err != nil {
+			return err
+		}
+		res.To, res.Applied = latest, true
+	}
+	var text string
+	switch {
+	case res.Applied:
+		text = fmt.Sprintf("Схема обновлена с версии %d до %d", res.From, res.To)
+	case from < latest:
+		text = fmt.Sprintf("Версия схемы %d, доступна %d: выполните quotesctl migrate", from, latest)
+	case from > latest:
+		text = fmt.Sprintf("Версия схемы %d новее поддерживаемой %d: обновите quotesctl", from, latest)
+	default:
+		text = fmt.Sprintf("Версия схемы %d актуальна", from)
+	}
+	return c.print(res, text)
+}
+
+// runCheck проверяет целостность базы. Найденные проблемы дают код завершения 1.
+func runCheck(c *ctl, args []string) error {
+	fs := c.flags("check")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	if c.cfg.DBMode != "sqlite" {
+		return errors.New("проверка целостности возможна только при DB_MODE=sqlite")
+	}
+	repo, err := c.openSQLite()
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+	problems, err := repo.CheckIntegrity(context.Background())
+	if err != nil {
+		return err
+	}
+	res := struct {
+		OK       bool     `json:"ok"`
+		Problems []string `json:"problems"`
+	}{len(problems) == 0, problems}
+	if res.Problems == nil {
+		res.Problems = []string{}
+	}
+	text := "Проблем не найдено"
+	if !res.OK {
+		text = "Найдены проблемы:\n  " + strings.Join(problems, "\n  ")
+	}
+	if err := c.print(res, text); err != nil {
+		return err
+	}
+	if !res.OK {
+		return fmt.Errorf("найдено проблем: %d", len(problems))
+	}
+	return nil
+}
+
+// tenantStats — статистика одного рабочего пространства.
+type tenantStats struct {
+	Tenant  string `json:"tenant"`
+	Quotes  int    `json:"quotes"`
+	Authors int    `json:"authors"`
+}
+
+// runStats выводит число цитат и авторов по пространствам, ключей API и размер базы.
+func runStats(c *ctl, args []string) error {
+	fs := c.flags("stats")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	repo, err := c.open()
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+	counts, err := repo.QuoteCounts()
+	if err != nil {
+		return err
+	}
+	res := struct {
+		Quotes      int           `json:"quotes"`
+		Tenants     []tenantStats `json:"tenants"`
+		KeysActive  int           `json:"keys_active"`
+		KeysRevoked int           `json:"keys_revoked"`
+		DBBytes     int64         `json:"db_bytes"`
+	}{Tenants: []tenantStats{}}
+	for name, n := range counts {
+		all, err := repo.Tenant(name).GetAll()
+		if err != nil {
+			return err
+		}
+		authors := make(map[string]bool)
+		for _, q := range all {
+			authors[q.Author] = true
+		}
+		res.Tenants = append(res.Tenants, tenantStats{Tenant: name, Quotes: n, Authors: len(authors)})
+		res.Quotes += n
+	}
+	sort.Slice(res.Tenants, func(i, j int) bool { return res.Tenants[i].Tenant < res.Tenants[j].Tenant })
+	keys, err := repo.ListKeys()
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if k.RevokedAt != nil {
+			res.KeysRevoked++
+		} else {
+			res.KeysActive++
+		}
+	}
+	if dir := c.memoryDir(); dir != "" {
+		res.DBBytes = dirSize(dir)
+	} else if fi, err := os.Stat(c.cfg.DBPath); err == nil {
+		res.DBBytes = fi.Size()
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Цитат: %d\n", res.Quotes)
+	for _, t := range res.Tenants {
+		fmt.Fprintf(&b, "  %s\t%d цитат, %d авторов\n", t.Tenant, t.Quotes, t.Authors)
+	}
+	fmt.Fprintf(&b, "Ключей API: %d активных, %d отозванных\n", res.KeysActive, res.KeysRevoked)
+	fmt.Fprintf(&b, "Размер базы: %d байт", res.DBBytes)
+	return c.print(res, b.String())
+}
+
+// dirSize возвращает суммарный размер файлов каталога dir без вложенных каталогов.
+func dirSize(dir string) int64 {
+	entries, _ := os.ReadDir(dir)
+	var n int64
+	for _, e := range entries {
+		if fi, err := e.Info(); err == nil && fi.Mode().IsRegular() {
+			n += fi.Size()
+		}
+	}
+	return n
+}
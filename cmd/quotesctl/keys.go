@@ -0,0 +1,96 @@
+package main
+
+import (
+	"Test_Project_Brand_Scout/internal/auth"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// issuedKey — выданный ключ в выводе JSON вместе с самим ключом, который больше не будет показан.
+type issuedKey struct {
+	auth.Key
+	Token string `json:"key"`
+}
+
+// runKeys выдаёт, перечисляет и отзывает ключи API.
+func runKeys(c *ctl, args []string) error {
+	if len(args) == 0 {
+		return usageError{"ожидается issue, list или revoke"}
+	}
+	sub, args := args[0], args[1:]
+	fs := c.flags("keys " + sub)
+	var name, scopes, ws *string
+	if sub == "issue" {
+		name = fs.String("name", "", "имя ключа, например имя сервиса")
+		scopes = fs.String("scopes", auth.ScopeRead, "области доступа через запятую: quotes:read, quotes:write, admin")
+		ws = fs.String("tenant", "", "рабочее пространство ключа; без него ключ даёт доступ ко всем пространствам")
+	}
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	if ws != nil && *ws != "" {
+		if _, err := tenant(*ws); err != nil {
+			return err
+		}
+	}
+
+	switch sub {
+	case "issue", "list":
+		if fs.NArg() > 0 {
+			return usageError{"лишние аргументы: " + strings.Join(fs.Args(), " ")}
+		}
+	case "revoke":
+		if fs.NArg() != 1 {
+			return usageError{"ожидается ID ключа"}
+		}
+		if _, err := strconv.Atoi(fs.Arg(0)); err != nil {
+			return usageError{"некорректный ID ключа: " + fs.Arg(0)}
+		}
+	default:
+		return usageError{fmt.Sprintf("неизвестная команда keys %s", sub)}
+	}
+
+	repo, err := c.open()
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+	keys := auth.NewKeyManager(repo)
+	switch sub {
+	case "issue":
+		k, token, err := keys.Issue(*name, *ws, strings.Split(*scopes, ","))
+		if err != nil {
+			return err
+		}
+		return c.print(issuedKey{Key: k, Token: token}, fmt.Sprintf("Ключ %d (%s) выдан с областями %s. Сохраните его, он больше не будет показан:\n%s",
+			k.ID, k.Name, strings.Join(k.Scopes, ","), token))
+	case "list":
+		list, err := keys.List()
+		if err != nil {
+			return err
+		}
+		if list == nil {
+			list = []auth.Key{}
+		}
+		var b strings.Builder
+		for _, k := range list {
+			status := "активен"
+			if k.RevokedAt != nil {
+				status = "отозван " + k.RevokedAt.Format("2006-01-02")
+			}
+			tenant := k.Tenant
+			if tenant == "" {
+				tenant = "*"
+			}
+			fmt.Fprintf(&b, "%d\t%s\t%s…\t%s\t%s\t%s\n", k.ID, k.Name, k.Prefix, tenant, strings.Join(k.Scopes, ","), status)
+		}
+		return c.print(list, b.String())
+	default:
+		id, _ := strconv.Atoi(fs.Arg(0))
+		if err := keys.Revoke(id); err != nil {
+			return err
+		}
+		return c.print(map[string]any{"id": id, "revoked": true}, fmt.Sprintf("Ключ %d отозван", id))
+	}
+}
@@ -0,0 +1,184 @@
+// Команда quotesctl обслуживает хранилище цитат напрямую, без запущенного сервера: импорт и экспорт,
+// резервные копии, миграции схемы, проверку целостности, статистику и ключи API.
+// Хранилище и остальные параметры берутся из той же конфигурации, что и у сервера.
+package main
+
+import (
+	"Test_Project_Brand_Scout/internal/config"
+	"Test_Project_Brand_Scout/internal/quotes"
+	"Test_Project_Brand_Scout/internal/sqlitedriver"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// usageError — ошибка в аргументах команды; процесс завершается с кодом 2.
+type usageError struct{ msg string }
+
+func (e usageError) Error() string { return e.msg }
+
+// command — подкоманда quotesctl.
+type command struct {
+	name    string
+	args    string // краткое описание аргументов для справки
+	summary string
+	run     func(c *ctl, args []string) error
+}
+
+// commands — подкоманды в порядке вывода справки.
+var commands = []command{
+	{"import", "[--format json|jsonl|csv] [--tenant WORKSPACE] [--skip-duplicates] [FILE]", "загрузить цитаты из файла или stdin", runImport},
+	{"export", "[--format json|jsonl|csv] [--tenant WORKSPACE] [-o FILE]", "выгрузить цитаты пространства", runExport},
+	{"backup", "FILE", "сохранить согласованную копию базы", runBackup},
+	{"restore", "[--force] FILE", "заменить базу проверенной копией (сервер должен быть остановлен)", runRestore},
+	{"migrate", "[--status]", "применить недостающие миграции схемы", runMigrate},
+	{"check", "", "проверить целостность базы и данных", runCheck},
+	{"stats", "", "показать число цитат, авторов и ключей", runStats},
+	{"keys", "issue|list|revoke ...", "управлять ключами API", runKeys},
+}
+
+// ctl — общее состояние подкоманд: конфигурация и потоки вывода.
+type ctl struct {
+	cfg            config.Config
+	stdout, stderr io.Writer
+	json           bool
+}
+
+func main() {
+	cfg, flags, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		usage(os.Stdout)
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Некорректная конфигурация:\n%v\n", err)
+		os.Exit(2)
+	}
+	os.Exit(run(cfg, flags.Args, os.Stdout, os.Stderr))
+}
+
+// run выполняет подкоманду args[0] и возвращает код завершения процесса:
+// 0 при успехе, 1 при ошибке, 2 при неверных аргументах.
+func run(cfg config.Config, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+		c := &ctl{cfg: cfg, stdout: stdout, stderr: stderr}
+		err := cmd.run(c, args[1:])
+		var uerr usageError
+		switch {
+		case err == nil:
+			return 0
+		case errors.Is(err, flag.ErrHelp):
+			return 0
+		case errors.As(err, &uerr):
+			fmt.Fprintln(stderr, uerr.msg)
+			fmt.Fprintf(stderr, "использование: quotesctl %s %s\n", cmd.name, cmd.args)
+			return 2
+		default:
+			fmt.Fprintf(stderr, "quotesctl %s: %v\n", cmd.name, err)
+			return 1
+		}
+	}
+	fmt.Fprintf(stderr, "неизвестная команда %q\n", args[0])
+	usage(stderr)
+	return 2
+}
+
+// usage выводит список подкоманд.
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "использование: quotesctl [--config FILE] [параметры конфигурации] КОМАНДА [аргументы]")
+	fmt.Fprintln(w, "\nКоманды:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w, "\nУ каждой команды есть флаг --json для вывода в формате JSON.")
+}
+
+// flags создаёт набор флагов подкоманды с общим флагом --json.
+func (c *ctl) flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("quotesctl "+name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.BoolVar(&c.json, "json", false, "вывести результат в JSON")
+	return fs
+}
+
+// parse разбирает флаги подкоманды; ошибка разбора становится usageError.
+func (c *ctl) parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return usageError{err.Error()}
+	}
+	return nil
+}
+
+// open открывает хранилище из конфигурации так же, как сервер; к базе SQLite применяются миграции.
+// В режиме memory нужен MEMORY_DIR: команда читает журнал и снимок и дописывает в них изменения,
+// поэтому сервер с тем же каталогом должен быть остановлен.
+func (c *ctl) open() (quotes.Repository, error) {
+	if c.cfg.DBMode == "memory" && c.memoryDir() == "" {
+		return nil, errors.New("без MEMORY_DIR цитаты режима memory живут только в памяти сервера: задайте MEMORY_DIR или используйте DB_MODE=sqlite или DB_MODE=bolt")
+	}
+	d, err := sqlitedriver.Lookup(c.cfg.SQLiteDriver)
+	if err != nil {
+		return nil, err
+	}
+	repo, err := quotes.Open(c.cfg.StorageURL(), quotes.WithSQLDriver(d))
+	if err != nil {
+		return nil, fmt.Errorf("открытие базы %s: %w", c.cfg.DBPath, err)
+	}
+	return repo, nil
+}
+
+// memoryDir возвращает каталог журнала и снимков режима memory из адреса хранилища;
+// в остальных режимах и без сохранения — пустую строку.
+func (c *ctl) memoryDir() string {
+	dsn, err := quotes.ParseDSN(c.cfg.StorageURL())
+	if err != nil || dsn.Scheme != "memory" {
+		return ""
+	}
+	return dsn.Path
+}
+
+// openSQLite открывает базу SQLite для команд, которым нужен сам файл базы. Режим хранения
+// проверяет вызывающий.
+func (c *ctl) openSQLite() (*quotes.SQLiteRepo, error) {
+	repo, err := c.open()
+	if err != nil {
+		return nil, err
+	}
+	return repo.(*quotes.SQLiteRepo), nil
+}
+
+// tenant проверяет имя рабочего пространства из флага.
+func tenant(name string) (string, error) {
+	if err := quotes.ValidTenant(name); err != nil {
+		return "", usageError{err.Error()}
+	}
+	return name, nil
+}
+
+// print выводит результат: v в JSON при --json, иначе текст text.
+func (c *ctl) print(v any, text string) error {
+	if c.json {
+		enc := json.NewEncoder(c.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	_, err := io.WriteString(c.stdout, text)
+	return err
+}
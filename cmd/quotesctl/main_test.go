@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"Test_Project_Brand_Scout/internal/config"
+)
+
+// testConfig возвращает конфигурацию с базой SQLite во временном каталоге
+func testConfig(t *testing.T) config.Config {
+	cfg := config.Defaults()
+	cfg.DBMode = "sqlite"
+	cfg.DBPath = filepath.Join(t.TempDir(), "quotes.db")
+	return cfg
+}
+
+// exec выполняет команду и возвращает код завершения и вывод
+func exec(cfg config.Config, args ...string) (int, string, string) {
+	var out, errOut bytes.Buffer
+	code := run(cfg, args, &out, &errOut)
+	return code, out.String(), errOut.String()
+}
+
+// mustExec выполняет команду и завершает тест при ненулевом коде
+func mustExec(t *testing.T, cfg config.Config, args ...string) string {
+	t.Helper()
+	code, out, errOut := exec(cfg, args...)
+	if code != 0 {
+		t.Fatalf("%v: ожидается код 0, получен %d: %s", args, code, errOut)
+	}
+	return out
+}
+
+// writeFile записывает файл во временный каталог теста
+func writeFile(t *testing.T, name, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("ошибка записи %s: %v", name, err)
+	}
+	return path
+}
+
+// TestImportExport проверяет импорт CSV и JSON Lines, пропуск повторов и выгрузку в разных форматах
+func TestImportExport(t *testing.T) {
+	cfg := testConfig(t)
+	csvFile := writeFile(t, "quotes.csv", "author,quote,score\nТолстой,\"Все счастливые семьи похожи друг на друга\",5\nЧехов,Краткость — сестра таланта,\n")
+	out := mustExec(t, cfg, "import", "--json", csvFile)
+	var res map[string]int
+	if err := json.Unmarshal([]byte(out), &res); err != nil || res["imported"] != 2 {
+		t.Fatalf("ожидается импорт 2 цитат, получено %s", out)
+	}
+
+	jsonl := writeFile(t, "more.jsonl", `{"author":"Чехов","quote":"Краткость — сестра таланта"}`+"\n"+`{"author":"Пушкин","quote":"Мороз и солнце"}`+"\n")
+	mustExec(t, cfg, "import", "--skip-duplicates", "--json", jsonl)
+	out = mustExec(t, cfg, "export", "--format", "json")
+	var list []map[string]any
+	if err := json.Unmarshal([]byte(out), &list); err != nil || len(list) != 3 {
+		t.Fatalf("ожидается 3 цитаты без повтора, получено %s", out)
+	}
+	if list[0]["author"] != "Толстой" || list[0]["score"] != float64(5) {
+		t.Errorf("оценка из CSV не сохранена: %v", list[0])
+	}
+
+	mustExec(t, cfg, "import", "--tenant", "team-a", jsonl)
+	csvOut := mustExec(t, cfg, "export", "--tenant", "team-a", "--format", "csv")
+	if !strings.HasPrefix(csvOut, "author,quote,score\n") || strings.Count(csvOut, "\n") != 3 {
+		t.Errorf("неожиданная выгрузка CSV: %q", csvOut)
+	}
+	path := filepath.Join(t.TempDir(), "out.jsonl")
+	mustExec(t, cfg, "export", "-o", path)
+	if data, _ := os.ReadFile(path); strings.Count(string(data), "\n") != 3 {
+		t.Errorf("формат должен определяться по расширению файла, получено %q", data)
+	}
+
+	bad := writeFile(t, "bad.json", `[{"author":"","quote":"без автора"}]`)
+	if code, _, errOut := exec(cfg, "import", bad); code != 1 || !strings.Contains(errOut, "запись 1") {
+		t.Errorf("ожидается код 1 с номером записи, получено %d %s", code, errOut)
+	}
+	if code, _, _ := exec(cfg, "export", "--format", "xml"); code != 2 {
+		t.Errorf("ожидается код 2 для неизвестного формата, получен %d", code)
+	}
+}
+
+// TestBackupRestore проверяет копию базы, отказ восстановления поверх базы без --force и проверку копии
+func TestBackupRestore(t *testing.T) {
+	cfg := testConfig(t)
+	mustExec(t, cfg, "import", writeFile(t, "q.jsonl", `{"author":"A","quote":"первая"}`+"\n"))
+	backup := filepath.Join(t.TempDir(), "backup.db")
+	mustExec(t, cfg, "backup", backup)
+	if code, _, _ := exec(cfg, "backup", backup); code != 1 {
+		t.Errorf("копия не должна перезаписывать существующий файл, получен код %d", code)
+	}
+
+	mustExec(t, cfg, "import", writeFile(t, "q2.jsonl", `{"author":"B","quote":"вторая"}`+"\n"))
+	if code, _, errOut := exec(cfg, "restore", backup); code != 1 || !strings.Contains(errOut, "--force") {
+		t.Errorf("без --force ожидается отказ, получено %d %s", code, errOut)
+	}
+	mustExec(t, cfg, "restore", "--force", backup)
+	out := mustExec(t, cfg, "export")
+	if !strings.Contains(out, "первая") || strings.Contains(out, "вторая") {
+		t.Errorf("после восстановления ожидается состояние копии, получено %s", out)
+	}
+
+	if _, err := os.Stat(backup + ".sha256"); err != nil {
+		t.Errorf("рядом с копией ожидается файл контрольной суммы: %v", err)
+	}
+	tampered := filepath.Join(t.TempDir(), "tampered.db")
+	data, _ := os.ReadFile(backup)
+	sum, _ := os.ReadFile(backup + ".sha256")
+	os.WriteFile(tampered, append(data, 0), 0o644)
+	os.WriteFile(tampered+".sha256", sum, 0o644)
+	if code, _, errOut := exec(cfg, "restore", "--force", tampered); code != 1 || !strings.Contains(errOut, "контрольная сумма") {
+		t.Errorf("копия с неверной контрольной суммой должна отклоняться, получено %d %s", code, errOut)
+	}
+
+	broken := writeFile(t, "broken.db", "это не база данных")
+	if code, _, errOut := exec(cfg, "restore", "--force", broken); code != 1 || !strings.Contains(errOut, "проверку") {
+		t.Errorf("повреждённая копия должна отклоняться, получено %d %s", code, errOut)
+	}
+}
+
+// TestMigrateCheckStats проверяет миграции, проверку целостности и статистику
+func TestMigrateCheckStats(t *testing.T) {
+	cfg := testConfig(t)
+	out := mustExec(t, cfg, "migrate", "--status", "--json")
+	var mig map[string]any
+	if err := json.Unmarshal([]byte(out), &mig); err != nil || mig["from"] != float64(0) || mig["applied"] != false {
+		t.Fatalf("новая база без миграций: ожидается версия 0, получено %s", out)
+	}
+	out = mustExec(t, cfg, "migrate", "--json")
+	json.Unmarshal([]byte(out), &mig)
+	if mig["applied"] != true || mig["to"] != mig["latest"] {
+		t.Errorf("ожидается применение всех миграций, получено %s", out)
+	}
+	if out := mustExec(t, cfg, "migrate"); !strings.Contains(out, "актуальна") {
+		t.Errorf("повторный запуск не должен ничего менять, получено %s", out)
+	}
+
+	mustExec(t, cfg, "import", writeFile(t, "q.jsonl", `{"author":"A","quote":"1"}`+"\n"+`{"author":"A","quote":"2"}`+"\n"))
+	mustExec(t, cfg, "keys", "issue", "--name", "ci")
+	if out := mustExec(t, cfg, "check"); !strings.Contains(out, "не найдено") {
+		t.Errorf("ожидается целая база, получено %s", out)
+	}
+
+	out = mustExec(t, cfg, "stats", "--json")
+	var stats struct {
+		Quotes     int `json:"quotes"`
+		KeysActive int `json:"keys_active"`
+		Tenants    []struct {
+			Authors int `json:"authors"`
+		} `json:"tenants"`
+	}
+	if err := json.Unmarshal([]byte(out), &stats); err != nil || stats.Quotes != 2 || stats.KeysActive != 1 || stats.Tenants[0].Authors != 1 {
+		t.Errorf("неожиданная статистика: %s", out)
+	}
+}
+
+// TestBolt проверяет импорт, выгрузку и статистику в базе bbolt и отказ команд, которым нужен файл SQLite
+func TestBolt(t *testing.T) {
+	cfg := config.Defaults()
+	cfg.DBMode, cfg.DBPath = "bolt", filepath.Join(t.TempDir(), "quotes.bolt")
+	mustExec(t, cfg, "import", "--tenant", "team-a", writeFile(t, "q.jsonl", `{"author":"A","quote":"первая"}`+"\n"+`{"author":"B","quote":"вторая"}`+"\n"))
+	if out := mustExec(t, cfg, "export", "--tenant", "team-a", "--format", "jsonl"); strings.Count(out, "\n") != 2 {
+		t.Errorf("ожидается выгрузка двух цитат, получено %q", out)
+	}
+	out := mustExec(t, cfg, "stats", "--json")
+	var stats struct {
+		Quotes  int   `json:"quotes"`
+		DBBytes int64 `json:"db_bytes"`
+	}
+	if err := json.Unmarshal([]byte(out), &stats); err != nil || stats.Quotes != 2 || stats.DBBytes == 0 {
+		t.Errorf("неожиданная статистика: %s", out)
+	}
+	for _, args := range [][]string{{"backup", filepath.Join(t.TempDir(), "b.db")}, {"check"}, {"migrate"}} {
+		if code, _, errOut := exec(cfg, args...); code != 1 || !strings.Contains(errOut, "DB_MODE=sqlite") {
+			t.Errorf("%v: ожидается код 1 с подсказкой DB_MODE=sqlite, получено %d %s", args, code, errOut)
+		}
+	}
+}
+
+// TestMemoryDir проверяет работу с сохраняемым хранилищем в памяти и отказ без MEMORY_DIR
+func TestMemoryDir(t *testing.T) {
+	cfg := config.Defaults()
+	cfg.DBMode, cfg.MemoryDir = "memory", t.TempDir()
+	mustExec(t, cfg, "import", writeFile(t, "q.jsonl", `{"author":"A","quote":"первая"}`+"\n"))
+	if out := mustExec(t, cfg, "export", "--format", "jsonl"); !strings.Contains(out, "первая") {
+		t.Errorf("импортированная цитата должна сохраниться в MEMORY_DIR, получено %q", out)
+	}
+	out := mustExec(t, cfg, "stats", "--json")
+	var stats struct {
+		Quotes  int   `json:"quotes"`
+		DBBytes int64 `json:"db_bytes"`
+	}
+	if err := json.Unmarshal([]byte(out), &stats); err != nil || stats.Quotes != 1 || stats.DBBytes == 0 {
+		t.Errorf("неожиданная статистика: %s", out)
+	}
+
+	cfg.MemoryDir = ""
+	if code, _, errOut := exec(cfg, "export"); code != 1 || !strings.Contains(errOut, "MEMORY_DIR") {
+		t.Errorf("без MEMORY_DIR ожидается код 1 с подсказкой, получено %d %s", code, errOut)
+	}
+}
+
+// TestKeys проверяет выдачу, список и отзыв ключей с выводом JSON
+func TestKeys(t *testing.T) {
+	cfg := testConfig(t)
+	out := mustExec(t, cfg, "keys", "issue", "--name", "ci", "--scopes", "quotes:read,quotes:write", "--json")
+	var issued map[string]any
+	if err := json.Unmarshal([]byte(out), &issued); err != nil || !strings.HasPrefix(issued["key"].(string), "qk_") || issued["name"] != "ci" {
+		t.Fatalf("ожидается JSON с ключом, получено %s", out)
+	}
+	mustExec(t, cfg, "keys", "revoke", "1")
+	out = mustExec(t, cfg, "keys", "list", "--json")
+	var list []map[string]any
+	if err := json.Unmarshal([]byte(out), &list); err != nil || len(list) != 1 || list[0]["revoked_at"] == nil || list[0]["hash"] != nil {
+		t.Errorf("ожидается отозванный ключ без хеша, получено %s", out)
+	}
+	if code, _, _ := exec(cfg, "keys", "rotate"); code != 2 {
+		t.Errorf("ожидается код 2 для неизвестной подкоманды, получен %d", code)
+	}
+	if code, _, _ := exec(cfg, "keys", "issue", "--scopes", "root"); code != 1 {
+		t.Errorf("ожидается код 1 для неизвестной области, получен %d", code)
+	}
+
+	// Ключи выдаются в тех же хранилищах, что и цитаты: в bbolt и в памяти с MEMORY_DIR
+	bolt := config.Defaults()
+	bolt.DBMode, bolt.DBPath = "bolt", filepath.Join(t.TempDir(), "quotes.bolt")
+	memory := config.Defaults()
+	memory.DBMode, memory.MemoryDir = "memory", t.TempDir()
+	for _, cfg := range []config.Config{bolt, memory} {
+		mustExec(t, cfg, "keys", "issue", "--name", "ci", "--tenant", "team-a")
+		if out := mustExec(t, cfg, "keys", "list"); !strings.Contains(out, "team-a") {
+			t.Errorf("%s: ожидается ключ пространства team-a, получено %s", cfg.DBMode, out)
+		}
+	}
+	memory.MemoryDir = ""
+	if code, _, errOut := exec(memory, "keys", "list"); code != 1 || !strings.Contains(errOut, "MEMORY_DIR") {
+		t.Errorf("в режиме memory без MEMORY_DIR ожидается код 1 с подсказкой, получен %d: %s", code, errOut)
+	}
+}
+
+// TestRunErrors проверяет коды завершения для неизвестной команды и режима memory
+func TestRunErrors(t *testing.T) {
+	if code, _, _ := exec(testConfig(t)); code != 2 {
+		t.Errorf("без команды ожидается код 2, получен %d", code)
+	}
+	if code, _, _ := exec(testConfig(t), "vacuum"); code != 2 {
+		t.Errorf("для неизвестной команды ожидается код 2, получен %d", code)
+	}
+	if code, _, errOut := exec(config.Defaults(), "stats"); code != 1 || !strings.Contains(errOut, "DB_MODE=sqlite") {
+		t.Errorf("в режиме memory ожидается код 1 с подсказкой, получено %d %s", code, errOut)
+	}
+}
@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// keyPrefix отличает ключи API от других токенов в заголовке Authorization.
+const keyPrefix = "qk_"
+
+// ErrKeyNotFound возвращается, если ключ не найден в хранилище.
+var ErrKeyNotFound = errors.New("ключ не найден")
+
+// ErrInvalidScope возвращается при выдаче ключа с неизвестной областью доступа.
+var ErrInvalidScope = errors.New("неизвестная область доступа")
+
+// knownScopes — области доступа, которые можно выдать ключу.
+var knownScopes = []string{ScopeRead, ScopeWrite, ScopeAdmin}
+
+// Key — ключ API в хранилище. Сам ключ не хранится: только его SHA-256 хеш
+// и короткий префикс, по которому ключ можно узнать в списке.
+type Key struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"`
+	Hash      string     `json:"-"`
+	Scopes    []string   `json:"scopes"`
+	Tenant    string     `json:"tenant,omitempty"` // пустое значение — ключ оператора без привязки
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// KeyStore описывает хранилище ключей API; его реализуют репозитории цитат.
+type KeyStore interface {
+	// CreateKey сохраняет ключ и возвращает его с присвоенным ID.
+	CreateKey(k Key) (Key, error)
+	// KeyByHash возвращает ключ по хешу или ErrKeyNotFound.
+	KeyByHash(hash string) (Key, error)
+	// ListKeys возвращает все ключи, включая отозванные.
+	ListKeys() ([]Key, error)
+	// RevokeKey помечает ключ отозванным или возвращает ErrKeyNotFound.
+	RevokeKey(id int, at time.Time) error
+}
+
+// HashKey возвращает шестнадцатеричный SHA-256 хеш ключа.
+// Ключи содержат 256 бит случайности, поэтому медленный хеш паролей не нужен.
+func HashKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// KeyManager выдаёт, перечисляет и отзывает ключи API и аутентифицирует по ним запросы.
+type KeyManager struct {
+	store KeyStore
+	now   func() time.Time
+}
+
+// NewKeyManager создаёт менеджер ключей поверх хранилища.
+func NewKeyManager(store KeyStore) *KeyManager {
+	return &KeyManager{store: store, now: time.Now}
+}
+
+// Issue создаёт ключ с именем name и областями scopes, привязанный к рабочему пространству tenant
+// (пустое значение — ключ оператора). Возвращает сохранённую запись и сам ключ — он показывается только один раз.
+func (m *KeyManager) Issue(name, tenant string, scopes []string) (Key, string, error) {
+	if len(scopes) == 0 {
+		return Key{}, "", fmt.Errorf("%w: не указано ни одной", ErrInvalidScope)
+	}
+	for _, s := range scopes {
+		if !slices.Contains(knownScopes, s) {
+			return Key{}, "", fmt.Errorf("%w: %s", ErrInvalidScope, s)
+		}
+	}
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return Key{}, "", err
+	}
+	token := keyPrefix + base64.RawURLEncoding.EncodeToString(raw)
+	k, err := m.store.CreateKey(Key{
+		Name:      name,
+		Prefix:    token[:len(keyPrefix)+6],
+		Hash:      HashKey(token),
+		Scopes:    scopes,
+		Tenant:    tenant,
+		CreatedAt: m.now().UTC(),
+	})
+	if err != nil {
+		return Key{}, "", err
+	}
+	return k, token, nil
+}
+
+// Ensure сохраняет заранее известный ключ token с областями scopes, если его ещё нет в хранилище.
+// Используется для первого административного ключа, когда выдать ключ через API ещё нечем.
+func (m *KeyManager) Ensure(name, token string, scopes []string) error {
+	if !strings.HasPrefix(token, keyPrefix) || len(token) < len(keyPrefix)+16 {
+		return fmt.Errorf("ключ должен начинаться с %s и содержать не меньше 16 символов после префикса", keyPrefix)
+	}
+	_, err := m.store.KeyByHash(HashKey(token))
+	if !errors.Is(err, ErrKeyNotFound) {
+		return err
+	}
+	_, err = m.store.CreateKey(Key{
+		Name:      name,
+		Prefix:    token[:len(keyPrefix)+6],
+		Hash:      HashKey(token),
+		Scopes:    scopes,
+		CreatedAt: m.now().UTC(),
+	})
+	return err
+}
+
+// List возвращает все ключи без их хешей.
+func (m *KeyManager) List() ([]Key, error) {
+	return m.store.ListKeys()
+}
+
+// Revoke отзывает ключ; дальнейшие запросы с ним получают 401.
+func (m *KeyManager) Revoke(id int) error {
+	return m.store.RevokeKey(id, m.now().UTC())
+}
+
+// Authenticate проверяет ключ из заголовка Authorization: Bearer.
+func (m *KeyManager) Authenticate(r *http.Request) (Identity, error) {
+	token, ok := BearerToken(r)
+	if !ok || !strings.HasPrefix(token, keyPrefix) {
+		return Identity{}, ErrUnauthorized
+	}
+	k, err := m.store.KeyByHash(HashKey(token))
+	if errors.Is(err, ErrKeyNotFound) || (err == nil && k.RevokedAt != nil) {
+		return Identity{}, ErrUnauthorized
+	}
+	if err != nil {
+		return Identity{}, err
+	}
+	return Identity{Subject: fmt.Sprintf("key:%d", k.ID), Scopes: k.Scopes, Tenant: k.Tenant}, nil
+}
@@ -0,0 +1,187 @@
+// Тесты выдачи, проверки и отзыва ключей API
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// memStore — простое хранилище ключей для тестов
+type memStore struct {
+	keys []Key
+}
+
+func (s *memStore) CreateKey(k Key) (Key, error) {
+	k.ID = len(s.keys) + 1
+	s.keys = append(s.keys, k)
+	return k, nil
+}
+
+func (s *memStore) KeyByHash(hash string) (Key, error) {
+	for _, k := range s.keys {
+		if k.Hash == hash {
+			return k, nil
+		}
+	}
+	return Key{}, ErrKeyNotFound
+}
+
+func (s *memStore) ListKeys() ([]Key, error) { return s.keys, nil }
+
+func (s *memStore) RevokeKey(id int, at time.Time) error {
+	for i := range s.keys {
+		if s.keys[i].ID == id {
+			s.keys[i].RevokedAt = &at
+			return nil
+		}
+	}
+	return ErrKeyNotFound
+}
+
+// bearer создаёт запрос с ключом в заголовке Authorization
+func bearer(token string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
+
+// TestKeyManagerLifecycle проверяет выдачу ключа, аутентификацию и отзыв
+func TestKeyManagerLifecycle(t *testing.T) {
+	store := &memStore{}
+	keys := NewKeyManager(store)
+
+	k, token, err := keys.Issue("ci", "", []string{ScopeRead, ScopeWrite})
+	if err != nil {
+		t.Fatalf("ошибка выдачи ключа: %v", err)
+	}
+	if !strings.HasPrefix(token, keyPrefix) || !strings.HasPrefix(token, k.Prefix) {
+		t.Errorf("неожиданный формат ключа %q с префиксом %q", token, k.Prefix)
+	}
+	if store.keys[0].Hash == token || store.keys[0].Hash != HashKey(token) {
+		t.Error("в хранилище должен попадать только хеш ключа")
+	}
+
+	id, err := keys.Authenticate(bearer(token))
+	if err != nil || id.Subject != "key:1" || !id.HasScope(ScopeWrite) || id.HasScope(ScopeAdmin) {
+		t.Errorf("неожиданная личность %+v, ошибка %v", id, err)
+	}
+	if _, err := keys.Authenticate(bearer(token + "x")); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("ожидается ErrUnauthorized для чужого ключа, получено %v", err)
+	}
+
+	if err := keys.Revoke(k.ID); err != nil {
+		t.Fatalf("ошибка отзыва ключа: %v", err)
+	}
+	if _, err := keys.Authenticate(bearer(token)); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("ожидается ErrUnauthorized для отозванного ключа, получено %v", err)
+	}
+
+	if _, _, err := keys.Issue("bad", "", []string{"quotes:everything"}); !errors.Is(err, ErrInvalidScope) {
+		t.Errorf("ожидается ErrInvalidScope, получено %v", err)
+	}
+	if _, _, err := keys.Issue("empty", "", nil); !errors.Is(err, ErrInvalidScope) {
+		t.Errorf("ожидается ErrInvalidScope для пустого списка, получено %v", err)
+	}
+}
+
+// TestKeyManagerEnsure проверяет сохранение заранее известного ключа без дублей
+func TestKeyManagerEnsure(t *testing.T) {
+	store := &memStore{}
+	keys := NewKeyManager(store)
+	token := "qk_bootstrap-secret-value"
+	for i := 0; i < 2; i++ {
+		if err := keys.Ensure("bootstrap", token, []string{ScopeAdmin}); err != nil {
+			t.Fatalf("ошибка Ensure: %v", err)
+		}
+	}
+	if len(store.keys) != 1 {
+		t.Errorf("ожидается один ключ, получено %d", len(store.keys))
+	}
+	if id, err := keys.Authenticate(bearer(token)); err != nil || !id.HasScope(ScopeAdmin) {
+		t.Errorf("ожидается административный доступ, получено %+v, %v", id, err)
+	}
+	if err := keys.Ensure("short", "qk_short", []string{ScopeAdmin}); err == nil {
+		t.Error("ожидается ошибка для слишком короткого ключа")
+	}
+}
+
+// TestKeyHandlers проверяет административные эндпоинты ключей
+func TestKeyHandlers(t *testing.T) {
+	keys := NewKeyManager(&memStore{})
+	keys.Ensure("root", "qk_root-secret-value-1234", []string{ScopeAdmin})
+	r := mux.NewRouter()
+	RegisterKeyHandlers(r, keys, keys)
+
+	do := func(method, path, body, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do(http.MethodGet, "/admin/keys", "", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("ожидается 401 без ключа, получен %d", w.Code)
+	}
+	w := do(http.MethodPost, "/admin/keys", `{"name":"app","scopes":["quotes:read"]}`, "qk_root-secret-value-1234")
+	if w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), `"key":"qk_`) {
+		t.Fatalf("ожидается 201 и ключ в ответе, получено %d %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodPost, "/admin/keys", `{"name":"x","scopes":["root"]}`, "qk_root-secret-value-1234"); w.Code != http.StatusBadRequest {
+		t.Errorf("ожидается 400 для неизвестной области, получен %d", w.Code)
+	}
+	w = do(http.MethodGet, "/admin/keys", "", "qk_root-secret-value-1234")
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), "hash") {
+		t.Errorf("список ключей не должен раскрывать хеши: %d %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodDelete, "/admin/keys/2", "", "qk_root-secret-value-1234"); w.Code != http.StatusNoContent {
+		t.Errorf("ожидается 204 при отзыве, получен %d", w.Code)
+	}
+	if w := do(http.MethodDelete, "/admin/keys/99", "", "qk_root-secret-value-1234"); w.Code != http.StatusNotFound {
+		t.Errorf("ожидается 404 для несуществующего ключа, получен %d", w.Code)
+	}
+}
+
+// TestKeyHandlersTenant проверяет, что администратор пространства управляет только ключами своего пространства
+func TestKeyHandlersTenant(t *testing.T) {
+	keys := NewKeyManager(&memStore{})
+	keys.Ensure("root", "qk_root-secret-value-1234", []string{ScopeAdmin})
+	_, teamAdmin, _ := keys.Issue("team-a admin", "team-a", []string{ScopeAdmin})
+	keys.Issue("team-b app", "team-b", []string{ScopeRead})
+	r := mux.NewRouter()
+	RegisterKeyHandlers(r, keys, keys)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+teamAdmin)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if id, _ := keys.Authenticate(bearer(teamAdmin)); id.Tenant != "team-a" {
+		t.Errorf("ожидается привязка к team-a, получено %q", id.Tenant)
+	}
+	w := do(http.MethodPost, "/admin/keys", `{"name":"app","scopes":["quotes:read"]}`)
+	if w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), `"tenant":"team-a"`) {
+		t.Errorf("ключ должен наследовать пространство администратора: %d %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodPost, "/admin/keys", `{"name":"x","scopes":["quotes:read"],"tenant":"team-b"}`); w.Code != http.StatusForbidden {
+		t.Errorf("ожидается 403 при выдаче ключа чужого пространства, получен %d", w.Code)
+	}
+	w = do(http.MethodGet, "/admin/keys", "")
+	if strings.Contains(w.Body.String(), "team-b") || strings.Contains(w.Body.String(), `"root"`) {
+		t.Errorf("в списке не должно быть чужих ключей: %s", w.Body.String())
+	}
+	if w := do(http.MethodDelete, "/admin/keys/3", ""); w.Code != http.StatusNotFound {
+		t.Errorf("ожидается 404 при отзыве ключа чужого пространства, получен %d", w.Code)
+	}
+}
@@ -0,0 +1,149 @@
+// Package auth содержит аутентификацию клиентов API и проверку прав доступа к маршрутам.
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Области доступа (scopes), которые проверяются на маршрутах.
+const (
+	ScopeRead  = "quotes:read"
+	ScopeWrite = "quotes:write"
+	ScopeAdmin = "admin"
+)
+
+// ErrUnauthorized возвращается, если учётные данные отсутствуют или недействительны.
+var ErrUnauthorized = errors.New("требуется действительный ключ доступа")
+
+// ErrForbidden возвращается, если у клиента нет нужной области доступа.
+var ErrForbidden = errors.New("недостаточно прав")
+
+// Identity описывает аутентифицированного клиента и его области доступа.
+type Identity struct {
+	Subject string   // устойчивый идентификатор клиента, например key:3 или jwt:<sub>
+	Roles   []string // роли из токена SSO; у ключей API пусто
+	Scopes  []string // выданные области доступа
+	// Tenant — рабочее пространство, к которому привязан клиент; пустое значение означает
+	// оператора, которому доступны все пространства
+	Tenant string
+}
+
+// HasScope сообщает, есть ли у клиента область scope; область admin разрешает всё.
+func (id Identity) HasScope(scope string) bool {
+	return slices.Contains(id.Scopes, scope) || slices.Contains(id.Scopes, ScopeAdmin)
+}
+
+// Authenticator проверяет учётные данные запроса и возвращает личность клиента.
+// Возвращает ErrUnauthorized, если учётные данные отсутствуют или недействительны.
+type Authenticator interface {
+	Authenticate(r *http.Request) (Identity, error)
+}
+
+// Chain объединяет несколько способов аутентификации: запрос принимается первым из них,
+// который его признал. Так ключи API и токены SSO работают одновременно.
+type Chain []Authenticator
+
+// Authenticate пробует аутентификаторы по очереди; ErrUnauthorized возвращается,
+// только если запрос не признал ни один из них.
+func (c Chain) Authenticate(r *http.Request) (Identity, error) {
+	err := ErrUnauthorized
+	for _, a := range c {
+		id, aerr := a.Authenticate(r)
+		if aerr == nil {
+			return id, nil
+		}
+		if !errors.Is(aerr, ErrUnauthorized) {
+			return Identity{}, aerr
+		}
+		err = aerr
+	}
+	return Identity{}, err
+}
+
+// identityKey — ключ контекста, под которым хранится Identity.
+type identityKey struct{}
+
+// WithIdentity возвращает контекст с сохранённой личностью клиента.
+func WithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// FromContext возвращает личность клиента, сохранённую middleware Require.
+func FromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(Identity)
+	return id, ok
+}
+
+// BearerToken извлекает токен из заголовка Authorization: Bearer <token>.
+func BearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// Identify определяет личность клиента до выбора обработчика, не требуя её:
+// запрос с недействительными учётными данными проходит дальше без личности в контексте.
+// Так middleware, которым нужен клиент (например, ограничитель запросов), работают до Require,
+// а Require не проверяет учётные данные повторно.
+func Identify(authn Authenticator, next http.Handler) http.Handler {
+	if authn == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, err := authn.Authenticate(r); err == nil {
+			r = r.WithContext(WithIdentity(r.Context(), id))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Require оборачивает обработчик проверкой области доступа scope.
+// Без аутентификатора (authn == nil) обработчик возвращается без изменений — режим без авторизации.
+// Личность, уже определённая Identify, используется без повторной проверки.
+// Отсутствие или недействительность ключа даёт 401, нехватка прав — 403, сбой хранилища — 500.
+func Require(authn Authenticator, scope string, next http.Handler) http.Handler {
+	if authn == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := FromContext(r.Context())
+		var err error
+		if !ok {
+			id, err = authn.Authenticate(r)
+		}
+		if errors.Is(err, ErrUnauthorized) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="quotes"`)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !id.HasScope(scope) {
+			w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+scope+`"`)
+			http.Error(w, ErrForbidden.Error(), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(WithIdentity(r.Context(), id)))
+	})
+}
+
+// OperatorOnly отклоняет запросы клиентов, привязанных к рабочему пространству, с 403. Им закрыты
+// эндпоинты с данными всех пространств: резервные копии и метрики. Ставится внутри Require.
+func OperatorOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, _ := FromContext(r.Context()); id.Tenant != "" {
+			http.Error(w, ErrForbidden.Error(), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
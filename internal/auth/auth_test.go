@@ -0,0 +1,105 @@
+// Тесты middleware проверки областей доступа
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// staticAuth — аутентификатор, принимающий единственный токен с заданными областями
+type staticAuth struct {
+	token  string
+	scopes []string
+}
+
+func (a staticAuth) Authenticate(r *http.Request) (Identity, error) {
+	if token, ok := BearerToken(r); !ok || token != a.token {
+		return Identity{}, ErrUnauthorized
+	}
+	return Identity{Subject: "test", Scopes: a.scopes}, nil
+}
+
+// TestRequire проверяет коды ответа middleware для разных учётных данных
+func TestRequire(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, found := FromContext(r.Context()); !found || id.Subject != "test" {
+			t.Error("личность клиента не передана в контексте")
+		}
+	})
+	reader := staticAuth{token: "reader", scopes: []string{ScopeRead}}
+	root := staticAuth{token: "root", scopes: []string{ScopeAdmin}}
+
+	cases := []struct {
+		name   string
+		authn  Authenticator
+		header string
+		scope  string
+		code   int
+	}{
+		{"без заголовка", reader, "", ScopeRead, http.StatusUnauthorized},
+		{"чужая схема", reader, "Basic reader", ScopeRead, http.StatusUnauthorized},
+		{"неверный токен", reader, "Bearer nope", ScopeRead, http.StatusUnauthorized},
+		{"достаточно прав", reader, "Bearer reader", ScopeRead, http.StatusOK},
+		{"схема в нижнем регистре", reader, "bearer reader", ScopeRead, http.StatusOK},
+		{"нет области", reader, "Bearer reader", ScopeWrite, http.StatusForbidden},
+		{"admin разрешает всё", root, "Bearer root", ScopeWrite, http.StatusOK},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if c.header != "" {
+			req.Header.Set("Authorization", c.header)
+		}
+		w := httptest.NewRecorder()
+		Require(c.authn, c.scope, ok).ServeHTTP(w, req)
+		if w.Code != c.code {
+			t.Errorf("%s: ожидается %d, получен %d", c.name, c.code, w.Code)
+		}
+		if c.code == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("%s: ожидается заголовок WWW-Authenticate", c.name)
+		}
+	}
+
+	// Без аутентификатора проверка отключена
+	w := httptest.NewRecorder()
+	called := false
+	Require(nil, ScopeAdmin, http.HandlerFunc(func(http.ResponseWriter, *http.Request) { called = true })).
+		ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !called || w.Code != http.StatusOK {
+		t.Errorf("ожидается пропуск запроса без аутентификатора, получен %d", w.Code)
+	}
+}
+
+// countingAuth считает вызовы Authenticate
+type countingAuth struct {
+	staticAuth
+	calls *int
+}
+
+func (a countingAuth) Authenticate(r *http.Request) (Identity, error) {
+	*a.calls++
+	return a.staticAuth.Authenticate(r)
+}
+
+// TestIdentify проверяет, что Identify не отклоняет запросы, а Require не проверяет личность повторно
+func TestIdentify(t *testing.T) {
+	calls := 0
+	authn := countingAuth{staticAuth{token: "reader", scopes: []string{ScopeRead}}, &calls}
+	h := Identify(authn, Require(authn, ScopeRead, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer reader")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || calls != 1 {
+		t.Errorf("ожидается 200 и одна проверка, получено %d и %d", w.Code, calls)
+	}
+
+	// Недействительный токен проходит Identify и отклоняется в Require
+	req.Header.Set("Authorization", "Bearer wrong")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("ожидается 401, получен %d", w.Code)
+	}
+}
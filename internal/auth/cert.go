@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+)
+
+// CertConfig — параметры аутентификации по клиентскому сертификату mTLS.
+type CertConfig struct {
+	// RoleMap сопоставляет Common Name сертификата с ролью viewer, editor или admin.
+	RoleMap map[string]string
+	// TenantMap привязывает сертификат по Common Name к рабочему пространству.
+	TenantMap map[string]string
+	// DefaultRole — роль сертификатов, которых нет в RoleMap; пусто — такие сертификаты не принимаются.
+	// Роль по умолчанию получают только сертификаты, привязанные к пространству в TenantMap.
+	DefaultRole string
+}
+
+// CertAuthenticator признаёт клиента по сертификату, проверенному TLS-сервером при рукопожатии.
+// Субъект сертификата становится личностью cert:<DN>, роль и пространство выбираются по Common Name.
+// Сертификат без пространства — сертификат оператора с доступом ко всем пространствам, поэтому
+// такой сертификат принимается, только если его роль явно задана в RoleMap.
+type CertAuthenticator struct {
+	cfg CertConfig
+}
+
+// NewCertAuthenticator проверяет, что все роли в конфигурации известны.
+func NewCertAuthenticator(cfg CertConfig) (*CertAuthenticator, error) {
+	for cn, role := range cfg.RoleMap {
+		if _, ok := roleScopes[role]; !ok {
+			return nil, fmt.Errorf("сертификату %q сопоставлена неизвестная роль %q", cn, role)
+		}
+	}
+	if _, ok := roleScopes[cfg.DefaultRole]; cfg.DefaultRole != "" && !ok {
+		return nil, fmt.Errorf("неизвестная роль сертификатов по умолчанию %q", cfg.DefaultRole)
+	}
+	return &CertAuthenticator{cfg: cfg}, nil
+}
+
+// Authenticate возвращает личность по первому проверенному сертификату клиента.
+// Непроверенные сертификаты (без цепочки до клиентского CA) не учитываются.
+func (a *CertAuthenticator) Authenticate(r *http.Request) (Identity, error) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return Identity{}, ErrUnauthorized
+	}
+	leaf := r.TLS.VerifiedChains[0][0]
+	cn := leaf.Subject.CommonName
+	tenant := a.cfg.TenantMap[cn]
+	role, ok := a.cfg.RoleMap[cn]
+	if !ok && tenant != "" {
+		role = a.cfg.DefaultRole
+	}
+	if role == "" {
+		return Identity{}, fmt.Errorf("%w: сертификату %s не назначена роль", ErrUnauthorized, leaf.Subject)
+	}
+	return Identity{
+		Subject: "cert:" + leaf.Subject.String(),
+		Tenant:  tenant,
+		Roles:   []string{role},
+		Scopes:  slices.Clone(roleScopes[role]),
+	}, nil
+}
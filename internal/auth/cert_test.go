@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestCertAuthenticator проверяет личность и роль по клиентскому сертификату
+func TestCertAuthenticator(t *testing.T) {
+	a, err := NewCertAuthenticator(CertConfig{RoleMap: map[string]string{"ci": RoleEditor}})
+	if err != nil {
+		t.Fatalf("ошибка NewCertAuthenticator: %v", err)
+	}
+	req := httptest.NewRequest("GET", "/quotes", nil)
+	if _, err := a.Authenticate(req); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("без TLS ожидается ErrUnauthorized, получено %v", err)
+	}
+
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "ci", Organization: []string{"Quotes"}}}
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	id, err := a.Authenticate(req)
+	if err != nil {
+		t.Fatalf("ошибка Authenticate: %v", err)
+	}
+	if id.Subject != "cert:CN=ci,O=Quotes" || !id.HasScope(ScopeWrite) || id.HasScope(ScopeAdmin) {
+		t.Errorf("неожиданная личность %+v", id)
+	}
+
+	// Сертификат без роли принимается только при DefaultRole и только с привязкой к пространству
+	cert.Subject.CommonName = "other"
+	if _, err := a.Authenticate(req); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("сертификат без роли должен отклоняться, получено %v", err)
+	}
+	team, _ := NewCertAuthenticator(CertConfig{DefaultRole: RoleAdmin, TenantMap: map[string]string{"other": "team-a"}})
+	if id, err := team.Authenticate(req); err != nil || id.Tenant != "team-a" || !id.HasScope(ScopeAdmin) {
+		t.Errorf("ожидается роль по умолчанию в пространстве team-a, получено %+v, %v", id, err)
+	}
+	cert.Subject.CommonName = "stranger"
+	if id, err := team.Authenticate(req); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("роль по умолчанию не должна делать сертификат без пространства оператором, получено %+v, %v", id, err)
+	}
+	cert.Subject.CommonName = "other"
+
+	// Предъявленный, но не проверенный сертификат не даёт личности
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	if _, err := team.Authenticate(req); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("непроверенный сертификат должен отклоняться, получено %v", err)
+	}
+
+	if _, err := NewCertAuthenticator(CertConfig{RoleMap: map[string]string{"ci": "root"}}); err == nil {
+		t.Error("ожидается ошибка для неизвестной роли")
+	}
+}
@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// RegisterKeyHandlers регистрирует административные эндпоинты управления ключами API:
+// выдачу, список и отзыв. Все они требуют области admin.
+// Администратор, привязанный к рабочему пространству, видит и выдаёт только ключи своего пространства.
+func RegisterKeyHandlers(r *mux.Router, keys *KeyManager, authn Authenticator) {
+	r.Handle("/admin/keys", Require(authn, ScopeAdmin, issueKey(keys))).Methods("POST")
+	r.Handle("/admin/keys", Require(authn, ScopeAdmin, listKeys(keys))).Methods("GET")
+	r.Handle("/admin/keys/{id}", Require(authn, ScopeAdmin, revokeKey(keys))).Methods("DELETE")
+}
+
+// issuedKey — ответ на выдачу ключа: запись и сам ключ, который больше не будет показан.
+type issuedKey struct {
+	Key
+	Token string `json:"key"`
+}
+
+// issueKey возвращает HandlerFunc для выдачи ключа.
+// Ожидает JSON с полями name и scopes, возвращает 201 Created и ключ.
+func issueKey(keys *KeyManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Name   string   `json:"name"`
+			Scopes []string `json:"scopes"`
+			Tenant string   `json:"tenant"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if id, _ := FromContext(r.Context()); id.Tenant != "" {
+			if body.Tenant != "" && body.Tenant != id.Tenant {
+				http.Error(w, ErrForbidden.Error(), http.StatusForbidden)
+				return
+			}
+			body.Tenant = id.Tenant
+		}
+		k, token, err := keys.Issue(body.Name, body.Tenant, body.Scopes)
+		if errors.Is(err, ErrInvalidScope) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(issuedKey{Key: k, Token: token})
+	}
+}
+
+// listKeys возвращает HandlerFunc для получения списка ключей без их хешей.
+func listKeys(keys *KeyManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		all, err := keys.List()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		id, _ := FromContext(r.Context())
+		list := []Key{}
+		for _, k := range all {
+			if visibleKey(id, k) {
+				list = append(list, k)
+			}
+		}
+		json.NewEncoder(w).Encode(list)
+	}
+}
+
+// revokeKey возвращает HandlerFunc для отзыва ключа по id.
+// Возвращает 204 No Content при успехе или 404, если ключ не найден.
+func revokeKey(keys *KeyManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if caller, _ := FromContext(r.Context()); caller.Tenant != "" {
+			// Ключ чужого пространства для такого администратора не существует
+			list, err := keys.List()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if i := slices.IndexFunc(list, func(k Key) bool { return k.ID == id }); i < 0 || !visibleKey(caller, list[i]) {
+				http.Error(w, ErrKeyNotFound.Error(), http.StatusNotFound)
+				return
+			}
+		}
+		err = keys.Revoke(id)
+		if errors.Is(err, ErrKeyNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// visibleKey сообщает, может ли клиент id видеть и отзывать ключ k:
+// оператору доступны все ключи, администратору пространства — только ключи этого пространства.
+func visibleKey(id Identity, k Key) bool {
+	return id.Tenant == "" || k.Tenant == id.Tenant
+}
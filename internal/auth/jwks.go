@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// jwk — открытый ключ в формате JSON Web Key (RFC 7517); поддерживаются RSA и EC P-256.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKey разбирает JWK в *rsa.PublicKey или *ecdsa.PublicKey.
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("слишком большая экспонента RSA")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("неподдерживаемая кривая %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+		if !pub.Curve.IsOnCurve(x, y) {
+			return nil, errors.New("точка ключа EC не лежит на кривой")
+		}
+		return pub, nil
+	default:
+		return nil, fmt.Errorf("неподдерживаемый тип ключа %q", k.Kty)
+	}
+}
+
+// decodeBigInt декодирует число из base64url без дополнения.
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("некорректное число в JWK")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// jwksMinRefresh — минимальный интервал между перезагрузками JWKS при встрече неизвестного kid,
+// чтобы поток токенов с поддельным kid не превращался в поток запросов к провайдеру.
+const jwksMinRefresh = time.Minute
+
+// keySet хранит ключи JWKS по kid и перезагружает их из файла или по URL.
+type keySet struct {
+	source string
+	client *http.Client
+
+	mu       sync.RWMutex
+	keys     map[string]crypto.PublicKey
+	loadedAt time.Time
+}
+
+// newKeySet создаёт набор ключей и сразу загружает его из source — пути к файлу или http(s) URL.
+func newKeySet(source string) (*keySet, error) {
+	ks := &keySet{source: source, client: &http.Client{Timeout: 10 * time.Second}}
+	if err := ks.reload(); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// fetch читает документ JWKS из файла или по URL.
+func (ks *keySet) fetch() ([]byte, error) {
+	if !strings.HasPrefix(ks.source, "http://") && !strings.HasPrefix(ks.source, "https://") {
+		return os.ReadFile(ks.source)
+	}
+	resp, err := ks.client.Get(ks.source)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("JWKS %s: статус %d", ks.source, resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// reload загружает JWKS и атомарно заменяет набор ключей.
+// Ключи неподдерживаемых типов пропускаются, но пустой набор считается ошибкой.
+func (ks *keySet) reload() error {
+	data, err := ks.fetch()
+	if err != nil {
+		return fmt.Errorf("загрузка JWKS: %w", err)
+	}
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("разбор JWKS: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = pub
+	}
+	if len(keys) == 0 {
+		return errors.New("JWKS не содержит поддерживаемых ключей подписи")
+	}
+	ks.mu.Lock()
+	ks.keys, ks.loadedAt = keys, time.Now()
+	ks.mu.Unlock()
+	return nil
+}
+
+// key возвращает ключ по kid; при промахе один раз перезагружает JWKS,
+// если с прошлой загрузки прошло не меньше jwksMinRefresh (провайдер мог сменить ключи).
+func (ks *keySet) key(kid string) (crypto.PublicKey, bool) {
+	ks.mu.RLock()
+	pub, ok := ks.keys[kid]
+	stale := time.Since(ks.loadedAt) >= jwksMinRefresh
+	ks.mu.RUnlock()
+	if ok || !stale {
+		return pub, ok
+	}
+	if err := ks.reload(); err != nil {
+		return nil, false
+	}
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	pub, ok = ks.keys[kid]
+	return pub, ok
+}
@@ -0,0 +1,245 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Роли, которые выдаёт корпоративный SSO; каждая раскрывается в набор областей доступа.
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+// roleScopes сопоставляет ролям области доступа, которые проверяют маршруты.
+var roleScopes = map[string][]string{
+	RoleViewer: {ScopeRead},
+	RoleEditor: {ScopeRead, ScopeWrite},
+	RoleAdmin:  {ScopeAdmin},
+}
+
+// JWTConfig — параметры проверки JWT.
+type JWTConfig struct {
+	JWKS        string            // путь к файлу JWKS или http(s) URL
+	Issuer      string            // ожидаемое значение iss
+	Audience    string            // значение, которое должно входить в aud
+	RoleClaim   string            // путь к claim с ролями через точку, например realm_access.roles
+	RoleMap     map[string]string // отображение ролей SSO на viewer, editor, admin; без него роли берутся как есть
+	Leeway      time.Duration     // допустимое расхождение часов при проверке exp и nbf
+	TenantClaim string            // путь к claim с рабочим пространством; пусто — токены не привязаны к пространству
+}
+
+// JWTAuthenticator проверяет токены RS256/ES256 по ключам JWKS и выдаёт личность с ролями.
+type JWTAuthenticator struct {
+	cfg  JWTConfig
+	keys *keySet
+	now  func() time.Time
+}
+
+// NewJWTAuthenticator загружает JWKS и создаёт аутентификатор.
+// Issuer и Audience обязательны: без них принимался бы токен любого приложения того же SSO.
+func NewJWTAuthenticator(cfg JWTConfig) (*JWTAuthenticator, error) {
+	if cfg.JWKS == "" || cfg.Issuer == "" || cfg.Audience == "" {
+		return nil, errors.New("для JWT нужно задать JWKS, issuer и audience")
+	}
+	if cfg.RoleClaim == "" {
+		cfg.RoleClaim = "roles"
+	}
+	for from, to := range cfg.RoleMap {
+		if _, ok := roleScopes[to]; !ok {
+			return nil, fmt.Errorf("роль %q сопоставлена неизвестной роли %q", from, to)
+		}
+	}
+	keys, err := newKeySet(cfg.JWKS)
+	if err != nil {
+		return nil, err
+	}
+	return &JWTAuthenticator{cfg: cfg, keys: keys, now: time.Now}, nil
+}
+
+// jwtHeader — заголовок JWT.
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Authenticate проверяет подпись, iss, aud, exp и nbf токена из заголовка Authorization: Bearer.
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (Identity, error) {
+	token, ok := BearerToken(r)
+	if !ok {
+		return Identity{}, ErrUnauthorized
+	}
+	claims, err := a.verify(token)
+	if err != nil {
+		return Identity{}, fmt.Errorf("%w: %v", ErrUnauthorized, err)
+	}
+	sub, _ := claims["sub"].(string)
+	if sub == "" {
+		return Identity{}, fmt.Errorf("%w: нет claim sub", ErrUnauthorized)
+	}
+	id := Identity{Subject: "jwt:" + sub}
+	if a.cfg.TenantClaim != "" {
+		// Токен без claim пространства отклоняется: иначе он получил бы доступ оператора ко всем пространствам
+		id.Tenant, _ = claimAt(claims, a.cfg.TenantClaim).(string)
+		if id.Tenant == "" {
+			return Identity{}, fmt.Errorf("%w: нет claim %s", ErrUnauthorized, a.cfg.TenantClaim)
+		}
+	}
+	for _, role := range a.roles(claims) {
+		id.Roles = append(id.Roles, role)
+		id.Scopes = append(id.Scopes, roleScopes[role]...)
+	}
+	return id, nil
+}
+
+// verify проверяет подпись и стандартные claims и возвращает все claims токена.
+func (a *JWTAuthenticator) verify(token string) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("токен должен состоять из трёх частей")
+	}
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	pub, ok := a.keys.key(header.Kid)
+	if !ok {
+		return nil, fmt.Errorf("неизвестный ключ %q", header.Kid)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("некорректная подпись")
+	}
+	if err := verifySignature(header.Alg, pub, parts[0]+"."+parts[1], sig); err != nil {
+		return nil, err
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	now := a.now()
+	exp, ok := numericDate(claims["exp"])
+	if !ok {
+		return nil, errors.New("нет claim exp")
+	}
+	if now.After(exp.Add(a.cfg.Leeway)) {
+		return nil, errors.New("срок действия токена истёк")
+	}
+	if nbf, ok := numericDate(claims["nbf"]); ok && now.Add(a.cfg.Leeway).Before(nbf) {
+		return nil, errors.New("токен ещё не действителен")
+	}
+	if iss, _ := claims["iss"].(string); iss != a.cfg.Issuer {
+		return nil, fmt.Errorf("неожиданный издатель %q", iss)
+	}
+	if !slices.Contains(stringList(claims["aud"]), a.cfg.Audience) {
+		return nil, errors.New("токен выпущен для другой аудитории")
+	}
+	return claims, nil
+}
+
+// verifySignature проверяет подпись алгоритмом alg; тип ключа должен соответствовать алгоритму,
+// иначе злоумышленник мог бы подменить alg в заголовке.
+func verifySignature(alg string, pub crypto.PublicKey, signed string, sig []byte) error {
+	digest := sha256.Sum256([]byte(signed))
+	switch alg {
+	case "RS256":
+		key, ok := pub.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("ключ не подходит для RS256")
+		}
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+			return errors.New("неверная подпись")
+		}
+	case "ES256":
+		key, ok := pub.(*ecdsa.PublicKey)
+		if !ok || len(sig) != 64 {
+			return errors.New("ключ или подпись не подходят для ES256")
+		}
+		r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+		if !ecdsa.Verify(key, digest[:], r, s) {
+			return errors.New("неверная подпись")
+		}
+	default:
+		return fmt.Errorf("неподдерживаемый алгоритм %q", alg)
+	}
+	return nil
+}
+
+// roles извлекает роли из claim RoleClaim и приводит их к известным ролям через RoleMap.
+func (a *JWTAuthenticator) roles(claims map[string]any) []string {
+	var res []string
+	for _, raw := range stringList(claimAt(claims, a.cfg.RoleClaim)) {
+		role := raw
+		if a.cfg.RoleMap != nil {
+			role = a.cfg.RoleMap[raw]
+		}
+		if _, ok := roleScopes[role]; ok && !slices.Contains(res, role) {
+			res = append(res, role)
+		}
+	}
+	return res
+}
+
+// claimAt возвращает значение claim по пути через точку или nil, если его нет.
+func claimAt(claims map[string]any, path string) any {
+	var value any = claims
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = obj[key]
+	}
+	return value
+}
+
+// decodeSegment декодирует часть JWT из base64url и разбирает JSON.
+func decodeSegment(seg string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return errors.New("некорректная кодировка токена")
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return errors.New("некорректный JSON в токене")
+	}
+	return nil
+}
+
+// numericDate разбирает claim NumericDate (секунды Unix).
+func numericDate(v any) (time.Time, bool) {
+	f, ok := v.(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(f), 0), true
+}
+
+// stringList приводит claim к списку строк: массив строк или строка с разделителями пробел/запятая.
+func stringList(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return strings.FieldsFunc(v, func(r rune) bool { return r == ' ' || r == ',' })
+	case []any:
+		var res []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				res = append(res, s)
+			}
+		}
+		return res
+	}
+	return nil
+}
@@ -0,0 +1,268 @@
+// Тесты проверки JWT по локально сгенерированным ключам без обращения к сети
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// testSigner подписывает токены локальными ключами RSA и EC
+type testSigner struct {
+	rsaKey *rsa.PrivateKey
+	ecKey  *ecdsa.PrivateKey
+}
+
+// newTestSigner генерирует пару ключей для тестов
+func newTestSigner(t *testing.T) *testSigner {
+	t.Helper()
+	rk, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("не удалось создать ключ RSA: %v", err)
+	}
+	ek, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("не удалось создать ключ EC: %v", err)
+	}
+	return &testSigner{rsaKey: rk, ecKey: ek}
+}
+
+// b64 кодирует байты в base64url без дополнения
+func b64(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+// jwks возвращает документ JWKS с открытыми ключами подписанта
+func (s *testSigner) jwks() []byte {
+	pad := func(i *big.Int) []byte { return i.FillBytes(make([]byte, 32)) }
+	doc := map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa1", "use": "sig", "n": b64(s.rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(s.rsaKey.E)).Bytes())},
+		{"kty": "EC", "kid": "ec1", "crv": "P-256", "x": b64(pad(s.ecKey.X)), "y": b64(pad(s.ecKey.Y))},
+	}}
+	data, _ := json.Marshal(doc)
+	return data
+}
+
+// sign создаёт токен с алгоритмом alg, ключом kid и claims
+func (s *testSigner) sign(t *testing.T, alg, kid string, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(signed))
+	var sig []byte
+	switch alg {
+	case "RS256":
+		var err error
+		if sig, err = rsa.SignPKCS1v15(rand.Reader, s.rsaKey, crypto.SHA256, digest[:]); err != nil {
+			t.Fatalf("ошибка подписи RS256: %v", err)
+		}
+	case "ES256":
+		r, ss, err := ecdsa.Sign(rand.Reader, s.ecKey, digest[:])
+		if err != nil {
+			t.Fatalf("ошибка подписи ES256: %v", err)
+		}
+		sig = append(r.FillBytes(make([]byte, 32)), ss.FillBytes(make([]byte, 32))...)
+	}
+	return signed + "." + b64(sig)
+}
+
+// claimsFor возвращает валидные claims с заданными ролями
+func claimsFor(roles ...string) map[string]any {
+	return map[string]any{
+		"sub":   "user-1",
+		"iss":   "https://sso.example",
+		"aud":   []string{"quotes-api", "other"},
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": roles,
+	}
+}
+
+// newTestJWT создаёт аутентификатор с JWKS из временного файла
+func newTestJWT(t *testing.T, s *testSigner, cfg JWTConfig) (*JWTAuthenticator, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, s.jwks(), 0o600); err != nil {
+		t.Fatalf("не удалось записать JWKS: %v", err)
+	}
+	cfg.JWKS, cfg.Issuer, cfg.Audience = path, "https://sso.example", "quotes-api"
+	a, err := NewJWTAuthenticator(cfg)
+	if err != nil {
+		t.Fatalf("ошибка NewJWTAuthenticator: %v", err)
+	}
+	return a, path
+}
+
+// TestJWTValidation проверяет подпись, издателя, аудиторию и сроки действия токена
+func TestJWTValidation(t *testing.T) {
+	s := newTestSigner(t)
+	a, _ := newTestJWT(t, s, JWTConfig{})
+
+	with := func(mod func(c map[string]any)) map[string]any {
+		c := claimsFor(RoleViewer)
+		mod(c)
+		return c
+	}
+	valid := s.sign(t, "RS256", "rsa1", claimsFor(RoleViewer))
+	tampered := valid[:len(valid)-4] + "AAAA"
+	cases := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{"RS256", valid, true},
+		{"ES256", s.sign(t, "ES256", "ec1", claimsFor(RoleEditor)), true},
+		{"подмена подписи", tampered, false},
+		{"истёк", s.sign(t, "RS256", "rsa1", with(func(c map[string]any) { c["exp"] = time.Now().Add(-time.Hour).Unix() })), false},
+		{"нет exp", s.sign(t, "RS256", "rsa1", with(func(c map[string]any) { delete(c, "exp") })), false},
+		{"ещё не действителен", s.sign(t, "RS256", "rsa1", with(func(c map[string]any) { c["nbf"] = time.Now().Add(time.Hour).Unix() })), false},
+		{"чужой издатель", s.sign(t, "RS256", "rsa1", with(func(c map[string]any) { c["iss"] = "https://evil.example" })), false},
+		{"чужая аудитория", s.sign(t, "RS256", "rsa1", with(func(c map[string]any) { c["aud"] = "billing" })), false},
+		{"аудитория строкой", s.sign(t, "RS256", "rsa1", with(func(c map[string]any) { c["aud"] = "quotes-api" })), true},
+		{"несовпадение alg и ключа", s.sign(t, "ES256", "rsa1", claimsFor(RoleViewer)), false},
+		{"неизвестный kid", s.sign(t, "RS256", "nope", claimsFor(RoleViewer)), false},
+		{"не JWT", "abc.def", false},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+c.token)
+		id, err := a.Authenticate(req)
+		if c.ok && (err != nil || id.Subject != "jwt:user-1") {
+			t.Errorf("%s: ожидается успешная проверка, получено %+v, %v", c.name, id, err)
+		}
+		if !c.ok && !errors.Is(err, ErrUnauthorized) {
+			t.Errorf("%s: ожидается ErrUnauthorized, получено %v", c.name, err)
+		}
+	}
+
+	// Токен с alg=none не принимается, даже если подпись пустая
+	header := b64([]byte(`{"alg":"none","kid":"rsa1"}`))
+	payload, _ := json.Marshal(claimsFor(RoleAdmin))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+header+"."+b64(payload)+".")
+	if _, err := a.Authenticate(req); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("ожидается отказ для alg=none, получено %v", err)
+	}
+}
+
+// TestJWTRoles проверяет отображение ролей на области доступа и маршруты
+func TestJWTRoles(t *testing.T) {
+	s := newTestSigner(t)
+	a, _ := newTestJWT(t, s, JWTConfig{
+		RoleClaim: "realm_access.roles",
+		RoleMap:   map[string]string{"quotes-readers": RoleViewer, "quotes-editors": RoleEditor},
+	})
+	claims := claimsFor()
+	claims["realm_access"] = map[string]any{"roles": []string{"quotes-editors", "unrelated"}}
+	token := s.sign(t, "ES256", "ec1", claims)
+
+	handler := Require(a, ScopeWrite, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := FromContext(r.Context())
+		if !slices.Equal(id.Roles, []string{RoleEditor}) {
+			t.Errorf("ожидается роль editor, получено %v", id.Roles)
+		}
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/quotes", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("ожидается 200 для editor, получен %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	Require(a, ScopeAdmin, http.NotFoundHandler()).ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("ожидается 403 для editor на админском маршруте, получен %d", w.Code)
+	}
+
+	if _, err := NewJWTAuthenticator(JWTConfig{JWKS: "x", Issuer: "i", Audience: "a", RoleMap: map[string]string{"x": "root"}}); err == nil {
+		t.Error("ожидается ошибка для отображения на неизвестную роль")
+	}
+	if _, err := NewJWTAuthenticator(JWTConfig{JWKS: "x"}); err == nil {
+		t.Error("ожидается ошибка без issuer и audience")
+	}
+}
+
+// TestJWKSReload проверяет загрузку JWKS по URL и перезагрузку при смене ключей
+func TestJWKSReload(t *testing.T) {
+	old, rotated := newTestSigner(t), newTestSigner(t)
+	current := old
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(current.jwks())
+	}))
+	defer srv.Close()
+
+	a, err := NewJWTAuthenticator(JWTConfig{JWKS: srv.URL, Issuer: "https://sso.example", Audience: "quotes-api"})
+	if err != nil {
+		t.Fatalf("ошибка загрузки JWKS по URL: %v", err)
+	}
+	current = rotated
+	token := rotated.sign(t, "RS256", "rsa1", claimsFor(RoleViewer))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	// Сразу после загрузки JWKS не перезапрашивается, подпись новым ключом не проходит
+	if _, err := a.Authenticate(req); err == nil {
+		t.Fatal("ожидается отказ до перезагрузки JWKS")
+	}
+	// Спустя минимальный интервал ключи перечитываются
+	a.keys.mu.Lock()
+	a.keys.loadedAt = time.Now().Add(-2 * jwksMinRefresh)
+	a.keys.mu.Unlock()
+	// kid совпадает, поэтому промаха нет — перезагрузку вызывает неизвестный kid
+	if _, ok := a.keys.key("unknown"); ok {
+		t.Fatal("неожиданный ключ unknown")
+	}
+	if _, err := a.Authenticate(req); err != nil {
+		t.Errorf("ожидается успех после перезагрузки JWKS, получено %v", err)
+	}
+}
+
+// TestChain проверяет, что ключи API и JWT принимаются одновременно
+func TestChain(t *testing.T) {
+	s := newTestSigner(t)
+	jwt, _ := newTestJWT(t, s, JWTConfig{})
+	keys := NewKeyManager(&memStore{})
+	keys.Ensure("root", "qk_root-secret-value-1234", []string{ScopeAdmin})
+	chain := Chain{keys, jwt}
+
+	for _, token := range []string{"qk_root-secret-value-1234", s.sign(t, "RS256", "rsa1", claimsFor(RoleViewer))} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		if _, err := chain.Authenticate(req); err != nil {
+			t.Errorf("ожидается успешная аутентификация, получено %v", err)
+		}
+	}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if _, err := chain.Authenticate(req); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("ожидается ErrUnauthorized без токена, получено %v", err)
+	}
+}
+
+// TestJWTTenantClaim проверяет привязку токена к рабочему пространству
+func TestJWTTenantClaim(t *testing.T) {
+	s := newTestSigner(t)
+	a, _ := newTestJWT(t, s, JWTConfig{TenantClaim: "org.workspace"})
+	claims := claimsFor(RoleEditor)
+	claims["org"] = map[string]any{"workspace": "team-a"}
+	if id, err := a.Authenticate(bearer(s.sign(t, "RS256", "rsa1", claims))); err != nil || id.Tenant != "team-a" {
+		t.Errorf("ожидается пространство team-a, получено %+v, %v", id, err)
+	}
+	// Без claim пространства токен не должен получить доступ оператора
+	if _, err := a.Authenticate(bearer(s.sign(t, "RS256", "rsa1", claimsFor(RoleEditor)))); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("ожидается ErrUnauthorized без claim пространства, получено %v", err)
+	}
+}
@@ -0,0 +1,281 @@
+// Package backup делает резервные копии базы по запросу и по расписанию: каждая копия —
+// файл с отметкой времени в имени и контрольной суммой SHA-256 рядом, старые копии удаляются
+// по правилу хранения «N ежедневных и M еженедельных».
+package backup
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Имена копий: quotes-20240501T120000.000Z.db, контрольная сумма — в quotes-....db.sha256.
+const (
+	prefix         = "quotes-"
+	suffix         = ".db"
+	timeLayout     = "20060102T150405.000Z"
+	checksumSuffix = ".sha256"
+)
+
+var (
+	// ErrNoChecksum возвращается VerifyChecksum, если рядом с копией нет файла контрольной суммы.
+	ErrNoChecksum = errors.New("нет файла контрольной суммы")
+	// ErrChecksumMismatch возвращается VerifyChecksum, если содержимое копии не совпадает с контрольной суммой.
+	ErrChecksumMismatch = errors.New("контрольная сумма не совпадает")
+)
+
+// Source записывает согласованную копию базы в новый файл path.
+type Source interface {
+	Backup(ctx context.Context, path string) error
+}
+
+// Retention — правило хранения: последняя копия каждого из Daily последних дней и каждой из Weekly
+// последних недель, в которые делались копии. Самая свежая копия хранится всегда.
+// Нулевые Daily и Weekly отключают удаление.
+type Retention struct {
+	Daily  int
+	Weekly int
+}
+
+// Snapshot — описание одной копии.
+type Snapshot struct {
+	Name    string    `json:"name"`
+	Path    string    `json:"path"`
+	Created time.Time `json:"created"`
+	Bytes   int64     `json:"bytes"`
+	SHA256  string    `json:"sha256,omitempty"`
+}
+
+// Manager создаёт копии в каталоге и удаляет устаревшие.
+type Manager struct {
+	dir  string
+	src  Source
+	keep Retention
+	now  func() time.Time
+	mu   sync.Mutex // копии создаются по одной
+}
+
+// New создаёт менеджер копий базы src в каталоге dir.
+func New(dir string, src Source, keep Retention) *Manager {
+	return &Manager{dir: dir, src: src, keep: keep, now: time.Now}
+}
+
+// Create делает копию, записывает её контрольную сумму и удаляет копии, не попадающие под правило хранения.
+func (m *Manager) Create(ctx context.Context) (Snapshot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return Snapshot{}, err
+	}
+	created := m.now().UTC()
+	name := prefix + created.Format(timeLayout) + suffix
+	path := filepath.Join(m.dir, name)
+	if err := m.src.Backup(ctx, path); err != nil {
+		return Snapshot{}, fmt.Errorf("копия %s: %w", name, err)
+	}
+	sum, err := WriteChecksum(path)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if _, err := m.prune(); err != nil {
+		return Snapshot{}, err
+	}
+	return Snapshot{Name: name, Path: path, Created: created.Truncate(time.Millisecond), Bytes: fi.Size(), SHA256: sum}, nil
+}
+
+// List возвращает копии каталога от новых к старым. Файлы с другими именами не учитываются.
+func (m *Manager) List() ([]Snapshot, error) {
+	entries, err := os.ReadDir(m.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Snapshot{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	list := []Snapshot{}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) {
+			continue
+		}
+		created, err := time.Parse(timeLayout, strings.TrimSuffix(strings.TrimPrefix(name, prefix), suffix))
+		if err != nil {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		path := filepath.Join(m.dir, name)
+		sum, _ := readChecksum(path)
+		list = append(list, Snapshot{Name: name, Path: path, Created: created, Bytes: fi.Size(), SHA256: sum})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Created.After(list[j].Created) })
+	return list, nil
+}
+
+// Prune удаляет копии, не попадающие под правило хранения, и возвращает удалённые.
+func (m *Manager) Prune() ([]Snapshot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.prune()
+}
+
+func (m *Manager) prune() ([]Snapshot, error) {
+	if m.keep.Daily == 0 && m.keep.Weekly == 0 {
+		return nil, nil
+	}
+	list, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+	var removed []Snapshot
+	for i, keep := range retained(list, m.keep) {
+		if keep {
+			continue
+		}
+		if err := remove(list[i].Path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, list[i])
+	}
+	return removed, nil
+}
+
+// retained отмечает копии, которые нужно сохранить по правилу keep. Список упорядочен от новых
+// к старым, поэтому копия — последняя за свой день, если предыдущая сделана в другой день; так же для недель.
+func retained(list []Snapshot, keep Retention) []bool {
+	res := make([]bool, len(list))
+	days, weeks := 0, 0
+	for i, s := range list {
+		if i == 0 || day(s.Created) != day(list[i-1].Created) {
+			days++
+			res[i] = res[i] || days <= keep.Daily
+		}
+		if i == 0 || week(s.Created) != week(list[i-1].Created) {
+			weeks++
+			res[i] = res[i] || weeks <= keep.Weekly
+		}
+	}
+	if len(res) > 0 {
+		res[0] = true
+	}
+	return res
+}
+
+func day(t time.Time) string { return t.Format("2006-01-02") }
+
+func week(t time.Time) string {
+	year, w := t.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, w)
+}
+
+// remove удаляет копию вместе с файлом контрольной суммы.
+func remove(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.Remove(path + checksumSuffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Run делает копию каждые interval до отмены ctx. Ошибки записываются в лог, следующая попытка —
+// по расписанию.
+func (m *Manager) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s, err := m.Create(ctx)
+			if err != nil {
+				log.Printf("Ошибка резервного копирования: %v", err)
+				continue
+			}
+			log.Printf("Резервная копия сохранена в %s (%d байт)", s.Path, s.Bytes)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// WriteChecksum считает SHA-256 файла path и записывает её в path.sha256 в формате sha256sum,
+// чтобы копию можно было проверить и без сервиса: sha256sum -c quotes-....db.sha256.
+func WriteChecksum(path string) (string, error) {
+	sum, err := fileSHA256(path)
+	if err != nil {
+		return "", err
+	}
+	line := sum + "  " + filepath.Base(path) + "\n"
+	if err := os.WriteFile(path+checksumSuffix, []byte(line), 0o644); err != nil {
+		return "", err
+	}
+	return sum, nil
+}
+
+// VerifyChecksum сверяет содержимое path с контрольной суммой из path.sha256.
+func VerifyChecksum(path string) error {
+	want, err := readChecksum(path)
+	if err != nil {
+		return err
+	}
+	got, err := fileSHA256(path)
+	if err != nil {
+		return err
+	}
+	if got != want {
+		return fmt.Errorf("%s: %w: ожидается %s, получено %s", path, ErrChecksumMismatch, want, got)
+	}
+	return nil
+}
+
+// readChecksum читает сумму из path.sha256: первое поле первой строки.
+func readChecksum(path string) (string, error) {
+	f, err := os.Open(path + checksumSuffix)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrNoChecksum
+	}
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	line, err := bufio.NewReader(f).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", fmt.Errorf("%s%s: пустой файл контрольной суммы", path, checksumSuffix)
+	}
+	return strings.ToLower(fields[0]), nil
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
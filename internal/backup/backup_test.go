@@ -0,0 +1,162 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// fileSource пишет в копию фиксированное содержимое.
+type fileSource struct{ data string }
+
+func (s fileSource) Backup(ctx context.Context, path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return errors.New("файл уже существует")
+	}
+	return os.WriteFile(path, []byte(s.data), 0o644)
+}
+
+// TestCreateChecksum проверяет имя копии, контрольную сумму и обнаружение изменённой копии
+func TestCreateChecksum(t *testing.T) {
+	dir := t.TempDir()
+	m := New(dir, fileSource{"данные"}, Retention{})
+	m.now = func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) }
+	s, err := m.Create(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Name != "quotes-20240501T120000.000Z.db" || s.Bytes != int64(len("данные")) || len(s.SHA256) != 64 {
+		t.Fatalf("неожиданное описание копии: %+v", s)
+	}
+	if err := VerifyChecksum(s.Path); err != nil {
+		t.Fatalf("целая копия должна проходить проверку: %v", err)
+	}
+	list, err := m.List()
+	if err != nil || len(list) != 1 || list[0].SHA256 != s.SHA256 || !list[0].Created.Equal(s.Created) {
+		t.Fatalf("список должен содержать созданную копию, получено %+v %v", list, err)
+	}
+
+	if err := os.WriteFile(s.Path, []byte("подмена"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyChecksum(s.Path); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("ожидается ErrChecksumMismatch, получено %v", err)
+	}
+	if err := VerifyChecksum(filepath.Join(dir, "other.db")); !errors.Is(err, ErrNoChecksum) {
+		t.Errorf("ожидается ErrNoChecksum, получено %v", err)
+	}
+	if _, err := m.Create(context.Background()); err == nil {
+		t.Error("копия с тем же именем не должна перезаписываться")
+	}
+}
+
+// TestRetention проверяет хранение последних копий за N дней и M недель
+func TestRetention(t *testing.T) {
+	dir := t.TempDir()
+	m := New(dir, fileSource{"x"}, Retention{Daily: 3, Weekly: 2})
+	// Понедельник 2024-04-01 … воскресенье 2024-04-14, по две копии в день
+	start := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	for d := 0; d < 14; d++ {
+		for _, h := range []int{6, 18} {
+			m.now = func() time.Time { return start.AddDate(0, 0, d).Add(time.Duration(h) * time.Hour) }
+			if _, err := m.Create(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	list, err := m.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, s := range list {
+		got = append(got, s.Created.Format("2006-01-02T15"))
+		if _, err := os.Stat(s.Path + ".sha256"); err != nil {
+			t.Errorf("у копии %s нет контрольной суммы", s.Name)
+		}
+	}
+	// Последние копии 14, 13 и 12 апреля и последняя копия предыдущей недели — 7 апреля
+	want := []string{"2024-04-14T18", "2024-04-13T18", "2024-04-12T18", "2024-04-07T18"}
+	if len(got) != len(want) {
+		t.Fatalf("ожидаются копии %v, получено %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("ожидаются копии %v, получено %v", want, got)
+		}
+	}
+	files, _ := os.ReadDir(dir)
+	if len(files) != 2*len(want) {
+		t.Errorf("удалённые копии должны удаляться вместе с контрольными суммами, в каталоге %d файлов", len(files))
+	}
+}
+
+// countSource считает копии и сообщает о каждой в канал.
+type countSource struct{ done chan struct{} }
+
+func (s countSource) Backup(ctx context.Context, path string) error {
+	if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+		return err
+	}
+	s.done <- struct{}{}
+	return nil
+}
+
+// TestRun проверяет копирование по расписанию до отмены контекста
+func TestRun(t *testing.T) {
+	src := countSource{done: make(chan struct{})}
+	m := New(t.TempDir(), src, Retention{})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.Run(ctx, 5*time.Millisecond)
+	}()
+	timeout := time.After(5 * time.Second)
+	for i := 0; i < 2; i++ {
+		select {
+		case <-src.done:
+		case <-timeout:
+			t.Fatal("копии по расписанию не появились")
+		}
+	}
+	cancel()
+	// Копия, начатая до отмены, может ждать отправки в канал
+	go func() {
+		for range src.done {
+		}
+	}()
+	<-done
+	close(src.done)
+}
+
+// TestHandlers проверяет создание копии и список копий через HTTP
+func TestHandlers(t *testing.T) {
+	router := mux.NewRouter()
+	RegisterHandlers(router, New(t.TempDir(), fileSource{"x"}, Retention{}), nil)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("POST", "/admin/backup", nil))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("ожидается 201, получен %d: %s", rec.Code, rec.Body)
+	}
+	var created Snapshot
+	if err := json.NewDecoder(rec.Body).Decode(&created); err != nil || created.SHA256 == "" {
+		t.Fatalf("ожидается описание копии с контрольной суммой, получено %+v %v", created, err)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/admin/backups", nil))
+	var list []Snapshot
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil || len(list) != 1 || list[0].Name != created.Name {
+		t.Errorf("ожидается список из созданной копии, получено %+v %v", list, err)
+	}
+}
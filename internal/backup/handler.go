@@ -0,0 +1,44 @@
+package backup
+
+import (
+	"Test_Project_Brand_Scout/internal/auth"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// RegisterHandlers регистрирует эндпоинты резервного копирования: создание копии и список копий.
+// Они требуют области admin; администратору, привязанному к рабочему пространству, они недоступны,
+// потому что копия содержит данные всех пространств.
+func RegisterHandlers(r *mux.Router, m *Manager, authn auth.Authenticator) {
+	r.Handle("/admin/backup", auth.Require(authn, auth.ScopeAdmin, auth.OperatorOnly(createBackup(m)))).Methods("POST")
+	r.Handle("/admin/backups", auth.Require(authn, auth.ScopeAdmin, auth.OperatorOnly(listBackups(m)))).Methods("GET")
+}
+
+// createBackup возвращает HandlerFunc, который делает копию и отвечает 201 Created с её описанием.
+func createBackup(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s, err := m.Create(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(s)
+	}
+}
+
+// listBackups возвращает HandlerFunc со списком копий от новых к старым.
+func listBackups(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := m.List()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(list)
+	}
+}
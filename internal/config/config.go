@@ -1,60 +1,119 @@
 // internal/config/config.go
 package config
 
-import (
-	"bufio"
-	"os"
-	"strings"
-)
+import "time"
 
 // Config содержит параметры конфигурации приложения.
+// Тег conf задаёт имя параметра в файле конфигурации; переменная окружения называется так же
+// в верхнем регистре (с префиксом QUOTES_ или без него), флаг — через дефис: db_mode, DB_MODE, --db-mode.
+// Тег default — значение по умолчанию, secret — параметр скрывается в выводе --print-config.
+//
+// Параметры делятся на статические (Static), которые читаются один раз при запуске, и динамические
+// (Dynamic), которые применяются без перезапуска по SIGHUP или после изменения файла конфигурации.
 type Config struct {
-	Port   string // порт HTTP-сервера
-	DBMode string // режим хранения (sqlite или memory)
-	DBPath string // путь к SQLite базе (файл или :memory:)
+	Static
+	Dynamic
 }
 
-// Load загружает конфигурацию из .env и переменных окружения.
-// Если значение не задано, используются значения по умолчанию: PORT=8080, DB_MODE=memory.
-func Load() Config {
-	loadDotEnv()
-
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
-	mode := os.Getenv("DB_MODE")
-	if mode == "" {
-		mode = "memory"
-	}
-	// Путь к SQLite базе из переменной окружения или quotes.db по умолчанию
-	dbPath := os.Getenv("DB_PATH")
-	if dbPath == "" {
-		dbPath = "quotes.db"
-	}
-	return Config{Port: port, DBMode: mode, DBPath: dbPath}
+// Static — параметры, которые применяются только при запуске: порт, хранилище, авторизация, TLS.
+type Static struct {
+	Port string `conf:"port" default:"8080"` // порт HTTP-сервера
+	// DatabaseURL — адрес хранилища: memory://, sqlite:///var/lib/quotes.db?_journal=WAL или bolt:///data/q.bolt.
+	// Если задан, определяет db_mode и db_path; пусто — адрес собирается из db_mode, db_path и memory_*
+	DatabaseURL string `conf:"database_url"`
+	DBMode      string `conf:"db_mode" default:"memory"`    // режим хранения (sqlite, bolt или memory)
+	DBPath      string `conf:"db_path" default:"quotes.db"` // путь к SQLite базе (файл или :memory:) или файлу bbolt
+	// SQLiteDriver — драйвер SQLite: mattn (go-sqlite3, нужен cgo), modernc (чистый Go) или auto — mattn, если он есть в сборке
+	SQLiteDriver string `conf:"sqlite_driver" default:"auto"`
+
+	MemoryDir              string        `conf:"memory_dir"`                            // каталог журнала и снимков при db_mode=memory; пусто — данные не сохраняются
+	MemoryFsync            string        `conf:"memory_fsync" default:"interval"`       // когда сбрасывать журнал на диск: always, interval или never
+	MemoryFsyncInterval    time.Duration `conf:"memory_fsync_interval" default:"1s"`    // период сброса журнала при memory_fsync=interval
+	MemorySnapshotInterval time.Duration `conf:"memory_snapshot_interval" default:"5m"` // как часто записывать снимок и очищать журнал, 0 — только при остановке
+
+	DailyWindow     int           `conf:"daily_no_repeat_days" default:"30"` // число дней, в течение которых цитата дня не повторяется
+	PopularHalfLife time.Duration `conf:"popular_half_life" default:"24h"`   // период полураспада вклада реакции в популярность
+
+	ViewFlushInterval time.Duration `conf:"view_flush_interval" default:"30s"` // как часто счётчики показов сбрасываются в хранилище, 0 — только при остановке
+
+	BackupDir        string        `conf:"backup_dir"`                     // каталог резервных копий SQLite; пусто — копирование выключено
+	BackupInterval   time.Duration `conf:"backup_interval" default:"24h"`  // как часто делать копию по расписанию, 0 — только по запросу
+	BackupKeepDaily  int           `conf:"backup_keep_daily" default:"7"`  // сколько последних дней хранить по одной копии
+	BackupKeepWeekly int           `conf:"backup_keep_weekly" default:"4"` // сколько последних недель хранить по одной копии
+
+	AuthMode         string `conf:"auth_mode" default:"none"`         // режимы авторизации через запятую: none, apikey, jwt, cert
+	AuthBootstrapKey string `conf:"auth_bootstrap_key" secret:"true"` // административный ключ, который создаётся при запуске, если его нет
+
+	JWTJWKS        string            `conf:"jwt_jwks"`                       // путь к файлу JWKS или URL
+	JWTIssuer      string            `conf:"jwt_issuer"`                     // ожидаемый издатель токенов (iss)
+	JWTAudience    string            `conf:"jwt_audience"`                   // ожидаемая аудитория токенов (aud)
+	JWTRoleClaim   string            `conf:"jwt_role_claim" default:"roles"` // claim с ролями, вложенные поля через точку
+	JWTRoleMap     map[string]string `conf:"jwt_role_mapping"`               // отображение ролей SSO на viewer, editor, admin
+	JWTLeeway      time.Duration     `conf:"jwt_leeway" default:"30s"`       // допустимое расхождение часов при проверке exp и nbf
+	JWTTenantClaim string            `conf:"jwt_tenant_claim"`               // claim с рабочим пространством; пусто — токены не привязаны к пространству
+
+	TenantMaxQuotes int            `conf:"tenant_max_quotes" default:"0"` // лимит цитат в рабочем пространстве по умолчанию, 0 — без ограничения
+	TenantQuotas    map[string]int `conf:"tenant_quotas"`                 // лимиты цитат отдельных пространств
+
+	RateLimitKey   string `conf:"rate_limit_key" default:"key"`      // по кому считаются ограничения: key, ip или tenant
+	RateLimitStore string `conf:"rate_limit_store" default:"memory"` // где хранятся счётчики: memory или sqlite (файл DB_PATH)
+
+	LogFormat string `conf:"log_format" default:"text"` // формат логов: text или json
+
+	ConfigReloadInterval time.Duration `conf:"config_reload_interval" default:"10s"` // как часто проверять изменение файла конфигурации и .env, 0 — только по SIGHUP
+
+	MetricsAddr string `conf:"metrics_addr"` // адрес отдельного служебного слушателя для /metrics; пусто — /metrics на основном порту
+
+	TraceExporter    string  `conf:"trace_exporter" default:"none"`    // экспорт спанов: none, otlp, stdout или file
+	TraceEndpoint    string  `conf:"trace_endpoint"`                   // адрес OTLP/HTTP коллектора
+	TraceFile        string  `conf:"trace_file" default:"traces.json"` // файл для спанов в формате JSON при TRACE_EXPORTER=file
+	TraceSampleRatio float64 `conf:"trace_sample_ratio" default:"1"`   // доля трассируемых запросов от 0 до 1
+
+	HealthTimeout   time.Duration `conf:"health_timeout" default:"2s"`      // ограничение времени одной проверки готовности
+	HealthMinFreeMB uint64        `conf:"health_min_free_mb" default:"100"` // минимум свободного места на разделе с базой, МБ
+
+	HTTPReadTimeout       time.Duration `conf:"http_read_timeout" default:"15s"`         // время на чтение всего запроса, 0 — без ограничения
+	HTTPReadHeaderTimeout time.Duration `conf:"http_read_header_timeout" default:"5s"`   // время на чтение заголовков запроса
+	HTTPWriteTimeout      time.Duration `conf:"http_write_timeout" default:"30s"`        // время на обработку запроса и запись ответа
+	HTTPIdleTimeout       time.Duration `conf:"http_idle_timeout" default:"120s"`        // сколько держать простаивающее keep-alive соединение
+	HTTPMaxHeaderBytes    int           `conf:"http_max_header_bytes" default:"1048576"` // максимальный размер заголовков запроса, байт
+	ShutdownTimeout       time.Duration `conf:"shutdown_timeout" default:"30s"`          // сколько ждать завершения текущих запросов при остановке, 0 — без ограничения
+	ShutdownDelay         time.Duration `conf:"shutdown_delay" default:"0s"`             // пауза между снятием готовности и закрытием слушателя
+
+	TLSCertFile          string            `conf:"tls_cert_file"`                            // сертификат сервера в PEM; пусто — сервер работает по HTTP
+	TLSKeyFile           string            `conf:"tls_key_file"`                             // закрытый ключ сервера в PEM
+	TLSMinVersion        string            `conf:"tls_min_version" default:"1.2"`            // минимальная версия TLS: 1.2 или 1.3
+	TLSCipherPolicy      string            `conf:"tls_cipher_policy" default:"intermediate"` // набор шифров: modern, intermediate или default
+	TLSReloadInterval    time.Duration     `conf:"tls_reload_interval" default:"30s"`        // как часто проверять изменение файлов сертификатов, 0 — только по SIGHUP
+	TLSClientCAFile      string            `conf:"tls_client_ca_file"`                       // CA для проверки клиентских сертификатов; пусто — mTLS выключен
+	TLSClientAuth        string            `conf:"tls_client_auth" default:"require"`        // require или optional: обязателен ли клиентский сертификат
+	TLSClientRoleMap     map[string]string `conf:"tls_client_role_mapping"`                  // роли клиентских сертификатов по Common Name
+	TLSClientTenantMap   map[string]string `conf:"tls_client_tenant_mapping"`                // рабочие пространства клиентских сертификатов по Common Name
+	TLSClientDefaultRole string            `conf:"tls_client_default_role"`                  // роль сертификатов из TLSClientTenantMap, которых нет в TLSClientRoleMap
+}
+
+// Dynamic — параметры, которые перезагружаются без перезапуска: логирование, ограничения запросов,
+// CORS и флаги функций.
+type Dynamic struct {
+	LogLevel   string   `conf:"log_level" default:"info"`    // минимальный уровень логов: debug, info, warn или error
+	LogBodies  bool     `conf:"log_bodies" default:"false"`  // логировать тела запросов и ответов
+	LogBodyMax int      `conf:"log_body_max" default:"2048"` // сколько байт каждого тела попадает в лог
+	LogRedact  []string `conf:"log_redact_fields"`           // дополнительные поля JSON, значения которых скрываются в логах
+
+	RateLimits map[string]RateLimit `conf:"rate_limits"`       // ограничения частоты по маршрутам ("POST /quotes", "*" — остальные)
+	RateDaily  map[string]int       `conf:"rate_daily_quotas"` // дневные квоты запросов по маршрутам
+
+	CORSOrigins []string      `conf:"cors_allowed_origins"`                                                   // источники, которым разрешены запросы из браузера, * — любые; пусто — CORS выключен
+	CORSMethods []string      `conf:"cors_allowed_methods" default:"GET,POST,PUT,DELETE"`                     // методы, разрешённые в ответе на preflight-запрос
+	CORSHeaders []string      `conf:"cors_allowed_headers" default:"Authorization,Content-Type,X-Request-ID"` // заголовки, разрешённые в ответе на preflight-запрос
+	CORSMaxAge  time.Duration `conf:"cors_max_age" default:"10m"`                                             // сколько браузер может кешировать ответ на preflight-запрос
+
+	Features []string `conf:"features"` // включённые флаги функций, например read_only
 }
 
-// loadDotEnv читает файл .env в корне проекта и устанавливает переменные окружения.
-func loadDotEnv() {
-	file, err := os.Open(".env")
-	if err != nil {
-		return
-	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) != 2 {
-			continue
-		}
-		key := strings.TrimSpace(parts[0])
-		val := strings.TrimSpace(parts[1])
-		os.Setenv(key, val)
-	}
+// RateLimit — ограничение частоты: Requests запросов за Per, не больше Burst запросов подряд.
+type RateLimit struct {
+	Requests int
+	Per      time.Duration
+	Burst    int
 }
@@ -1,8 +1,15 @@
 package config
 
 import (
+	"bytes"
+	"errors"
+	"flag"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 // withEnv очищает и восстанавливает переменную окружения (ключ, значение)
@@ -17,13 +24,33 @@ func withEnv(key, value string, fn func()) {
 	}
 }
 
+// loadEnv загружает конфигурацию из переменных окружения env в формате KEY=VALUE и флагов args
+func loadEnv(env []string, args ...string) (Config, error) {
+	cfg, _, err := load(args, env, io.Discard)
+	return cfg, err
+}
+
+// mustLoad загружает конфигурацию и завершает тест при ошибке
+func mustLoad(t *testing.T, env ...string) Config {
+	t.Helper()
+	cfg, err := loadEnv(env)
+	if err != nil {
+		t.Fatalf("ошибка загрузки конфигурации: %v", err)
+	}
+	return cfg
+}
+
+// wantError проверяет, что загрузка с переменными env завершается ошибкой, упоминающей substr
+func wantError(t *testing.T, substr string, env ...string) {
+	t.Helper()
+	if _, err := loadEnv(env); err == nil || !strings.Contains(err.Error(), substr) {
+		t.Errorf("%v: ожидается ошибка с %q, получено %v", env, substr, err)
+	}
+}
+
 // TestLoadDefaults проверяет значения по умолчанию при отсутствии переменных окружения
 func TestLoadDefaults(t *testing.T) {
-	// очищаем переменные окружения
-	_ = os.Unsetenv("PORT")
-	_ = os.Unsetenv("DB_MODE")
-
-	cfg := Load()
+	cfg := mustLoad(t)
 	if cfg.Port != "8080" {
 		t.Errorf("ожидаемый порт по умолчанию 8080, получен %s", cfg.Port)
 	}
@@ -36,7 +63,10 @@ func TestLoadDefaults(t *testing.T) {
 func TestLoadFromEnv(t *testing.T) {
 	withEnv("PORT", "9090", func() {
 		withEnv("DB_MODE", "sqlite", func() {
-			cfg := Load()
+			cfg, _, err := Load(nil)
+			if err != nil {
+				t.Fatalf("ошибка Load: %v", err)
+			}
 			if cfg.Port != "9090" {
 				t.Errorf("ожидаемый порт 9090, получен %s", cfg.Port)
 			}
@@ -46,3 +76,250 @@ func TestLoadFromEnv(t *testing.T) {
 		})
 	})
 }
+
+// TestLoadDailyWindow проверяет разбор окна без повторов для цитаты дня
+func TestLoadDailyWindow(t *testing.T) {
+	if cfg := mustLoad(t, "DAILY_NO_REPEAT_DAYS=7"); cfg.DailyWindow != 7 {
+		t.Errorf("ожидается окно 7 дней, получено %d", cfg.DailyWindow)
+	}
+	wantError(t, "DAILY_NO_REPEAT_DAYS", "DAILY_NO_REPEAT_DAYS=неделя")
+}
+
+// TestLoadPopularHalfLife проверяет разбор периода полураспада популярности
+func TestLoadPopularHalfLife(t *testing.T) {
+	if cfg := mustLoad(t, "POPULAR_HALF_LIFE=6h"); cfg.PopularHalfLife != 6*time.Hour {
+		t.Errorf("ожидается 6h, получено %v", cfg.PopularHalfLife)
+	}
+	if cfg := mustLoad(t, "POPULAR_HALF_LIFE="); cfg.PopularHalfLife != 24*time.Hour {
+		t.Errorf("ожидается значение по умолчанию 24h, получено %v", cfg.PopularHalfLife)
+	}
+	wantError(t, "popular_half_life", "POPULAR_HALF_LIFE=0s")
+}
+
+// TestLoadJWTRoleMap проверяет разбор отображения ролей провайдера на роли сервиса
+func TestLoadJWTRoleMap(t *testing.T) {
+	cfg := mustLoad(t, "JWT_ROLE_MAPPING=quotes-admins=admin, readers=viewer")
+	if len(cfg.JWTRoleMap) != 2 || cfg.JWTRoleMap["quotes-admins"] != "admin" || cfg.JWTRoleMap["readers"] != "viewer" {
+		t.Errorf("неожиданное отображение ролей: %v", cfg.JWTRoleMap)
+	}
+	wantError(t, "битая", "JWT_ROLE_MAPPING=quotes-admins=admin,битая")
+	wantError(t, "superuser", "JWT_ROLE_MAPPING=quotes-admins=superuser")
+}
+
+// TestLoadTenantQuotas проверяет разбор лимитов цитат рабочих пространств
+func TestLoadTenantQuotas(t *testing.T) {
+	cfg := mustLoad(t, "TENANT_QUOTAS=team-a=100")
+	if len(cfg.TenantQuotas) != 1 || cfg.TenantQuotas["team-a"] != 100 {
+		t.Errorf("неожиданные лимиты: %v", cfg.TenantQuotas)
+	}
+	if cfg.TenantMaxQuotes != 0 {
+		t.Errorf("ожидается лимит по умолчанию 0, получено %d", cfg.TenantMaxQuotes)
+	}
+	wantError(t, "team-b", "TENANT_QUOTAS=team-a=100,team-b=-1")
+	wantError(t, "team-c", "TENANT_QUOTAS=team-c=много")
+}
+
+// TestLoadRateLimits проверяет разбор правил ограничения запросов
+func TestLoadRateLimits(t *testing.T) {
+	cfg := mustLoad(t, "RATE_LIMITS=POST /quotes=10/m:20, *=5/30s")
+	want := map[string]RateLimit{
+		"POST /quotes": {Requests: 10, Per: time.Minute, Burst: 20},
+		"*":            {Requests: 5, Per: 30 * time.Second, Burst: 5},
+	}
+	if len(cfg.RateLimits) != len(want) {
+		t.Fatalf("ожидается %d правила, получено %v", len(want), cfg.RateLimits)
+	}
+	for route, l := range want {
+		if cfg.RateLimits[route] != l {
+			t.Errorf("%s: ожидается %+v, получено %+v", route, l, cfg.RateLimits[route])
+		}
+	}
+	if cfg.RateLimitKey != "key" || cfg.RateLimitStore != "memory" {
+		t.Errorf("неожиданные значения по умолчанию: %q, %q", cfg.RateLimitKey, cfg.RateLimitStore)
+	}
+	wantError(t, "bad", "RATE_LIMITS=bad=x/m")
+	wantError(t, "zero", "RATE_LIMITS=zero=0/s")
+}
+
+// TestLoadLogging проверяет настройки логирования и то, что тела по умолчанию не логируются
+func TestLoadLogging(t *testing.T) {
+	cfg := mustLoad(t)
+	if cfg.LogLevel != "info" || cfg.LogFormat != "text" || cfg.LogBodies || cfg.LogBodyMax != 2048 {
+		t.Errorf("неожиданные значения по умолчанию: %+v", cfg)
+	}
+	cfg = mustLoad(t, "LOG_FORMAT=json", "LOG_BODIES=true", "LOG_REDACT_FIELDS=email, phone,")
+	if cfg.LogFormat != "json" || !cfg.LogBodies {
+		t.Errorf("ожидается json с телами, получено %q, %v", cfg.LogFormat, cfg.LogBodies)
+	}
+	if len(cfg.LogRedact) != 2 || cfg.LogRedact[0] != "email" || cfg.LogRedact[1] != "phone" {
+		t.Errorf("неожиданный список скрываемых полей: %q", cfg.LogRedact)
+	}
+	wantError(t, "log_format", "LOG_FORMAT=xml")
+}
+
+// TestLoadTracing проверяет настройки трассировки
+func TestLoadTracing(t *testing.T) {
+	if cfg := mustLoad(t); cfg.TraceExporter != "none" || cfg.TraceSampleRatio != 1 || cfg.TraceFile != "traces.json" {
+		t.Errorf("неожиданные значения по умолчанию: %q, %v, %q", cfg.TraceExporter, cfg.TraceSampleRatio, cfg.TraceFile)
+	}
+	if cfg := mustLoad(t, "TRACE_SAMPLE_RATIO=0.25"); cfg.TraceSampleRatio != 0.25 {
+		t.Errorf("ожидается доля 0.25, получено %v", cfg.TraceSampleRatio)
+	}
+	wantError(t, "trace_sample_ratio", "TRACE_SAMPLE_RATIO=5")
+}
+
+// TestLoadHealth проверяет настройки проверок готовности
+func TestLoadHealth(t *testing.T) {
+	if cfg := mustLoad(t); cfg.HealthTimeout != 2*time.Second || cfg.HealthMinFreeMB != 100 {
+		t.Errorf("неожиданные значения по умолчанию: %v, %d", cfg.HealthTimeout, cfg.HealthMinFreeMB)
+	}
+	if cfg := mustLoad(t, "HEALTH_MIN_FREE_MB=5"); cfg.HealthMinFreeMB != 5 {
+		t.Errorf("ожидается 5 МБ, получено %d", cfg.HealthMinFreeMB)
+	}
+}
+
+// TestLoadServer проверяет таймауты сервера и остановки
+func TestLoadServer(t *testing.T) {
+	cfg := mustLoad(t)
+	if cfg.HTTPReadHeaderTimeout != 5*time.Second || cfg.HTTPWriteTimeout != 30*time.Second ||
+		cfg.HTTPMaxHeaderBytes != 1<<20 || cfg.ShutdownTimeout != 30*time.Second || cfg.ShutdownDelay != 0 {
+		t.Errorf("неожиданные значения по умолчанию: %+v", cfg)
+	}
+	if cfg := mustLoad(t, "HTTP_WRITE_TIMEOUT=0s"); cfg.HTTPWriteTimeout != 0 {
+		t.Errorf("0 должен отключать таймаут, получено %v", cfg.HTTPWriteTimeout)
+	}
+	wantError(t, "SHUTDOWN_TIMEOUT", "SHUTDOWN_TIMEOUT=-1s")
+}
+
+// TestLoadTLS проверяет настройки TLS
+func TestLoadTLS(t *testing.T) {
+	cfg := mustLoad(t)
+	if cfg.TLSCertFile != "" || cfg.TLSMinVersion != "1.2" || cfg.TLSCipherPolicy != "intermediate" || cfg.TLSClientAuth != "require" {
+		t.Errorf("неожиданные значения по умолчанию: %+v", cfg)
+	}
+	if cfg := mustLoad(t, "TLS_CLIENT_ROLE_MAPPING=ci=editor,ops=admin"); cfg.TLSClientRoleMap["ops"] != "admin" {
+		t.Errorf("ожидается роль admin для ops, получено %v", cfg.TLSClientRoleMap)
+	}
+	if cfg := mustLoad(t, "TLS_CLIENT_TENANT_MAPPING=ci=team-a", "TLS_CLIENT_DEFAULT_ROLE=viewer"); cfg.TLSClientTenantMap["ci"] != "team-a" {
+		t.Errorf("ожидается пространство team-a для ci, получено %v", cfg.TLSClientTenantMap)
+	}
+	wantError(t, "tls_key_file", "TLS_CERT_FILE=cert.pem")
+	wantError(t, "tls_client_tenant_mapping", "TLS_CLIENT_DEFAULT_ROLE=admin")
+}
+
+// TestLoadValidation проверяет понятные ошибки для опечаток в режимах и некорректных портов
+func TestLoadValidation(t *testing.T) {
+	wantError(t, `db_mode: неизвестное значение "sqllite"`, "DB_MODE=sqllite")
+	wantError(t, "port", "PORT=http")
+	wantError(t, "port", "PORT=70000")
+	wantError(t, "auth_mode", "AUTH_MODE=apikey,oauth")
+	wantError(t, "jwt_issuer", "AUTH_MODE=jwt")
+	wantError(t, "QUOTES_DB_MOD", "QUOTES_DB_MOD=sqlite")
+	wantError(t, "sqlite_driver", "SQLITE_DRIVER=cgo")
+
+	// Все ошибки сообщаются сразу
+	_, err := loadEnv([]string{"DB_MODE=mongo", "LOG_LEVEL=trace"})
+	if err == nil || !strings.Contains(err.Error(), "db_mode") || !strings.Contains(err.Error(), "log_level") {
+		t.Errorf("ожидаются обе ошибки, получено %v", err)
+	}
+}
+
+// TestLoadDatabaseURL проверяет, что адрес хранилища задаёт режим и путь, а без него адрес
+// собирается из прежних параметров
+func TestLoadDatabaseURL(t *testing.T) {
+	cfg := mustLoad(t, "DB_MODE=memory", "DATABASE_URL=sqlite:///var/lib/quotes.db?_journal=WAL")
+	if cfg.DBMode != "sqlite" || cfg.DBPath != "/var/lib/quotes.db" || cfg.StorageURL() != "sqlite:///var/lib/quotes.db?_journal=WAL" {
+		t.Errorf("адрес хранилища не применён: %q, %q, %q", cfg.DBMode, cfg.DBPath, cfg.StorageURL())
+	}
+	if cfg := mustLoad(t, "DB_MODE=bolt", "DB_PATH=/data/q.bolt"); cfg.StorageURL() != "bolt:///data/q.bolt" {
+		t.Errorf("ожидается адрес bolt:///data/q.bolt, получено %q", cfg.StorageURL())
+	}
+	if cfg := mustLoad(t); cfg.StorageURL() != "memory://" {
+		t.Errorf("ожидается адрес memory://, получено %q", cfg.StorageURL())
+	}
+	cfg = mustLoad(t, "MEMORY_DIR=/var/lib/quotes", "MEMORY_FSYNC=always")
+	if got := cfg.StorageURL(); got != "memory:///var/lib/quotes?fsync=always&fsync_interval=1s&snapshot_interval=5m0s" {
+		t.Errorf("параметры сохранения не перенесены в адрес: %q", got)
+	}
+	wantError(t, "database_url", "DATABASE_URL=mongodb://localhost/quotes")
+	wantError(t, "database_url", "DATABASE_URL=/var/lib/quotes.db")
+	wantError(t, "db_path", "DATABASE_URL=sqlite://")
+}
+
+// TestLoadLayers проверяет порядок слоёв: файл, переменные без префикса, с префиксом, флаги
+func TestLoadLayers(t *testing.T) {
+	dir := t.TempDir()
+	yamlFile := filepath.Join(dir, "quotes.yaml")
+	os.WriteFile(yamlFile, []byte(`
+port: 7000
+db:
+  mode: sqlite
+  path: /var/lib/quotes.db
+log_level: debug
+log_redact_fields: [email, phone]
+rate_limits:
+  "POST /quotes": 10/m:20
+`), 0o600)
+
+	cfg, flags, err := load([]string{"--config", yamlFile, "--log-bodies", "keys", "list"}, []string{"LOG_LEVEL=warn"}, io.Discard)
+	if err != nil {
+		t.Fatalf("ошибка загрузки: %v", err)
+	}
+	if cfg.Port != "7000" || cfg.DBMode != "sqlite" || cfg.DBPath != "/var/lib/quotes.db" {
+		t.Errorf("значения из файла не применены: %+v", cfg)
+	}
+	if len(cfg.LogRedact) != 2 || cfg.RateLimits["POST /quotes"].Burst != 20 {
+		t.Errorf("списки и таблицы из файла не разобраны: %q, %v", cfg.LogRedact, cfg.RateLimits)
+	}
+	if cfg.LogLevel != "warn" || !cfg.LogBodies {
+		t.Errorf("переменная и флаг должны перекрывать файл: %q, %v", cfg.LogLevel, cfg.LogBodies)
+	}
+	if len(flags.Args) != 2 || flags.Args[0] != "keys" {
+		t.Errorf("ожидаются аргументы подкоманды, получено %q", flags.Args)
+	}
+
+	env := []string{"QUOTES_CONFIG=" + yamlFile, "PORT=7001", "QUOTES_PORT=7002"}
+	if cfg, _ := loadEnv(env); cfg.Port != "7002" {
+		t.Errorf("переменная с префиксом должна перекрывать переменную без префикса, получено %s", cfg.Port)
+	}
+	if cfg, _ := loadEnv(env, "--port=7003"); cfg.Port != "7003" {
+		t.Errorf("флаг должен перекрывать переменные, получено %s", cfg.Port)
+	}
+
+	tomlFile := filepath.Join(dir, "quotes.toml")
+	os.WriteFile(tomlFile, []byte("db_mode = \"sqlite\"\nhealth_timeout = \"5s\"\n[tenant]\nmax_quotes = 50\n"), 0o600)
+	if cfg, err := loadEnv(nil, "--config", tomlFile); err != nil || cfg.HealthTimeout != 5*time.Second || cfg.TenantMaxQuotes != 50 {
+		t.Errorf("файл TOML не применён: %+v, %v", cfg, err)
+	}
+
+	os.WriteFile(yamlFile, []byte("db_mod: sqlite\n"), 0o600)
+	if _, err := loadEnv(nil, "--config", yamlFile); err == nil || !strings.Contains(err.Error(), "db_mod") {
+		t.Errorf("ожидается ошибка для неизвестного параметра в файле, получено %v", err)
+	}
+	if _, err := loadEnv(nil, "-h"); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("ожидается flag.ErrHelp, получено %v", err)
+	}
+}
+
+// TestPrint проверяет вывод конфигурации со скрытыми секретами и его обратную загрузку
+func TestPrint(t *testing.T) {
+	cfg := mustLoad(t, "AUTH_MODE=apikey", "AUTH_BOOTSTRAP_KEY=qk_secret", "RATE_LIMITS=*=5/30s:10")
+	var buf bytes.Buffer
+	if err := Print(&buf, cfg); err != nil {
+		t.Fatalf("ошибка Print: %v", err)
+	}
+	out := buf.String()
+	if strings.Contains(out, "qk_secret") || !strings.Contains(out, `auth_bootstrap_key: "[REDACTED]"`) {
+		t.Errorf("секрет должен быть скрыт: %s", out)
+	}
+
+	path := filepath.Join(t.TempDir(), "printed.yaml")
+	os.WriteFile(path, buf.Bytes(), 0o600)
+	again, err := loadEnv(nil, "--config", path)
+	if err != nil {
+		t.Fatalf("выведенная конфигурация должна загружаться: %v", err)
+	}
+	if again.RateLimits["*"] != cfg.RateLimits["*"] || again.AuthMode != "apikey" {
+		t.Errorf("после обратной загрузки получено %+v", again)
+	}
+}
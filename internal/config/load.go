@@ -0,0 +1,490 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix — префикс переменных окружения сервиса. Переменные без префикса (PORT, DB_MODE)
+// тоже принимаются, но переменная с префиксом важнее.
+const EnvPrefix = "QUOTES_"
+
+// envConfigFile — переменная с путём к файлу конфигурации, если он не задан флагом --config.
+const envConfigFile = EnvPrefix + "CONFIG"
+
+// CLIEnvPrefix — префикс переменных консольного клиента quotes. Сервис их не читает и не считает
+// неизвестными, поэтому клиент и сервер можно запускать в одном окружении.
+const CLIEnvPrefix = EnvPrefix + "CLI_"
+
+// Flags — параметры командной строки, которые не входят в конфигурацию сервиса.
+type Flags struct {
+	File        string   // файл конфигурации из --config или QUOTES_CONFIG
+	PrintConfig bool     // --print-config: вывести действующую конфигурацию и выйти
+	Args        []string // аргументы после флагов, например подкоманда keys
+
+	args []string // исходные аргументы, с которыми конфигурация перечитывается при перезагрузке
+}
+
+// field описывает один параметр конфигурации.
+type field struct {
+	key     string // имя в файле: db_mode
+	def     string // значение по умолчанию в текстовом виде
+	secret  bool
+	dynamic bool  // параметр из Dynamic, перезагружается без перезапуска
+	index   []int // путь к полю в Config
+}
+
+// fields — параметры Config в порядке объявления: сначала Static, затем Dynamic.
+var fields = func() []field {
+	dynamic, _ := reflect.TypeOf(Config{}).FieldByName("Dynamic")
+	var res []field
+	for _, f := range reflect.VisibleFields(reflect.TypeOf(Config{})) {
+		if f.Anonymous {
+			continue
+		}
+		res = append(res, field{
+			key:     f.Tag.Get("conf"),
+			def:     f.Tag.Get("default"),
+			secret:  f.Tag.Get("secret") == "true",
+			dynamic: f.Index[0] == dynamic.Index[0],
+			index:   f.Index,
+		})
+	}
+	return res
+}()
+
+// lookup находит параметр по имени в файле.
+func lookup(key string) (field, bool) {
+	for _, f := range fields {
+		if f.key == key {
+			return f, true
+		}
+	}
+	return field{}, false
+}
+
+// env и flagName возвращают имя переменной окружения и флага параметра.
+func (f field) env() string      { return strings.ToUpper(f.key) }
+func (f field) flagName() string { return strings.ReplaceAll(f.key, "_", "-") }
+
+// Defaults возвращает конфигурацию из значений по умолчанию.
+func Defaults() Config {
+	var c Config
+	for _, f := range fields {
+		if f.def != "" {
+			if err := f.set(&c, f.def); err != nil {
+				panic(fmt.Sprintf("config: некорректное значение по умолчанию %s: %v", f.key, err))
+			}
+		}
+	}
+	return c
+}
+
+// Load собирает конфигурацию слоями: значения по умолчанию, файл YAML или TOML (--config или
+// QUOTES_CONFIG), файл .env в рабочем каталоге, переменные окружения без префикса и с префиксом
+// QUOTES_, флаги командной строки args. Каждый следующий слой переопределяет предыдущий; пустое
+// значение не переопределяет ничего. Неизвестные параметры в файле, переменные QUOTES_ с неизвестным
+// именем, некорректные значения и недопустимые режимы дают ошибку. При -h возвращается flag.ErrHelp.
+func Load(args []string) (Config, Flags, error) {
+	return load(args, os.Environ(), os.Stderr)
+}
+
+// load — Load с явными переменными окружения и выводом справки по флагам.
+func load(args, environ []string, usage io.Writer) (Config, Flags, error) {
+	flags := Flags{args: args}
+	fs := flag.NewFlagSet("quotes", flag.ContinueOnError)
+	fs.SetOutput(usage)
+	fs.StringVar(&flags.File, "config", "", "файл конфигурации YAML или TOML")
+	fs.BoolVar(&flags.PrintConfig, "print-config", false, "вывести действующую конфигурацию без секретов и выйти")
+	values := make(map[string]*flagValue, len(fields))
+	for _, f := range fields {
+		v := &flagValue{isBool: reflect.TypeOf(Config{}).FieldByIndex(f.index).Type.Kind() == reflect.Bool}
+		values[f.key] = v
+		usage := fmt.Sprintf("переменная %s", f.env())
+		if f.def != "" {
+			usage += ", по умолчанию " + f.def
+		}
+		fs.Var(v, f.flagName(), usage)
+	}
+	if err := fs.Parse(args); err != nil {
+		return Config{}, flags, err
+	}
+	flags.Args = fs.Args()
+
+	env := parseEnviron(environ)
+	if flags.File == "" {
+		flags.File = env[envConfigFile]
+	}
+	var layers []layer
+	if flags.File != "" {
+		file, err := readFile(flags.File)
+		if err != nil {
+			return Config{}, flags, err
+		}
+		layers = append(layers, file)
+	}
+	dotenv := readDotEnv(".env")
+	layers = append(layers,
+		envLayer("файл .env:", dotenv, ""), envLayer("файл .env:", dotenv, EnvPrefix),
+		envLayer("переменная", env, ""), envLayer("переменная", env, EnvPrefix),
+	)
+	cli := layer{source: "флаг"}
+	for _, f := range fields {
+		if v := values[f.key]; v.set {
+			cli.add(f.key, "--"+f.flagName(), v.value)
+		}
+	}
+	layers = append(layers, cli)
+
+	var errs []error
+	for name := range env {
+		if rest, ok := strings.CutPrefix(name, EnvPrefix); ok && name != envConfigFile && !strings.HasPrefix(name, CLIEnvPrefix) {
+			if _, known := lookup(strings.ToLower(rest)); !known {
+				errs = append(errs, fmt.Errorf("неизвестная переменная окружения %s", name))
+			}
+		}
+	}
+	cfg := Defaults()
+	for _, l := range layers {
+		errs = append(errs, l.apply(&cfg)...)
+	}
+	cfg.applyDatabaseURL()
+	if len(errs) == 0 {
+		errs = append(errs, cfg.Validate())
+	}
+	if err := errors.Join(errs...); err != nil {
+		return Config{}, flags, err
+	}
+	return cfg, flags, nil
+}
+
+// layer — значения параметров из одного источника.
+type layer struct {
+	source string
+	values []value
+	errs   []error
+}
+
+// value — значение параметра и место, откуда оно взято, для сообщений об ошибках.
+type value struct {
+	key, origin, raw string
+}
+
+func (l *layer) add(key, origin, raw string) {
+	l.values = append(l.values, value{key: key, origin: origin, raw: raw})
+}
+
+// apply записывает значения слоя в cfg и возвращает ошибки разбора.
+func (l layer) apply(cfg *Config) []error {
+	errs := l.errs
+	for _, v := range l.values {
+		if strings.TrimSpace(v.raw) == "" {
+			continue
+		}
+		f, _ := lookup(v.key)
+		if err := f.set(cfg, v.raw); err != nil {
+			errs = append(errs, fmt.Errorf("%s %s: %w", l.source, v.origin, err))
+		}
+	}
+	return errs
+}
+
+// envLayer выбирает из переменных окружения параметры конфигурации с префиксом prefix.
+func envLayer(source string, env map[string]string, prefix string) layer {
+	l := layer{source: source}
+	for _, f := range fields {
+		name := prefix + f.env()
+		if raw, ok := env[name]; ok {
+			l.add(f.key, name, raw)
+		}
+	}
+	return l
+}
+
+// parseEnviron превращает список KEY=VALUE в словарь.
+func parseEnviron(environ []string) map[string]string {
+	env := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			env[k] = v
+		}
+	}
+	return env
+}
+
+// readDotEnv читает файл .env в формате KEY=VALUE; отсутствующий файл даёт пустой словарь.
+func readDotEnv(path string) map[string]string {
+	env := make(map[string]string)
+	file, err := os.Open(path)
+	if err != nil {
+		return env
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, val, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		env[strings.TrimSpace(key)] = strings.TrimSpace(val)
+	}
+	return env
+}
+
+// readFile читает файл конфигурации; формат определяется расширением: .yaml, .yml или .toml.
+// Вложенные разделы склеиваются через подчёркивание: db: {mode: sqlite} — то же, что db_mode: sqlite.
+func readFile(path string) (layer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return layer{}, fmt.Errorf("чтение файла конфигурации: %w", err)
+	}
+	doc := make(map[string]any)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &doc)
+	case ".toml":
+		err = toml.Unmarshal(data, &doc)
+	default:
+		return layer{}, fmt.Errorf("неизвестный формат файла конфигурации %s (ожидается .yaml, .yml или .toml)", path)
+	}
+	if err != nil {
+		return layer{}, fmt.Errorf("разбор файла конфигурации %s: %w", path, err)
+	}
+	l := layer{source: "файл " + path + ":"}
+	flatten(&l, "", doc)
+	return l, nil
+}
+
+// flatten добавляет в слой значения документа. Таблица, имя которой не совпадает с параметром,
+// считается разделом; таблица-параметр (например rate_limits) записывается парами key=value.
+func flatten(l *layer, prefix string, doc map[string]any) {
+	keys := make([]string, 0, len(doc))
+	for k := range doc {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		key := prefix + strings.ToLower(strings.ReplaceAll(k, "-", "_"))
+		v := doc[k]
+		if _, known := lookup(key); known {
+			l.add(key, key, scalarText(v))
+			continue
+		}
+		if section, ok := v.(map[string]any); ok {
+			flatten(l, key+"_", section)
+			continue
+		}
+		l.errs = append(l.errs, fmt.Errorf("%s неизвестный параметр %s", l.source, key))
+	}
+}
+
+// scalarText приводит значение из файла к текстовому виду, который понимает field.set:
+// списки — через запятую, таблицы — парами key=value.
+func scalarText(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = scalarText(item)
+		}
+		return strings.Join(items, ",")
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		pairs := make([]string, len(keys))
+		for i, k := range keys {
+			pairs[i] = k + "=" + scalarText(v[k])
+		}
+		return strings.Join(pairs, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// flagValue запоминает, был ли флаг задан явно, чтобы незаданные флаги не перекрывали другие слои.
+type flagValue struct {
+	value  string
+	set    bool
+	isBool bool
+}
+
+func (v *flagValue) String() string { return v.value }
+
+func (v *flagValue) Set(s string) error {
+	v.value, v.set = s, true
+	return nil
+}
+
+// IsBoolFlag позволяет писать логические флаги без значения: --log-bodies.
+func (v *flagValue) IsBoolFlag() bool { return v.isBool }
+
+// set разбирает raw по типу поля и записывает результат в c.
+func (f field) set(c *Config, raw string) error {
+	dst := reflect.ValueOf(c).Elem().FieldByIndex(f.index)
+	raw = strings.TrimSpace(raw)
+	switch p := dst.Addr().Interface().(type) {
+	case *string:
+		*p = raw
+	case *int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("ожидается целое число, получено %q", raw)
+		}
+		*p = n
+	case *uint64:
+		n, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("ожидается неотрицательное целое число, получено %q", raw)
+		}
+		*p = n
+	case *float64:
+		x, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("ожидается число, получено %q", raw)
+		}
+		*p = x
+	case *bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("ожидается true или false, получено %q", raw)
+		}
+		*p = b
+	case *time.Duration:
+		d, err := time.ParseDuration(raw)
+		if err != nil || d < 0 {
+			return fmt.Errorf("ожидается неотрицательная длительность вроде 30s или 5m, получено %q", raw)
+		}
+		*p = d
+	case *[]string:
+		*p = parseList(raw)
+	case *map[string]string:
+		m, err := parseMap(raw)
+		if err != nil {
+			return err
+		}
+		*p = m
+	case *map[string]int:
+		m, err := parseIntMap(raw)
+		if err != nil {
+			return err
+		}
+		*p = m
+	case *map[string]RateLimit:
+		m, err := parseRateLimits(raw)
+		if err != nil {
+			return err
+		}
+		*p = m
+	default:
+		panic(fmt.Sprintf("config: неподдерживаемый тип параметра %s", f.key))
+	}
+	return nil
+}
+
+// parseMap разбирает пары вида "a=b,c=d" в словарь; пустая строка даёт nil.
+func parseMap(raw string) (map[string]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	res := make(map[string]string)
+	for _, pair := range strings.Split(raw, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("ожидаются пары ключ=значение через запятую, получено %q", strings.TrimSpace(pair))
+		}
+		res[strings.TrimSpace(key)] = strings.TrimSpace(val)
+	}
+	return res, nil
+}
+
+// parseList разбирает список через запятую, пропуская пустые элементы; пустая строка даёт nil.
+func parseList(raw string) []string {
+	var res []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			res = append(res, item)
+		}
+	}
+	return res
+}
+
+// parseIntMap разбирает пары вида "a=10,b=20" с неотрицательными целыми значениями.
+func parseIntMap(raw string) (map[string]int, error) {
+	pairs, err := parseMap(raw)
+	if pairs == nil {
+		return nil, err
+	}
+	res := make(map[string]int, len(pairs))
+	for key, val := range pairs {
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("%s: ожидается неотрицательное целое число, получено %q", key, val)
+		}
+		res[key] = n
+	}
+	return res, nil
+}
+
+// parseRateLimits разбирает правила вида "POST /quotes=10/m:20,*=100/s".
+// Значение — число запросов за единицу (s, m, h или длительность вроде 30s) и необязательный burst
+// после двоеточия; без burst он равен числу запросов.
+func parseRateLimits(raw string) (map[string]RateLimit, error) {
+	pairs, err := parseMap(raw)
+	if pairs == nil {
+		return nil, err
+	}
+	res := make(map[string]RateLimit, len(pairs))
+	for route, spec := range pairs {
+		bad := fmt.Errorf("%s: ожидается правило вида 10/m или 10/30s:20, получено %q", route, spec)
+		spec, burstRaw, hasBurst := strings.Cut(spec, ":")
+		count, unit, ok := strings.Cut(spec, "/")
+		if !ok {
+			return nil, bad
+		}
+		n, err := strconv.Atoi(count)
+		if err != nil || n <= 0 {
+			return nil, bad
+		}
+		if unit == "s" || unit == "m" || unit == "h" {
+			unit = "1" + unit
+		}
+		per, err := time.ParseDuration(unit)
+		if err != nil || per <= 0 {
+			return nil, bad
+		}
+		burst := n
+		if hasBurst {
+			if burst, err = strconv.Atoi(burstRaw); err != nil || burst <= 0 {
+				return nil, bad
+			}
+		}
+		res[route] = RateLimit{Requests: n, Per: per, Burst: burst}
+	}
+	return res, nil
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redacted заменяет значения секретных параметров при выводе.
+const redacted = "[REDACTED]"
+
+// Print выводит конфигурацию в формате YAML, пригодном для файла --config.
+// Значения секретных параметров заменяются на [REDACTED].
+func Print(w io.Writer, c Config) error {
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+	for _, f := range fields {
+		val := f.text(c)
+		if f.secret && val != "" {
+			val = redacted
+		}
+		doc.Content = append(doc.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: f.key},
+			&yaml.Node{Kind: yaml.ScalarNode, Value: val, Style: yaml.DoubleQuotedStyle},
+		)
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// text возвращает значение параметра в текстовом виде, который понимает field.set.
+func (f field) text(c Config) string {
+	switch v := reflect.ValueOf(c).FieldByIndex(f.index).Interface().(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Duration:
+		return v.String()
+	case []string:
+		return strings.Join(v, ",")
+	case map[string]string:
+		return joinPairs(v, func(s string) string { return s })
+	case map[string]int:
+		return joinPairs(v, strconv.Itoa)
+	case map[string]RateLimit:
+		return joinPairs(v, func(l RateLimit) string { return fmt.Sprintf("%d/%s:%d", l.Requests, l.Per, l.Burst) })
+	default:
+		panic(fmt.Sprintf("config: неподдерживаемый тип параметра %s", f.key))
+	}
+}
+
+// joinPairs записывает словарь парами key=value через запятую в порядке ключей.
+func joinPairs[V any](m map[string]V, format func(V) string) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + format(m[k])
+	}
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"Test_Project_Brand_Scout/internal/filewatch"
+)
+
+// Subscriber применяет новые динамические параметры к компоненту. Ошибка отменяет перезагрузку.
+type Subscriber func(Dynamic) error
+
+// subscriber — подписчик и имя компонента для сообщений об ошибках.
+type subscriber struct {
+	name  string
+	apply Subscriber
+}
+
+// Reloader хранит действующую конфигурацию и перезагружает её динамическую часть.
+// Новая конфигурация собирается теми же слоями, что и при запуске, и проверяется целиком;
+// затем подписчики получают её по очереди. Если подписчик отказал, уже применившие её подписчики
+// получают прежние параметры, а действующей остаётся прежняя конфигурация.
+type Reloader struct {
+	load  func() (Config, error)
+	files *filewatch.Watcher
+
+	// mu упорядочивает перезагрузки; cur читается без блокировки
+	mu   sync.Mutex
+	cur  atomic.Pointer[Config]
+	subs []subscriber
+}
+
+// NewReloader создаёт Reloader для конфигурации cfg, загруженной с флагами flags. При перезагрузке
+// конфигурация перечитывается с теми же аргументами командной строки и текущим окружением;
+// отслеживаются файл конфигурации и .env.
+func NewReloader(cfg Config, flags Flags) *Reloader {
+	files := []string{".env"}
+	if flags.File != "" {
+		files = append(files, flags.File)
+	}
+	return newReloader(cfg, files, func() (Config, error) {
+		c, _, err := load(flags.args, os.Environ(), io.Discard)
+		return c, err
+	})
+}
+
+// newReloader — NewReloader с явной функцией загрузки и списком отслеживаемых файлов.
+func newReloader(cfg Config, files []string, load func() (Config, error)) *Reloader {
+	r := &Reloader{load: load, files: filewatch.New(files...)}
+	r.cur.Store(&cfg)
+	return r
+}
+
+// Current возвращает действующую конфигурацию.
+func (r *Reloader) Current() Config {
+	return *r.cur.Load()
+}
+
+// Subscribe добавляет подписчика под именем name. Подписчики уведомляются в порядке подписки
+// и только если динамические параметры изменились.
+func (r *Reloader) Subscribe(name string, apply Subscriber) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subs = append(r.subs, subscriber{name: name, apply: apply})
+}
+
+// Reload перечитывает конфигурацию и применяет изменившиеся динамические параметры.
+// Изменения статических параметров не применяются: они пишутся в лог как требующие перезапуска.
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Файлы отмечаются прочитанными и при ошибке, чтобы Watch не повторял загрузку тех же файлов
+	r.files.Seen()
+	next, err := r.load()
+	if err != nil {
+		return fmt.Errorf("новая конфигурация отклонена:\n%w", err)
+	}
+	old := r.Current()
+	if changed := staticChanges(old, next); len(changed) > 0 {
+		log.Printf("Параметры %s применяются только после перезапуска", strings.Join(changed, ", "))
+	}
+	next.Static = old.Static
+	if reflect.DeepEqual(old.Dynamic, next.Dynamic) {
+		return nil
+	}
+	for i, s := range r.subs {
+		if err := s.apply(next.Dynamic); err != nil {
+			var errs []error
+			for _, prev := range r.subs[:i] {
+				if rerr := prev.apply(old.Dynamic); rerr != nil {
+					errs = append(errs, fmt.Errorf("откат %s: %w", prev.name, rerr))
+				}
+			}
+			return errors.Join(append([]error{fmt.Errorf("%s: %w", s.name, err)}, errs...)...)
+		}
+	}
+	r.cur.Store(&next)
+	return nil
+}
+
+// staticChanges возвращает имена статических параметров, которые различаются в a и b.
+func staticChanges(a, b Config) []string {
+	var changed []string
+	for _, f := range fields {
+		if !f.dynamic && f.text(a) != f.text(b) {
+			changed = append(changed, f.key)
+		}
+	}
+	return changed
+}
+
+// Watch раз в interval проверяет файл конфигурации и .env и перезагружает конфигурацию после
+// их изменения. Ошибка пишется в лог, а следующая попытка будет после нового изменения файлов.
+// Завершается с отменой ctx.
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	r.files.Watch(ctx, interval, func() {
+		if err := r.Reload(); err != nil {
+			log.Printf("Не удалось перезагрузить конфигурацию: %v", err)
+			return
+		}
+		log.Println("Конфигурация перезагружена после изменения файла")
+	})
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestReload проверяет применение динамических параметров, отказ при ошибке проверки и откат подписчиков
+func TestReload(t *testing.T) {
+	start := mustLoad(t)
+	env := []string{"LOG_LEVEL=debug"}
+	r := newReloader(start, nil, func() (Config, error) { return loadEnv(env) })
+
+	var applied []string
+	r.Subscribe("level", func(d Dynamic) error {
+		applied = append(applied, d.LogLevel)
+		return nil
+	})
+	if err := r.Reload(); err != nil {
+		t.Fatalf("ошибка Reload: %v", err)
+	}
+	if r.Current().LogLevel != "debug" || len(applied) != 1 || applied[0] != "debug" {
+		t.Errorf("новый уровень не применён: %q, подписчик получил %q", r.Current().LogLevel, applied)
+	}
+
+	// Без изменений подписчики не уведомляются
+	if err := r.Reload(); err != nil || len(applied) != 1 {
+		t.Errorf("повторная загрузка без изменений: %v, уведомлений %d", err, len(applied))
+	}
+
+	// Некорректная конфигурация отклоняется до уведомления подписчиков
+	env = []string{"LOG_LEVEL=verbose"}
+	if err := r.Reload(); err == nil || !strings.Contains(err.Error(), "log_level") {
+		t.Errorf("ожидается ошибка проверки log_level, получено %v", err)
+	}
+	if r.Current().LogLevel != "debug" || len(applied) != 1 {
+		t.Errorf("после ошибки должна действовать прежняя конфигурация: %q, %q", r.Current().LogLevel, applied)
+	}
+
+	// Отказ второго подписчика откатывает первого
+	r.Subscribe("cors", func(d Dynamic) error {
+		if len(d.CORSOrigins) > 0 {
+			return errors.New("сбой")
+		}
+		return nil
+	})
+	env = []string{"LOG_LEVEL=warn", "CORS_ALLOWED_ORIGINS=https://example.com"}
+	if err := r.Reload(); err == nil || !strings.Contains(err.Error(), "cors: сбой") {
+		t.Errorf("ожидается ошибка подписчика cors, получено %v", err)
+	}
+	if got := applied[len(applied)-2:]; got[0] != "warn" || got[1] != "debug" {
+		t.Errorf("первый подписчик должен получить новый уровень, затем прежний, получено %q", applied)
+	}
+	if r.Current().LogLevel != "debug" {
+		t.Errorf("после отката должен действовать прежний уровень, получен %q", r.Current().LogLevel)
+	}
+}
+
+// TestReloadStatic проверяет, что статические параметры не меняются без перезапуска
+func TestReloadStatic(t *testing.T) {
+	r := newReloader(mustLoad(t), nil, func() (Config, error) { return loadEnv([]string{"PORT=9090", "LOG_BODIES=true"}) })
+	if err := r.Reload(); err != nil {
+		t.Fatalf("ошибка Reload: %v", err)
+	}
+	if cfg := r.Current(); cfg.Port != "8080" || !cfg.LogBodies {
+		t.Errorf("ожидается прежний порт и новый log_bodies, получено %s, %v", cfg.Port, cfg.LogBodies)
+	}
+	if changed := staticChanges(mustLoad(t), mustLoad(t, "PORT=9090", "LOG_BODIES=true")); len(changed) != 1 || changed[0] != "port" {
+		t.Errorf("ожидается изменение только port, получено %q", changed)
+	}
+}
+
+// TestReloaderWatch проверяет перезагрузку после изменения файла конфигурации
+func TestReloaderWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quotes.yaml")
+	os.WriteFile(path, []byte("log_level: info\n"), 0o600)
+	cfg, err := loadEnv(nil, "--config", path)
+	if err != nil {
+		t.Fatalf("ошибка загрузки: %v", err)
+	}
+	r := newReloader(cfg, []string{path}, func() (Config, error) { return loadEnv(nil, "--config", path) })
+	levels := make(chan string, 4)
+	r.Subscribe("level", func(d Dynamic) error {
+		levels <- d.LogLevel
+		return nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Watch(ctx, 10*time.Millisecond)
+
+	os.WriteFile(path, []byte("log_level: error\nfeatures: [read_only]\n"), 0o600)
+	select {
+	case level := <-levels:
+		if level != "error" || !slices.Equal(r.Current().Features, []string{"read_only"}) {
+			t.Errorf("ожидается уровень error и флаг read_only, получено %q, %q", level, r.Current().Features)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("конфигурация не перезагружена после изменения файла")
+	}
+}
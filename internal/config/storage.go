@@ -0,0 +1,39 @@
+package config
+
+import (
+	"net/url"
+	"strings"
+)
+
+// StorageURL возвращает адрес хранилища для quotes.Open: database_url, если он задан,
+// иначе адрес, собранный из db_mode, db_path и параметров memory_*.
+func (s Static) StorageURL() string {
+	if s.DatabaseURL != "" {
+		return s.DatabaseURL
+	}
+	if s.DBMode != "memory" && s.DBMode != "" {
+		return s.DBMode + "://" + s.DBPath
+	}
+	if s.MemoryDir == "" {
+		return "memory://"
+	}
+	q := url.Values{}
+	q.Set("fsync", s.MemoryFsync)
+	q.Set("fsync_interval", s.MemoryFsyncInterval.String())
+	q.Set("snapshot_interval", s.MemorySnapshotInterval.String())
+	return "memory://" + s.MemoryDir + "?" + q.Encode()
+}
+
+// applyDatabaseURL выводит db_mode и db_path из database_url, чтобы проверки конфигурации,
+// ограничитель запросов и quotesctl видели тот же режим и файл, что и хранилище.
+// Параметры адреса проверяет бэкенд при открытии.
+func (s *Static) applyDatabaseURL() {
+	scheme, rest, ok := strings.Cut(s.DatabaseURL, "://")
+	if !ok {
+		return
+	}
+	s.DBMode = strings.ToLower(scheme)
+	if s.DBMode != "memory" {
+		s.DBPath, _, _ = strings.Cut(rest, "?")
+	}
+}
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"Test_Project_Brand_Scout/internal/sqlitedriver"
+)
+
+// Допустимые значения параметров-перечислений.
+var (
+	dbModes        = []string{"memory", "sqlite", "bolt"}
+	authModes      = []string{"none", "apikey", "jwt", "cert"}
+	roles          = []string{"viewer", "editor", "admin"}
+	rateLimitKeys  = []string{"key", "ip", "tenant"}
+	rateStores     = []string{"memory", "sqlite"}
+	fsyncPolicies  = []string{"always", "interval", "never"}
+	logLevels      = []string{"debug", "info", "warn", "error"}
+	logFormats     = []string{"text", "json"}
+	traceExporters = []string{"none", "otlp", "stdout", "file"}
+	tlsVersions    = []string{"1.2", "1.3"}
+	cipherPolicies = []string{"modern", "intermediate", "default"}
+	clientAuths    = []string{"require", "optional"}
+	featureFlags   = []string{"read_only"}
+)
+
+// Validate проверяет согласованность конфигурации и возвращает все найденные ошибки сразу.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	oneOf := func(key, val string, allowed []string) {
+		check(slices.Contains(allowed, val), "%s: неизвестное значение %q (ожидается %s)", key, val, strings.Join(allowed, ", "))
+	}
+
+	port, err := strconv.Atoi(c.Port)
+	check(err == nil && port > 0 && port <= 65535, "port: ожидается номер порта от 1 до 65535, получено %q", c.Port)
+	if c.DatabaseURL != "" {
+		scheme, _, ok := strings.Cut(c.DatabaseURL, "://")
+		check(ok && slices.Contains(dbModes, strings.ToLower(scheme)), "database_url: ожидается адрес вида %s://..., получено %q",
+			strings.Join(dbModes, "://, "), c.DatabaseURL)
+	} else {
+		oneOf("db_mode", c.DBMode, dbModes)
+	}
+	check(c.DBMode != "sqlite" || c.DBPath != "", "db_path: при db_mode=sqlite нужно указать путь к базе")
+	check(c.DBMode != "bolt" || c.DBPath != "", "db_path: при db_mode=bolt нужно указать путь к файлу bbolt")
+	if _, err := sqlitedriver.Lookup(c.SQLiteDriver); err != nil {
+		check(false, "sqlite_driver: %v", err)
+	}
+	oneOf("memory_fsync", c.MemoryFsync, fsyncPolicies)
+	check(c.MemoryFsync != "interval" || c.MemoryFsyncInterval > 0, "memory_fsync_interval: при memory_fsync=interval ожидается положительная длительность")
+	check(c.MemorySnapshotInterval >= 0, "memory_snapshot_interval: ожидается неотрицательная длительность")
+	check(c.DailyWindow >= 0, "daily_no_repeat_days: ожидается неотрицательное число, получено %d", c.DailyWindow)
+	check(c.PopularHalfLife > 0, "popular_half_life: ожидается положительная длительность")
+	check(c.BackupDir == "" || c.DBMode == "sqlite", "backup_dir: резервные копии поддерживаются только при db_mode=sqlite")
+	check(c.BackupInterval >= 0, "backup_interval: ожидается неотрицательная длительность")
+	check(c.BackupKeepDaily >= 0 && c.BackupKeepWeekly >= 0, "backup_keep_daily, backup_keep_weekly: ожидаются неотрицательные числа")
+
+	for _, mode := range parseList(c.AuthMode) {
+		oneOf("auth_mode", mode, authModes)
+		switch mode {
+		case "jwt":
+			check(c.JWTJWKS != "" && c.JWTIssuer != "" && c.JWTAudience != "",
+				"auth_mode=jwt: нужно задать jwt_jwks, jwt_issuer и jwt_audience")
+		case "cert":
+			check(c.TLSClientCAFile != "", "auth_mode=cert: нужно задать tls_client_ca_file")
+		}
+	}
+	for from, to := range c.JWTRoleMap {
+		check(slices.Contains(roles, to), "jwt_role_mapping: роль %q сопоставлена неизвестной роли %q", from, to)
+	}
+	check(c.TenantMaxQuotes >= 0, "tenant_max_quotes: ожидается неотрицательное число, получено %d", c.TenantMaxQuotes)
+
+	oneOf("rate_limit_key", c.RateLimitKey, rateLimitKeys)
+	oneOf("rate_limit_store", c.RateLimitStore, rateStores)
+	oneOf("log_level", c.LogLevel, logLevels)
+	oneOf("log_format", c.LogFormat, logFormats)
+	check(c.LogBodyMax > 0, "log_body_max: ожидается положительное число, получено %d", c.LogBodyMax)
+	for _, origin := range c.CORSOrigins {
+		u, err := url.Parse(origin)
+		check(origin == "*" || (err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && u.Path == ""),
+			"cors_allowed_origins: ожидается * или источник вида https://example.com, получено %q", origin)
+	}
+	for _, flag := range c.Features {
+		oneOf("features", flag, featureFlags)
+	}
+	if c.MetricsAddr != "" {
+		_, _, err := net.SplitHostPort(c.MetricsAddr)
+		check(err == nil, "metrics_addr: ожидается адрес вида host:port, получено %q", c.MetricsAddr)
+	}
+
+	oneOf("trace_exporter", c.TraceExporter, traceExporters)
+	check(c.TraceSampleRatio >= 0 && c.TraceSampleRatio <= 1, "trace_sample_ratio: ожидается число от 0 до 1, получено %v", c.TraceSampleRatio)
+	check(c.HealthTimeout > 0, "health_timeout: ожидается положительная длительность")
+	check(c.HTTPMaxHeaderBytes > 0, "http_max_header_bytes: ожидается положительное число, получено %d", c.HTTPMaxHeaderBytes)
+
+	check((c.TLSCertFile == "") == (c.TLSKeyFile == ""), "tls_cert_file и tls_key_file задаются вместе")
+	check(c.TLSClientCAFile == "" || c.TLSCertFile != "", "tls_client_ca_file: mTLS требует tls_cert_file и tls_key_file")
+	oneOf("tls_min_version", c.TLSMinVersion, tlsVersions)
+	oneOf("tls_cipher_policy", c.TLSCipherPolicy, cipherPolicies)
+	oneOf("tls_client_auth", c.TLSClientAuth, clientAuths)
+	for cn, role := range c.TLSClientRoleMap {
+		check(slices.Contains(roles, role), "tls_client_role_mapping: сертификату %q сопоставлена неизвестная роль %q", cn, role)
+	}
+	check(c.TLSClientDefaultRole == "" || slices.Contains(roles, c.TLSClientDefaultRole),
+		"tls_client_default_role: неизвестная роль %q", c.TLSClientDefaultRole)
+	check(c.TLSClientDefaultRole == "" || len(c.TLSClientTenantMap) > 0,
+		"tls_client_default_role: роль по умолчанию получают только сертификаты из tls_client_tenant_mapping")
+	return errors.Join(errs...)
+}
@@ -0,0 +1,79 @@
+// Package cors разрешает браузерам обращаться к API с других источников (Cross-Origin Resource Sharing).
+// Параметры можно заменить во время работы, не пересоздавая middleware.
+package cors
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// exposed — заголовки ответа, которые браузер показывает скрипту с другого источника.
+var exposed = strings.Join([]string{"X-Request-ID", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After"}, ", ")
+
+// Options задаёт правила CORS.
+type Options struct {
+	Origins []string      // разрешённые источники вида https://example.com, * — любые; пусто — CORS выключен
+	Methods []string      // методы, разрешённые в ответе на preflight-запрос
+	Headers []string      // заголовки запроса, разрешённые в ответе на preflight-запрос
+	MaxAge  time.Duration // сколько браузер может кешировать ответ на preflight-запрос
+}
+
+// allows сообщает, разрешён ли источник origin.
+func (o Options) allows(origin string) bool {
+	return slices.Contains(o.Origins, "*") || slices.Contains(o.Origins, origin)
+}
+
+// CORS — middleware, добавляющий заголовки Access-Control-* и отвечающий на preflight-запросы.
+type CORS struct {
+	opts atomic.Pointer[Options]
+}
+
+// New создаёт middleware с параметрами opts.
+func New(opts Options) *CORS {
+	c := &CORS{}
+	c.Set(opts)
+	return c
+}
+
+// Set заменяет параметры; новые правила действуют со следующего запроса.
+func (c *CORS) Set(opts Options) {
+	c.opts.Store(&opts)
+}
+
+// Middleware добавляет заголовки CORS к ответам на запросы с разрешённых источников и сам отвечает
+// 204 на preflight-запросы OPTIONS с заголовком Access-Control-Request-Method. Запросы без Origin
+// и с неразрешённых источников проходят без изменений: браузер сам не отдаст ответ скрипту.
+func (c *CORS) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		opts := c.opts.Load()
+		origin := r.Header.Get("Origin")
+		h := w.Header()
+		if len(opts.Origins) > 0 {
+			h.Add("Vary", "Origin")
+		}
+		if origin == "" || !opts.allows(origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		h.Set("Access-Control-Allow-Origin", origin)
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+			h.Set("Access-Control-Allow-Methods", strings.Join(opts.Methods, ", "))
+			if len(opts.Headers) > 0 {
+				h.Set("Access-Control-Allow-Headers", strings.Join(opts.Headers, ", "))
+			}
+			if opts.MaxAge > 0 {
+				h.Set("Access-Control-Max-Age", strconv.Itoa(int(opts.MaxAge.Seconds())))
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		h.Set("Access-Control-Expose-Headers", exposed)
+		next.ServeHTTP(w, r)
+	})
+}
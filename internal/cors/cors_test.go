@@ -0,0 +1,59 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// send выполняет запрос method с заголовками headers через middleware c
+func send(c *CORS, method string, headers map[string]string) *httptest.ResponseRecorder {
+	h := c.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	req := httptest.NewRequest(method, "/quotes", nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+// TestMiddleware проверяет заголовки для разрешённых и неразрешённых источников и preflight-запросы
+func TestMiddleware(t *testing.T) {
+	c := New(Options{
+		Origins: []string{"https://app.example.com"},
+		Methods: []string{"GET", "POST"},
+		Headers: []string{"Authorization"},
+		MaxAge:  10 * time.Minute,
+	})
+	w := send(c, http.MethodGet, map[string]string{"Origin": "https://app.example.com"})
+	if w.Code != http.StatusTeapot || w.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" ||
+		w.Header().Get("Access-Control-Expose-Headers") == "" {
+		t.Errorf("ожидаются заголовки CORS для разрешённого источника: %d %v", w.Code, w.Header())
+	}
+	if w := send(c, http.MethodGet, map[string]string{"Origin": "https://evil.example.com"}); w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("неразрешённый источник не должен получать заголовки: %v", w.Header())
+	}
+
+	w = send(c, http.MethodOptions, map[string]string{"Origin": "https://app.example.com", "Access-Control-Request-Method": "POST"})
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Methods") != "GET, POST" ||
+		w.Header().Get("Access-Control-Allow-Headers") != "Authorization" || w.Header().Get("Access-Control-Max-Age") != "600" {
+		t.Errorf("неожиданный ответ на preflight: %d %v", w.Code, w.Header())
+	}
+}
+
+// TestSet проверяет замену правил во время работы
+func TestSet(t *testing.T) {
+	c := New(Options{})
+	origin := map[string]string{"Origin": "https://app.example.com"}
+	if w := send(c, http.MethodGet, origin); w.Header().Get("Access-Control-Allow-Origin") != "" || w.Header().Get("Vary") != "" {
+		t.Errorf("без источников CORS выключен: %v", w.Header())
+	}
+	c.Set(Options{Origins: []string{"*"}})
+	if w := send(c, http.MethodGet, origin); w.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" {
+		t.Errorf("после Set ожидается разрешение любого источника: %v", w.Header())
+	}
+}
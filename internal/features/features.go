@@ -0,0 +1,52 @@
+// Package features хранит включённые флаги функций. Набор флагов можно заменить во время работы.
+package features
+
+import (
+	"net/http"
+	"slices"
+	"sync/atomic"
+)
+
+// ReadOnly переводит API в режим только для чтения: изменяющие запросы отклоняются с кодом 503.
+// Полезен на время обслуживания хранилища.
+const ReadOnly = "read_only"
+
+// Flags — набор включённых флагов.
+type Flags struct {
+	enabled atomic.Pointer[[]string]
+}
+
+// New создаёт набор с включёнными флагами names.
+func New(names []string) *Flags {
+	f := &Flags{}
+	f.Set(names)
+	return f
+}
+
+// Set заменяет набор включённых флагов.
+func (f *Flags) Set(names []string) {
+	names = slices.Clone(names)
+	f.enabled.Store(&names)
+}
+
+// Enabled сообщает, включён ли флаг name.
+func (f *Flags) Enabled(name string) bool {
+	return slices.Contains(*f.enabled.Load(), name)
+}
+
+// Middleware отклоняет запросы, изменяющие данные, пока включён флаг ReadOnly.
+// Запросы GET, HEAD и OPTIONS проходят всегда.
+func (f *Flags) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			if f.Enabled(ReadOnly) {
+				w.Header().Set("Retry-After", "60")
+				http.Error(w, "сервис временно работает только на чтение", http.StatusServiceUnavailable)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
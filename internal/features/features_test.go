@@ -0,0 +1,28 @@
+package features
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestReadOnly проверяет отклонение изменяющих запросов и снятие режима через Set
+func TestReadOnly(t *testing.T) {
+	f := New([]string{ReadOnly})
+	h := f.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	send := func(method string) int {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, "/quotes", nil))
+		return w.Code
+	}
+	if code := send(http.MethodGet); code != http.StatusOK {
+		t.Errorf("GET в режиме только для чтения: ожидается 200, получен %d", code)
+	}
+	if code := send(http.MethodPost); code != http.StatusServiceUnavailable {
+		t.Errorf("POST в режиме только для чтения: ожидается 503, получен %d", code)
+	}
+	f.Set(nil)
+	if f.Enabled(ReadOnly) || send(http.MethodDelete) != http.StatusOK {
+		t.Error("после снятия флага изменяющие запросы должны проходить")
+	}
+}
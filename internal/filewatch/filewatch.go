@@ -0,0 +1,88 @@
+// Package filewatch обнаруживает замену файлов по времени изменения и размеру. Так сервис
+// перечитывает конфигурацию и сертификаты без перезапуска и без системных уведомлений о файлах.
+package filewatch
+
+import (
+	"context"
+	"maps"
+	"os"
+	"sync"
+	"time"
+)
+
+// stamp — время изменения и размер файла, по которым обнаруживается его замена.
+type stamp struct {
+	mod  time.Time
+	size int64
+}
+
+// Watcher следит за набором файлов. Отсутствующий файл получает нулевую отметку,
+// поэтому появление и удаление файла тоже считаются изменением.
+type Watcher struct {
+	paths []string
+
+	mu   sync.Mutex
+	seen map[string]stamp
+}
+
+// New создаёт Watcher для файлов paths; пустые пути пропускаются. Текущее состояние файлов
+// считается прочитанным.
+func New(paths ...string) *Watcher {
+	w := &Watcher{}
+	for _, path := range paths {
+		if path != "" {
+			w.paths = append(w.paths, path)
+		}
+	}
+	w.seen = w.stamps()
+	return w
+}
+
+// stamps возвращает отметки отслеживаемых файлов.
+func (w *Watcher) stamps() map[string]stamp {
+	stamps := make(map[string]stamp, len(w.paths))
+	for _, path := range w.paths {
+		var st stamp
+		if fi, err := os.Stat(path); err == nil {
+			st = stamp{mod: fi.ModTime(), size: fi.Size()}
+		}
+		stamps[path] = st
+	}
+	return stamps
+}
+
+// Seen отмечает текущее состояние файлов как прочитанное. Вызывается перед чтением файлов:
+// изменение во время чтения будет замечено при следующей проверке.
+func (w *Watcher) Seen() {
+	cur := w.stamps()
+	w.mu.Lock()
+	w.seen = cur
+	w.mu.Unlock()
+}
+
+// Changed сообщает, изменился ли хотя бы один файл после последнего Seen.
+func (w *Watcher) Changed() bool {
+	cur := w.stamps()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return !maps.Equal(cur, w.seen)
+}
+
+// Watch раз в interval проверяет файлы и после их изменения вызывает reload. Состояние файлов
+// отмечается прочитанным до вызова, поэтому неудачная загрузка не повторяется на каждом тике,
+// а ждёт нового изменения. Завершается с отменой ctx.
+func (w *Watcher) Watch(ctx context.Context, interval time.Duration, reload func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if w.Changed() {
+			w.Seen()
+			reload()
+		}
+	}
+}
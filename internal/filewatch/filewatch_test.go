@@ -0,0 +1,62 @@
+package filewatch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestChanged проверяет обнаружение изменения, появления и удаления файла
+func TestChanged(t *testing.T) {
+	dir := t.TempDir()
+	path, missing := filepath.Join(dir, "a.yaml"), filepath.Join(dir, "b.yaml")
+	os.WriteFile(path, []byte("a"), 0o600)
+	w := New(path, "", missing)
+	if w.Changed() {
+		t.Fatal("без изменений файлы не должны считаться изменёнными")
+	}
+	os.WriteFile(path, []byte("ab"), 0o600)
+	if !w.Changed() {
+		t.Error("ожидается изменение после записи в файл")
+	}
+	w.Seen()
+	if w.Changed() {
+		t.Error("после Seen изменение не должно повторяться")
+	}
+	os.WriteFile(missing, []byte("b"), 0o600)
+	if !w.Changed() {
+		t.Error("ожидается изменение после появления файла")
+	}
+	w.Seen()
+	os.Remove(path)
+	if !w.Changed() {
+		t.Error("ожидается изменение после удаления файла")
+	}
+}
+
+// TestWatch проверяет, что reload вызывается один раз на изменение
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.yaml")
+	w := New(path)
+	reloads := make(chan struct{}, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w.Watch(ctx, time.Millisecond, func() { reloads <- struct{}{} })
+	}()
+	os.WriteFile(path, []byte("a"), 0o600)
+	select {
+	case <-reloads:
+	case <-time.After(5 * time.Second):
+		t.Fatal("изменение файла не вызвало перезагрузку")
+	}
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	<-done
+	if n := len(reloads); n != 0 {
+		t.Errorf("без новых изменений перезагрузка не должна повторяться, лишних вызовов: %d", n)
+	}
+}
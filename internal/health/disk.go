@@ -0,0 +1,22 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+)
+
+// DiskSpace проверяет, что на разделе с файлом path свободно не меньше min байт.
+func DiskSpace(path string, min uint64) Check {
+	dir := filepath.Dir(path)
+	return func(ctx context.Context) error {
+		free, err := freeSpace(dir)
+		if err != nil {
+			return err
+		}
+		if free < min {
+			return fmt.Errorf("свободно %d МБ, требуется не меньше %d МБ", free>>20, min>>20)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,142 @@
+// Package health отвечает на пробы оркестратора: /healthz сообщает, что процесс жив,
+// /readyz выполняет зарегистрированные проверки зависимостей и перестаёт отвечать успехом при остановке.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Статусы проверок и отчёта.
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// ErrShuttingDown возвращается проверкой готовности после начала остановки сервера.
+var ErrShuttingDown = errors.New("сервер останавливается")
+
+// Check проверяет одну зависимость и возвращает ошибку, если она недоступна.
+// Проверка должна завершаться при отмене ctx.
+type Check func(ctx context.Context) error
+
+// Result — результат одной проверки.
+type Result struct {
+	Name    string  `json:"name"`
+	Status  string  `json:"status"`
+	Error   string  `json:"error,omitempty"`
+	Latency float64 `json:"latency_ms"`
+}
+
+// Report — сводка проверок готовности.
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// Registry хранит проверки готовности и признак остановки сервера.
+type Registry struct {
+	mu       sync.RWMutex
+	names    []string
+	checks   map[string]Check
+	timeout  time.Duration
+	stopping atomic.Bool
+}
+
+// NewRegistry создаёт реестр, ограничивающий каждую проверку временем timeout.
+func NewRegistry(timeout time.Duration) *Registry {
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+	return &Registry{checks: make(map[string]Check), timeout: timeout}
+}
+
+// Register добавляет проверку name; повторная регистрация заменяет прежнюю проверку.
+func (r *Registry) Register(name string, check Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.checks[name]; !ok {
+		r.names = append(r.names, name)
+	}
+	r.checks[name] = check
+}
+
+// Shutdown помечает сервер останавливающимся: с этого момента /readyz отвечает 503,
+// и балансировщик перестаёт направлять новые запросы, пока обрабатываются текущие.
+func (r *Registry) Shutdown() {
+	r.stopping.Store(true)
+}
+
+// Run выполняет все проверки параллельно и возвращает отчёт в порядке регистрации.
+// После Shutdown проверки не выполняются, а отчёт содержит одну неуспешную проверку shutdown.
+func (r *Registry) Run(ctx context.Context) Report {
+	if r.stopping.Load() {
+		return Report{Status: StatusFail, Checks: []Result{{Name: "shutdown", Status: StatusFail, Error: ErrShuttingDown.Error()}}}
+	}
+	r.mu.RLock()
+	names := append([]string(nil), r.names...)
+	checks := make([]Check, len(names))
+	for i, name := range names {
+		checks[i] = r.checks[name]
+	}
+	r.mu.RUnlock()
+
+	rep := Report{Status: StatusOK, Checks: make([]Result, len(names))}
+	var wg sync.WaitGroup
+	for i := range names {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cctx, cancel := context.WithTimeout(ctx, r.timeout)
+			defer cancel()
+			start := time.Now()
+			err := checks[i](cctx)
+			res := Result{Name: names[i], Status: StatusOK, Latency: float64(time.Since(start).Microseconds()) / 1000}
+			if err != nil {
+				res.Status = StatusFail
+				res.Error = err.Error()
+			}
+			rep.Checks[i] = res
+		}(i)
+	}
+	wg.Wait()
+	for _, res := range rep.Checks {
+		if res.Status != StatusOK {
+			rep.Status = StatusFail
+		}
+	}
+	return rep
+}
+
+// ReadyHandler отвечает на /readyz отчётом проверок: 200, если все проверки прошли, иначе 503.
+func (r *Registry) ReadyHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		rep := r.Run(req.Context())
+		code := http.StatusOK
+		if rep.Status != StatusOK {
+			code = http.StatusServiceUnavailable
+		}
+		writeJSON(w, code, rep)
+	}
+}
+
+// LiveHandler отвечает на /healthz: процесс запущен и обрабатывает запросы.
+// Зависимости не проверяются, чтобы сбой базы не приводил к перезапуску процесса.
+func LiveHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, Report{Status: StatusOK, Checks: []Result{}})
+	}
+}
+
+// writeJSON отправляет v в формате JSON с кодом code.
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
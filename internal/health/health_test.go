@@ -0,0 +1,90 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// ready выполняет запрос к /readyz и разбирает отчёт
+func ready(t *testing.T, r *Registry) (int, Report) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r.ReadyHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var rep Report
+	if err := json.NewDecoder(w.Body).Decode(&rep); err != nil {
+		t.Fatalf("ошибка разбора JSON ответа: %v", err)
+	}
+	return w.Code, rep
+}
+
+// TestReadyHandler проверяет порядок проверок, статус ответа и текст ошибки
+func TestReadyHandler(t *testing.T) {
+	r := NewRegistry(time.Second)
+	r.Register("db", func(context.Context) error { return nil })
+	if code, rep := ready(t, r); code != http.StatusOK || rep.Status != StatusOK || len(rep.Checks) != 1 {
+		t.Fatalf("ожидается 200 и одна успешная проверка, получено %d %+v", code, rep)
+	}
+
+	r.Register("cache", func(context.Context) error { return errors.New("недоступен") })
+	code, rep := ready(t, r)
+	if code != http.StatusServiceUnavailable || rep.Status != StatusFail {
+		t.Fatalf("ожидается 503, получено %d %+v", code, rep)
+	}
+	if rep.Checks[0].Name != "db" || rep.Checks[1].Name != "cache" {
+		t.Errorf("проверки должны идти в порядке регистрации: %+v", rep.Checks)
+	}
+	if rep.Checks[1].Status != StatusFail || rep.Checks[1].Error != "недоступен" {
+		t.Errorf("ожидается текст ошибки проверки, получено %+v", rep.Checks[1])
+	}
+}
+
+// TestReadyTimeout проверяет, что зависшая проверка прерывается по таймауту
+func TestReadyTimeout(t *testing.T) {
+	r := NewRegistry(20 * time.Millisecond)
+	r.Register("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	start := time.Now()
+	code, rep := ready(t, r)
+	if code != http.StatusServiceUnavailable || rep.Checks[0].Latency < 20 {
+		t.Errorf("ожидается 503 с длительностью не меньше таймаута, получено %d %+v", code, rep)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("проверка должна прерываться по таймауту")
+	}
+}
+
+// TestShutdown проверяет, что после начала остановки /readyz отвечает 503, а /healthz — 200
+func TestShutdown(t *testing.T) {
+	r := NewRegistry(0)
+	r.Register("db", func(context.Context) error { return nil })
+	r.Shutdown()
+	if code, rep := ready(t, r); code != http.StatusServiceUnavailable || rep.Checks[0].Error != ErrShuttingDown.Error() {
+		t.Errorf("ожидается 503 при остановке, получено %d %+v", code, rep)
+	}
+	w := httptest.NewRecorder()
+	LiveHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("ожидается 200 от /healthz, получено %d", w.Code)
+	}
+}
+
+// TestDiskSpace проверяет проверку свободного места
+func TestDiskSpace(t *testing.T) {
+	path := t.TempDir() + "/quotes.db"
+	if err := DiskSpace(path, 0)(context.Background()); err != nil {
+		t.Errorf("без минимума проверка должна проходить: %v", err)
+	}
+	if free, _ := freeSpace(t.TempDir()); free == ^uint64(0) {
+		t.Skip("свободное место не определяется на этой платформе")
+	}
+	if err := DiskSpace(path, ^uint64(0))(context.Background()); err == nil {
+		t.Error("ожидается ошибка при недостатке места")
+	}
+}
@@ -0,0 +1,229 @@
+// Package httplog пишет структурированный лог HTTP-запросов через log/slog
+// и присваивает каждому запросу идентификатор X-Request-ID.
+package httplog
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/trace"
+
+	"Test_Project_Brand_Scout/internal/instrument"
+)
+
+// HeaderRequestID — заголовок, в котором передаётся и возвращается идентификатор запроса.
+const HeaderRequestID = "X-Request-ID"
+
+// DefaultMaxBody — сколько байт тела попадает в лог, если Options.MaxBody не задан.
+const DefaultMaxBody = 2048
+
+// DefaultRedact — поля JSON, значения которых никогда не попадают в лог.
+var DefaultRedact = []string{"key", "token", "access_token", "refresh_token", "password", "secret", "authorization"}
+
+// redacted заменяет значения скрытых полей.
+const redacted = `"[REDACTED]"`
+
+// requestIDPattern ограничивает принимаемые от клиента идентификаторы, чтобы они безопасно попадали в логи.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// ParseLevel разбирает уровень логирования: debug, info, warn или error.
+func ParseLevel(level string) (slog.Level, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return 0, fmt.Errorf("неизвестный уровень логирования %q (ожидается debug, info, warn или error)", level)
+	}
+	return lvl, nil
+}
+
+// NewLogger создаёт логгер, пишущий в w в формате format (json или text) начиная с уровня level
+// (debug, info, warn или error).
+func NewLogger(w io.Writer, format, level string) (*slog.Logger, error) {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	return NewLeveledLogger(w, format, lvl)
+}
+
+// NewLeveledLogger — NewLogger с уровнем slog.Leveler; *slog.LevelVar позволяет менять уровень
+// без пересоздания логгера.
+func NewLeveledLogger(w io.Writer, format string, level slog.Leveler) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: level}
+	switch format {
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("неизвестный формат логов %q (ожидается json или text)", format)
+	}
+}
+
+// Options настраивает логирование запросов.
+type Options struct {
+	Bodies  bool     // логировать тела запросов и ответов
+	MaxBody int      // сколько байт каждого тела попадает в лог
+	Redact  []string // поля JSON, скрываемые в дополнение к DefaultRedact
+}
+
+// Logger — middleware логирования запросов.
+type Logger struct {
+	log *slog.Logger
+	cur atomic.Pointer[settings]
+}
+
+// settings — действующие параметры Logger, подменяются целиком в SetOptions.
+type settings struct {
+	opts   Options
+	redact *regexp.Regexp
+}
+
+// New создаёт middleware, пишущий в log; nil означает slog.Default().
+func New(log *slog.Logger, opts Options) *Logger {
+	if log == nil {
+		log = slog.Default()
+	}
+	l := &Logger{log: log}
+	l.SetOptions(opts)
+	return l
+}
+
+// SetOptions заменяет параметры логирования; запросы, которые уже обрабатываются, дописываются по прежним.
+func (l *Logger) SetOptions(opts Options) {
+	if opts.MaxBody <= 0 {
+		opts.MaxBody = DefaultMaxBody
+	}
+	names := make([]string, 0, len(DefaultRedact)+len(opts.Redact))
+	for _, f := range append(append([]string{}, DefaultRedact...), opts.Redact...) {
+		if f = strings.TrimSpace(f); f != "" {
+			names = append(names, regexp.QuoteMeta(f))
+		}
+	}
+	// Значение — строка (возможно, обрезанная лимитом) или скаляр до разделителя
+	re := regexp.MustCompile(`(?i)"(` + strings.Join(names, "|") + `)"\s*:\s*("(?:[^"\\]|\\.)*"?|[^,}\]\s]+)`)
+	l.cur.Store(&settings{opts: opts, redact: re})
+}
+
+// requestIDKey — ключ контекста, под которым хранится идентификатор запроса.
+type requestIDKey struct{}
+
+// RequestID возвращает идентификатор запроса из контекста или пустую строку.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// newRequestID генерирует случайный идентификатор запроса.
+func newRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// Middleware присваивает запросу идентификатор и после ответа пишет в лог метод, шаблон маршрута,
+// статус, длительность и размеры тел. Тела запроса и ответа логируются только при Options.Bodies,
+// не длиннее Options.MaxBody и со скрытыми значениями полей из списка Redact.
+func (l *Logger) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		cfg := l.cur.Load()
+		id := r.Header.Get(HeaderRequestID)
+		if !requestIDPattern.MatchString(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(HeaderRequestID, id)
+		r = r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id))
+
+		in := &countingBody{ReadCloser: r.Body}
+		var reqBody []byte
+		if r.Body != nil && r.Body != http.NoBody {
+			if cfg.opts.Bodies {
+				// Читается только начало тела, остальное хендлер дочитает сам
+				reqBody, _ = io.ReadAll(io.LimitReader(r.Body, int64(cfg.opts.MaxBody)+1))
+				in.ReadCloser = struct {
+					io.Reader
+					io.Closer
+				}{io.MultiReader(bytes.NewReader(reqBody), r.Body), r.Body}
+			}
+			r.Body = in
+		}
+		sw := instrument.NewResponseWriter(w)
+		if cfg.opts.Bodies {
+			sw.Capture = cfg.opts.MaxBody + 1
+		}
+		next.ServeHTTP(sw, r)
+
+		status := sw.Status()
+		attrs := []slog.Attr{
+			slog.String("request_id", id),
+			slog.String("method", r.Method),
+			slog.String("route", routeTemplate(r)),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.Int64("bytes_in", in.n),
+			slog.Int64("bytes_out", sw.Written()),
+			slog.String("remote", r.RemoteAddr),
+		}
+		if sc := trace.SpanContextFromContext(r.Context()); sc.IsValid() {
+			attrs = append(attrs, slog.String("trace_id", sc.TraceID().String()))
+		}
+		if cfg.opts.Bodies {
+			attrs = append(attrs,
+				slog.String("request_body", cfg.body(reqBody)),
+				slog.String("response_body", cfg.body(sw.Body.Bytes())),
+			)
+		}
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		l.log.LogAttrs(r.Context(), level, "HTTP-запрос", attrs...)
+	})
+}
+
+// body готовит тело к записи в лог: обрезает до MaxBody и скрывает значения полей Redact.
+func (cfg *settings) body(b []byte) string {
+	truncated := len(b) > cfg.opts.MaxBody
+	if truncated {
+		b = b[:cfg.opts.MaxBody]
+	}
+	s := cfg.redact.ReplaceAllString(string(b), `"$1":`+redacted)
+	if truncated {
+		s += "…"
+	}
+	return s
+}
+
+// routeTemplate возвращает шаблон маршрута mux или пустую строку, если маршрут не найден.
+func routeTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if tpl, err := route.GetPathTemplate(); err == nil {
+			return tpl
+		}
+	}
+	return ""
+}
+
+// countingBody считает прочитанные хендлером байты тела запроса.
+type countingBody struct {
+	io.ReadCloser
+	n int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,143 @@
+package httplog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// newTestRouter создаёт маршрутизатор с логированием в буфер в формате JSON
+func newTestRouter(t *testing.T, opts Options, h http.HandlerFunc) (*mux.Router, *bytes.Buffer) {
+	t.Helper()
+	var buf bytes.Buffer
+	log, err := NewLogger(&buf, "json", "info")
+	if err != nil {
+		t.Fatalf("ошибка создания логгера: %v", err)
+	}
+	l := New(log, opts)
+	r := mux.NewRouter()
+	r.Use(l.Middleware)
+	r.HandleFunc("/quotes/{id}", h)
+	return r, &buf
+}
+
+// entry разбирает единственную запись лога
+func entry(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+	var e map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+		t.Fatalf("ожидается одна JSON-запись, получено %q: %v", buf.String(), err)
+	}
+	return e
+}
+
+// TestMiddlewareFields проверяет поля записи и то, что тела по умолчанию не логируются
+func TestMiddlewareFields(t *testing.T) {
+	r, buf := newTestRouter(t, Options{}, func(w http.ResponseWriter, r *http.Request) {
+		io.ReadAll(r.Body)
+		if RequestID(r.Context()) == "" {
+			t.Error("идентификатор запроса должен быть в контексте")
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":1}`))
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/quotes/7", strings.NewReader(`{"author":"A"}`)))
+
+	e := entry(t, buf)
+	if e["route"] != "/quotes/{id}" || e["path"] != "/quotes/7" || e["method"] != "POST" {
+		t.Errorf("неожиданные маршрут и метод: %v", e)
+	}
+	if e["status"] != float64(201) || e["bytes_in"] != float64(14) || e["bytes_out"] != float64(8) {
+		t.Errorf("неожиданные статус и размеры: %v", e)
+	}
+	if _, ok := e["latency"]; !ok {
+		t.Error("в записи нет длительности")
+	}
+	if _, ok := e["request_body"]; ok {
+		t.Error("тела не должны логироваться без Options.Bodies")
+	}
+	if id := w.Header().Get(HeaderRequestID); id == "" || e["request_id"] != id {
+		t.Errorf("идентификатор в ответе %q не совпадает с логом %v", id, e["request_id"])
+	}
+}
+
+// TestMiddlewareRequestID проверяет передачу идентификатора клиента и замену некорректного
+func TestMiddlewareRequestID(t *testing.T) {
+	r, _ := newTestRouter(t, Options{}, func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/quotes/1", nil)
+	req.Header.Set(HeaderRequestID, "upstream-42")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if got := w.Header().Get(HeaderRequestID); got != "upstream-42" {
+		t.Errorf("ожидается идентификатор клиента, получен %q", got)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/quotes/1", nil)
+	req.Header.Set(HeaderRequestID, "bad id\nwith newline")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if got := w.Header().Get(HeaderRequestID); len(got) != 32 {
+		t.Errorf("некорректный идентификатор должен заменяться сгенерированным, получен %q", got)
+	}
+}
+
+// TestMiddlewareBodies проверяет ограничение размера и скрытие полей в логируемых телах
+func TestMiddlewareBodies(t *testing.T) {
+	long := strings.Repeat("x", 100)
+	r, buf := newTestRouter(t, Options{Bodies: true, MaxBody: 64, Redact: []string{"email"}},
+		func(w http.ResponseWriter, r *http.Request) {
+			data, _ := io.ReadAll(r.Body)
+			if !strings.HasSuffix(string(data), long+`"}`) {
+				t.Errorf("хендлер должен получить тело целиком, получено %q", data)
+			}
+			w.Write([]byte(`{"key":"qk_secret","nested":{"Password":"p"},"text":"` + long + `"}`))
+		})
+	body := `{"email":"a@b.c","token":"t0","n":5,"text":"` + long + `"}`
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/quotes/1", strings.NewReader(body)))
+
+	e := entry(t, buf)
+	req, _ := e["request_body"].(string)
+	resp, _ := e["response_body"].(string)
+	for _, secret := range []string{"a@b.c", "t0", "qk_secret", `"p"`} {
+		if strings.Contains(req, secret) || strings.Contains(resp, secret) {
+			t.Errorf("значение %q не скрыто: %q / %q", secret, req, resp)
+		}
+	}
+	if !strings.Contains(req, `"email":"[REDACTED]"`) || !strings.Contains(resp, `"Password":"[REDACTED]"`) {
+		t.Errorf("ожидаются скрытые поля: %q / %q", req, resp)
+	}
+	if !strings.HasSuffix(req, "…") || strings.Contains(req, long) {
+		t.Errorf("тело запроса должно быть обрезано до 64 байт: %q", req)
+	}
+	if e["bytes_in"] != float64(len(body)) {
+		t.Errorf("ожидается bytes_in=%d, получено %v", len(body), e["bytes_in"])
+	}
+}
+
+// TestNewLogger проверяет разбор формата и уровня
+func TestNewLogger(t *testing.T) {
+	var buf bytes.Buffer
+	log, err := NewLogger(&buf, "text", "warn")
+	if err != nil {
+		t.Fatalf("ошибка создания логгера: %v", err)
+	}
+	log.Info("скрыто")
+	log.Warn("видно")
+	if strings.Contains(buf.String(), "скрыто") || !strings.Contains(buf.String(), "level=WARN") {
+		t.Errorf("неожиданный вывод: %q", buf.String())
+	}
+	if _, err := NewLogger(&buf, "xml", "info"); err == nil {
+		t.Error("ожидается ошибка для неизвестного формата")
+	}
+	if _, err := NewLogger(&buf, "json", "verbose"); err == nil {
+		t.Error("ожидается ошибка для неизвестного уровня")
+	}
+}
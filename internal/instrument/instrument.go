@@ -0,0 +1,73 @@
+// Package instrument содержит общие части middleware логирования, метрик и трассировки:
+// запись статуса ответа и разделение ошибок хранилища на обычные исходы и сбои.
+package instrument
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+
+	"Test_Project_Brand_Scout/internal/auth"
+	"Test_Project_Brand_Scout/internal/quotes"
+)
+
+// ResponseWriter запоминает статус и размер ответа и сохраняет не больше Capture байт тела.
+type ResponseWriter struct {
+	http.ResponseWriter
+	// Capture — сколько байт тела сохранить в Body; 0 — не сохранять.
+	Capture int
+	Body    bytes.Buffer
+	status  int
+	n       int64
+}
+
+// NewResponseWriter оборачивает w.
+func NewResponseWriter(w http.ResponseWriter) *ResponseWriter {
+	return &ResponseWriter{ResponseWriter: w}
+}
+
+// WriteHeader сохраняет код статуса и передаёт его оригинальному ResponseWriter
+func (w *ResponseWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write считает байты ответа и сохраняет начало тела, если задан Capture
+func (w *ResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if rest := w.Capture - w.Body.Len(); rest > 0 {
+		w.Body.Write(b[:min(rest, len(b))])
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.n += int64(n)
+	return n, err
+}
+
+// Unwrap даёт http.ResponseController доступ к исходному ResponseWriter.
+func (w *ResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Status возвращает код статуса ответа; если обработчик ничего не записал, ответ — 200.
+func (w *ResponseWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Written возвращает число байт тела ответа.
+func (w *ResponseWriter) Written() int64 {
+	return w.n
+}
+
+// Expected сообщает, что ошибка хранилища — обычный исход операции, а не сбой: такие ошибки
+// не считаются в метриках ошибок и не помечают спан ошибкой.
+func Expected(err error) bool {
+	return errors.Is(err, quotes.ErrNotFound) || errors.Is(err, quotes.ErrEmpty) ||
+		errors.Is(err, quotes.ErrQuotaExceeded) || errors.Is(err, auth.ErrKeyNotFound)
+}
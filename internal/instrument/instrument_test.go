@@ -0,0 +1,42 @@
+package instrument
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Test_Project_Brand_Scout/internal/auth"
+	"Test_Project_Brand_Scout/internal/quotes"
+)
+
+// TestResponseWriter проверяет статус по умолчанию, подсчёт байт и сохранение начала тела
+func TestResponseWriter(t *testing.T) {
+	w := NewResponseWriter(httptest.NewRecorder())
+	if w.Status() != http.StatusOK {
+		t.Errorf("без записи ожидается статус 200, получен %d", w.Status())
+	}
+	w.Capture = 4
+	w.WriteHeader(http.StatusTeapot)
+	w.WriteHeader(http.StatusInternalServerError)
+	w.Write([]byte("привет"))
+	if w.Status() != http.StatusTeapot || w.Written() != int64(len("привет")) || w.Body.String() != "пр" {
+		t.Errorf("неожиданное состояние: статус %d, байт %d, тело %q", w.Status(), w.Written(), w.Body.String())
+	}
+	if rc := http.NewResponseController(w); rc.Flush() != nil {
+		t.Error("ResponseController должен добраться до исходного ResponseWriter")
+	}
+}
+
+// TestExpected проверяет, какие ошибки хранилища не считаются сбоями
+func TestExpected(t *testing.T) {
+	for _, err := range []error{quotes.ErrNotFound, quotes.ErrEmpty, fmt.Errorf("создание: %w", quotes.ErrQuotaExceeded), auth.ErrKeyNotFound} {
+		if !Expected(err) {
+			t.Errorf("%v: ожидается обычный исход", err)
+		}
+	}
+	if Expected(errors.New("database is locked")) {
+		t.Error("сбой хранилища не должен считаться обычным исходом")
+	}
+}
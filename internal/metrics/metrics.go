@@ -0,0 +1,129 @@
+// Package metrics собирает метрики сервиса в формате Prometheus: HTTP-запросы, операции
+// репозитория, число цитат, пул соединений SQLite и показатели среды выполнения Go.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"Test_Project_Brand_Scout/internal/instrument"
+	"Test_Project_Brand_Scout/internal/quotes"
+)
+
+// namespace — общий префикс имён метрик сервиса.
+const namespace = "quotes"
+
+// unmatchedRoute — метка маршрута для запросов, не попавших ни в один маршрут.
+// Путь запроса в метку не попадает, чтобы сканеры не раздували число рядов.
+const unmatchedRoute = "unmatched"
+
+// Metrics хранит реестр метрик сервиса.
+type Metrics struct {
+	reg         *prometheus.Registry
+	requests    *prometheus.CounterVec
+	latency     *prometheus.HistogramVec
+	repoLatency *prometheus.HistogramVec
+	repoErrors  *prometheus.CounterVec
+}
+
+// New создаёт реестр с метриками HTTP, репозитория и среды выполнения Go.
+func New() *Metrics {
+	m := &Metrics{
+		reg: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Число HTTP-запросов по методу, шаблону маршрута и статусу.",
+		}, []string{"method", "route", "status"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Длительность обработки HTTP-запросов.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		repoLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "repository_operation_duration_seconds",
+			Help:      "Длительность операций репозитория по бэкенду и операции.",
+			Buckets:   []float64{.0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"backend", "operation"}),
+		repoErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "repository_errors_total",
+			Help:      "Число ошибок операций репозитория; ErrNotFound, ErrEmpty и ErrQuotaExceeded не считаются.",
+		}, []string{"backend", "operation"}),
+	}
+	m.reg.MustRegister(
+		m.requests, m.latency, m.repoLatency, m.repoErrors,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Handler возвращает обработчик /metrics в текстовом формате Prometheus.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.reg, promhttp.HandlerOpts{Registry: m.reg})
+}
+
+// RegisterQuoteCount добавляет метрику числа цитат по рабочим пространствам.
+// Значения читаются из репозитория при каждом сборе метрик.
+func (m *Metrics) RegisterQuoteCount(repo quotes.Repository) error {
+	return m.reg.Register(&quoteCollector{repo: repo, desc: prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "stored"),
+		"Число сохранённых цитат в рабочем пространстве.",
+		[]string{"tenant"}, nil,
+	)})
+}
+
+// RegisterDB добавляет статистику пула соединений базы name.
+func (m *Metrics) RegisterDB(db *sql.DB, name string) error {
+	return m.reg.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// Middleware считает запросы и их длительность по шаблону маршрута mux.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := instrument.NewResponseWriter(w)
+		next.ServeHTTP(sw, r)
+
+		route := unmatchedRoute
+		if cur := mux.CurrentRoute(r); cur != nil {
+			if tpl, err := cur.GetPathTemplate(); err == nil {
+				route = tpl
+			}
+		}
+		labels := prometheus.Labels{"method": r.Method, "route": route, "status": strconv.Itoa(sw.Status())}
+		m.requests.With(labels).Inc()
+		m.latency.With(labels).Observe(time.Since(start).Seconds())
+	})
+}
+
+// quoteCollector отдаёт число цитат по пространствам на момент сбора метрик.
+type quoteCollector struct {
+	repo quotes.Repository
+	desc *prometheus.Desc
+}
+
+func (c *quoteCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *quoteCollector) Collect(ch chan<- prometheus.Metric) {
+	counts, err := c.repo.QuoteCounts()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+	for tenant, n := range counts {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(n), tenant)
+	}
+}
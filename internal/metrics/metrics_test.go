@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"Test_Project_Brand_Scout/internal/quotes"
+	"Test_Project_Brand_Scout/internal/sqlitedriver"
+)
+
+// scrape возвращает текст /metrics
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("ожидается 200 от /metrics, получен %d", w.Code)
+	}
+	data, _ := io.ReadAll(w.Body)
+	return string(data)
+}
+
+// expectLines проверяет, что в выводе есть все строки
+func expectLines(t *testing.T, out string, lines ...string) {
+	t.Helper()
+	for _, line := range lines {
+		if !strings.Contains(out, line) {
+			t.Errorf("в метриках нет строки %q", line)
+		}
+	}
+}
+
+// TestMiddleware проверяет счётчики и гистограммы запросов по шаблону маршрута
+func TestMiddleware(t *testing.T) {
+	m := New()
+	r := mux.NewRouter()
+	r.Use(m.Middleware)
+	r.HandleFunc("/quotes/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if mux.Vars(r)["id"] == "0" {
+			http.Error(w, "нет", http.StatusNotFound)
+		}
+	})
+	r.NotFoundHandler = m.Middleware(http.NotFoundHandler())
+	for _, path := range []string{"/quotes/1", "/quotes/2", "/quotes/0", "/secret-scan"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	out := scrape(t, m)
+	expectLines(t, out,
+		`quotes_http_requests_total{method="GET",route="/quotes/{id}",status="200"} 2`,
+		`quotes_http_requests_total{method="GET",route="/quotes/{id}",status="404"} 1`,
+		`quotes_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`quotes_http_request_duration_seconds_count{method="GET",route="/quotes/{id}",status="200"} 2`,
+		"go_goroutines",
+	)
+	if strings.Contains(out, "secret-scan") {
+		t.Error("путь несуществующего маршрута не должен попадать в метки")
+	}
+}
+
+// failingRepo возвращает ошибку хранилища из GetAll
+type failingRepo struct {
+	quotes.Repository
+}
+
+func (failingRepo) GetAll() ([]quotes.Quote, error) {
+	return nil, errors.New("диск недоступен")
+}
+
+// TestRepository проверяет длительности и ошибки операций и число цитат по пространствам
+func TestRepository(t *testing.T) {
+	m := New()
+	raw := quotes.NewMemoryRepository()
+	repo := m.Repository(raw, "memory")
+	if err := m.RegisterQuoteCount(raw); err != nil {
+		t.Fatalf("ошибка регистрации: %v", err)
+	}
+	repo.Create(quotes.Quote{Author: "A", Text: "a"})
+	repo.Tenant("team-b").Create(quotes.Quote{Author: "B", Text: "b"})
+	if _, err := repo.GetByID(42); !errors.Is(err, quotes.ErrNotFound) {
+		t.Fatalf("обёртка должна возвращать ошибку репозитория, получено %v", err)
+	}
+	m.Repository(failingRepo{raw}, "memory").GetAll()
+
+	expectLines(t, scrape(t, m),
+		`quotes_repository_operation_duration_seconds_count{backend="memory",operation="create"} 2`,
+		`quotes_repository_operation_duration_seconds_count{backend="memory",operation="get_by_id"} 1`,
+		`quotes_repository_errors_total{backend="memory",operation="get_all"} 1`,
+		`quotes_stored{tenant="default"} 1`,
+		`quotes_stored{tenant="team-b"} 1`,
+	)
+	if out := scrape(t, m); strings.Contains(out, `repository_errors_total{backend="memory",operation="get_by_id"}`) {
+		t.Error("ErrNotFound не должна считаться ошибкой хранилища")
+	}
+}
+
+// TestRegisterDB проверяет статистику пула соединений
+func TestRegisterDB(t *testing.T) {
+	db, err := sqlitedriver.Default().Open(":memory:")
+	if err != nil {
+		t.Fatalf("ошибка открытия базы: %v", err)
+	}
+	defer db.Close()
+	m := New()
+	if err := m.RegisterDB(db, "quotes"); err != nil {
+		t.Fatalf("ошибка регистрации: %v", err)
+	}
+	expectLines(t, scrape(t, m), `go_sql_max_open_connections{db_name="quotes"}`)
+}
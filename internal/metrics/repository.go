@@ -0,0 +1,165 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"Test_Project_Brand_Scout/internal/auth"
+	"Test_Project_Brand_Scout/internal/instrument"
+	"Test_Project_Brand_Scout/internal/quotes"
+)
+
+// Repository оборачивает quotes.Repository и измеряет длительность и ошибки каждой операции.
+type Repository struct {
+	next    quotes.Repository
+	backend string
+	m       *Metrics
+}
+
+// Repository возвращает репозиторий, измеряющий операции repo с меткой бэкенда backend.
+func (m *Metrics) Repository(repo quotes.Repository, backend string) quotes.Repository {
+	return &Repository{next: repo, backend: backend, m: m}
+}
+
+// observe выполняет операцию op и записывает её длительность и ошибку.
+func observe[T any](r *Repository, op string, fn func() (T, error)) (T, error) {
+	start := time.Now()
+	res, err := fn()
+	r.m.repoLatency.WithLabelValues(r.backend, op).Observe(time.Since(start).Seconds())
+	if err != nil && !instrument.Expected(err) {
+		r.m.repoErrors.WithLabelValues(r.backend, op).Inc()
+	}
+	return res, err
+}
+
+// observeErr — observe для операций, возвращающих только ошибку.
+func observeErr(r *Repository, op string, fn func() error) error {
+	_, err := observe(r, op, func() (struct{}, error) { return struct{}{}, fn() })
+	return err
+}
+
+// Unwrap возвращает исходный репозиторий.
+func (r *Repository) Unwrap() quotes.Repository {
+	return r.next
+}
+
+// WithContext привязывает исходный репозиторий к контексту запроса.
+func (r *Repository) WithContext(ctx context.Context) quotes.Repository {
+	return &Repository{next: quotes.BindContext(r.next, ctx), backend: r.backend, m: r.m}
+}
+
+func (r *Repository) Tenant(id string) quotes.Repository {
+	return &Repository{next: r.next.Tenant(id), backend: r.backend, m: r.m}
+}
+
+func (r *Repository) Create(q quotes.Quote) (int, error) {
+	return observe(r, "create", func() (int, error) { return r.next.Create(q) })
+}
+
+func (r *Repository) CreateLimited(q quotes.Quote, limit int) (int, error) {
+	return observe(r, "create", func() (int, error) { return r.next.CreateLimited(q, limit) })
+}
+
+func (r *Repository) Close() error {
+	return r.next.Close()
+}
+
+func (r *Repository) QuoteCounts() (map[string]int, error) {
+	return observe(r, "quote_counts", r.next.QuoteCounts)
+}
+
+func (r *Repository) GetAll() ([]quotes.Quote, error) {
+	return observe(r, "get_all", r.next.GetAll)
+}
+
+func (r *Repository) GetByID(id int) (quotes.Quote, error) {
+	return observe(r, "get_by_id", func() (quotes.Quote, error) { return r.next.GetByID(id) })
+}
+
+func (r *Repository) GetRandom() (quotes.Quote, error) {
+	return observe(r, "get_random", r.next.GetRandom)
+}
+
+func (r *Repository) GetRandomWith(rnd quotes.Rand) (quotes.Quote, error) {
+	return observe(r, "get_random", func() (quotes.Quote, error) { return r.next.GetRandomWith(rnd) })
+}
+
+func (r *Repository) GetWeightedRandomWith(rnd quotes.Rand) (quotes.Quote, error) {
+	return observe(r, "get_weighted_random", func() (quotes.Quote, error) { return r.next.GetWeightedRandomWith(rnd) })
+}
+
+func (r *Repository) SetScore(id, score int) error {
+	return observeErr(r, "set_score", func() error { return r.next.SetScore(id, score) })
+}
+
+func (r *Repository) FilterByAuthor(author string) ([]quotes.Quote, error) {
+	return observe(r, "filter_by_author", func() ([]quotes.Quote, error) { return r.next.FilterByAuthor(author) })
+}
+
+func (r *Repository) Page(author string, after, limit int) ([]quotes.Quote, error) {
+	return observe(r, "page", func() ([]quotes.Quote, error) { return r.next.Page(author, after, limit) })
+}
+
+func (r *Repository) Delete(id int) error {
+	return observeErr(r, "delete", func() error { return r.next.Delete(id) })
+}
+
+func (r *Repository) SetReaction(re quotes.Reaction) (quotes.Quote, error) {
+	return observe(r, "set_reaction", func() (quotes.Quote, error) { return r.next.SetReaction(re) })
+}
+
+func (r *Repository) DeleteReaction(quoteID int, kind, client string) (quotes.Quote, error) {
+	return observe(r, "delete_reaction", func() (quotes.Quote, error) { return r.next.DeleteReaction(quoteID, kind, client) })
+}
+
+func (r *Repository) ReactionsSince(since time.Time) ([]quotes.Reaction, error) {
+	return observe(r, "reactions_since", func() ([]quotes.Reaction, error) { return r.next.ReactionsSince(since) })
+}
+
+func (r *Repository) AddViews(views []quotes.ViewBucket, requests []quotes.RequestBucket) error {
+	return observeErr(r, "add_views", func() error { return r.next.AddViews(views, requests) })
+}
+
+func (r *Repository) ViewsSince(since time.Time) ([]quotes.ViewBucket, error) {
+	return observe(r, "views_since", func() ([]quotes.ViewBucket, error) { return r.next.ViewsSince(since) })
+}
+
+func (r *Repository) RequestsSince(since time.Time) ([]quotes.RequestBucket, error) {
+	return observe(r, "requests_since", func() ([]quotes.RequestBucket, error) { return r.next.RequestsSince(since) })
+}
+
+func (r *Repository) CreateKey(k auth.Key) (auth.Key, error) {
+	return observe(r, "create_key", func() (auth.Key, error) { return r.next.CreateKey(k) })
+}
+
+func (r *Repository) KeyByHash(hash string) (auth.Key, error) {
+	return observe(r, "key_by_hash", func() (auth.Key, error) { return r.next.KeyByHash(hash) })
+}
+
+func (r *Repository) ListKeys() ([]auth.Key, error) {
+	return observe(r, "list_keys", r.next.ListKeys)
+}
+
+func (r *Repository) RevokeKey(id int, at time.Time) error {
+	return observeErr(r, "revoke_key", func() error { return r.next.RevokeKey(id, at) })
+}
+
+func (r *Repository) GetDailyPick(date string) (quotes.DailyPick, error) {
+	return observe(r, "get_daily_pick", func() (quotes.DailyPick, error) { return r.next.GetDailyPick(date) })
+}
+
+func (r *Repository) SaveDailyPick(p quotes.DailyPick) (quotes.DailyPick, error) {
+	return observe(r, "save_daily_pick", func() (quotes.DailyPick, error) { return r.next.SaveDailyPick(p) })
+}
+
+func (r *Repository) PinDailyPick(p quotes.DailyPick) error {
+	return observeErr(r, "pin_daily_pick", func() error { return r.next.PinDailyPick(p) })
+}
+
+func (r *Repository) DeleteDailyPick(date string) error {
+	return observeErr(r, "delete_daily_pick", func() error { return r.next.DeleteDailyPick(date) })
+}
+
+func (r *Repository) DailyPicksBetween(from, to string) ([]quotes.DailyPick, error) {
+	return observe(r, "daily_picks_between", func() ([]quotes.DailyPick, error) { return r.next.DailyPicksBetween(from, to) })
+}
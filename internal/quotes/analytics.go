@@ -0,0 +1,245 @@
+package quotes
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// viewCounter накапливает показы и запросы в памяти до сброса в репозиторий,
+// чтобы горячий путь не записывал строку в базу на каждый запрос.
+// Счётчик общий для всех пространств, поэтому ключи содержат пространство.
+type viewCounter struct {
+	mu       sync.Mutex
+	views    map[tenantView]int64
+	requests map[tenantRequest]int64
+}
+
+// tenantView и tenantRequest — бакеты счётчиков с пространством, к которому они относятся.
+type tenantView struct {
+	tenant string
+	ViewBucket
+}
+
+type tenantRequest struct {
+	tenant string
+	RequestBucket
+}
+
+// newViewCounter создаёт пустой счётчик показов.
+func newViewCounter() *viewCounter {
+	return &viewCounter{views: make(map[tenantView]int64), requests: make(map[tenantRequest]int64)}
+}
+
+// AuthorViews — число показов цитат автора.
+type AuthorViews struct {
+	Author string `json:"author"`
+	Views  int64  `json:"views"`
+}
+
+// QuoteViews — цитата вместе с числом её показов.
+type QuoteViews struct {
+	Quote
+	Views int64 `json:"views"`
+}
+
+// HourlyRequests — число запросов за час в целом и по маршрутам.
+type HourlyRequests struct {
+	Hour   time.Time        `json:"hour"`
+	Total  int64            `json:"total"`
+	Routes map[string]int64 `json:"routes"`
+}
+
+// Analytics — сводка показов за окно: популярные цитаты и авторы, объём запросов
+// по часам и цитаты, которые не показывались ни разу.
+type Analytics struct {
+	From        time.Time        `json:"from"`
+	To          time.Time        `json:"to"`
+	TopQuotes   []QuoteViews     `json:"top_quotes"`
+	TopAuthors  []AuthorViews    `json:"top_authors"`
+	Requests    []HourlyRequests `json:"requests"`
+	NeverServed []Quote          `json:"never_served"`
+}
+
+// RecordViews учитывает один запрос к маршруту route и показ каждой из цитат ответа.
+// Счётчики агрегируются по часам в памяти и попадают в репозиторий при FlushViews.
+func (s *Service) RecordViews(route string, served ...Quote) {
+	hour := s.now().UTC().Truncate(time.Hour)
+	s.views.mu.Lock()
+	defer s.views.mu.Unlock()
+	s.views.requests[tenantRequest{s.tenant, RequestBucket{Hour: hour, Route: route}}]++
+	for _, q := range served {
+		s.views.views[tenantView{s.tenant, ViewBucket{Hour: hour, QuoteID: q.ID}}]++
+	}
+}
+
+// FlushViews переносит накопленные счётчики всех пространств в репозиторий.
+// При ошибке записи счётчики возвращаются в буфер и будут сохранены при следующем сбросе.
+func (s *Service) FlushViews() error {
+	s.views.mu.Lock()
+	views, requests := s.views.views, s.views.requests
+	s.views.views, s.views.requests = make(map[tenantView]int64), make(map[tenantRequest]int64)
+	s.views.mu.Unlock()
+	if len(views) == 0 && len(requests) == 0 {
+		return nil
+	}
+
+	vb := make(map[string][]ViewBucket)
+	for key, n := range views {
+		key.Views = n
+		vb[key.tenant] = append(vb[key.tenant], key.ViewBucket)
+	}
+	rb := make(map[string][]RequestBucket)
+	for key, n := range requests {
+		key.Requests = n
+		rb[key.tenant] = append(rb[key.tenant], key.RequestBucket)
+	}
+	tenants := make(map[string]bool)
+	for t := range vb {
+		tenants[t] = true
+	}
+	for t := range rb {
+		tenants[t] = true
+	}
+	var errs []error
+	for t := range tenants {
+		if err := s.repo.Tenant(t).AddViews(vb[t], rb[t]); err != nil {
+			errs = append(errs, err)
+			s.views.mu.Lock()
+			for key, n := range views {
+				if key.tenant == t {
+					s.views.views[key] += n
+				}
+			}
+			for key, n := range requests {
+				if key.tenant == t {
+					s.views.requests[key] += n
+				}
+			}
+			s.views.mu.Unlock()
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RunViewFlusher периодически сбрасывает счётчики показов до закрытия stop.
+// Перед выходом выполняется последний сброс.
+func (s *Service) RunViewFlusher(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.FlushViews(); err != nil {
+				log.Printf("Ошибка сохранения счётчиков показов: %v", err)
+			}
+		case <-stop:
+			if err := s.FlushViews(); err != nil {
+				log.Printf("Ошибка сохранения счётчиков показов: %v", err)
+			}
+			return
+		}
+	}
+}
+
+// Analytics строит сводку показов за окно window, ограничивая топы limit записями.
+// Перед построением сбрасывает накопленные счётчики, чтобы отчёт включал свежие данные.
+func (s *Service) Analytics(window time.Duration, limit int) (report Analytics, err error) {
+	s, end := s.trace("Analytics")
+	defer func() { end(err) }()
+	if err := s.FlushViews(); err != nil {
+		return Analytics{}, err
+	}
+	now := s.now().UTC()
+	from := now.Add(-window).Truncate(time.Hour)
+	res := Analytics{
+		From:        from,
+		To:          now,
+		TopQuotes:   []QuoteViews{},
+		TopAuthors:  []AuthorViews{},
+		Requests:    []HourlyRequests{},
+		NeverServed: []Quote{},
+	}
+
+	all, err := s.repo.GetAll()
+	if err != nil {
+		return Analytics{}, err
+	}
+	byID := make(map[int]Quote, len(all))
+	for _, q := range all {
+		byID[q.ID] = q
+	}
+
+	// Цитаты без единого показа считаются по всей истории, а не только по окну
+	ever, err := s.repo.ViewsSince(time.Time{})
+	if err != nil {
+		return Analytics{}, err
+	}
+	served := make(map[int]bool, len(ever))
+	perQuote := make(map[int]int64)
+	for _, v := range ever {
+		served[v.QuoteID] = true
+		if !v.Hour.Before(from) {
+			perQuote[v.QuoteID] += v.Views
+		}
+	}
+	for _, q := range all {
+		if !served[q.ID] {
+			res.NeverServed = append(res.NeverServed, q)
+		}
+	}
+	sort.Slice(res.NeverServed, func(i, j int) bool { return res.NeverServed[i].ID < res.NeverServed[j].ID })
+
+	perAuthor := make(map[string]int64)
+	for id, n := range perQuote {
+		q, ok := byID[id]
+		if !ok {
+			continue
+		}
+		res.TopQuotes = append(res.TopQuotes, QuoteViews{Quote: q, Views: n})
+		perAuthor[q.Author] += n
+	}
+	sort.Slice(res.TopQuotes, func(i, j int) bool {
+		if res.TopQuotes[i].Views != res.TopQuotes[j].Views {
+			return res.TopQuotes[i].Views > res.TopQuotes[j].Views
+		}
+		return res.TopQuotes[i].ID < res.TopQuotes[j].ID
+	})
+	for author, n := range perAuthor {
+		res.TopAuthors = append(res.TopAuthors, AuthorViews{Author: author, Views: n})
+	}
+	sort.Slice(res.TopAuthors, func(i, j int) bool {
+		if res.TopAuthors[i].Views != res.TopAuthors[j].Views {
+			return res.TopAuthors[i].Views > res.TopAuthors[j].Views
+		}
+		return res.TopAuthors[i].Author < res.TopAuthors[j].Author
+	})
+	if limit > 0 && len(res.TopQuotes) > limit {
+		res.TopQuotes = res.TopQuotes[:limit]
+	}
+	if limit > 0 && len(res.TopAuthors) > limit {
+		res.TopAuthors = res.TopAuthors[:limit]
+	}
+
+	requests, err := s.repo.RequestsSince(from)
+	if err != nil {
+		return Analytics{}, err
+	}
+	hourly := make(map[time.Time]*HourlyRequests)
+	for _, q := range requests {
+		h, ok := hourly[q.Hour]
+		if !ok {
+			h = &HourlyRequests{Hour: q.Hour, Routes: make(map[string]int64)}
+			hourly[q.Hour] = h
+		}
+		h.Total += q.Requests
+		h.Routes[q.Route] += q.Requests
+	}
+	for _, h := range hourly {
+		res.Requests = append(res.Requests, *h)
+	}
+	sort.Slice(res.Requests, func(i, j int) bool { return res.Requests[i].Hour.Before(res.Requests[j].Hour) })
+	return res, nil
+}
@@ -0,0 +1,115 @@
+// Тесты для учёта показов цитат и отчёта аналитики
+package quotes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// TestAnalyticsBackends проверяет агрегацию показов по часам и отчёт в обоих бэкендах
+func TestAnalyticsBackends(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 30, 0, 0, time.UTC)
+	for name, repo := range testBackends(t) {
+		repo.Create(Quote{Author: "A", Text: "a1"})
+		repo.Create(Quote{Author: "A", Text: "a2"})
+		repo.Create(Quote{Author: "B", Text: "b1"})
+		repo.Create(Quote{Author: "C", Text: "c1"})
+		clock := now
+		svc := NewService(repo, WithClock(func() time.Time { return clock }))
+
+		q1, _ := repo.GetByID(1)
+		q2, _ := repo.GetByID(2)
+		q3, _ := repo.GetByID(3)
+		svc.RecordViews("/quotes/{id}", q3)
+		svc.RecordViews("/quotes/{id}", q3)
+		svc.RecordViews("/quotes/random", q1)
+		if err := svc.FlushViews(); err != nil {
+			t.Fatalf("%s: ошибка сброса счётчиков: %v", name, err)
+		}
+		// Следующий час: ещё два показа первой и один второй
+		clock = now.Add(time.Hour)
+		svc.RecordViews("/quotes", q1, q2)
+		svc.RecordViews("/quotes/{id}", q1)
+
+		// Показы накапливаются по часам, а не строкой на запрос
+		if err := svc.FlushViews(); err != nil {
+			t.Fatalf("%s: ошибка сброса счётчиков: %v", name, err)
+		}
+		buckets, _ := repo.ViewsSince(time.Time{})
+		if len(buckets) != 4 {
+			t.Errorf("%s: ожидается 4 часовых бакета показов, получено %d", name, len(buckets))
+		}
+
+		report, err := svc.Analytics(24*time.Hour, 10)
+		if err != nil {
+			t.Fatalf("%s: ошибка Analytics: %v", name, err)
+		}
+		if len(report.TopQuotes) != 3 || report.TopQuotes[0].ID != 1 || report.TopQuotes[0].Views != 3 {
+			t.Errorf("%s: ожидается цитата 1 с тремя показами на первом месте, получено %+v", name, report.TopQuotes)
+		}
+		if len(report.TopAuthors) != 2 || report.TopAuthors[0].Author != "A" || report.TopAuthors[0].Views != 4 {
+			t.Errorf("%s: ожидается автор A с четырьмя показами, получено %+v", name, report.TopAuthors)
+		}
+		if len(report.NeverServed) != 1 || report.NeverServed[0].ID != 4 {
+			t.Errorf("%s: ожидается непоказанная цитата 4, получено %+v", name, report.NeverServed)
+		}
+		if len(report.Requests) != 2 || report.Requests[0].Total != 3 || report.Requests[1].Routes["/quotes"] != 1 {
+			t.Errorf("%s: неверный объём запросов по часам: %+v", name, report.Requests)
+		}
+
+		// Окно округляется вниз до начала часа: 30 минут от 13:30 — это только бакет 13:00
+		report, _ = svc.Analytics(30*time.Minute, 1)
+		if len(report.TopQuotes) != 1 || report.TopQuotes[0].Views != 2 || len(report.Requests) != 1 {
+			t.Errorf("%s: неверный отчёт за час: %+v", name, report)
+		}
+	}
+}
+
+// TestAnalyticsHandlers проверяет учёт показов через эндпоинты и отчёт /admin/analytics
+func TestAnalyticsHandlers(t *testing.T) {
+	repo := NewMemoryRepository()
+	repo.Create(Quote{Author: "A", Text: "a"})
+	repo.Create(Quote{Author: "B", Text: "b"})
+	r := mux.NewRouter()
+	RegisterHandlers(r, NewService(repo), nil)
+
+	for _, path := range []string{"/quotes/1", "/quotes/1", "/quotes?author=A", "/quotes/random?seed=1"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: ожидается 200, получен %d", path, w.Code)
+		}
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/quotes/100", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("ожидается 404 для несуществующей цитаты, получен %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/analytics?window=1d", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("ожидается 200 для аналитики, получен %d", w.Code)
+	}
+	var report Analytics
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("ошибка разбора JSON отчёта: %v", err)
+	}
+	if len(report.Requests) != 1 || report.Requests[0].Routes["/quotes/{id}"] != 2 || report.Requests[0].Total != 4 {
+		t.Errorf("ожидается 4 запроса, 2 из них к /quotes/{id}, получено %+v", report.Requests)
+	}
+	if len(report.TopQuotes) == 0 || report.TopQuotes[0].ID != 1 || report.TopQuotes[0].Views < 3 {
+		t.Errorf("ожидается цитата 1 на первом месте, получено %+v", report.TopQuotes)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/analytics?limit=1000", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("ожидается 400 для limit вне диапазона, получен %d", w.Code)
+	}
+}
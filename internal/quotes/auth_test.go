@@ -0,0 +1,78 @@
+// Тесты хранения ключей API в репозиториях и проверки областей доступа на маршрутах цитат
+package quotes
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"Test_Project_Brand_Scout/internal/auth"
+)
+
+// TestKeyStoreBackends проверяет хранение ключей API в обоих бэкендах
+func TestKeyStoreBackends(t *testing.T) {
+	for name, repo := range testBackends(t) {
+		created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		k, err := repo.CreateKey(auth.Key{Name: "ci", Prefix: "qk_abc", Hash: "h1", Scopes: []string{auth.ScopeRead, auth.ScopeWrite}, CreatedAt: created})
+		if err != nil || k.ID != 1 {
+			t.Fatalf("%s: ошибка CreateKey: %+v, %v", name, k, err)
+		}
+		got, err := repo.KeyByHash("h1")
+		if err != nil || got.Name != "ci" || len(got.Scopes) != 2 || !got.CreatedAt.Equal(created) {
+			t.Errorf("%s: неожиданный ключ %+v, %v", name, got, err)
+		}
+		if _, err := repo.KeyByHash("nope"); !errors.Is(err, auth.ErrKeyNotFound) {
+			t.Errorf("%s: ожидается ErrKeyNotFound, получено %v", name, err)
+		}
+		if err := repo.RevokeKey(1, created.Add(time.Hour)); err != nil {
+			t.Fatalf("%s: ошибка RevokeKey: %v", name, err)
+		}
+		repo.RevokeKey(1, created.Add(2*time.Hour)) // повторный отзыв не меняет время
+		list, _ := repo.ListKeys()
+		if len(list) != 1 || list[0].RevokedAt == nil || !list[0].RevokedAt.Equal(created.Add(time.Hour)) {
+			t.Errorf("%s: ожидается отозванный ключ, получено %+v", name, list)
+		}
+		if err := repo.RevokeKey(7, created); !errors.Is(err, auth.ErrKeyNotFound) {
+			t.Errorf("%s: ожидается ErrKeyNotFound при отзыве, получено %v", name, err)
+		}
+	}
+}
+
+// TestHandlersRequireScopes проверяет, что маршруты цитат требуют нужных областей доступа
+func TestHandlersRequireScopes(t *testing.T) {
+	repo := NewMemoryRepository()
+	repo.Create(Quote{Author: "A", Text: "a"})
+	keys := auth.NewKeyManager(repo)
+	_, reader, _ := keys.Issue("reader", "", []string{auth.ScopeRead})
+	_, writer, _ := keys.Issue("writer", "", []string{auth.ScopeRead, auth.ScopeWrite})
+	r := mux.NewRouter()
+	RegisterHandlers(r, NewService(repo), keys)
+
+	cases := []struct {
+		method, path, token string
+		code                int
+	}{
+		{http.MethodGet, "/quotes", "", http.StatusUnauthorized},
+		{http.MethodGet, "/quotes", reader, http.StatusOK},
+		{http.MethodPost, "/quotes/1/like", reader, http.StatusOK},
+		{http.MethodDelete, "/quotes/1", reader, http.StatusForbidden},
+		{http.MethodGet, "/admin/analytics", writer, http.StatusForbidden},
+		{http.MethodDelete, "/quotes/1", writer, http.StatusNoContent},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(c.method, c.path, strings.NewReader(""))
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != c.code {
+			t.Errorf("%s %s: ожидается %d, получен %d", c.method, c.path, c.code, w.Code)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package quotes
+
+import (
+	"errors"
+	"hash/fnv"
+	"sort"
+	"time"
+)
+
+// dateLayout — формат календарной даты цитаты дня.
+const dateLayout = "2006-01-02"
+
+// ErrInvalidDate возвращается, если дата не соответствует формату YYYY-MM-DD.
+var ErrInvalidDate = errors.New("дата должна быть в формате YYYY-MM-DD")
+
+// DailyQuote — цитата дня вместе с датой и признаком ручного закрепления.
+type DailyQuote struct {
+	Date   string `json:"date"`
+	Pinned bool   `json:"pinned"`
+	Quote
+}
+
+// Daily возвращает цитату дня для текущей календарной даты в часовом поясе loc.
+// Цитата меняется в локальную полночь и одинакова для всех клиентов в этот день.
+func (s *Service) Daily(loc *time.Location) (DailyQuote, error) {
+	return s.DailyFor(s.now().In(loc).Format(dateLayout))
+}
+
+// DailyFor возвращает цитату дня для указанной даты.
+// Если выбор ещё не сделан, он вычисляется детерминированно и сохраняется в репозитории,
+// поэтому результат не меняется после перезапуска и совпадает у экземпляров с общей базой.
+func (s *Service) DailyFor(date string) (dq DailyQuote, err error) {
+	s, end := s.trace("DailyFor")
+	defer func() { end(err) }()
+	if _, err := time.Parse(dateLayout, date); err != nil {
+		return DailyQuote{}, ErrInvalidDate
+	}
+	p, err := s.repo.GetDailyPick(date)
+	if errors.Is(err, ErrNotFound) {
+		p, err = s.pickDaily(date)
+	}
+	if err != nil {
+		return DailyQuote{}, err
+	}
+	q, err := s.repo.GetByID(p.QuoteID)
+	if err != nil {
+		return DailyQuote{}, err
+	}
+	return DailyQuote{Date: p.Date, Pinned: p.Pinned, Quote: q}, nil
+}
+
+// PinDaily закрепляет цитату id за датой, заменяя автоматический выбор.
+func (s *Service) PinDaily(date string, id int) (err error) {
+	s, end := s.trace("PinDaily")
+	defer func() { end(err) }()
+	if _, err := time.Parse(dateLayout, date); err != nil {
+		return ErrInvalidDate
+	}
+	if _, err := s.repo.GetByID(id); err != nil {
+		return err
+	}
+	return s.repo.PinDailyPick(DailyPick{Date: date, QuoteID: id, Pinned: true})
+}
+
+// UnpinDaily снимает закрепление с даты; при следующем запросе цитата будет выбрана автоматически.
+func (s *Service) UnpinDaily(date string) (err error) {
+	s, end := s.trace("UnpinDaily")
+	defer func() { end(err) }()
+	if _, err := time.Parse(dateLayout, date); err != nil {
+		return ErrInvalidDate
+	}
+	return s.repo.DeleteDailyPick(date)
+}
+
+// pickDaily выбирает цитату для даты: исключает цитаты дня за последние dailyWindow дней
+// и берёт элемент по хешу даты из отсортированного по ID списка оставшихся.
+func (s *Service) pickDaily(date string) (DailyPick, error) {
+	all, err := s.repo.GetAll()
+	if err != nil {
+		return DailyPick{}, err
+	}
+	if len(all) == 0 {
+		return DailyPick{}, ErrEmpty
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].ID < all[j].ID })
+
+	candidates := all
+	if s.dailyWindow > 0 {
+		day, _ := time.Parse(dateLayout, date)
+		from := day.AddDate(0, 0, -s.dailyWindow).Format(dateLayout)
+		recent, err := s.repo.DailyPicksBetween(from, date)
+		if err != nil {
+			return DailyPick{}, err
+		}
+		used := make(map[int]bool, len(recent))
+		for _, p := range recent {
+			used[p.QuoteID] = true
+		}
+		var fresh []Quote
+		for _, q := range all {
+			if !used[q.ID] {
+				fresh = append(fresh, q)
+			}
+		}
+		// Если все цитаты уже показывались в окне, повтор неизбежен
+		if len(fresh) > 0 {
+			candidates = fresh
+		}
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(date))
+	q := candidates[h.Sum64()%uint64(len(candidates))]
+	return s.repo.SaveDailyPick(DailyPick{Date: date, QuoteID: q.ID})
+}
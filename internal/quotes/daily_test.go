@@ -0,0 +1,160 @@
+// Тесты для цитаты дня: детерминированный выбор, часовые пояса, окно без повторов и закрепление
+package quotes
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// fixedClock возвращает функцию времени, всегда отдающую t
+func fixedClock(t time.Time) func() time.Time {
+	return func() time.Time { return t }
+}
+
+// seedQuotes создаёт в репозитории n цитат
+func seedQuotes(t *testing.T, repo Repository, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if _, err := repo.Create(Quote{Author: "A", Text: strings.Repeat("q", i+1)}); err != nil {
+			t.Fatalf("ошибка создания цитаты: %v", err)
+		}
+	}
+}
+
+// TestDailyDeterministic проверяет, что цитата дня одинакова в течение дня и совпадает у разных бэкендов
+func TestDailyDeterministic(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	repos := testBackends(t)
+	for _, repo := range repos {
+		seedQuotes(t, repo, 10)
+	}
+
+	mem := repos["memory"]
+	first, err := NewService(mem, WithClock(fixedClock(now))).Daily(time.UTC)
+	if err != nil {
+		t.Fatalf("ошибка получения цитаты дня: %v", err)
+	}
+	if first.Date != "2026-10-19" {
+		t.Errorf("ожидается дата 2026-10-19, получено %s", first.Date)
+	}
+	// Новый сервис поверх того же репозитория (как после перезапуска) возвращает ту же цитату
+	again, _ := NewService(mem, WithClock(fixedClock(now.Add(11*time.Hour)))).Daily(time.UTC)
+	if again.ID != first.ID {
+		t.Errorf("цитата дня изменилась в течение дня: %d -> %d", first.ID, again.ID)
+	}
+	for name, repo := range repos {
+		other, err := NewService(repo, WithClock(fixedClock(now))).Daily(time.UTC)
+		if err != nil || other.ID != first.ID {
+			t.Errorf("бэкенды выбрали разные цитаты дня: memory=%d, %s=%d (%v)", first.ID, name, other.ID, err)
+		}
+	}
+}
+
+// TestDailyTimezone проверяет, что дата цитаты дня зависит от часового пояса
+func TestDailyTimezone(t *testing.T) {
+	// 22:30 UTC — в Москве уже следующий день
+	now := time.Date(2026, 10, 19, 22, 30, 0, 0, time.UTC)
+	repo := NewMemoryRepository()
+	seedQuotes(t, repo, 3)
+	svc := NewService(repo, WithClock(fixedClock(now)))
+
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Skipf("нет базы часовых поясов: %v", err)
+	}
+	utc, _ := svc.Daily(time.UTC)
+	msk, _ := svc.Daily(moscow)
+	if utc.Date != "2026-10-19" || msk.Date != "2026-10-20" {
+		t.Errorf("ожидаются даты 2026-10-19 и 2026-10-20, получено %s и %s", utc.Date, msk.Date)
+	}
+}
+
+// TestDailyNoRepeat проверяет, что в пределах окна цитаты дня не повторяются
+func TestDailyNoRepeat(t *testing.T) {
+	repo := NewMemoryRepository()
+	seedQuotes(t, repo, 5)
+	svc := NewService(repo, WithDailyWindow(4))
+
+	seen := map[int]bool{}
+	day := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		q, err := svc.DailyFor(day.AddDate(0, 0, i).Format(dateLayout))
+		if err != nil {
+			t.Fatalf("ошибка получения цитаты дня: %v", err)
+		}
+		if seen[q.ID] {
+			t.Errorf("цитата %d повторилась в пределах окна", q.ID)
+		}
+		seen[q.ID] = true
+	}
+}
+
+// TestDailyPin проверяет, что закреплённая редактором цитата заменяет автоматический выбор
+func TestDailyPin(t *testing.T) {
+	for name, repo := range testBackends(t) {
+		seedQuotes(t, repo, 3)
+		svc := NewService(repo)
+		auto, _ := svc.DailyFor("2026-03-08")
+		pin := auto.ID%3 + 1
+		if err := svc.PinDaily("2026-03-08", pin); err != nil {
+			t.Fatalf("%s: ошибка закрепления: %v", name, err)
+		}
+		q, _ := svc.DailyFor("2026-03-08")
+		if q.ID != pin || !q.Pinned {
+			t.Errorf("%s: ожидается закреплённая цитата %d, получено %+v", name, pin, q)
+		}
+		if err := svc.PinDaily("2026-03-08", 100); !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: ожидается ErrNotFound для несуществующей цитаты, получено %v", name, err)
+		}
+		if err := svc.PinDaily("08.03.2026", pin); !errors.Is(err, ErrInvalidDate) {
+			t.Errorf("%s: ожидается ErrInvalidDate, получено %v", name, err)
+		}
+	}
+}
+
+// TestDailyHandlers проверяет эндпоинты цитаты дня и её закрепления
+func TestDailyHandlers(t *testing.T) {
+	repo := NewMemoryRepository()
+	seedQuotes(t, repo, 3)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	r := mux.NewRouter()
+	RegisterHandlers(r, NewService(repo, WithClock(fixedClock(now))), nil)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/quotes/daily?tz=Nowhere/City", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("ожидается 400 для неизвестного часового пояса, получен %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/admin/daily/2026-10-19", strings.NewReader(`{"id":2}`)))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("ожидается 204 при закреплении, получен %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/quotes/daily", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("ожидается 200 для цитаты дня, получен %d", w.Code)
+	}
+	var q DailyQuote
+	if err := json.Unmarshal(w.Body.Bytes(), &q); err != nil {
+		t.Fatalf("ошибка разбора JSON цитаты дня: %v", err)
+	}
+	if q.ID != 2 || !q.Pinned || q.Date != "2026-10-19" {
+		t.Errorf("ожидается закреплённая цитата 2 на 2026-10-19, получено %+v", q)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/admin/daily/2026-10-19", nil))
+	if w.Code != http.StatusNoContent {
+		t.Errorf("ожидается 204 при снятии закрепления, получен %d", w.Code)
+	}
+}
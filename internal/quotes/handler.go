@@ -2,19 +2,79 @@ package quotes
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+
+	"Test_Project_Brand_Scout/internal/auth"
 )
 
-// RegisterHandlers регистрирует HTTP-эндпоинты для операций с цитатами: создание, получение списка, случайная цитата, фильтрация и удаление.
-func RegisterHandlers(r *mux.Router, svc *Service) {
-	r.HandleFunc("/quotes", createQuote(svc)).Methods("POST")
-	r.HandleFunc("/quotes", filterQuotes(svc)).Queries("author", "{author}").Methods("GET")
-	r.HandleFunc("/quotes", listQuotes(svc)).Methods("GET")
-	r.HandleFunc("/quotes/random", randomQuote(svc)).Methods("GET")
-	r.HandleFunc("/quotes/{id}", deleteQuote(svc)).Methods("DELETE")
+// RegisterHandlers регистрирует HTTP-эндпоинты для операций с цитатами: создание, получение списка, случайная цитата,
+// цитата дня, популярные цитаты, получение по id, фильтрация, удаление, лайки и оценки, а также административные эндпоинты.
+// Ответы со списками и отдельными цитатами учитываются в статистике показов.
+// Каждый маршрут требует своей области доступа у authn; при authn == nil авторизация отключена.
+// Те же маршруты доступны с префиксом /w/{workspace} для работы с рабочим пространством, см. resolveTenant.
+func RegisterHandlers(r *mux.Router, svc *Service, authn auth.Authenticator) {
+	registerRoutes(r.PathPrefix("/w/{workspace}").Subrouter(), svc, authn)
+	registerRoutes(r, svc, authn)
+}
+
+// registerRoutes регистрирует маршруты цитат на r; обработчики получают сервис пространства запроса.
+func registerRoutes(r *mux.Router, svc *Service, authn auth.Authenticator) {
+	require := func(scope string, h func(*Service) http.HandlerFunc) http.Handler {
+		return auth.Require(authn, scope, resolveTenant(svc, h))
+	}
+	read := func(h func(*Service) http.HandlerFunc) http.Handler { return require(auth.ScopeRead, h) }
+	write := func(h func(*Service) http.HandlerFunc) http.Handler { return require(auth.ScopeWrite, h) }
+	admin := func(h func(*Service) http.HandlerFunc) http.Handler { return require(auth.ScopeAdmin, h) }
+
+	r.Handle("/quotes", write(createQuote)).Methods("POST")
+	r.Handle("/quotes", read(filterQuotes)).Queries("author", "{author}").Methods("GET")
+	r.Handle("/quotes", read(listQuotes)).Methods("GET")
+	r.Handle("/quotes/random", read(randomQuote)).Methods("GET")
+	r.Handle("/quotes/daily", read(dailyQuote)).Methods("GET")
+	r.Handle("/quotes/popular", read(popularQuotes)).Methods("GET")
+	r.Handle("/quotes/{id}", read(getQuote)).Methods("GET")
+	r.Handle("/quotes/{id}", write(deleteQuote)).Methods("DELETE")
+	// Реакции — действия читателя, поэтому достаточно области чтения
+	r.Handle("/quotes/{id}/like", read(likeQuote)).Methods("POST")
+	r.Handle("/quotes/{id}/like", read(unlikeQuote)).Methods("DELETE")
+	r.Handle("/quotes/{id}/rating", read(rateQuote)).Methods("PUT")
+	r.Handle("/admin/analytics", admin(analytics)).Methods("GET")
+	r.Handle("/admin/quotes/{id}/score", admin(setScore)).Methods("PUT")
+	r.Handle("/admin/daily/{date}", admin(pinDaily)).Methods("PUT")
+	r.Handle("/admin/daily/{date}", admin(unpinDaily)).Methods("DELETE")
+}
+
+// resolveTenant определяет рабочее пространство запроса и передаёт обработчику сервис этого пространства.
+// Пространство берётся из префикса /w/{workspace}, иначе из привязки клиента, иначе используется DefaultTenant.
+// Клиент, привязанный к пространству, получает 403 при обращении к другому пространству.
+func resolveTenant(svc *Service, h func(*Service) http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant := mux.Vars(r)["workspace"]
+		if tenant != "" {
+			if err := ValidTenant(tenant); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		if id, ok := auth.FromContext(r.Context()); ok && id.Tenant != "" {
+			if tenant != "" && tenant != id.Tenant {
+				http.Error(w, auth.ErrForbidden.Error(), http.StatusForbidden)
+				return
+			}
+			tenant = id.Tenant
+		}
+		tenant = normalizeTenant(tenant)
+		r = r.WithContext(WithTenant(r.Context(), tenant))
+		h(svc.Tenant(tenant).WithContext(r.Context())).ServeHTTP(w, r)
+	})
 }
 
 // createQuote возвращает HandlerFunc для создания новой цитаты.
@@ -27,6 +87,10 @@ func createQuote(svc *Service) http.HandlerFunc {
 			return
 		}
 		id, err := svc.Create(q.Author, q.Text)
+		if errors.Is(err, ErrQuotaExceeded) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
@@ -37,35 +101,117 @@ func createQuote(svc *Service) http.HandlerFunc {
 }
 
 // listQuotes возвращает HandlerFunc для получения списка всех цитат.
+// Поддерживает постраничную выдачу параметрами after и limit, см. writePage.
 func listQuotes(svc *Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		list, _ := svc.GetAll()
-		if list == nil {
-			list = []Quote{}
+		writePage(w, r, svc, "")
+	}
+}
+
+// maxPageLimit — наибольший размер страницы списка цитат.
+const maxPageLimit = 1000
+
+// pageParams разбирает параметры постраничной выдачи: after — ID последней цитаты предыдущей страницы,
+// limit — размер страницы от 1 до maxPageLimit. Без параметров выдаётся весь список.
+func pageParams(r *http.Request) (after, limit int, err error) {
+	query := r.URL.Query()
+	if raw := query.Get("after"); raw != "" {
+		if after, err = strconv.Atoi(raw); err != nil || after < 0 {
+			return 0, 0, errors.New("after должен быть неотрицательным целым числом")
+		}
+	}
+	if raw := query.Get("limit"); raw != "" {
+		if limit, err = strconv.Atoi(raw); err != nil || limit < 1 || limit > maxPageLimit {
+			return 0, 0, fmt.Errorf("limit должен быть от 1 до %d", maxPageLimit)
 		}
-		json.NewEncoder(w).Encode(list)
 	}
+	return after, limit, nil
+}
+
+// writePage отвечает страницей цитат: не больше limit цитат с ID больше after, автора author, если он задан.
+// Страница выбирается в хранилище в порядке ID, поэтому страницы не пересекаются и не пропускают цитат,
+// добавленных между запросами: так клиенты обходят список и следят за новыми цитатами,
+// не получая и не засчитывая в показы весь список. Пустой результат — пустой массив, а не null.
+func writePage(w http.ResponseWriter, r *http.Request, svc *Service, author string) {
+	after, limit, err := pageParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	list, err := svc.Page(author, after, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if list == nil {
+		list = []Quote{}
+	}
+	svc.RecordViews(routeTemplate(r), list...)
+	json.NewEncoder(w).Encode(list)
 }
 
 // randomQuote возвращает HandlerFunc для получения случайной цитаты.
+// Необязательный параметр seed делает выбор воспроизводимым, weighted=true включает выбор пропорционально весу.
 func randomQuote(svc *Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		q, err := svc.GetRandom()
+		var p RandomParams
+		query := r.URL.Query()
+		if raw := query.Get("seed"); raw != "" {
+			seed, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			p.Seed = &seed
+		}
+		if raw := query.Get("weighted"); raw != "" {
+			weighted, err := strconv.ParseBool(raw)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			p.Weighted = weighted
+		}
+		q, err := svc.Random(p)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		svc.RecordViews(routeTemplate(r), q)
+		json.NewEncoder(w).Encode(q)
+	}
+}
+
+// getQuote возвращает HandlerFunc для получения цитаты по id.
+// Возвращает 404, если цитата не найдена.
+func getQuote(svc *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		q, err := svc.GetByID(id)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusNotFound)
 			return
 		}
+		svc.RecordViews(routeTemplate(r), q)
 		json.NewEncoder(w).Encode(q)
 	}
 }
 
 // filterQuotes возвращает HandlerFunc для фильтрации цитат по автору.
-// Принимает параметр URL author и возвращает все цитаты указанного автора.
+// Принимает параметр URL author и возвращает все цитаты указанного автора; after и limit — как у listQuotes.
 func filterQuotes(svc *Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		author := mux.Vars(r)["author"]
-		list, _ := svc.FilterByAuthor(author)
-		json.NewEncoder(w).Encode(list)
+		if author == "" {
+			// Автор обязателен при создании цитаты, поэтому пустому фильтру не соответствует ни одна цитата
+			json.NewEncoder(w).Encode([]Quote{})
+			return
+		}
+		writePage(w, r, svc, author)
 	}
 }
 
@@ -85,3 +231,263 @@ func deleteQuote(svc *Service) http.HandlerFunc {
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
+
+// dailyQuote возвращает HandlerFunc для получения цитаты дня.
+// Необязательный параметр tz задаёт часовой пояс IANA (по умолчанию UTC).
+func dailyQuote(svc *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		loc := time.UTC
+		if tz := r.URL.Query().Get("tz"); tz != "" {
+			var err error
+			if loc, err = time.LoadLocation(tz); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		q, err := svc.Daily(loc)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		svc.RecordViews(routeTemplate(r), q.Quote)
+		json.NewEncoder(w).Encode(q)
+	}
+}
+
+// setScore возвращает HandlerFunc для установки оценки редактора.
+// Ожидает JSON с полем score, возвращает 204 No Content при успехе.
+func setScore(svc *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var body struct {
+			Score int `json:"score"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		err = svc.SetScore(id, body.Score)
+		switch {
+		case errors.Is(err, ErrInvalidScore):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, ErrNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
+
+// pinDaily возвращает HandlerFunc для закрепления цитаты за датой.
+// Ожидает JSON с полем id, возвращает 204 No Content при успехе.
+func pinDaily(svc *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			ID int `json:"id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		err := svc.PinDaily(mux.Vars(r)["date"], body.ID)
+		switch {
+		case errors.Is(err, ErrInvalidDate):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, ErrNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
+
+// unpinDaily возвращает HandlerFunc для снятия закрепления цитаты дня с даты.
+func unpinDaily(svc *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := svc.UnpinDaily(mux.Vars(r)["date"])
+		switch {
+		case errors.Is(err, ErrInvalidDate):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
+
+// clientID определяет клиента для дедупликации реакций: аутентифицированная личность, а без неё —
+// IP-адрес из RemoteAddr. Заголовкам клиента не доверяем: иначе один клиент ставил бы лайки
+// под любым числом выдуманных имён.
+func clientID(r *http.Request) string {
+	if id, ok := auth.FromContext(r.Context()); ok && id.Subject != "" {
+		return id.Subject
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// writeReactionResult записывает обновлённую цитату или ошибку реакции с подходящим статусом.
+func writeReactionResult(w http.ResponseWriter, q Quote, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidRating), errors.Is(err, ErrNoClient):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	default:
+		json.NewEncoder(w).Encode(q)
+	}
+}
+
+// likeQuote возвращает HandlerFunc, ставящий лайк цитате от имени клиента.
+// Возвращает цитату с обновлёнными счётчиками.
+func likeQuote(svc *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		q, err := svc.Like(id, clientID(r))
+		writeReactionResult(w, q, err)
+	}
+}
+
+// unlikeQuote возвращает HandlerFunc, снимающий лайк клиента с цитаты.
+func unlikeQuote(svc *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		q, err := svc.Unlike(id, clientID(r))
+		writeReactionResult(w, q, err)
+	}
+}
+
+// rateQuote возвращает HandlerFunc для оценки цитаты.
+// Ожидает JSON с полем stars от 1 до 5; повторная оценка клиента заменяет предыдущую.
+func rateQuote(svc *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var body struct {
+			Stars int `json:"stars"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		q, err := svc.Rate(id, clientID(r), body.Stars)
+		writeReactionResult(w, q, err)
+	}
+}
+
+// popularQuotes возвращает HandlerFunc для рейтинга популярных цитат.
+// Параметр window задаёт окно (например 7d или 12h, по умолчанию 7d), limit — число цитат (по умолчанию 10).
+func popularQuotes(svc *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		window := 7 * 24 * time.Hour
+		if raw := query.Get("window"); raw != "" {
+			var err error
+			if window, err = parseWindow(raw); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		limit := 10
+		if raw := query.Get("limit"); raw != "" {
+			var err error
+			if limit, err = strconv.Atoi(raw); err != nil || limit < 1 || limit > 100 {
+				http.Error(w, "limit должен быть от 1 до 100", http.StatusBadRequest)
+				return
+			}
+		}
+		list, err := svc.Popular(window, limit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(list)
+	}
+}
+
+// parseWindow разбирает длительность окна: помимо единиц time.ParseDuration поддерживает дни (7d).
+func parseWindow(raw string) (time.Duration, error) {
+	var d time.Duration
+	if days, ok := strings.CutSuffix(raw, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, errors.New("некорректное окно: " + raw)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(raw); err != nil {
+			return 0, err
+		}
+	}
+	if d <= 0 {
+		return 0, errors.New("окно должно быть положительным: " + raw)
+	}
+	return d, nil
+}
+
+// routeTemplate возвращает шаблон маршрута mux (например /quotes/{id}) для учёта запросов,
+// чтобы счётчики не дробились по конкретным значениям параметров.
+func routeTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if tpl, err := route.GetPathTemplate(); err == nil {
+			return tpl
+		}
+	}
+	return r.URL.Path
+}
+
+// analytics возвращает HandlerFunc для отчёта о показах цитат.
+// Параметр window задаёт окно (по умолчанию 7d), limit — размер топов (по умолчанию 10).
+func analytics(svc *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		window := 7 * 24 * time.Hour
+		if raw := query.Get("window"); raw != "" {
+			var err error
+			if window, err = parseWindow(raw); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		limit := 10
+		if raw := query.Get("limit"); raw != "" {
+			var err error
+			if limit, err = strconv.Atoi(raw); err != nil || limit < 1 || limit > 100 {
+				http.Error(w, "limit должен быть от 1 до 100", http.StatusBadRequest)
+				return
+			}
+		}
+		report, err := svc.Analytics(window, limit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(report)
+	}
+}
@@ -17,7 +17,7 @@ func setupRouter() *mux.Router {
 	repo := NewMemoryRepository()
 	svc := NewService(repo)
 	r := mux.NewRouter()
-	RegisterHandlers(r, svc)
+	RegisterHandlers(r, svc, nil)
 	return r
 }
 
@@ -72,6 +72,36 @@ func TestListAndRandomAndFilterDeleteHandlers(t *testing.T) {
 		t.Errorf("ожидалось 2 цитаты, получено %d", len(list))
 	}
 
+	// only quotes after id
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/quotes?after=1", nil))
+	var newer []Quote
+	if err := json.Unmarshal(w.Body.Bytes(), &newer); err != nil || len(newer) != 1 || newer[0].ID != 2 {
+		t.Errorf("ожидалась только цитата с id=2 после id=1, получено %s", w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/quotes?after=2", nil))
+	if body := w.Body.String(); body != "[]\n" {
+		t.Errorf("ожидался пустой массив после последней цитаты, получено %q", body)
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/quotes?limit=1", nil))
+	if err := json.Unmarshal(w.Body.Bytes(), &newer); err != nil || len(newer) != 1 || newer[0].ID != 1 {
+		t.Errorf("ожидалась первая страница из цитаты id=1, получено %s", w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/quotes?author=B&after=1&limit=5", nil))
+	if err := json.Unmarshal(w.Body.Bytes(), &newer); err != nil || len(newer) != 1 || newer[0].Author != "B" {
+		t.Errorf("ожидалась страница цитат автора B, получено %s", w.Body.String())
+	}
+	for _, query := range []string{"after=-1", "limit=0", "limit=1001", "author=A&limit=x"} {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/quotes?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("ожидаемый статус 400 для %s, получен %d", query, w.Code)
+		}
+	}
+
 	// random
 	w = httptest.NewRecorder()
 	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/quotes/random", nil))
@@ -0,0 +1,75 @@
+package quotes
+
+import (
+	"math/rand"
+	"sync"
+
+	"Test_Project_Brand_Scout/internal/sqlitedriver"
+)
+
+// Rand — источник случайности для выбора цитат.
+// Позволяет подменить глобальный math/rand детерминированным генератором.
+type Rand interface {
+	// Intn возвращает случайное число в диапазоне [0, n).
+	Intn(n int) int
+	// Int63n возвращает случайное число в диапазоне [0, n).
+	Int63n(n int64) int64
+}
+
+// globalRand использует глобальный генератор math/rand, безопасный для конкурентного доступа.
+type globalRand struct{}
+
+// Intn возвращает случайное число в диапазоне [0, n) из глобального генератора.
+func (globalRand) Intn(n int) int { return rand.Intn(n) }
+
+// Int63n возвращает случайное число в диапазоне [0, n) из глобального генератора.
+func (globalRand) Int63n(n int64) int64 { return rand.Int63n(n) }
+
+// lockedRand защищает генератор math/rand мьютексом, так как *rand.Rand не потокобезопасен.
+type lockedRand struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+// NewSeededRand создаёт потокобезопасный генератор с фиксированным seed.
+// Одинаковый seed на одинаковых данных даёт одинаковую последовательность цитат в любом репозитории.
+func NewSeededRand(seed int64) Rand {
+	return &lockedRand{r: rand.New(rand.NewSource(seed))}
+}
+
+// Intn возвращает случайное число в диапазоне [0, n).
+func (l *lockedRand) Intn(n int) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Intn(n)
+}
+
+// Int63n возвращает случайное число в диапазоне [0, n).
+func (l *lockedRand) Int63n(n int64) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Int63n(n)
+}
+
+// repoOptions содержит необязательные параметры репозиториев.
+type repoOptions struct {
+	rnd    Rand
+	driver *sqlitedriver.Driver // драйвер SQLite
+}
+
+// RepoOption настраивает необязательные параметры репозитория.
+type RepoOption func(*repoOptions)
+
+// WithRepoRand задаёт источник случайности, который репозиторий использует в GetRandom.
+func WithRepoRand(rnd Rand) RepoOption {
+	return func(o *repoOptions) { o.rnd = rnd }
+}
+
+// applyRepoOptions применяет опции поверх значений по умолчанию.
+func applyRepoOptions(opts []RepoOption) repoOptions {
+	o := repoOptions{rnd: globalRand{}, driver: sqlitedriver.Default()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
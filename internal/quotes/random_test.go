@@ -0,0 +1,100 @@
+// Тесты для воспроизводимого выбора случайной цитаты
+package quotes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Test_Project_Brand_Scout/internal/sqlitedriver"
+
+	"github.com/gorilla/mux"
+)
+
+// TestSeededRandomSameAcrossBackends проверяет, что одинаковый seed даёт одну и ту же цитату во всех бэкендах
+func TestSeededRandomSameAcrossBackends(t *testing.T) {
+	services := map[string]*Service{}
+	for name, repo := range testBackends(t) {
+		svc := NewService(repo)
+		for _, text := range []string{"a", "b", "c", "d", "e", "f", "g"} {
+			if _, err := svc.Create("A", text); err != nil {
+				t.Fatalf("%s: ошибка создания цитаты: %v", name, err)
+			}
+		}
+		services[name] = svc
+	}
+	for seed := int64(0); seed < 20; seed++ {
+		want, err := services["memory"].GetRandomSeeded(seed)
+		if err != nil {
+			t.Fatalf("memory: ошибка GetRandomSeeded: %v", err)
+		}
+		for name, svc := range services {
+			if got, err := svc.GetRandomSeeded(seed); err != nil || got != want {
+				t.Errorf("seed=%d: %s вернул %v (%v), memory — %v", seed, name, got, err, want)
+			}
+		}
+	}
+}
+
+// TestInjectedRepoRand проверяет, что репозиторий использует переданный источник случайности
+func TestInjectedRepoRand(t *testing.T) {
+	// У каждого репозитория свой источник с одинаковым seed
+	repos := map[string]Repository{
+		"memory": NewMemoryRepository(WithRepoRand(NewSeededRand(7))),
+		"bolt":   openTestBolt(t, WithRepoRand(NewSeededRand(7))),
+	}
+	for _, name := range sqlitedriver.Available() {
+		d, _ := sqlitedriver.Lookup(name)
+		repos["sqlite/"+name] = openTestSQLite(t, ":memory:", WithSQLDriver(d), WithRepoRand(NewSeededRand(7)))
+	}
+	for _, repo := range repos {
+		for _, text := range []string{"a", "b", "c", "d", "e"} {
+			repo.Create(Quote{Author: "A", Text: text})
+		}
+	}
+	for i := 0; i < 10; i++ {
+		want, _ := repos["memory"].GetRandom()
+		for name, repo := range repos {
+			if name == "memory" {
+				continue
+			}
+			if got, _ := repo.GetRandom(); got != want {
+				t.Fatalf("шаг %d: последовательность %s разошлась с memory: %v и %v", i, name, got, want)
+			}
+		}
+	}
+}
+
+// TestRandomSeedHandler проверяет параметр seed эндпоинта /quotes/random
+func TestRandomSeedHandler(t *testing.T) {
+	repo := NewMemoryRepository()
+	for _, text := range []string{"a", "b", "c", "d", "e"} {
+		repo.Create(Quote{Author: "A", Text: text})
+	}
+	r := mux.NewRouter()
+	RegisterHandlers(r, NewService(repo), nil)
+
+	var got []Quote
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/quotes/random?seed=42", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("ожидается 200 OK, получен %d", w.Code)
+		}
+		var q Quote
+		if err := json.Unmarshal(w.Body.Bytes(), &q); err != nil {
+			t.Fatalf("ошибка разбора JSON: %v", err)
+		}
+		got = append(got, q)
+	}
+	if got[0] != got[1] || got[1] != got[2] {
+		t.Errorf("ожидается одна и та же цитата для seed=42, получено %v", got)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/quotes/random?seed=abc", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("ожидается 400 для некорректного seed, получен %d", w.Code)
+	}
+}
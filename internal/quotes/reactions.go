@@ -0,0 +1,122 @@
+package quotes
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// ErrInvalidRating возвращается, если оценка вне диапазона от 1 до 5 звёзд.
+var ErrInvalidRating = errors.New("оценка должна быть от 1 до 5")
+
+// ErrNoClient возвращается, если не удалось определить клиента для дедупликации реакций.
+var ErrNoClient = errors.New("не удалось определить клиента")
+
+// defaultPopularHalfLife — период полураспада вклада реакции в популярность по умолчанию.
+const defaultPopularHalfLife = 24 * time.Hour
+
+// PopularQuote — цитата вместе с её популярностью за выбранное окно.
+type PopularQuote struct {
+	Quote
+	Popularity float64 `json:"popularity"`
+}
+
+// WithPopularHalfLife задаёт период, за который вклад реакции в популярность уменьшается вдвое.
+func WithPopularHalfLife(d time.Duration) Option {
+	return func(s *Service) { s.halfLife = d }
+}
+
+// Like ставит лайк цитате от имени клиента; повторный лайк ничего не меняет.
+func (s *Service) Like(id int, client string) (q Quote, err error) {
+	s, end := s.trace("Like")
+	defer func() { end(err) }()
+	if client == "" {
+		return Quote{}, ErrNoClient
+	}
+	return s.repo.SetReaction(Reaction{QuoteID: id, Kind: ReactionLike, Client: client, Value: 1, CreatedAt: s.now()})
+}
+
+// Unlike снимает лайк клиента с цитаты.
+func (s *Service) Unlike(id int, client string) (q Quote, err error) {
+	s, end := s.trace("Unlike")
+	defer func() { end(err) }()
+	if client == "" {
+		return Quote{}, ErrNoClient
+	}
+	return s.repo.DeleteReaction(id, ReactionLike, client)
+}
+
+// Rate сохраняет оценку клиента от 1 до 5 звёзд, заменяя его предыдущую оценку.
+func (s *Service) Rate(id int, client string, stars int) (q Quote, err error) {
+	s, end := s.trace("Rate")
+	defer func() { end(err) }()
+	if client == "" {
+		return Quote{}, ErrNoClient
+	}
+	if stars < 1 || stars > 5 {
+		return Quote{}, ErrInvalidRating
+	}
+	return s.repo.SetReaction(Reaction{QuoteID: id, Kind: ReactionRating, Client: client, Value: stars, CreatedAt: s.now()})
+}
+
+// Popular возвращает до limit цитат с наибольшей популярностью за окно window.
+// Каждая реакция в окне даёт вклад, который экспоненциально затухает с её возрастом:
+// лайк — +1, оценка — от -1 (одна звезда) до +1 (пять звёзд). Поэтому свежие высоко
+// оценённые цитаты обгоняют старые, набравшие реакции давно.
+func (s *Service) Popular(window time.Duration, limit int) (list []PopularQuote, err error) {
+	s, end := s.trace("Popular")
+	defer func() { end(err) }()
+	now := s.now()
+	reactions, err := s.repo.ReactionsSince(now.Add(-window))
+	if err != nil {
+		return nil, err
+	}
+	halfLife := s.halfLife
+	if halfLife <= 0 {
+		halfLife = defaultPopularHalfLife
+	}
+	scores := make(map[int]float64)
+	for _, re := range reactions {
+		decay := math.Exp2(-float64(now.Sub(re.CreatedAt)) / float64(halfLife))
+		scores[re.QuoteID] += reactionValue(re) * decay
+	}
+
+	ranked := make([]PopularQuote, 0, len(scores))
+	for id, score := range scores {
+		if score > 0 {
+			ranked = append(ranked, PopularQuote{Quote: Quote{ID: id}, Popularity: score})
+		}
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Popularity != ranked[j].Popularity {
+			return ranked[i].Popularity > ranked[j].Popularity
+		}
+		return ranked[i].ID < ranked[j].ID
+	})
+	if limit > 0 && len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	res := ranked[:0]
+	for _, p := range ranked {
+		q, err := s.repo.GetByID(p.ID)
+		// Цитату могли удалить между чтением реакций и чтением цитаты
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		p.Quote = q
+		res = append(res, p)
+	}
+	return res, nil
+}
+
+// reactionValue возвращает вклад реакции в популярность до учёта затухания.
+func reactionValue(re Reaction) float64 {
+	if re.Kind == ReactionRating {
+		return float64(re.Value-3) / 2
+	}
+	return 1
+}
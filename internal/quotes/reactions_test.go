@@ -0,0 +1,197 @@
+// Тесты для лайков, оценок и рейтинга популярных цитат
+package quotes
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// TestReactionsBackends проверяет дедупликацию лайков и пересчёт оценок в обоих бэкендах
+func TestReactionsBackends(t *testing.T) {
+	for name, repo := range testBackends(t) {
+		repo.Create(Quote{Author: "A", Text: "a"})
+		svc := NewService(repo)
+
+		svc.Like(1, "alice")
+		q, err := svc.Like(1, "alice")
+		if err != nil || q.Likes != 1 {
+			t.Errorf("%s: повторный лайк не должен увеличивать счётчик: %+v, %v", name, q, err)
+		}
+		q, _ = svc.Like(1, "bob")
+		if q.Likes != 2 {
+			t.Errorf("%s: ожидается 2 лайка, получено %d", name, q.Likes)
+		}
+		q, _ = svc.Unlike(1, "alice")
+		if q.Likes != 1 {
+			t.Errorf("%s: ожидается 1 лайк после снятия, получено %d", name, q.Likes)
+		}
+
+		svc.Rate(1, "alice", 2)
+		svc.Rate(1, "alice", 5) // заменяет предыдущую оценку
+		q, _ = svc.Rate(1, "bob", 4)
+		if q.Ratings != 2 || q.Rating != 4.5 {
+			t.Errorf("%s: ожидается 2 оценки со средним 4.5, получено %d и %v", name, q.Ratings, q.Rating)
+		}
+		if stored, _ := repo.GetByID(1); stored.Likes != 1 || stored.Ratings != 2 {
+			t.Errorf("%s: агрегаты не сохранены в репозитории: %+v", name, stored)
+		}
+
+		if _, err := svc.Rate(1, "alice", 6); !errors.Is(err, ErrInvalidRating) {
+			t.Errorf("%s: ожидается ErrInvalidRating, получено %v", name, err)
+		}
+		if _, err := svc.Like(100, "alice"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: ожидается ErrNotFound, получено %v", name, err)
+		}
+		if _, err := svc.Like(1, ""); !errors.Is(err, ErrNoClient) {
+			t.Errorf("%s: ожидается ErrNoClient, получено %v", name, err)
+		}
+
+		repo.Delete(1)
+		if rs, _ := repo.ReactionsSince(time.Time{}); len(rs) != 0 {
+			t.Errorf("%s: реакции удалённой цитаты должны удаляться, осталось %d", name, len(rs))
+		}
+	}
+}
+
+// TestLikesAffectWeight проверяет, что лайки увеличивают вес цитаты без оценки редактора
+func TestLikesAffectWeight(t *testing.T) {
+	repo := openTestSQLite(t, ":memory:")
+	repo.Create(Quote{Author: "A", Text: "a"})
+	repo.Create(Quote{Author: "B", Text: "b"})
+	svc := NewService(repo)
+	for i := 0; i < 98; i++ {
+		svc.Like(2, strings.Repeat("c", i+1))
+	}
+	hits := 0
+	rnd := NewSeededRand(5)
+	for i := 0; i < 500; i++ {
+		if q, _ := repo.GetWeightedRandomWith(rnd); q.ID == 2 {
+			hits++
+		}
+	}
+	// Вес цитаты 2 — 99 из 100
+	if hits < 470 {
+		t.Errorf("ожидается около 495 выборов цитаты с лайками, получено %d", hits)
+	}
+}
+
+// TestPopularDecay проверяет, что свежая высоко оценённая цитата обгоняет старую с большим числом лайков
+func TestPopularDecay(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	for name, repo := range testBackends(t) {
+		for _, text := range []string{"старая", "новая", "непопулярная", "забытая"} {
+			repo.Create(Quote{Author: "A", Text: text})
+		}
+		react := func(id int, kind, client string, value int, age time.Duration) {
+			repo.SetReaction(Reaction{QuoteID: id, Kind: kind, Client: client, Value: value, CreatedAt: now.Add(-age)})
+		}
+		// Старая: три лайка пятидневной давности
+		for _, c := range []string{"a", "b", "c"} {
+			react(1, ReactionLike, c, 1, 5*24*time.Hour)
+		}
+		// Новая: лайк и пять звёзд час назад
+		react(2, ReactionLike, "a", 1, time.Hour)
+		react(2, ReactionRating, "a", 5, time.Hour)
+		// Непопулярная: одна звезда
+		react(3, ReactionRating, "b", 1, time.Hour)
+		// Забытая: лайк за пределами окна
+		react(4, ReactionLike, "a", 1, 30*24*time.Hour)
+
+		svc := NewService(repo, WithClock(fixedClock(now)))
+		list, err := svc.Popular(7*24*time.Hour, 10)
+		if err != nil {
+			t.Fatalf("%s: ошибка Popular: %v", name, err)
+		}
+		if len(list) != 2 || list[0].ID != 2 || list[1].ID != 1 {
+			t.Errorf("%s: ожидается порядок [2 1], получено %+v", name, list)
+		}
+		if list, _ := svc.Popular(7*24*time.Hour, 1); len(list) != 1 {
+			t.Errorf("%s: limit не применён: %+v", name, list)
+		}
+	}
+}
+
+// TestReactionHandlers проверяет эндпоинты лайков, оценок и популярных цитат
+func TestReactionHandlers(t *testing.T) {
+	repo := NewMemoryRepository()
+	repo.Create(Quote{Author: "A", Text: "a"})
+	r := mux.NewRouter()
+	RegisterHandlers(r, NewService(repo), nil)
+
+	const alice = "10.0.0.1:5000"
+	do := func(method, path, body, addr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if addr != "" {
+			req.RemoteAddr = addr
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	do(http.MethodPost, "/quotes/1/like", "", alice)
+	w := do(http.MethodPost, "/quotes/1/like", "", alice)
+	var q Quote
+	json.Unmarshal(w.Body.Bytes(), &q)
+	if w.Code != http.StatusOK || q.Likes != 1 {
+		t.Errorf("ожидается 200 и один лайк, получено %d и %+v", w.Code, q)
+	}
+	// Заголовок X-Client-ID не позволяет выдать себя за другого клиента
+	req := httptest.NewRequest(http.MethodPost, "/quotes/1/like", nil)
+	req.RemoteAddr = "10.0.0.1:5001"
+	req.Header.Set("X-Client-ID", "mallory")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	json.Unmarshal(w.Body.Bytes(), &q)
+	if q.Likes != 1 {
+		t.Errorf("лайк с того же IP под другим X-Client-ID не должен учитываться, получено %+v", q)
+	}
+	w = do(http.MethodPost, "/quotes/1/like", "", "10.0.0.2:5000")
+	json.Unmarshal(w.Body.Bytes(), &q)
+	if q.Likes != 2 {
+		t.Errorf("ожидается второй лайк от клиента с другим IP, получено %+v", q)
+	}
+	if w := do(http.MethodDelete, "/quotes/1/like", "", alice); w.Code != http.StatusOK {
+		t.Errorf("ожидается 200 при снятии лайка, получен %d", w.Code)
+	}
+	if w := do(http.MethodPut, "/quotes/1/rating", `{"stars":5}`, alice); w.Code != http.StatusOK {
+		t.Errorf("ожидается 200 при оценке, получен %d", w.Code)
+	}
+	if w := do(http.MethodPut, "/quotes/1/rating", `{"stars":0}`, alice); w.Code != http.StatusBadRequest {
+		t.Errorf("ожидается 400 для оценки вне диапазона, получен %d", w.Code)
+	}
+	if w := do(http.MethodPost, "/quotes/7/like", "", alice); w.Code != http.StatusNotFound {
+		t.Errorf("ожидается 404 для несуществующей цитаты, получен %d", w.Code)
+	}
+
+	w = do(http.MethodGet, "/quotes/popular?window=7d", "", "")
+	var list []PopularQuote
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || len(list) != 1 || list[0].ID != 1 {
+		t.Errorf("ожидается одна популярная цитата, получено %s", w.Body.String())
+	}
+	for _, bad := range []string{"window=abc", "window=-1d", "limit=0"} {
+		if w := do(http.MethodGet, "/quotes/popular?"+bad, "", ""); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: ожидается 400, получен %d", bad, w.Code)
+		}
+	}
+}
+
+// TestParseWindow проверяет разбор окна популярности
+func TestParseWindow(t *testing.T) {
+	cases := map[string]time.Duration{"7d": 7 * 24 * time.Hour, "12h": 12 * time.Hour, "90m": 90 * time.Minute}
+	for raw, want := range cases {
+		if got, err := parseWindow(raw); err != nil || got != want {
+			t.Errorf("%s: ожидается %v, получено %v, %v", raw, want, got, err)
+		}
+	}
+	if _, err := parseWindow("0d"); err == nil {
+		t.Error("ожидается ошибка для нулевого окна")
+	}
+}
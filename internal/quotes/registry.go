@@ -0,0 +1,122 @@
+package quotes
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DSN — разобранный адрес хранилища вида scheme://path?param=value, например memory://,
+// sqlite:///var/lib/quotes.db?_journal=WAL или bolt:///data/q.bolt. Path — всё между "://" и "?"
+// без раскодирования, поэтому относительный путь пишется как sqlite://quotes.db.
+type DSN struct {
+	Scheme string
+	Path   string
+	Params url.Values
+}
+
+// Factory открывает репозиторий бэкенда по адресу. Фабрика проверяет параметры адреса
+// и возвращает ошибку для неизвестных параметров и некорректных значений.
+type Factory func(dsn DSN, opts ...RepoOption) (Repository, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[string]Factory)
+)
+
+// Register регистрирует бэкенд хранения под схемой scheme. Как и sql.Register, паникует
+// при повторной регистрации схемы: это ошибка в программе, а не в конфигурации.
+func Register(scheme string, f Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	if _, dup := factories[scheme]; dup {
+		panic("quotes: бэкенд " + scheme + " зарегистрирован дважды")
+	}
+	factories[scheme] = f
+}
+
+// Schemes возвращает схемы зарегистрированных бэкендов по алфавиту.
+func Schemes() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+	schemes := make([]string, 0, len(factories))
+	for s := range factories {
+		schemes = append(schemes, s)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// ParseDSN разбирает адрес хранилища. Схема обязательна; параметры после "?" разбираются как в URL.
+func ParseDSN(s string) (DSN, error) {
+	scheme, rest, ok := strings.Cut(s, "://")
+	if !ok || scheme == "" {
+		return DSN{}, fmt.Errorf("адрес хранилища %q: ожидается вид scheme://path, например memory:// или sqlite:///var/lib/quotes.db", s)
+	}
+	path, query, _ := strings.Cut(rest, "?")
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return DSN{}, fmt.Errorf("адрес хранилища %q: %w", s, err)
+	}
+	return DSN{Scheme: strings.ToLower(scheme), Path: path, Params: params}, nil
+}
+
+// Open открывает репозиторий бэкенда, выбранного по схеме адреса dsn.
+func Open(dsn string, opts ...RepoOption) (Repository, error) {
+	d, err := ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	factoriesMu.RLock()
+	f, ok := factories[d.Scheme]
+	factoriesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("неизвестный бэкенд хранения %q (ожидается %s)", d.Scheme, strings.Join(Schemes(), ", "))
+	}
+	repo, err := f(d, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", d.Scheme, err)
+	}
+	return repo, nil
+}
+
+// allow проверяет, что в адресе нет параметров, кроме names, и что каждый задан один раз.
+func (d DSN) allow(names ...string) error {
+	for name, values := range d.Params {
+		if !slices.Contains(names, name) {
+			if len(names) == 0 {
+				return fmt.Errorf("неизвестный параметр %q: бэкенд %s не принимает параметров", name, d.Scheme)
+			}
+			return fmt.Errorf("неизвестный параметр %q (ожидается %s)", name, strings.Join(names, ", "))
+		}
+		if len(values) > 1 {
+			return fmt.Errorf("параметр %q задан несколько раз", name)
+		}
+	}
+	return nil
+}
+
+// duration возвращает параметр name как длительность; без параметра — def.
+func (d DSN) duration(name string, def time.Duration) (time.Duration, error) {
+	raw := d.Params.Get(name)
+	if raw == "" {
+		return def, nil
+	}
+	v, err := time.ParseDuration(raw)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("параметр %s: ожидается неотрицательная длительность, например 1s, получено %q", name, raw)
+	}
+	return v, nil
+}
+
+// requirePath возвращает ошибку, если в адресе файлового бэкенда нет пути.
+func (d DSN) requirePath() error {
+	if d.Path == "" {
+		return fmt.Errorf("в адресе нужен путь к файлу, например %s:///var/lib/quotes/quotes.%s", d.Scheme, d.Scheme)
+	}
+	return nil
+}
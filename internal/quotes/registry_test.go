@@ -0,0 +1,100 @@
+package quotes
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestParseDSN проверяет разбор адреса хранилища на схему, путь и параметры
+func TestParseDSN(t *testing.T) {
+	cases := []struct {
+		in, scheme, path, params string
+	}{
+		{"memory://", "memory", "", ""},
+		{"sqlite:///var/lib/quotes.db?_journal=WAL", "sqlite", "/var/lib/quotes.db", "_journal=WAL"},
+		{"sqlite://:memory:", "sqlite", ":memory:", ""},
+		{"SQLite://quotes.db", "sqlite", "quotes.db", ""},
+		{"bolt:///data/q.bolt?timeout=5s", "bolt", "/data/q.bolt", "timeout=5s"},
+	}
+	for _, c := range cases {
+		d, err := ParseDSN(c.in)
+		if err != nil || d.Scheme != c.scheme || d.Path != c.path || d.Params.Encode() != c.params {
+			t.Errorf("%s: получено %+v, %v", c.in, d, err)
+		}
+	}
+	for _, in := range []string{"", "quotes.db", "://x", "sqlite:///q.db?a=%zz"} {
+		if _, err := ParseDSN(in); err == nil {
+			t.Errorf("%q: ожидается ошибка разбора", in)
+		}
+	}
+}
+
+// TestOpenDSN проверяет выбор бэкенда по схеме и отказ для неизвестных схем и параметров
+func TestOpenDSN(t *testing.T) {
+	dir := t.TempDir()
+	for _, dsn := range []string{
+		"memory://",
+		"memory://" + filepath.Join(dir, "wal") + "?fsync=always&snapshot_interval=0s",
+		"sqlite://:memory:",
+		"sqlite://" + filepath.Join(dir, "quotes.db") + "?_journal=wal&_busy_timeout=5000&_fk=true&_sync=NORMAL",
+		"bolt://" + filepath.Join(dir, "quotes.bolt") + "?timeout=2s",
+	} {
+		repo, err := Open(dsn)
+		if err != nil {
+			t.Errorf("%s: ошибка открытия: %v", dsn, err)
+			continue
+		}
+		if _, err := repo.Create(Quote{Author: "A", Text: "a"}); err != nil {
+			t.Errorf("%s: ошибка создания цитаты: %v", dsn, err)
+		}
+		repo.Close()
+	}
+
+	for dsn, want := range map[string]string{
+		"mongodb://localhost/quotes":         "неизвестный бэкенд",
+		"memory://?fsync=always":             "требуют каталога",
+		"memory:///tmp/q?fsync=sometimes":    "fsync",
+		"memory:///tmp/q?fsync_interval=x":   "fsync_interval",
+		"sqlite://":                          "нужен путь",
+		"sqlite://:memory:?_journal=fast":    "_journal",
+		"sqlite://:memory:?_busy_timeout=-1": "_busy_timeout",
+		"sqlite://:memory:?_cache_size=10":   "неизвестный параметр",
+		"sqlite://:memory:?mode=rx":          "mode",
+		"sqlite://:memory:?_fk=1&_fk=0":      "несколько раз",
+		"bolt://":                            "нужен путь",
+		"bolt:///tmp/q.bolt?sync=1":          "неизвестный параметр",
+	} {
+		if _, err := Open(dsn); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: ожидается ошибка с %q, получено %v", dsn, want, err)
+		}
+	}
+}
+
+// TestOpenDSNPragmas проверяет, что параметры адреса применяются к соединениям базы
+func TestOpenDSNPragmas(t *testing.T) {
+	repo, err := Open("sqlite://" + filepath.Join(t.TempDir(), "quotes.db") + "?_journal=WAL&_busy_timeout=2500")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	db := repo.(*SQLiteRepo).DB()
+	var mode string
+	var timeout int
+	if err := db.QueryRow("PRAGMA journal_mode").Scan(&mode); err != nil || mode != "wal" {
+		t.Errorf("ожидается journal_mode wal, получено %q, %v", mode, err)
+	}
+	if err := db.QueryRow("PRAGMA busy_timeout").Scan(&timeout); err != nil || timeout != 2500 {
+		t.Errorf("ожидается busy_timeout 2500, получено %d, %v", timeout, err)
+	}
+}
+
+// TestRegisterDuplicate проверяет панику при повторной регистрации схемы
+func TestRegisterDuplicate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("повторная регистрация схемы должна паниковать")
+		}
+	}()
+	Register("memory", openMemoryDSN)
+}
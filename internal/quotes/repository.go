@@ -1,24 +1,144 @@
 package quotes
 
+import (
+	"errors"
+	"time"
+
+	"Test_Project_Brand_Scout/internal/auth"
+)
+
+// ErrNotFound возвращается, если запрошенная запись не найдена.
+var ErrNotFound = errors.New("нет такой цитаты")
+
+// ErrEmpty возвращается, если в хранилище нет ни одной цитаты.
+var ErrEmpty = errors.New("нету доступных цитат")
+
+// ErrQuotaExceeded возвращается, если в рабочем пространстве уже достигнут лимит цитат.
+var ErrQuotaExceeded = errors.New("превышена квота цитат рабочего пространства")
+
 // Quote представляет цитату с ID, автором и текстом.
 // Поле ID содержит уникальный идентификатор, Author — автора цитаты, Text — сам текст цитаты.
+// Score — необязательная оценка редактора, задающая вес цитаты при взвешенном выборе.
+// Likes, Ratings и Rating — агрегаты реакций клиентов, которые поддерживает репозиторий.
+// Tenant — рабочее пространство, которому принадлежит цитата; его задаёт репозиторий.
 type Quote struct {
-	ID     int    `json:"id"`
-	Author string `json:"author"`
-	Text   string `json:"quote"`
+	ID      int     `json:"id"`
+	Tenant  string  `json:"tenant,omitempty"`
+	Author  string  `json:"author"`
+	Text    string  `json:"quote"`
+	Score   int     `json:"score,omitempty"`
+	Likes   int     `json:"likes,omitempty"`
+	Ratings int     `json:"ratings,omitempty"` // число оценок
+	Rating  float64 `json:"rating,omitempty"`  // средняя оценка от 1 до 5
+}
+
+// Виды реакций клиентов на цитату.
+const (
+	ReactionLike   = "like"
+	ReactionRating = "rating"
+)
+
+// Reaction — реакция одного клиента на цитату: лайк или оценка в звёздах.
+// На каждую пару (цитата, вид реакции) у клиента хранится не больше одной записи.
+type Reaction struct {
+	QuoteID   int
+	Kind      string
+	Client    string
+	Value     int // 1 для лайка, число звёзд для оценки
+	CreatedAt time.Time
+}
+
+// DailyPick описывает цитату, закреплённую за календарным днём.
+// Date хранится в формате YYYY-MM-DD, Pinned означает ручной выбор редактора.
+type DailyPick struct {
+	Date    string
+	QuoteID int
+	Pinned  bool
+}
+
+// ViewBucket — число показов цитаты за один час.
+type ViewBucket struct {
+	Hour    time.Time
+	QuoteID int
+	Views   int64
+}
+
+// RequestBucket — число запросов к маршруту за один час.
+type RequestBucket struct {
+	Hour     time.Time
+	Route    string
+	Requests int64
 }
 
 // Repository описывает набор операций для хранения и получения цитат.
 // Реализации могут хранить данные в памяти, базе данных и т.д.
+// Все операции с цитатами, реакциями, показами и цитатой дня ограничены рабочим пространством
+// репозитория: конструкторы возвращают пространство DefaultTenant, другие открываются через Tenant.
+// ID цитат уникальны во всём хранилище, поэтому цитата чужого пространства просто не находится.
 type Repository interface {
+	// Tenant возвращает репозиторий того же хранилища, ограниченный рабочим пространством id.
+	Tenant(id string) Repository
 	// Create сохраняет новую цитату и возвращает её ID или ошибку.
 	Create(q Quote) (int, error)
-	// GetAll возвращает все сохранённые цитаты или ошибку.
+	// CreateLimited сохраняет цитату, только если в пространстве меньше limit цитат,
+	// иначе возвращает ErrQuotaExceeded. Проверка и вставка атомарны; limit <= 0 снимает ограничение.
+	CreateLimited(q Quote, limit int) (int, error)
+	// QuoteCounts возвращает число цитат во всех непустых рабочих пространствах хранилища.
+	QuoteCounts() (map[string]int, error)
+	// GetAll возвращает все сохранённые цитаты в порядке ID или ошибку.
 	GetAll() ([]Quote, error)
+	// GetByID возвращает цитату по ID или ErrNotFound.
+	GetByID(id int) (Quote, error)
 	// GetRandom возвращает случайную цитату или ошибку, если нечего вернуть.
 	GetRandom() (Quote, error)
-	// FilterByAuthor возвращает цитаты указанного автора.
+	// GetRandomWith возвращает случайную цитату, выбранную с помощью rnd.
+	// Цитаты упорядочиваются по ID, поэтому одинаковый источник даёт одинаковый результат в любом бэкенде.
+	GetRandomWith(rnd Rand) (Quote, error)
+	// GetWeightedRandomWith возвращает цитату с вероятностью, пропорциональной её весу.
+	GetWeightedRandomWith(rnd Rand) (Quote, error)
+	// SetScore задаёт оценку редактора для цитаты или возвращает ErrNotFound.
+	SetScore(id, score int) error
+	// FilterByAuthor возвращает цитаты указанного автора в порядке ID.
 	FilterByAuthor(author string) ([]Quote, error)
+	// Page возвращает в порядке ID не больше limit цитат с ID больше after, только автора author,
+	// если он задан; limit 0 снимает ограничение. Страница читается без загрузки всего пространства.
+	Page(author string, after, limit int) ([]Quote, error)
 	// Delete удаляет цитату по ID, возвращает ошибку, если не найдена.
 	Delete(id int) error
+
+	// SetReaction сохраняет реакцию клиента и пересчитывает агрегаты цитаты.
+	// Повторный лайк не меняет запись, повторная оценка заменяет предыдущую.
+	// Возвращает обновлённую цитату или ErrNotFound.
+	SetReaction(r Reaction) (Quote, error)
+	// DeleteReaction удаляет реакцию клиента и возвращает обновлённую цитату или ErrNotFound.
+	DeleteReaction(quoteID int, kind, client string) (Quote, error)
+	// ReactionsSince возвращает реакции, созданные не раньше since.
+	ReactionsSince(since time.Time) ([]Reaction, error)
+
+	// AddViews прибавляет накопленные часовые счётчики показов и запросов к сохранённым.
+	AddViews(views []ViewBucket, requests []RequestBucket) error
+	// ViewsSince возвращает часовые счётчики показов начиная с часа since.
+	ViewsSince(since time.Time) ([]ViewBucket, error)
+	// RequestsSince возвращает часовые счётчики запросов начиная с часа since.
+	RequestsSince(since time.Time) ([]RequestBucket, error)
+
+	// Ключи API хранятся в том же хранилище, что и цитаты, и общие для всех пространств:
+	// привязка ключа к пространству записана в самом ключе.
+	auth.KeyStore
+
+	// GetDailyPick возвращает цитату дня для даты или ErrNotFound, если выбор ещё не сделан.
+	GetDailyPick(date string) (DailyPick, error)
+	// SaveDailyPick сохраняет автоматический выбор, только если для даты ещё нет записи,
+	// и возвращает действующую запись. Так несколько экземпляров сходятся к одному выбору.
+	SaveDailyPick(p DailyPick) (DailyPick, error)
+	// PinDailyPick закрепляет цитату за датой, перезаписывая автоматический выбор.
+	PinDailyPick(p DailyPick) error
+	// DeleteDailyPick удаляет запись о цитате дня для даты.
+	DeleteDailyPick(date string) error
+	// DailyPicksBetween возвращает записи за даты в диапазоне [from, to).
+	DailyPicksBetween(from, to string) ([]DailyPick, error)
+
+	// Close освобождает ресурсы хранилища, например соединения с базой. Репозитории пространств,
+	// полученные через Tenant, используют те же ресурсы, поэтому хранилище закрывается один раз.
+	Close() error
 }
@@ -0,0 +1,913 @@
+package quotes
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"Test_Project_Brand_Scout/internal/auth"
+)
+
+// boltSchemaVersion — версия раскладки бакетов, которую понимает эта версия сервиса.
+const boltSchemaVersion = 2
+
+// Бакеты верхнего уровня и вложенные бакеты пространства.
+var (
+	bucketMeta    = []byte("meta")
+	bucketQuotes  = []byte("quotes")  // ID -> цитата в JSON; последовательность бакета выдаёт ID
+	bucketTenants = []byte("tenants") // имя пространства -> бакет пространства
+	bucketKeys    = []byte("keys")    // ID -> ключ API в JSON; последовательность бакета выдаёт ID
+	bucketHashes  = []byte("key_hashes")
+
+	bucketIDs       = []byte("ids")     // ID -> пусто: цитаты пространства в порядке ID
+	bucketAuthors   = []byte("authors") // автор 0x00 ID -> пусто: вторичный индекс по автору
+	bucketBlocks    = []byte("blocks")  // номер блока ID -> число цитат в блоке, для случайного выбора
+	bucketReactions = []byte("reactions")
+	bucketDaily     = []byte("daily")
+	bucketViews     = []byte("views")
+	bucketRequests  = []byte("requests")
+	// Индексы записей цитаты, чтобы удаление цитаты не просматривало все показы и цитаты дня
+	bucketViewIndex  = []byte("quote_views") // ID, час -> пусто: часы, за которые у цитаты есть показы
+	bucketDailyIndex = []byte("quote_daily") // ID, дата -> пусто: даты, за которыми записана цитата
+
+	keyVersion = []byte("version")
+	keyCount   = []byte("count") // число цитат пространства
+)
+
+var tenantBuckets = [][]byte{bucketIDs, bucketAuthors, bucketBlocks, bucketReactions, bucketDaily, bucketViews, bucketRequests, bucketViewIndex, bucketDailyIndex}
+
+// sampleBlockBits задаёт размер блока индекса случайного выбора: 1024 последовательных ID.
+const sampleBlockBits = 10
+
+// BoltRepo реализует хранение цитат одного рабочего пространства в файле bbolt.
+// Цитаты всех пространств лежат в одном бакете по ID, а у каждого пространства есть свой бакет
+// с упорядоченным списком ID, индексом по автору, реакциями, показами и цитатами дня.
+// Для равномерного выбора пространство хранит число цитат в каждом блоке из 1024 ID: k-я по порядку
+// цитата находится проходом по счётчикам блоков и коротким проходом курсора внутри блока,
+// поэтому выбор совпадает с другими бэкендами и не читает весь список.
+// Файл bbolt открывается одним процессом, поэтому индекс весов ведётся в памяти инкрементально.
+type BoltRepo struct {
+	db     *bolt.DB
+	rnd    Rand
+	tenant string
+	shared *boltShared
+	idx    *boltIndex
+}
+
+// boltShared хранит индексы весов всех пространств файла.
+type boltShared struct {
+	mu      sync.Mutex
+	indexes map[string]*boltIndex
+}
+
+// boltIndex — индекс весов пространства; nil в weights означает, что его нужно построить из файла.
+type boltIndex struct {
+	mu      sync.Mutex
+	weights *weightIndex
+}
+
+func init() {
+	Register("bolt", openBoltDSN)
+}
+
+// openBoltDSN открывает файл bbolt по адресу bolt:///path; параметр timeout задаёт, сколько ждать
+// файла, занятого другим процессом (по умолчанию 1s, 0 — без ограничения).
+func openBoltDSN(dsn DSN, opts ...RepoOption) (Repository, error) {
+	if err := dsn.allow("timeout"); err != nil {
+		return nil, err
+	}
+	if err := dsn.requirePath(); err != nil {
+		return nil, err
+	}
+	timeout, err := dsn.duration("timeout", time.Second)
+	if err != nil {
+		return nil, err
+	}
+	return openBolt(dsn.Path, timeout, applyRepoOptions(opts))
+}
+
+// OpenBoltRepository открывает или создаёт файл bbolt по пути path и возвращает репозиторий
+// пространства DefaultTenant. Если файл занят другим процессом, через секунду возвращается ошибка.
+func OpenBoltRepository(path string, opts ...RepoOption) (Repository, error) {
+	return openBolt(path, time.Second, applyRepoOptions(opts))
+}
+
+// openBolt открывает файл bbolt, ожидая освобождения занятого файла не дольше timeout.
+func openBolt(path string, timeout time.Duration, o repoOptions) (Repository, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: timeout})
+	if err != nil {
+		return nil, fmt.Errorf("открытие %s: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketMeta, bucketQuotes, bucketTenants, bucketKeys, bucketHashes} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		meta := tx.Bucket(bucketMeta)
+		if v := meta.Get(keyVersion); v != nil {
+			ver := decodeUint(v)
+			if ver > boltSchemaVersion {
+				return fmt.Errorf("версия схемы %d новее поддерживаемой %d", ver, boltSchemaVersion)
+			}
+			if ver < 2 {
+				if err := indexQuoteRecords(tx); err != nil {
+					return fmt.Errorf("миграция схемы до версии 2: %w", err)
+				}
+			}
+		}
+		return meta.Put(keyVersion, encodeUint(boltSchemaVersion))
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	base := &BoltRepo{db: db, rnd: o.rnd, shared: &boltShared{indexes: make(map[string]*boltIndex)}}
+	return base.Tenant(DefaultTenant), nil
+}
+
+// indexQuoteRecords строит индексы показов и цитат дня по ID цитаты в файле версии 1.
+func indexQuoteRecords(tx *bolt.Tx) error {
+	tenants := tx.Bucket(bucketTenants)
+	var names [][]byte
+	if err := tenants.ForEach(func(name, _ []byte) error {
+		names = append(names, slices.Clone(name))
+		return nil
+	}); err != nil {
+		return err
+	}
+	for _, name := range names {
+		b := tenants.Bucket(name)
+		viewIndex, err := b.CreateBucketIfNotExists(bucketViewIndex)
+		if err != nil {
+			return err
+		}
+		dailyIndex, err := b.CreateBucketIfNotExists(bucketDailyIndex)
+		if err != nil {
+			return err
+		}
+		if err := b.Bucket(bucketViews).ForEach(func(k, _ []byte) error {
+			return viewIndex.Put(quoteKey(int(decodeUint(k[8:])), k[:8]), nil)
+		}); err != nil {
+			return err
+		}
+		if err := b.Bucket(bucketDaily).ForEach(func(k, v []byte) error {
+			var p DailyPick
+			if err := json.Unmarshal(v, &p); err != nil {
+				return err
+			}
+			return dailyIndex.Put(quoteKey(p.QuoteID, k), nil)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Tenant возвращает репозиторий пространства id поверх того же файла.
+func (r *BoltRepo) Tenant(id string) Repository {
+	id = normalizeTenant(id)
+	r.shared.mu.Lock()
+	defer r.shared.mu.Unlock()
+	idx, ok := r.shared.indexes[id]
+	if !ok {
+		idx = &boltIndex{}
+		r.shared.indexes[id] = idx
+	}
+	return &BoltRepo{db: r.db, rnd: r.rnd, tenant: id, shared: r.shared, idx: idx}
+}
+
+// Close закрывает файл bbolt.
+func (r *BoltRepo) Close() error {
+	return r.db.Close()
+}
+
+// encodeUint и decodeUint кодируют числа в big-endian, чтобы ключи бакетов сортировались по значению.
+func encodeUint(v uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, v)
+}
+
+func decodeUint(b []byte) uint64 {
+	return binary.BigEndian.Uint64(b)
+}
+
+func itob(id int) []byte { return encodeUint(uint64(id)) }
+
+// authorKey — ключ индекса по автору; 0x00 отделяет автора от ID, чтобы префикс автора не совпал
+// с началом другого имени.
+func authorKey(author string, id int) []byte {
+	return append(append([]byte(author), 0), itob(id)...)
+}
+
+// reactionPrefix — префикс ключей реакций цитаты; reactionBoltKey добавляет вид и клиента.
+func reactionPrefix(id int) []byte { return itob(id) }
+
+func reactionBoltKey(id int, kind, client string) []byte {
+	return append(append(append(reactionPrefix(id), kind...), 0), client...)
+}
+
+// quoteKey — ключ индекса записей цитаты: ID цитаты, затем ключ записи без ID.
+func quoteKey(id int, rest []byte) []byte {
+	return append(itob(id), rest...)
+}
+
+// hourKey — ключ часовых счётчиков: час в секундах Unix, затем ID цитаты или маршрут.
+// Время до 1970 года, например нулевое time.Time в запросе «с начала», даёт наименьший ключ.
+func hourKey(hour time.Time, rest []byte) []byte {
+	return append(encodeUint(uint64(max(hour.Unix(), 0))), rest...)
+}
+
+// tenantBucket возвращает бакет пространства или nil, если в пространстве ещё ничего не сохранялось.
+func (r *BoltRepo) tenantBucket(tx *bolt.Tx) *bolt.Bucket {
+	return tx.Bucket(bucketTenants).Bucket([]byte(r.tenant))
+}
+
+// ensureTenant возвращает бакет пространства, создавая его и вложенные бакеты при первой записи.
+func (r *BoltRepo) ensureTenant(tx *bolt.Tx) (*bolt.Bucket, error) {
+	b, err := tx.Bucket(bucketTenants).CreateBucketIfNotExists([]byte(r.tenant))
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range tenantBuckets {
+		if _, err := b.CreateBucketIfNotExists(name); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// view выполняет чтение в бакете пространства; для пустого пространства fn не вызывается.
+func (r *BoltRepo) view(fn func(tx *bolt.Tx, b *bolt.Bucket) error) error {
+	return r.db.View(func(tx *bolt.Tx) error {
+		b := r.tenantBucket(tx)
+		if b == nil {
+			return nil
+		}
+		return fn(tx, b)
+	})
+}
+
+// update выполняет запись в бакете пространства.
+func (r *BoltRepo) update(fn func(tx *bolt.Tx, b *bolt.Bucket) error) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		b, err := r.ensureTenant(tx)
+		if err != nil {
+			return err
+		}
+		return fn(tx, b)
+	})
+}
+
+// mutate выполняет изменение цитат и после фиксации обновляет индекс весов через apply.
+// Запись в пространство идёт под блокировкой индекса, поэтому индекс меняется в порядке фиксаций.
+func (r *BoltRepo) mutate(fn func(tx *bolt.Tx, b *bolt.Bucket) error, apply func(w *weightIndex)) error {
+	r.idx.mu.Lock()
+	defer r.idx.mu.Unlock()
+	if err := r.update(fn); err != nil {
+		return err
+	}
+	if r.idx.weights != nil {
+		apply(r.idx.weights)
+	}
+	return nil
+}
+
+// getQuote читает цитату пространства по ID или возвращает ErrNotFound.
+func (r *BoltRepo) getQuote(tx *bolt.Tx, id int) (Quote, error) {
+	data := tx.Bucket(bucketQuotes).Get(itob(id))
+	if data == nil {
+		return Quote{}, ErrNotFound
+	}
+	var q Quote
+	if err := json.Unmarshal(data, &q); err != nil {
+		return Quote{}, err
+	}
+	if q.Tenant != r.tenant {
+		return Quote{}, ErrNotFound
+	}
+	return q, nil
+}
+
+func putQuote(tx *bolt.Tx, q Quote) error {
+	data, err := json.Marshal(q)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(bucketQuotes).Put(itob(q.ID), data)
+}
+
+// addCount меняет число цитат пространства и блока, в который попадает id.
+func addCount(b *bolt.Bucket, id int, delta int64) error {
+	count := int64(0)
+	if v := b.Get(keyCount); v != nil {
+		count = int64(decodeUint(v))
+	}
+	if err := b.Put(keyCount, encodeUint(uint64(count+delta))); err != nil {
+		return err
+	}
+	blocks := b.Bucket(bucketBlocks)
+	block := encodeUint(uint64(id) >> sampleBlockBits)
+	n := int64(0)
+	if v := blocks.Get(block); v != nil {
+		n = int64(decodeUint(v))
+	}
+	if n+delta == 0 {
+		return blocks.Delete(block)
+	}
+	return blocks.Put(block, encodeUint(uint64(n+delta)))
+}
+
+// count возвращает число цитат в бакете пространства.
+func count(b *bolt.Bucket) int {
+	if v := b.Get(keyCount); v != nil {
+		return int(decodeUint(v))
+	}
+	return 0
+}
+
+// Create сохраняет новую цитату и возвращает её ID или ошибку.
+func (r *BoltRepo) Create(q Quote) (int, error) {
+	return r.CreateLimited(q, 0)
+}
+
+// CreateLimited сохраняет цитату, если в пространстве меньше limit цитат.
+// Запись в bbolt выполняется одной транзакцией за раз, поэтому проверка и вставка атомарны.
+func (r *BoltRepo) CreateLimited(q Quote, limit int) (int, error) {
+	q.Tenant = r.tenant
+	q.Likes, q.Ratings, q.Rating = 0, 0, 0
+	err := r.mutate(func(tx *bolt.Tx, b *bolt.Bucket) error {
+		if limit > 0 && count(b) >= limit {
+			return ErrQuotaExceeded
+		}
+		seq, err := tx.Bucket(bucketQuotes).NextSequence()
+		if err != nil {
+			return err
+		}
+		q.ID = int(seq)
+		if err := putQuote(tx, q); err != nil {
+			return err
+		}
+		if err := b.Bucket(bucketIDs).Put(itob(q.ID), nil); err != nil {
+			return err
+		}
+		if err := b.Bucket(bucketAuthors).Put(authorKey(q.Author, q.ID), nil); err != nil {
+			return err
+		}
+		return addCount(b, q.ID, 1)
+	}, func(w *weightIndex) {
+		w.set(q.ID, quoteWeight(q))
+	})
+	if err != nil {
+		return 0, err
+	}
+	return q.ID, nil
+}
+
+// QuoteCounts возвращает число цитат во всех непустых пространствах файла.
+func (r *BoltRepo) QuoteCounts() (map[string]int, error) {
+	counts := make(map[string]int)
+	err := r.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketTenants).ForEachBucket(func(name []byte) error {
+			if n := count(tx.Bucket(bucketTenants).Bucket(name)); n > 0 {
+				counts[string(name)] = n
+			}
+			return nil
+		})
+	})
+	return counts, err
+}
+
+// GetAll возвращает цитаты пространства в порядке ID.
+func (r *BoltRepo) GetAll() ([]Quote, error) {
+	var res []Quote
+	err := r.view(func(tx *bolt.Tx, b *bolt.Bucket) error {
+		var err error
+		res, err = r.listQuotes(tx, b)
+		return err
+	})
+	return res, err
+}
+
+// listQuotes читает все цитаты пространства в порядке ID.
+func (r *BoltRepo) listQuotes(tx *bolt.Tx, b *bolt.Bucket) ([]Quote, error) {
+	var res []Quote
+	err := b.Bucket(bucketIDs).ForEach(func(k, _ []byte) error {
+		q, err := r.getQuote(tx, int(decodeUint(k)))
+		if err != nil {
+			return err
+		}
+		res = append(res, q)
+		return nil
+	})
+	return res, err
+}
+
+// GetByID возвращает цитату по ID или ErrNotFound.
+func (r *BoltRepo) GetByID(id int) (Quote, error) {
+	var q Quote
+	err := r.db.View(func(tx *bolt.Tx) error {
+		var err error
+		q, err = r.getQuote(tx, id)
+		return err
+	})
+	return q, err
+}
+
+// GetRandom возвращает случайную цитату или ErrEmpty.
+func (r *BoltRepo) GetRandom() (Quote, error) {
+	return r.GetRandomWith(r.rnd)
+}
+
+// GetRandomWith возвращает k-ю по порядку ID цитату, где k выбирает rnd. Нужный блок находится
+// по счётчикам блоков, внутри блока курсор проходит не больше 1024 записей.
+func (r *BoltRepo) GetRandomWith(rnd Rand) (Quote, error) {
+	q, err := Quote{}, ErrEmpty
+	verr := r.view(func(tx *bolt.Tx, b *bolt.Bucket) error {
+		n := count(b)
+		if n == 0 {
+			return nil
+		}
+		k := rnd.Intn(n)
+		c := b.Bucket(bucketBlocks).Cursor()
+		for block, v := c.First(); block != nil; block, v = c.Next() {
+			inBlock := int(decodeUint(v))
+			if k >= inBlock {
+				k -= inBlock
+				continue
+			}
+			ids := b.Bucket(bucketIDs).Cursor()
+			id, _ := ids.Seek(encodeUint(decodeUint(block) << sampleBlockBits))
+			for ; k > 0; k-- {
+				id, _ = ids.Next()
+			}
+			q, err = r.getQuote(tx, int(decodeUint(id)))
+			return nil
+		}
+		err = errors.New("счётчики блоков не совпадают с числом цитат")
+		return nil
+	})
+	if verr != nil {
+		return Quote{}, verr
+	}
+	return q, err
+}
+
+// GetWeightedRandomWith возвращает цитату с вероятностью, пропорциональной её весу.
+// Индекс весов строится из файла при первом выборе и дальше обновляется при изменениях.
+func (r *BoltRepo) GetWeightedRandomWith(rnd Rand) (Quote, error) {
+	r.idx.mu.Lock()
+	defer r.idx.mu.Unlock()
+	if r.idx.weights == nil {
+		list, err := r.GetAll()
+		if err != nil {
+			return Quote{}, err
+		}
+		r.idx.weights = newWeightIndex(list)
+	}
+	id, ok := r.idx.weights.pick(rnd)
+	if !ok {
+		return Quote{}, ErrEmpty
+	}
+	return r.GetByID(id)
+}
+
+// SetScore задаёт оценку редактора и обновляет вес цитаты в индексе.
+func (r *BoltRepo) SetScore(id, score int) error {
+	var q Quote
+	return r.mutate(func(tx *bolt.Tx, b *bolt.Bucket) error {
+		var err error
+		if q, err = r.getQuote(tx, id); err != nil {
+			return err
+		}
+		q.Score = score
+		return putQuote(tx, q)
+	}, func(w *weightIndex) {
+		w.set(id, quoteWeight(q))
+	})
+}
+
+// FilterByAuthor возвращает цитаты автора в порядке ID по индексу авторов.
+func (r *BoltRepo) FilterByAuthor(author string) ([]Quote, error) {
+	var res []Quote
+	err := r.view(func(tx *bolt.Tx, b *bolt.Bucket) error {
+		prefix := append([]byte(author), 0)
+		c := b.Bucket(bucketAuthors).Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			q, err := r.getQuote(tx, int(decodeUint(k[len(prefix):])))
+			if err != nil {
+				return err
+			}
+			res = append(res, q)
+		}
+		return nil
+	})
+	return res, err
+}
+
+// Page читает страницу цитат курсором по списку ID пространства или, для автора, по индексу авторов,
+// начиная с первого ключа после after.
+func (r *BoltRepo) Page(author string, after, limit int) ([]Quote, error) {
+	var res []Quote
+	err := r.view(func(tx *bolt.Tx, b *bolt.Bucket) error {
+		c, prefix := b.Bucket(bucketIDs).Cursor(), []byte(nil)
+		if author != "" {
+			c, prefix = b.Bucket(bucketAuthors).Cursor(), append([]byte(author), 0)
+		}
+		start := append(slices.Clip(prefix), itob(after+1)...)
+		for k, _ := c.Seek(start); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			if limit > 0 && len(res) == limit {
+				break
+			}
+			q, err := r.getQuote(tx, int(decodeUint(k[len(prefix):])))
+			if err != nil {
+				return err
+			}
+			res = append(res, q)
+		}
+		return nil
+	})
+	return res, err
+}
+
+// Delete удаляет цитату вместе с её реакциями, показами и записями о цитате дня.
+// Записи цитаты находятся по префиксу её ID, поэтому остальные показы и даты не читаются.
+func (r *BoltRepo) Delete(id int) error {
+	return r.mutate(func(tx *bolt.Tx, b *bolt.Bucket) error {
+		q, err := r.getQuote(tx, id)
+		if err != nil {
+			return err
+		}
+		if err := tx.Bucket(bucketQuotes).Delete(itob(id)); err != nil {
+			return err
+		}
+		if err := b.Bucket(bucketIDs).Delete(itob(id)); err != nil {
+			return err
+		}
+		if err := b.Bucket(bucketAuthors).Delete(authorKey(q.Author, id)); err != nil {
+			return err
+		}
+		if err := addCount(b, id, -1); err != nil {
+			return err
+		}
+		if _, err := deletePrefix(b.Bucket(bucketReactions), reactionPrefix(id)); err != nil {
+			return err
+		}
+		hours, err := deletePrefix(b.Bucket(bucketViewIndex), itob(id))
+		if err != nil {
+			return err
+		}
+		for _, hour := range hours {
+			if err := b.Bucket(bucketViews).Delete(append(hour, itob(id)...)); err != nil {
+				return err
+			}
+		}
+		dates, err := deletePrefix(b.Bucket(bucketDailyIndex), itob(id))
+		if err != nil {
+			return err
+		}
+		for _, date := range dates {
+			if err := b.Bucket(bucketDaily).Delete(date); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, func(w *weightIndex) {
+		w.remove(id)
+	})
+}
+
+// deletePrefix удаляет из бакета записи с префиксом prefix и возвращает их ключи без префикса.
+func deletePrefix(b *bolt.Bucket, prefix []byte) ([][]byte, error) {
+	var rest [][]byte
+	c := b.Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		rest = append(rest, slices.Clone(k[len(prefix):]))
+	}
+	for _, k := range rest {
+		if err := b.Delete(append(slices.Clone(prefix), k...)); err != nil {
+			return nil, err
+		}
+	}
+	return rest, nil
+}
+
+// SetReaction сохраняет реакцию клиента и пересчитывает агрегаты цитаты.
+func (r *BoltRepo) SetReaction(re Reaction) (Quote, error) {
+	return r.react(re.QuoteID, func(reactions *bolt.Bucket) error {
+		key := reactionBoltKey(re.QuoteID, re.Kind, re.Client)
+		// Повторный лайк сохраняет исходное время, чтобы не продлевать его вклад в популярность
+		if re.Kind == ReactionLike && reactions.Get(key) != nil {
+			return nil
+		}
+		data, err := json.Marshal(re)
+		if err != nil {
+			return err
+		}
+		return reactions.Put(key, data)
+	})
+}
+
+// DeleteReaction удаляет реакцию клиента и пересчитывает агрегаты цитаты.
+func (r *BoltRepo) DeleteReaction(quoteID int, kind, client string) (Quote, error) {
+	return r.react(quoteID, func(reactions *bolt.Bucket) error {
+		return reactions.Delete(reactionBoltKey(quoteID, kind, client))
+	})
+}
+
+// react изменяет реакции цитаты через change, пересчитывает её агрегаты и вес.
+func (r *BoltRepo) react(id int, change func(reactions *bolt.Bucket) error) (Quote, error) {
+	var q Quote
+	err := r.mutate(func(tx *bolt.Tx, b *bolt.Bucket) error {
+		var err error
+		if q, err = r.getQuote(tx, id); err != nil {
+			return err
+		}
+		reactions := b.Bucket(bucketReactions)
+		if err := change(reactions); err != nil {
+			return err
+		}
+		q.Likes, q.Ratings, q.Rating = 0, 0, 0
+		sum := 0
+		c := reactions.Cursor()
+		prefix := reactionPrefix(id)
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var re Reaction
+			if err := json.Unmarshal(v, &re); err != nil {
+				return err
+			}
+			switch re.Kind {
+			case ReactionLike:
+				q.Likes++
+			case ReactionRating:
+				q.Ratings++
+				sum += re.Value
+			}
+		}
+		if q.Ratings > 0 {
+			q.Rating = float64(sum) / float64(q.Ratings)
+		}
+		return putQuote(tx, q)
+	}, func(w *weightIndex) {
+		w.set(id, quoteWeight(q))
+	})
+	return q, err
+}
+
+// ReactionsSince возвращает реакции пространства, созданные не раньше since.
+func (r *BoltRepo) ReactionsSince(since time.Time) ([]Reaction, error) {
+	var res []Reaction
+	err := r.view(func(tx *bolt.Tx, b *bolt.Bucket) error {
+		return b.Bucket(bucketReactions).ForEach(func(_, v []byte) error {
+			var re Reaction
+			if err := json.Unmarshal(v, &re); err != nil {
+				return err
+			}
+			if !re.CreatedAt.Before(since) {
+				res = append(res, re)
+			}
+			return nil
+		})
+	})
+	return res, err
+}
+
+// AddViews прибавляет часовые счётчики к сохранённым; показы удалённых цитат отбрасываются.
+func (r *BoltRepo) AddViews(views []ViewBucket, requests []RequestBucket) error {
+	return r.update(func(tx *bolt.Tx, b *bolt.Bucket) error {
+		for _, v := range views {
+			if _, err := r.getQuote(tx, v.QuoteID); err != nil {
+				continue
+			}
+			if err := addCounter(b.Bucket(bucketViews), hourKey(v.Hour, itob(v.QuoteID)), v.Views); err != nil {
+				return err
+			}
+			if err := b.Bucket(bucketViewIndex).Put(quoteKey(v.QuoteID, hourKey(v.Hour, nil)), nil); err != nil {
+				return err
+			}
+		}
+		for _, q := range requests {
+			if err := addCounter(b.Bucket(bucketRequests), hourKey(q.Hour, []byte(q.Route)), q.Requests); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// addCounter прибавляет n к счётчику по ключу key.
+func addCounter(b *bolt.Bucket, key []byte, n int64) error {
+	if v := b.Get(key); v != nil {
+		n += int64(decodeUint(v))
+	}
+	return b.Put(key, encodeUint(uint64(n)))
+}
+
+// ViewsSince возвращает часовые счётчики показов начиная с часа since.
+func (r *BoltRepo) ViewsSince(since time.Time) ([]ViewBucket, error) {
+	var res []ViewBucket
+	err := r.view(func(tx *bolt.Tx, b *bolt.Bucket) error {
+		c := b.Bucket(bucketViews).Cursor()
+		for k, v := c.Seek(hourKey(since, nil)); k != nil; k, v = c.Next() {
+			res = append(res, ViewBucket{
+				Hour:    time.Unix(int64(decodeUint(k[:8])), 0).UTC(),
+				QuoteID: int(decodeUint(k[8:])),
+				Views:   int64(decodeUint(v)),
+			})
+		}
+		return nil
+	})
+	return res, err
+}
+
+// RequestsSince возвращает часовые счётчики запросов начиная с часа since.
+func (r *BoltRepo) RequestsSince(since time.Time) ([]RequestBucket, error) {
+	var res []RequestBucket
+	err := r.view(func(tx *bolt.Tx, b *bolt.Bucket) error {
+		c := b.Bucket(bucketRequests).Cursor()
+		for k, v := c.Seek(hourKey(since, nil)); k != nil; k, v = c.Next() {
+			res = append(res, RequestBucket{
+				Hour:     time.Unix(int64(decodeUint(k[:8])), 0).UTC(),
+				Route:    string(k[8:]),
+				Requests: int64(decodeUint(v)),
+			})
+		}
+		return nil
+	})
+	return res, err
+}
+
+// GetDailyPick возвращает цитату дня для даты или ErrNotFound.
+func (r *BoltRepo) GetDailyPick(date string) (DailyPick, error) {
+	var p DailyPick
+	found := false
+	err := r.view(func(tx *bolt.Tx, b *bolt.Bucket) error {
+		v := b.Bucket(bucketDaily).Get([]byte(date))
+		if v == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(v, &p)
+	})
+	if err == nil && !found {
+		err = ErrNotFound
+	}
+	return p, err
+}
+
+// SaveDailyPick сохраняет выбор, если для даты ещё нет записи, и возвращает действующую запись.
+func (r *BoltRepo) SaveDailyPick(p DailyPick) (DailyPick, error) {
+	err := r.update(func(tx *bolt.Tx, b *bolt.Bucket) error {
+		daily := b.Bucket(bucketDaily)
+		if v := daily.Get([]byte(p.Date)); v != nil {
+			return json.Unmarshal(v, &p)
+		}
+		return putDailyPick(b, p)
+	})
+	return p, err
+}
+
+// PinDailyPick закрепляет цитату за датой, перезаписывая существующую запись.
+func (r *BoltRepo) PinDailyPick(p DailyPick) error {
+	p.Pinned = true
+	return r.update(func(tx *bolt.Tx, b *bolt.Bucket) error {
+		return putDailyPick(b, p)
+	})
+}
+
+// DeleteDailyPick удаляет запись о цитате дня для даты.
+func (r *BoltRepo) DeleteDailyPick(date string) error {
+	return r.update(func(tx *bolt.Tx, b *bolt.Bucket) error {
+		return deleteDailyPick(b, date)
+	})
+}
+
+// putDailyPick записывает цитату дня вместо прежней записи за дату и обновляет индекс дат цитаты.
+func putDailyPick(b *bolt.Bucket, p DailyPick) error {
+	if err := deleteDailyPick(b, p.Date); err != nil {
+		return err
+	}
+	if err := putJSON(b.Bucket(bucketDaily), []byte(p.Date), p); err != nil {
+		return err
+	}
+	return b.Bucket(bucketDailyIndex).Put(quoteKey(p.QuoteID, []byte(p.Date)), nil)
+}
+
+// deleteDailyPick удаляет запись о цитате дня за дату вместе с её ключом в индексе дат цитаты.
+func deleteDailyPick(b *bolt.Bucket, date string) error {
+	daily := b.Bucket(bucketDaily)
+	v := daily.Get([]byte(date))
+	if v == nil {
+		return nil
+	}
+	var p DailyPick
+	if err := json.Unmarshal(v, &p); err != nil {
+		return err
+	}
+	if err := b.Bucket(bucketDailyIndex).Delete(quoteKey(p.QuoteID, []byte(date))); err != nil {
+		return err
+	}
+	return daily.Delete([]byte(date))
+}
+
+// DailyPicksBetween возвращает записи за даты в диапазоне [from, to), упорядоченные по дате.
+func (r *BoltRepo) DailyPicksBetween(from, to string) ([]DailyPick, error) {
+	var res []DailyPick
+	err := r.view(func(tx *bolt.Tx, b *bolt.Bucket) error {
+		c := b.Bucket(bucketDaily).Cursor()
+		for k, v := c.Seek([]byte(from)); k != nil && string(k) < to; k, v = c.Next() {
+			var p DailyPick
+			if err := json.Unmarshal(v, &p); err != nil {
+				return err
+			}
+			res = append(res, p)
+		}
+		return nil
+	})
+	return res, err
+}
+
+func putJSON(b *bolt.Bucket, key []byte, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return b.Put(key, data)
+}
+
+// CreateKey сохраняет ключ API и возвращает его с присвоенным ID.
+func (r *BoltRepo) CreateKey(k auth.Key) (auth.Key, error) {
+	k.Scopes = slices.Clone(k.Scopes)
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		keys := tx.Bucket(bucketKeys)
+		seq, err := keys.NextSequence()
+		if err != nil {
+			return err
+		}
+		k.ID = int(seq)
+		if err := putJSON(keys, itob(k.ID), newStoredKey(k)); err != nil {
+			return err
+		}
+		return tx.Bucket(bucketHashes).Put([]byte(k.Hash), itob(k.ID))
+	})
+	return k, err
+}
+
+// getKey читает ключ API по ID или возвращает auth.ErrKeyNotFound.
+func getKey(tx *bolt.Tx, id []byte) (auth.Key, error) {
+	v := tx.Bucket(bucketKeys).Get(id)
+	if v == nil {
+		return auth.Key{}, auth.ErrKeyNotFound
+	}
+	var k storedKey
+	if err := json.Unmarshal(v, &k); err != nil {
+		return auth.Key{}, err
+	}
+	return k.key(), nil
+}
+
+// KeyByHash возвращает ключ API по хешу или auth.ErrKeyNotFound.
+func (r *BoltRepo) KeyByHash(hash string) (auth.Key, error) {
+	var k auth.Key
+	err := r.db.View(func(tx *bolt.Tx) error {
+		id := tx.Bucket(bucketHashes).Get([]byte(hash))
+		if id == nil {
+			return auth.ErrKeyNotFound
+		}
+		var err error
+		k, err = getKey(tx, id)
+		return err
+	})
+	return k, err
+}
+
+// ListKeys возвращает ключи API в порядке ID.
+func (r *BoltRepo) ListKeys() ([]auth.Key, error) {
+	var res []auth.Key
+	err := r.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketKeys).ForEach(func(id, _ []byte) error {
+			k, err := getKey(tx, id)
+			res = append(res, k)
+			return err
+		})
+	})
+	return res, err
+}
+
+// RevokeKey помечает ключ API отозванным; повторный отзыв сохраняет исходное время.
+func (r *BoltRepo) RevokeKey(id int, at time.Time) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		k, err := getKey(tx, itob(id))
+		if err != nil || k.RevokedAt != nil {
+			return err
+		}
+		k.RevokedAt = &at
+		return putJSON(tx.Bucket(bucketKeys), itob(id), newStoredKey(k))
+	})
+}
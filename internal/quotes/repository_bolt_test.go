@@ -0,0 +1,233 @@
+package quotes
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// openTestBolt открывает репозиторий bbolt во временном каталоге теста
+func openTestBolt(t *testing.T, opts ...RepoOption) Repository {
+	t.Helper()
+	repo, err := OpenBoltRepository(filepath.Join(t.TempDir(), "quotes.bolt"), opts...)
+	if err != nil {
+		t.Fatalf("ошибка открытия bbolt: %v", err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	return repo
+}
+
+// Тесты для репозитория цитат в bbolt
+func TestBoltRepoCRUD(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quotes.bolt")
+	repo, err := OpenBoltRepository(path)
+	if err != nil {
+		t.Fatalf("ошибка открытия bbolt: %v", err)
+	}
+	if all, err := repo.GetAll(); err != nil || len(all) != 0 {
+		t.Fatalf("ожидается пустое хранилище, получено %v %v", all, err)
+	}
+	for _, q := range []Quote{{Author: "A", Text: "first"}, {Author: "AB", Text: "second"}, {Author: "A", Text: "third"}} {
+		if _, err := repo.Create(q); err != nil {
+			t.Fatalf("ошибка создания цитаты: %v", err)
+		}
+	}
+
+	// Индекс по автору не путает автора A с автором AB
+	listA, err := repo.FilterByAuthor("A")
+	if err != nil || len(listA) != 2 || listA[0].ID != 1 || listA[1].ID != 3 {
+		t.Errorf("ожидаются цитаты 1 и 3 автора A, получено %v %v", listA, err)
+	}
+	if err := repo.Delete(100); err != ErrNotFound {
+		t.Errorf("ожидается ErrNotFound при удалении несуществующего ID, получено %v", err)
+	}
+	if err := repo.Delete(1); err != nil {
+		t.Fatalf("ошибка удаления цитаты: %v", err)
+	}
+	if _, err := repo.GetByID(1); err != ErrNotFound {
+		t.Errorf("удалённая цитата не должна находиться, получено %v", err)
+	}
+	if list, _ := repo.FilterByAuthor("A"); len(list) != 1 || list[0].ID != 3 {
+		t.Errorf("удалённая цитата должна уйти из индекса по автору, получено %v", list)
+	}
+	if _, err := repo.Tenant("other").GetByID(2); err != ErrNotFound {
+		t.Errorf("цитата чужого пространства не должна находиться, получено %v", err)
+	}
+
+	// После повторного открытия данные и счётчик ID сохраняются
+	if err := repo.Close(); err != nil {
+		t.Fatal(err)
+	}
+	repo, err = OpenBoltRepository(path)
+	if err != nil {
+		t.Fatalf("ошибка повторного открытия bbolt: %v", err)
+	}
+	defer repo.Close()
+	if all, _ := repo.GetAll(); len(all) != 2 || all[0].Text != "second" {
+		t.Errorf("ожидаются две цитаты после повторного открытия, получено %v", all)
+	}
+	if id, _ := repo.Create(Quote{Author: "C", Text: "fourth"}); id != 4 {
+		t.Errorf("ожидается ID 4 после повторного открытия, получен %d", id)
+	}
+	if _, err := OpenBoltRepository(path); err == nil {
+		t.Error("файл, занятый другим подключением, не должен открываться")
+	}
+}
+
+// TestBoltRepoRandomEmpty проверяет ErrEmpty для пустого хранилища
+func TestBoltRepoRandomEmpty(t *testing.T) {
+	repo := openTestBolt(t)
+	if _, err := repo.GetRandom(); err != ErrEmpty {
+		t.Errorf("ожидается ErrEmpty, получено %v", err)
+	}
+	if _, err := repo.GetWeightedRandomWith(NewSeededRand(1)); err != ErrEmpty {
+		t.Errorf("ожидается ErrEmpty для взвешенного выбора, получено %v", err)
+	}
+}
+
+// TestBoltRepoSampling проверяет случайный выбор по счётчикам блоков, когда цитаты занимают
+// несколько блоков и часть из них удалена: выбор совпадает с хранилищем в памяти
+func TestBoltRepoSampling(t *testing.T) {
+	bl := openTestBolt(t)
+	mem := NewMemoryRepository()
+	other := bl.Tenant("other")
+	for i := 0; i < 2500; i++ {
+		repo := bl
+		// Цитаты другого пространства занимают часть ID внутри блоков
+		if i%7 == 0 {
+			repo = other
+			mem.Tenant("other").Create(Quote{Author: "O", Text: "o"})
+		} else {
+			mem.Create(Quote{Author: "A", Text: "q"})
+		}
+		if _, err := repo.Create(Quote{Author: map[bool]string{true: "O", false: "A"}[i%7 == 0], Text: map[bool]string{true: "o", false: "q"}[i%7 == 0]}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for id := 1000; id < 1100; id++ {
+		bl.Delete(id)
+		mem.Delete(id)
+	}
+	rb, rm := NewSeededRand(5), NewSeededRand(5)
+	for i := 0; i < 200; i++ {
+		a, err := mem.GetRandomWith(rm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := bl.GetRandomWith(rb)
+		if err != nil || a != b {
+			t.Fatalf("шаг %d: bolt выбрал %v (%v), memory — %v", i, b, err, a)
+		}
+	}
+}
+
+// TestBoltRepoSchemaVersion проверяет отказ открывать файл более новой схемы
+func TestBoltRepoSchemaVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quotes.bolt")
+	repo, err := OpenBoltRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo.Close()
+	db, err := bolt.Open(path, 0o600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketMeta).Put(keyVersion, encodeUint(boltSchemaVersion+1))
+	})
+	db.Close()
+	if _, err := OpenBoltRepository(path); err == nil || !strings.Contains(err.Error(), "новее") {
+		t.Errorf("ожидается ошибка версии схемы, получено %v", err)
+	}
+}
+
+// fillBoltRecords создаёт две цитаты с показами и цитатами дня; дата 2024-05-03 сначала
+// записана за цитатой 2, затем закреплена за цитатой 1
+func fillBoltRecords(t *testing.T, repo Repository) {
+	t.Helper()
+	at := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	repo.Create(Quote{Author: "A", Text: "первая"})
+	repo.Create(Quote{Author: "B", Text: "вторая"})
+	err := repo.AddViews([]ViewBucket{
+		{Hour: at, QuoteID: 1, Views: 1}, {Hour: at.Add(time.Hour), QuoteID: 1, Views: 2}, {Hour: at, QuoteID: 2, Views: 3},
+	}, nil)
+	if err != nil {
+		t.Fatalf("ошибка AddViews: %v", err)
+	}
+	repo.SaveDailyPick(DailyPick{Date: "2024-05-01", QuoteID: 1})
+	repo.SaveDailyPick(DailyPick{Date: "2024-05-02", QuoteID: 2})
+	repo.SaveDailyPick(DailyPick{Date: "2024-05-03", QuoteID: 2})
+	repo.PinDailyPick(DailyPick{Date: "2024-05-03", QuoteID: 1})
+}
+
+// checkBoltDelete удаляет цитату 2 и проверяет, что удалились только её показы и цитаты дня
+func checkBoltDelete(t *testing.T, repo Repository) {
+	t.Helper()
+	if err := repo.Delete(2); err != nil {
+		t.Fatalf("ошибка удаления: %v", err)
+	}
+	views, _ := repo.ViewsSince(time.Time{})
+	if len(views) != 2 || views[0].QuoteID != 1 || views[1].QuoteID != 1 {
+		t.Errorf("ожидаются только показы цитаты 1, получено %+v", views)
+	}
+	daily, _ := repo.DailyPicksBetween("2024-01-01", "2025-01-01")
+	if len(daily) != 2 || daily[0].Date != "2024-05-01" || daily[1].Date != "2024-05-03" || daily[1].QuoteID != 1 {
+		t.Errorf("ожидаются цитаты дня 2024-05-01 и закреплённая 2024-05-03, получено %+v", daily)
+	}
+	if err := repo.Delete(1); err != nil {
+		t.Fatalf("ошибка удаления: %v", err)
+	}
+	views, _ = repo.ViewsSince(time.Time{})
+	daily, _ = repo.DailyPicksBetween("2024-01-01", "2025-01-01")
+	if len(views) != 0 || len(daily) != 0 {
+		t.Errorf("после удаления всех цитат ожидаются пустые показы и цитаты дня, получено %+v %+v", views, daily)
+	}
+}
+
+// TestBoltRepoDeleteRecords проверяет, что удаление цитаты находит её показы и цитаты дня по индексу
+func TestBoltRepoDeleteRecords(t *testing.T) {
+	repo := openTestBolt(t)
+	fillBoltRecords(t, repo)
+	checkBoltDelete(t, repo)
+}
+
+// TestBoltRepoMigrateIndexes проверяет построение индексов записей цитат в файле версии 1
+func TestBoltRepoMigrateIndexes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quotes.bolt")
+	repo, err := OpenBoltRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fillBoltRecords(t, repo)
+	repo.Close()
+
+	// Файл версии 1 без индексов
+	db, err := bolt.Open(path, 0o600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketTenants).Bucket([]byte(DefaultTenant))
+		for _, name := range [][]byte{bucketViewIndex, bucketDailyIndex} {
+			if err := b.DeleteBucket(name); err != nil {
+				return err
+			}
+		}
+		return tx.Bucket(bucketMeta).Put(keyVersion, encodeUint(1))
+	})
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err = OpenBoltRepository(path)
+	if err != nil {
+		t.Fatalf("ошибка открытия файла версии 1: %v", err)
+	}
+	defer repo.Close()
+	checkBoltDelete(t, repo)
+}
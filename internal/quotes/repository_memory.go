@@ -1,31 +1,143 @@
 package quotes
 
 import (
-	"errors"
-	"math/rand"
+	"slices"
+	"sort"
 	"sync"
+	"time"
+
+	"Test_Project_Brand_Scout/internal/auth"
 )
 
-// MemoryRepo реализует хранение цитат в памяти с поддержкой конкурентного доступа.
+// MemoryRepo реализует хранение цитат одного рабочего пространства в памяти
+// с поддержкой конкурентного доступа. Пространства одного хранилища связаны через shared.
 type MemoryRepo struct {
 	mu     sync.RWMutex
+	tenant string
+	shared *memoryShared
 	data   []Quote
-	nextID int
+	daily  map[string]DailyPick
+	rnd    Rand
+	// weights — индекс весов для взвешенного выбора, обновляется при каждом изменении
+	weights *weightIndex
+	// reactions хранит реакции клиентов по ID цитаты
+	reactions map[int]map[reactionKey]Reaction
+	// views и requests — часовые счётчики показов и запросов
+	views    map[ViewBucket]int64
+	requests map[RequestBucket]int64
+}
+
+// memoryShared — общее для всех пространств состояние: реестр пространств,
+// сквозной счётчик ID цитат, ключи API и журнал изменений. Блокировка mu берётся после MemoryRepo.mu,
+// но не наоборот; persist берётся первой: изменения держат её на чтение, снимок — на запись.
+type memoryShared struct {
+	persist sync.RWMutex
+	// wal — журнал изменений; nil, если хранилище не сохраняется на диск
+	wal     *memoryWAL
+	mu      sync.Mutex
+	rnd     Rand
+	tenants map[string]*MemoryRepo
+	nextID  int
+	// keys — ключи API; nextKeyID — ID следующего выданного ключа
+	keys      []auth.Key
+	nextKeyID int
+}
+
+// reactionKey идентифицирует реакцию клиента на одну цитату.
+type reactionKey struct {
+	kind   string
+	client string
 }
 
-// NewMemoryRepository создаёт и возвращает новый in-memory репозиторий цитат.
-func NewMemoryRepository() Repository {
-	return &MemoryRepo{data: make([]Quote, 0), nextID: 1}
+// NewMemoryRepository создаёт и возвращает новый in-memory репозиторий цитат
+// для пространства DefaultTenant.
+func NewMemoryRepository(opts ...RepoOption) Repository {
+	o := applyRepoOptions(opts)
+	shared := &memoryShared{rnd: o.rnd, tenants: make(map[string]*MemoryRepo), nextID: 1, nextKeyID: 1}
+	return shared.tenant(DefaultTenant)
+}
+
+// tenant возвращает репозиторий пространства id, создавая его при первом обращении.
+func (s *memoryShared) tenant(id string) *MemoryRepo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r, ok := s.tenants[id]; ok {
+		return r
+	}
+	r := &MemoryRepo{
+		tenant:    id,
+		shared:    s,
+		data:      make([]Quote, 0),
+		daily:     make(map[string]DailyPick),
+		rnd:       s.rnd,
+		weights:   newWeightIndex(nil),
+		reactions: make(map[int]map[reactionKey]Reaction),
+		views:     make(map[ViewBucket]int64),
+		requests:  make(map[RequestBucket]int64),
+	}
+	s.tenants[id] = r
+	return r
+}
+
+// Tenant возвращает репозиторий пространства id в том же хранилище.
+func (r *MemoryRepo) Tenant(id string) Repository {
+	return r.shared.tenant(normalizeTenant(id))
 }
 
 // Create сохраняет новую цитату в памяти и возвращает её ID или ошибку.
 func (r *MemoryRepo) Create(q Quote) (int, error) {
+	return r.CreateLimited(q, 0)
+}
+
+// CreateLimited сохраняет цитату, если в пространстве меньше limit цитат.
+// ID выдаются сквозным счётчиком хранилища, поэтому в каждом пространстве они возрастают.
+func (r *MemoryRepo) CreateLimited(q Quote, limit int) (int, error) {
+	defer r.shared.logged()()
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	q.ID = r.nextID
-	r.nextID++
+	if limit > 0 && len(r.data) >= limit {
+		return 0, ErrQuotaExceeded
+	}
+	r.shared.mu.Lock()
+	q.ID = r.shared.nextID
+	r.shared.nextID++
+	r.shared.mu.Unlock()
+	q.Tenant = r.tenant
 	r.data = append(r.data, q)
-	return q.ID, nil
+	r.weights.set(q.ID, quoteWeight(q))
+	return q.ID, r.shared.log(walRecord{Op: opCreate, Tenant: r.tenant, Quote: &q})
+}
+
+// Close закрывает хранилище. Сохраняемое на диск хранилище записывает последний снимок
+// и закрывает журнал; без сохранения Close ничего не делает.
+func (r *MemoryRepo) Close() error {
+	return r.shared.close()
+}
+
+// Snapshot записывает состояние сохраняемого на диск хранилища компактным снимком и очищает журнал.
+// Без сохранения ничего не делает.
+func (r *MemoryRepo) Snapshot() error {
+	return r.shared.compact()
+}
+
+// QuoteCounts возвращает число цитат во всех непустых пространствах хранилища.
+func (r *MemoryRepo) QuoteCounts() (map[string]int, error) {
+	r.shared.mu.Lock()
+	tenants := make([]*MemoryRepo, 0, len(r.shared.tenants))
+	for _, t := range r.shared.tenants {
+		tenants = append(tenants, t)
+	}
+	r.shared.mu.Unlock()
+
+	counts := make(map[string]int)
+	for _, t := range tenants {
+		t.mu.RLock()
+		if n := len(t.data); n > 0 {
+			counts[t.tenant] = n
+		}
+		t.mu.RUnlock()
+	}
+	return counts, nil
 }
 
 // GetAll возвращает копию списка всех сохранённых цитат.
@@ -35,14 +147,62 @@ func (r *MemoryRepo) GetAll() ([]Quote, error) {
 	return append([]Quote(nil), r.data...), nil
 }
 
+// GetByID возвращает цитату по ID или ErrNotFound.
+func (r *MemoryRepo) GetByID(id int) (Quote, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if i, ok := r.indexOf(id); ok {
+		return r.data[i], nil
+	}
+	return Quote{}, ErrNotFound
+}
+
+// indexOf ищет позицию цитаты в data бинарным поиском; вызывается под блокировкой.
+func (r *MemoryRepo) indexOf(id int) (int, bool) {
+	i := sort.Search(len(r.data), func(i int) bool { return r.data[i].ID >= id })
+	return i, i < len(r.data) && r.data[i].ID == id
+}
+
 // GetRandom возвращает случайную цитату или ошибку, если список пуст.
 func (r *MemoryRepo) GetRandom() (Quote, error) {
+	return r.GetRandomWith(r.rnd)
+}
+
+// GetRandomWith возвращает случайную цитату, выбранную с помощью rnd.
+// Срез data всегда упорядочен по ID, так как ID выдаются по возрастанию.
+func (r *MemoryRepo) GetRandomWith(rnd Rand) (Quote, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	if len(r.data) == 0 {
-		return Quote{}, errors.New("нету доступных цитат")
+		return Quote{}, ErrEmpty
+	}
+	return r.data[rnd.Intn(len(r.data))], nil
+}
+
+// GetWeightedRandomWith возвращает цитату с вероятностью, пропорциональной её весу.
+func (r *MemoryRepo) GetWeightedRandomWith(rnd Rand) (Quote, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	id, ok := r.weights.pick(rnd)
+	if !ok {
+		return Quote{}, ErrEmpty
+	}
+	i, _ := r.indexOf(id)
+	return r.data[i], nil
+}
+
+// SetScore задаёт оценку редактора и обновляет вес цитаты в индексе.
+func (r *MemoryRepo) SetScore(id, score int) error {
+	defer r.shared.logged()()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i, ok := r.indexOf(id)
+	if !ok {
+		return ErrNotFound
 	}
-	return r.data[rand.Intn(len(r.data))], nil
+	r.data[i].Score = score
+	r.weights.set(id, quoteWeight(r.data[i]))
+	return r.shared.log(walRecord{Op: opSetScore, Tenant: r.tenant, ID: id, Score: score})
 }
 
 // FilterByAuthor возвращает все цитаты указанного автора.
@@ -58,15 +218,276 @@ func (r *MemoryRepo) FilterByAuthor(author string) ([]Quote, error) {
 	return res, nil
 }
 
+// Page возвращает страницу цитат, начиная поиск с первой цитаты после after.
+func (r *MemoryRepo) Page(author string, after, limit int) ([]Quote, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var res []Quote
+	for _, q := range r.data[sort.Search(len(r.data), func(i int) bool { return r.data[i].ID > after }):] {
+		if limit > 0 && len(res) == limit {
+			break
+		}
+		if author == "" || q.Author == author {
+			res = append(res, q)
+		}
+	}
+	return res, nil
+}
+
 // Delete удаляет цитату по ID, возвращает ошибку, если цитата не найдена.
+// Вместе с цитатой удаляются ссылающиеся на неё записи о цитате дня.
 func (r *MemoryRepo) Delete(id int) error {
+	defer r.shared.logged()()
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	for i, q := range r.data {
 		if q.ID == id {
 			r.data = append(r.data[:i], r.data[i+1:]...)
-			return nil
+			r.weights.remove(id)
+			delete(r.reactions, id)
+			for key := range r.views {
+				if key.QuoteID == id {
+					delete(r.views, key)
+				}
+			}
+			for date, p := range r.daily {
+				if p.QuoteID == id {
+					delete(r.daily, date)
+				}
+			}
+			return r.shared.log(walRecord{Op: opDelete, Tenant: r.tenant, ID: id})
+		}
+	}
+	return ErrNotFound
+}
+
+// SetReaction сохраняет реакцию клиента и пересчитывает агрегаты цитаты.
+func (r *MemoryRepo) SetReaction(re Reaction) (Quote, error) {
+	defer r.shared.logged()()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i, ok := r.indexOf(re.QuoteID)
+	if !ok {
+		return Quote{}, ErrNotFound
+	}
+	byKey := r.reactions[re.QuoteID]
+	if byKey == nil {
+		byKey = make(map[reactionKey]Reaction)
+		r.reactions[re.QuoteID] = byKey
+	}
+	key := reactionKey{kind: re.Kind, client: re.Client}
+	// Повторный лайк сохраняет исходное время, чтобы не продлевать его вклад в популярность
+	if _, liked := byKey[key]; !liked || re.Kind != ReactionLike {
+		byKey[key] = re
+	}
+	r.recount(i)
+	return r.data[i], r.shared.log(walRecord{Op: opSetReaction, Tenant: r.tenant, Reaction: &re})
+}
+
+// DeleteReaction удаляет реакцию клиента и пересчитывает агрегаты цитаты.
+func (r *MemoryRepo) DeleteReaction(quoteID int, kind, client string) (Quote, error) {
+	defer r.shared.logged()()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i, ok := r.indexOf(quoteID)
+	if !ok {
+		return Quote{}, ErrNotFound
+	}
+	delete(r.reactions[quoteID], reactionKey{kind: kind, client: client})
+	r.recount(i)
+	return r.data[i], r.shared.log(walRecord{Op: opDeleteReaction, Tenant: r.tenant, ID: quoteID, Kind: kind, Client: client})
+}
+
+// recount пересчитывает агрегаты реакций цитаты data[i] и её вес; вызывается под блокировкой.
+func (r *MemoryRepo) recount(i int) {
+	q := &r.data[i]
+	q.Likes, q.Ratings, q.Rating = 0, 0, 0
+	sum := 0
+	for _, re := range r.reactions[q.ID] {
+		switch re.Kind {
+		case ReactionLike:
+			q.Likes++
+		case ReactionRating:
+			q.Ratings++
+			sum += re.Value
+		}
+	}
+	if q.Ratings > 0 {
+		q.Rating = float64(sum) / float64(q.Ratings)
+	}
+	r.weights.set(q.ID, quoteWeight(*q))
+}
+
+// ReactionsSince возвращает реакции, созданные не раньше since.
+func (r *MemoryRepo) ReactionsSince(since time.Time) ([]Reaction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var res []Reaction
+	for _, byKey := range r.reactions {
+		for _, re := range byKey {
+			if !re.CreatedAt.Before(since) {
+				res = append(res, re)
+			}
+		}
+	}
+	return res, nil
+}
+
+// AddViews прибавляет часовые счётчики к сохранённым; показы удалённых цитат отбрасываются.
+// Ключом служит бакет с обнулённым счётчиком, чтобы хранить по одной записи на час.
+func (r *MemoryRepo) AddViews(views []ViewBucket, requests []RequestBucket) error {
+	defer r.shared.logged()()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rec := walRecord{Op: opAddViews, Tenant: r.tenant, Requests: requests}
+	for _, v := range views {
+		if _, ok := r.indexOf(v.QuoteID); !ok {
+			continue
+		}
+		rec.Views = append(rec.Views, v)
+		n := v.Views
+		v.Views = 0
+		r.views[v] += n
+	}
+	for _, q := range requests {
+		n := q.Requests
+		q.Requests = 0
+		r.requests[q] += n
+	}
+	if len(rec.Views) == 0 && len(rec.Requests) == 0 {
+		return nil
+	}
+	return r.shared.log(rec)
+}
+
+// ViewsSince возвращает часовые счётчики показов начиная с часа since.
+func (r *MemoryRepo) ViewsSince(since time.Time) ([]ViewBucket, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var res []ViewBucket
+	for key, n := range r.views {
+		if !key.Hour.Before(since) {
+			key.Views = n
+			res = append(res, key)
+		}
+	}
+	return res, nil
+}
+
+// RequestsSince возвращает часовые счётчики запросов начиная с часа since.
+func (r *MemoryRepo) RequestsSince(since time.Time) ([]RequestBucket, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var res []RequestBucket
+	for key, n := range r.requests {
+		if !key.Hour.Before(since) {
+			key.Requests = n
+			res = append(res, key)
+		}
+	}
+	return res, nil
+}
+
+// GetDailyPick возвращает цитату дня для даты или ErrNotFound.
+func (r *MemoryRepo) GetDailyPick(date string) (DailyPick, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.daily[date]
+	if !ok {
+		return DailyPick{}, ErrNotFound
+	}
+	return p, nil
+}
+
+// SaveDailyPick сохраняет выбор, если для даты ещё нет записи, и возвращает действующую запись.
+func (r *MemoryRepo) SaveDailyPick(p DailyPick) (DailyPick, error) {
+	defer r.shared.logged()()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cur, ok := r.daily[p.Date]; ok {
+		return cur, nil
+	}
+	r.daily[p.Date] = p
+	return p, r.shared.log(walRecord{Op: opSaveDaily, Tenant: r.tenant, Daily: &p})
+}
+
+// PinDailyPick закрепляет цитату за датой, перезаписывая существующую запись.
+func (r *MemoryRepo) PinDailyPick(p DailyPick) error {
+	defer r.shared.logged()()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p.Pinned = true
+	r.daily[p.Date] = p
+	return r.shared.log(walRecord{Op: opPinDaily, Tenant: r.tenant, Daily: &p})
+}
+
+// DeleteDailyPick удаляет запись о цитате дня для даты.
+func (r *MemoryRepo) DeleteDailyPick(date string) error {
+	defer r.shared.logged()()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.daily, date)
+	return r.shared.log(walRecord{Op: opDeleteDaily, Tenant: r.tenant, Date: date})
+}
+
+// DailyPicksBetween возвращает записи за даты в диапазоне [from, to), упорядоченные по дате.
+func (r *MemoryRepo) DailyPicksBetween(from, to string) ([]DailyPick, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var res []DailyPick
+	for date, p := range r.daily {
+		if date >= from && date < to {
+			res = append(res, p)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Date < res[j].Date })
+	return res, nil
+}
+
+// CreateKey сохраняет ключ API и возвращает его с присвоенным ID.
+func (r *MemoryRepo) CreateKey(k auth.Key) (auth.Key, error) {
+	defer r.shared.logged()()
+	r.shared.mu.Lock()
+	defer r.shared.mu.Unlock()
+	k.ID = r.shared.nextKeyID
+	r.shared.nextKeyID++
+	k.Scopes = slices.Clone(k.Scopes)
+	r.shared.keys = append(r.shared.keys, k)
+	return k, r.shared.log(walRecord{Op: opCreateKey, Key: newStoredKey(k)})
+}
+
+// KeyByHash возвращает ключ API по хешу или auth.ErrKeyNotFound.
+func (r *MemoryRepo) KeyByHash(hash string) (auth.Key, error) {
+	r.shared.mu.Lock()
+	defer r.shared.mu.Unlock()
+	for _, k := range r.shared.keys {
+		if k.Hash == hash {
+			return k, nil
+		}
+	}
+	return auth.Key{}, auth.ErrKeyNotFound
+}
+
+// ListKeys возвращает копию списка ключей API.
+func (r *MemoryRepo) ListKeys() ([]auth.Key, error) {
+	r.shared.mu.Lock()
+	defer r.shared.mu.Unlock()
+	return append([]auth.Key(nil), r.shared.keys...), nil
+}
+
+// RevokeKey помечает ключ API отозванным; повторный отзыв сохраняет исходное время.
+func (r *MemoryRepo) RevokeKey(id int, at time.Time) error {
+	defer r.shared.logged()()
+	r.shared.mu.Lock()
+	defer r.shared.mu.Unlock()
+	for i := range r.shared.keys {
+		if r.shared.keys[i].ID == id {
+			if r.shared.keys[i].RevokedAt != nil {
+				return nil
+			}
+			r.shared.keys[i].RevokedAt = &at
+			return r.shared.log(walRecord{Op: opRevokeKey, ID: id, At: at})
 		}
 	}
-	return errors.New("нет такой цитаты")
+	return auth.ErrKeyNotFound
 }
@@ -0,0 +1,621 @@
+package quotes
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"Test_Project_Brand_Scout/internal/auth"
+)
+
+// Политики сброса журнала на диск.
+const (
+	FsyncAlways   = "always"   // fsync после каждой записи: изменение не теряется даже при сбое питания
+	FsyncInterval = "interval" // fsync раз в FsyncInterval: при сбое питания теряется не больше интервала
+	FsyncNever    = "never"    // сброс на диск остаётся операционной системе
+)
+
+// Файлы каталога хранилища.
+const (
+	walFile      = "wal.log"
+	snapshotFile = "snapshot.db"
+)
+
+// walHeaderSize — заголовок записи: длина данных и CRC-32C данных, оба uint32 little-endian.
+const walHeaderSize = 8
+
+var walTable = crc32.MakeTable(crc32.Castagnoli)
+
+// MemoryPersistence задаёт сохранение хранилища в памяти на диск. Каждое изменение дописывается
+// в журнал Dir/wal.log, а периодически всё состояние записывается компактным снимком Dir/snapshot.db
+// и журнал очищается. При открытии читается снимок, затем журнал.
+type MemoryPersistence struct {
+	Dir              string
+	Fsync            string        // FsyncAlways, FsyncInterval или FsyncNever; пусто — FsyncInterval
+	FsyncInterval    time.Duration // период сброса при FsyncInterval; 0 — одна секунда
+	SnapshotInterval time.Duration // период снимков; 0 — снимок только при закрытии
+}
+
+func init() {
+	Register("memory", openMemoryDSN)
+}
+
+// openMemoryDSN открывает хранилище в памяти по адресу memory://. Адрес с каталогом, например
+// memory:///var/lib/quotes?fsync=always, включает журнал и снимки в этом каталоге; параметры fsync,
+// fsync_interval и snapshot_interval соответствуют полям MemoryPersistence.
+func openMemoryDSN(dsn DSN, opts ...RepoOption) (Repository, error) {
+	if err := dsn.allow("fsync", "fsync_interval", "snapshot_interval"); err != nil {
+		return nil, err
+	}
+	if dsn.Path == "" {
+		if len(dsn.Params) > 0 {
+			return nil, errors.New("параметры сохранения требуют каталога, например memory:///var/lib/quotes?fsync=always")
+		}
+		return NewMemoryRepository(opts...), nil
+	}
+	p := MemoryPersistence{Dir: dsn.Path, Fsync: dsn.Params.Get("fsync")}
+	var err error
+	if p.FsyncInterval, err = dsn.duration("fsync_interval", 0); err != nil {
+		return nil, err
+	}
+	if p.SnapshotInterval, err = dsn.duration("snapshot_interval", 0); err != nil {
+		return nil, err
+	}
+	return OpenMemoryRepository(p, opts...)
+}
+
+// OpenMemoryRepository открывает хранилище в памяти, сохраняемое в каталог p.Dir, и восстанавливает
+// состояние из снимка и журнала. Оборванная последняя запись журнала, например после сбоя во время
+// записи, отбрасывается вместе с нулевым хвостом файла; повреждение в середине журнала или в снимке
+// возвращает ошибку, и журнал не обрезается.
+// Если запись в журнал не удалась, изменение остаётся только в памяти, метод возвращает ошибку,
+// а все последующие изменения отклоняются до перезапуска: иначе журнал разошёлся бы с памятью.
+func OpenMemoryRepository(p MemoryPersistence, opts ...RepoOption) (Repository, error) {
+	switch p.Fsync {
+	case "":
+		p.Fsync = FsyncInterval
+	case FsyncAlways, FsyncInterval, FsyncNever:
+	default:
+		return nil, fmt.Errorf("неизвестная политика fsync %q (ожидается always, interval или never)", p.Fsync)
+	}
+	if p.FsyncInterval <= 0 {
+		p.FsyncInterval = time.Second
+	}
+	if err := os.MkdirAll(p.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	repo := NewMemoryRepository(opts...).(*MemoryRepo)
+	shared := repo.shared
+	seq, err := shared.loadSnapshot(filepath.Join(p.Dir, snapshotFile))
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(p.Dir, walFile), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if seq, err = shared.replay(f, seq); err != nil {
+		f.Close()
+		return nil, err
+	}
+	w := &memoryWAL{opts: p, f: f, seq: seq, stop: make(chan struct{}), done: make(chan struct{})}
+	shared.wal = w
+	go shared.runWAL()
+	return repo, nil
+}
+
+// memoryWAL — открытый журнал хранилища. Блокировка mu берётся последней, после memoryShared.mu.
+type memoryWAL struct {
+	opts MemoryPersistence
+	mu   sync.Mutex
+	f    *os.File
+	seq  uint64 // номер последней записи
+	// dirty — в журнале есть записи, ещё не сброшенные на диск
+	dirty bool
+	// err — ошибка записи, после которой журнал больше не принимает изменений
+	err       error
+	closed    bool
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// walRecord — одно изменение хранилища. Op задаёт метод, остальные поля — его аргументы.
+type walRecord struct {
+	Seq      uint64          `json:"seq"`
+	Op       string          `json:"op"`
+	Tenant   string          `json:"tenant,omitempty"`
+	ID       int             `json:"id,omitempty"`
+	Score    int             `json:"score,omitempty"`
+	Quote    *Quote          `json:"quote,omitempty"`
+	Reaction *Reaction       `json:"reaction,omitempty"`
+	Kind     string          `json:"kind,omitempty"`
+	Client   string          `json:"client,omitempty"`
+	Views    []ViewBucket    `json:"views,omitempty"`
+	Requests []RequestBucket `json:"requests,omitempty"`
+	Daily    *DailyPick      `json:"daily,omitempty"`
+	Date     string          `json:"date,omitempty"`
+	Key      *storedKey      `json:"key,omitempty"`
+	At       time.Time       `json:"at,omitzero"`
+}
+
+// Операции журнала.
+const (
+	opCreate         = "create"
+	opSetScore       = "set_score"
+	opDelete         = "delete"
+	opSetReaction    = "set_reaction"
+	opDeleteReaction = "delete_reaction"
+	opAddViews       = "add_views"
+	opSaveDaily      = "save_daily"
+	opPinDaily       = "pin_daily"
+	opDeleteDaily    = "delete_daily"
+	opCreateKey      = "create_key"
+	opRevokeKey      = "revoke_key"
+)
+
+// storedKey — ключ API вместе с хешем, который auth.Key не выводит в JSON.
+type storedKey struct {
+	auth.Key
+	Hash string `json:"hash"`
+}
+
+func newStoredKey(k auth.Key) *storedKey { return &storedKey{Key: k, Hash: k.Hash} }
+
+func (k storedKey) key() auth.Key {
+	k.Key.Hash = k.Hash
+	return k.Key
+}
+
+// memorySnapshot — полное состояние хранилища на момент записи журнала Seq.
+type memorySnapshot struct {
+	Seq       uint64           `json:"seq"`
+	NextID    int              `json:"next_id"`
+	NextKeyID int              `json:"next_key_id"`
+	Keys      []storedKey      `json:"keys"`
+	Tenants   []tenantSnapshot `json:"tenants"`
+}
+
+// tenantSnapshot — состояние одного рабочего пространства.
+type tenantSnapshot struct {
+	Tenant    string          `json:"tenant"`
+	Quotes    []Quote         `json:"quotes"`
+	Daily     []DailyPick     `json:"daily,omitempty"`
+	Reactions []Reaction      `json:"reactions,omitempty"`
+	Views     []ViewBucket    `json:"views,omitempty"`
+	Requests  []RequestBucket `json:"requests,omitempty"`
+}
+
+// logged вызывается в начале изменяющего метода: пока изменение не записано в журнал,
+// снимок не делается. Возвращает функцию, которую нужно вызвать по завершении метода.
+func (s *memoryShared) logged() func() {
+	s.persist.RLock()
+	return s.persist.RUnlock
+}
+
+// log дописывает изменение в журнал; без журнала ничего не делает. Вызывается под блокировкой
+// пространства, поэтому изменения одного пространства записываются в порядке применения.
+func (s *memoryShared) log(rec walRecord) error {
+	w := s.wal
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	if w.closed {
+		return errors.New("хранилище закрыто")
+	}
+	rec.Seq = w.seq + 1
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if _, err := w.f.Write(frame(payload)); err != nil {
+		w.err = fmt.Errorf("запись журнала: %w", err)
+		return w.err
+	}
+	w.seq = rec.Seq
+	if w.opts.Fsync == FsyncAlways {
+		if err := w.f.Sync(); err != nil {
+			w.err = fmt.Errorf("сброс журнала: %w", err)
+			return w.err
+		}
+		return nil
+	}
+	w.dirty = true
+	return nil
+}
+
+// frame добавляет к данным заголовок с длиной и контрольной суммой.
+func frame(payload []byte) []byte {
+	buf := make([]byte, walHeaderSize+len(payload))
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(buf[4:8], crc32.Checksum(payload, walTable))
+	copy(buf[walHeaderSize:], payload)
+	return buf
+}
+
+// errTorn означает, что запись оборвана: она упирается в конец данных, то есть данных меньше,
+// чем указано в заголовке, или контрольная сумма последней записи не сходится. Так же читается
+// хвост из нулей, который файловая система может оставить после сбоя вместо недописанных данных.
+var errTorn = errors.New("оборванная запись")
+
+// readFrame читает одну запись из data и возвращает её данные и размер вместе с заголовком.
+// Испорченная запись, после которой в data есть другие данные, — повреждение, а не обрыв.
+func readFrame(data []byte) ([]byte, int, error) {
+	if len(data) < walHeaderSize {
+		return nil, 0, errTorn
+	}
+	// Пустых записей не бывает, а нулевой заголовок иначе сошёлся бы с контрольной суммой пустых данных
+	if zeroed(data[:walHeaderSize]) {
+		if zeroed(data) {
+			return nil, 0, errTorn
+		}
+		return nil, 0, errors.New("нулевой заголовок записи")
+	}
+	n := int(binary.LittleEndian.Uint32(data[0:4]))
+	if n > len(data)-walHeaderSize {
+		// Испорченная длина в середине журнала тоже выводит запись за конец данных
+		if hasFrame(data[walHeaderSize:]) {
+			return nil, 0, errors.New("длина записи выходит за конец журнала, но за ней есть целые записи")
+		}
+		return nil, 0, errTorn
+	}
+	payload := data[walHeaderSize : walHeaderSize+n]
+	if crc32.Checksum(payload, walTable) != binary.LittleEndian.Uint32(data[4:8]) {
+		if walHeaderSize+n == len(data) {
+			return nil, 0, errTorn
+		}
+		return nil, 0, errors.New("контрольная сумма записи не совпадает")
+	}
+	return payload, walHeaderSize + n, nil
+}
+
+// walRecordStart — начало данных любой записи: и walRecord, и memorySnapshot начинаются с поля seq.
+var walRecordStart = []byte(`{"seq":`)
+
+// hasFrame сообщает, что после начала данных data есть целая запись: её ищет по началу данных
+// и проверяет контрольной суммой. Кавычки внутри строк JSON экранируются, поэтому начало записи
+// не встречается внутри данных других записей.
+func hasFrame(data []byte) bool {
+	for off := walHeaderSize; off < len(data); {
+		i := bytes.Index(data[off:], walRecordStart)
+		if i < 0 {
+			return false
+		}
+		h := data[off+i-walHeaderSize:]
+		n := int(binary.LittleEndian.Uint32(h[0:4]))
+		if n <= len(h)-walHeaderSize && crc32.Checksum(h[walHeaderSize:walHeaderSize+n], walTable) == binary.LittleEndian.Uint32(h[4:8]) {
+			return true
+		}
+		off += i + 1
+	}
+	return false
+}
+
+// zeroed сообщает, что все байты data нулевые.
+func zeroed(data []byte) bool {
+	return !slices.ContainsFunc(data, func(b byte) bool { return b != 0 })
+}
+
+// replay применяет записи журнала с номерами больше seq и возвращает номер последней записи.
+// Оборванная последняя запись или нулевой хвост отрезается, чтобы новые записи шли сразу
+// за последней целой; повреждение в середине журнала возвращает ошибку со смещением записи.
+func (s *memoryShared) replay(f *os.File, seq uint64) (uint64, error) {
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return 0, err
+	}
+	off := 0
+	for off < len(data) {
+		payload, n, err := readFrame(data[off:])
+		if errors.Is(err, errTorn) {
+			log.Printf("Журнал %s: отброшена оборванная последняя запись (%d байт)", f.Name(), len(data)-off)
+			if err := f.Truncate(int64(off)); err != nil {
+				return 0, err
+			}
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("журнал %s повреждён на смещении %d: %w", f.Name(), off, err)
+		}
+		var rec walRecord
+		if err := json.Unmarshal(payload, &rec); err != nil {
+			return 0, fmt.Errorf("журнал %s: запись на смещении %d: %w", f.Name(), off, err)
+		}
+		off += n
+		// Записи до снимка остаются в журнале, если сбой случился между снимком и очисткой журнала
+		if rec.Seq <= seq {
+			continue
+		}
+		if err := s.apply(rec); err != nil {
+			return 0, fmt.Errorf("журнал %s: запись %d (%s): %w", f.Name(), rec.Seq, rec.Op, err)
+		}
+		seq = rec.Seq
+	}
+	return seq, nil
+}
+
+// apply повторяет изменение из журнала. Вызывается до подключения журнала, поэтому методы
+// ничего не записывают повторно.
+func (s *memoryShared) apply(rec walRecord) error {
+	// Ключи API общие для пространств, в их записях пространство не указывается
+	r := s.tenant(cmp.Or(rec.Tenant, DefaultTenant))
+	var err error
+	switch rec.Op {
+	case opCreate:
+		// Пространства пишут в журнал независимо, поэтому ID может идти не по возрастанию
+		next := max(s.nextID, rec.Quote.ID+1)
+		s.nextID = rec.Quote.ID
+		_, err = r.Create(*rec.Quote)
+		s.nextID = next
+	case opSetScore:
+		err = r.SetScore(rec.ID, rec.Score)
+	case opDelete:
+		err = r.Delete(rec.ID)
+	case opSetReaction:
+		_, err = r.SetReaction(*rec.Reaction)
+	case opDeleteReaction:
+		_, err = r.DeleteReaction(rec.ID, rec.Kind, rec.Client)
+	case opAddViews:
+		err = r.AddViews(rec.Views, rec.Requests)
+	case opSaveDaily:
+		_, err = r.SaveDailyPick(*rec.Daily)
+	case opPinDaily:
+		err = r.PinDailyPick(*rec.Daily)
+	case opDeleteDaily:
+		err = r.DeleteDailyPick(rec.Date)
+	case opCreateKey:
+		next := max(s.nextKeyID, rec.Key.ID+1)
+		s.nextKeyID = rec.Key.ID
+		_, err = r.CreateKey(rec.Key.key())
+		s.nextKeyID = next
+	case opRevokeKey:
+		err = r.RevokeKey(rec.ID, rec.At)
+	default:
+		err = fmt.Errorf("неизвестная операция %q", rec.Op)
+	}
+	return err
+}
+
+// runWAL сбрасывает журнал на диск и делает снимки по расписанию до закрытия хранилища.
+func (s *memoryShared) runWAL() {
+	w := s.wal
+	defer close(w.done)
+	var syncC, snapC <-chan time.Time
+	if w.opts.Fsync == FsyncInterval {
+		t := time.NewTicker(w.opts.FsyncInterval)
+		defer t.Stop()
+		syncC = t.C
+	}
+	if w.opts.SnapshotInterval > 0 {
+		t := time.NewTicker(w.opts.SnapshotInterval)
+		defer t.Stop()
+		snapC = t.C
+	}
+	for {
+		select {
+		case <-syncC:
+			if err := w.sync(); err != nil {
+				log.Printf("Ошибка сброса журнала: %v", err)
+			}
+		case <-snapC:
+			if err := s.compact(); err != nil {
+				log.Printf("Ошибка записи снимка хранилища: %v", err)
+			}
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// sync сбрасывает на диск записанные, но не сброшенные записи журнала.
+func (w *memoryWAL) sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.dirty || w.err != nil || w.closed {
+		return w.err
+	}
+	if err := w.f.Sync(); err != nil {
+		w.err = fmt.Errorf("сброс журнала: %w", err)
+		return w.err
+	}
+	w.dirty = false
+	return nil
+}
+
+// compact записывает состояние хранилища компактным снимком и очищает журнал.
+// На время записи изменения ждут, чтение продолжается.
+func (s *memoryShared) compact() error {
+	w := s.wal
+	if w == nil {
+		return nil
+	}
+	s.persist.Lock()
+	defer s.persist.Unlock()
+	snap := s.snapshot()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil || w.closed {
+		return w.err
+	}
+	snap.Seq = w.seq
+	payload, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	if err := writeFileSync(filepath.Join(w.opts.Dir, snapshotFile), frame(payload)); err != nil {
+		return err
+	}
+	// Снимок уже на диске: если очистка журнала не удастся, записи до Seq пропустятся при чтении
+	if err := w.f.Truncate(0); err != nil {
+		w.err = fmt.Errorf("очистка журнала: %w", err)
+		return w.err
+	}
+	if err := w.f.Sync(); err != nil {
+		w.err = fmt.Errorf("сброс журнала: %w", err)
+		return w.err
+	}
+	w.dirty = false
+	return nil
+}
+
+// snapshot собирает состояние хранилища. Вызывается под s.persist, поэтому изменений нет.
+func (s *memoryShared) snapshot() memorySnapshot {
+	s.mu.Lock()
+	snap := memorySnapshot{NextID: s.nextID, NextKeyID: s.nextKeyID, Keys: []storedKey{}, Tenants: []tenantSnapshot{}}
+	for _, k := range s.keys {
+		snap.Keys = append(snap.Keys, *newStoredKey(k))
+	}
+	tenants := make([]*MemoryRepo, 0, len(s.tenants))
+	for _, t := range s.tenants {
+		tenants = append(tenants, t)
+	}
+	s.mu.Unlock()
+	sort.Slice(tenants, func(i, j int) bool { return tenants[i].tenant < tenants[j].tenant })
+
+	for _, r := range tenants {
+		r.mu.RLock()
+		t := tenantSnapshot{Tenant: r.tenant, Quotes: append([]Quote{}, r.data...)}
+		for _, p := range r.daily {
+			t.Daily = append(t.Daily, p)
+		}
+		for _, byKey := range r.reactions {
+			for _, re := range byKey {
+				t.Reactions = append(t.Reactions, re)
+			}
+		}
+		for key, n := range r.views {
+			key.Views = n
+			t.Views = append(t.Views, key)
+		}
+		for key, n := range r.requests {
+			key.Requests = n
+			t.Requests = append(t.Requests, key)
+		}
+		r.mu.RUnlock()
+		snap.Tenants = append(snap.Tenants, t)
+	}
+	return snap
+}
+
+// loadSnapshot восстанавливает состояние из снимка path и возвращает номер последней вошедшей
+// в него записи журнала. Отсутствие снимка — пустое хранилище.
+func (s *memoryShared) loadSnapshot(path string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	payload, n, err := readFrame(data)
+	if err == nil && n != len(data) {
+		err = errors.New("лишние данные после снимка")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("снимок %s повреждён: %w", path, err)
+	}
+	var snap memorySnapshot
+	if err := json.Unmarshal(payload, &snap); err != nil {
+		return 0, fmt.Errorf("снимок %s: %w", path, err)
+	}
+	s.nextID, s.nextKeyID = snap.NextID, snap.NextKeyID
+	for _, k := range snap.Keys {
+		s.keys = append(s.keys, k.key())
+	}
+	for _, t := range snap.Tenants {
+		r := s.tenant(t.Tenant)
+		r.data = t.Quotes
+		for _, q := range r.data {
+			r.weights.set(q.ID, quoteWeight(q))
+		}
+		for _, p := range t.Daily {
+			r.daily[p.Date] = p
+		}
+		for _, re := range t.Reactions {
+			if r.reactions[re.QuoteID] == nil {
+				r.reactions[re.QuoteID] = make(map[reactionKey]Reaction)
+			}
+			r.reactions[re.QuoteID][reactionKey{kind: re.Kind, client: re.Client}] = re
+		}
+		for _, v := range t.Views {
+			n := v.Views
+			v.Views = 0
+			r.views[v] = n
+		}
+		for _, q := range t.Requests {
+			n := q.Requests
+			q.Requests = 0
+			r.requests[q] = n
+		}
+	}
+	return snap.Seq, nil
+}
+
+// writeFileSync атомарно заменяет файл path: пишет временный файл, сбрасывает его на диск,
+// переименовывает и сбрасывает каталог, чтобы переименование пережило сбой питания.
+func writeFileSync(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
+
+// close останавливает фоновую работу, записывает последний снимок и закрывает журнал.
+func (s *memoryShared) close() error {
+	w := s.wal
+	if w == nil {
+		return nil
+	}
+	var err error
+	w.closeOnce.Do(func() {
+		close(w.stop)
+		<-w.done
+		err = s.compact()
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		w.closed = true
+		if w.err == nil && w.dirty {
+			err = errors.Join(err, w.f.Sync())
+		}
+		err = errors.Join(err, w.f.Close())
+	})
+	return err
+}
@@ -0,0 +1,274 @@
+// Тесты сохранения хранилища в памяти на диск
+package quotes
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"Test_Project_Brand_Scout/internal/auth"
+)
+
+// openPersistent открывает сохраняемое хранилище в dir с fsync после каждой записи
+func openPersistent(t *testing.T, dir string) Repository {
+	t.Helper()
+	repo, err := OpenMemoryRepository(MemoryPersistence{Dir: dir, Fsync: FsyncAlways})
+	if err != nil {
+		t.Fatalf("ошибка открытия хранилища: %v", err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	return repo
+}
+
+// fillPersistent выполняет по одному изменению каждого вида в двух пространствах
+func fillPersistent(t *testing.T, repo Repository) {
+	t.Helper()
+	at := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	team := repo.Tenant("team")
+	for _, step := range []func() error{
+		func() error { _, err := repo.Create(Quote{Author: "A", Text: "первая"}); return err },
+		func() error { _, err := team.Create(Quote{Author: "B", Text: "команды"}); return err },
+		func() error { _, err := repo.Create(Quote{Author: "A", Text: "удаляемая"}); return err },
+		func() error { return repo.SetScore(1, 7) },
+		func() error {
+			_, err := repo.SetReaction(Reaction{QuoteID: 1, Kind: ReactionRating, Client: "c1", Value: 4, CreatedAt: at})
+			return err
+		},
+		func() error {
+			_, err := repo.SetReaction(Reaction{QuoteID: 1, Kind: ReactionLike, Client: "c2", Value: 1, CreatedAt: at})
+			return err
+		},
+		func() error { _, err := repo.DeleteReaction(1, ReactionLike, "c2"); return err },
+		func() error {
+			return repo.AddViews([]ViewBucket{{Hour: at, QuoteID: 1, Views: 3}}, []RequestBucket{{Hour: at, Route: "/quotes", Requests: 5}})
+		},
+		func() error { _, err := repo.SaveDailyPick(DailyPick{Date: "2024-05-01", QuoteID: 3}); return err },
+		func() error { return team.PinDailyPick(DailyPick{Date: "2024-05-02", QuoteID: 2}) },
+		func() error { return repo.Delete(3) },
+		func() error {
+			_, err := repo.CreateKey(auth.Key{Name: "ci", Hash: "h1", Scopes: []string{auth.ScopeAdmin}, CreatedAt: at})
+			return err
+		},
+		func() error { return repo.RevokeKey(1, at.Add(time.Hour)) },
+	} {
+		if err := step(); err != nil {
+			t.Fatalf("ошибка изменения хранилища: %v", err)
+		}
+	}
+}
+
+// persistentState собирает состояние хранилища для сравнения после перезапуска
+func persistentState(t *testing.T, repo Repository) map[string]any {
+	t.Helper()
+	state := make(map[string]any)
+	for _, name := range []string{DefaultTenant, "team"} {
+		r := repo.Tenant(name)
+		all, _ := r.GetAll()
+		daily, _ := r.DailyPicksBetween("2024-01-01", "2025-01-01")
+		reactions, _ := r.ReactionsSince(time.Time{})
+		views, _ := r.ViewsSince(time.Time{})
+		requests, _ := r.RequestsSince(time.Time{})
+		state[name] = []any{all, daily, reactions, views, requests}
+	}
+	keys, _ := repo.ListKeys()
+	counts, _ := repo.QuoteCounts()
+	state["keys"], state["counts"] = keys, counts
+	return state
+}
+
+// TestMemoryPersistenceReopen проверяет восстановление из журнала и из снимка после закрытия
+func TestMemoryPersistenceReopen(t *testing.T) {
+	dir := t.TempDir()
+	repo := openPersistent(t, dir)
+	fillPersistent(t, repo)
+	want := persistentState(t, repo)
+
+	// Без закрытия: состояние восстанавливается только из журнала
+	fromWAL := openPersistent(t, dir)
+	if got := persistentState(t, fromWAL); !reflect.DeepEqual(got, want) {
+		t.Fatalf("состояние из журнала отличается:\nполучено  %v\nожидается %v", got, want)
+	}
+	if err := repo.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := os.Stat(filepath.Join(dir, walFile)); err != nil || fi.Size() != 0 {
+		t.Fatalf("после снимка журнал должен быть пуст: %v %v", fi, err)
+	}
+	reopened := openPersistent(t, dir)
+	if got := persistentState(t, reopened); !reflect.DeepEqual(got, want) {
+		t.Fatalf("состояние из снимка отличается:\nполучено  %v\nожидается %v", got, want)
+	}
+	if k, err := reopened.KeyByHash("h1"); err != nil || k.RevokedAt == nil {
+		t.Errorf("ключ должен находиться по хешу и быть отозван, получено %+v %v", k, err)
+	}
+	id, err := reopened.Create(Quote{Author: "C", Text: "после перезапуска"})
+	if err != nil || id != 4 {
+		t.Errorf("счётчик ID должен продолжиться с 4, получено %d %v", id, err)
+	}
+}
+
+// TestMemoryPersistenceTornRecord проверяет отбрасывание оборванной последней записи и отказ
+// открывать журнал, повреждённый в середине
+func TestMemoryPersistenceTornRecord(t *testing.T) {
+	dir := t.TempDir()
+	repo := openPersistent(t, dir)
+	repo.Create(Quote{Author: "A", Text: "первая"})
+	repo.Create(Quote{Author: "B", Text: "вторая"})
+	path := filepath.Join(dir, walFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Сбой во время записи третьей цитаты оставил половину записи
+	third := frame([]byte(`{"seq":3,"op":"create","tenant":"default","quote":{"id":3,"author":"C","quote":"третья"}}`))
+	torn := append(append([]byte{}, data...), third[:len(third)/2]...)
+	if err := os.WriteFile(path, torn, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	recovered := openPersistent(t, dir)
+	if all, _ := recovered.GetAll(); len(all) != 2 {
+		t.Fatalf("ожидаются две целые цитаты, получено %v", all)
+	}
+	if fi, _ := os.Stat(path); fi.Size() != int64(len(data)) {
+		t.Errorf("оборванная запись должна отрезаться: размер %d, ожидается %d", fi.Size(), len(data))
+	}
+	if id, err := recovered.Create(Quote{Author: "C", Text: "третья"}); err != nil || id != 3 {
+		t.Fatalf("ожидается цитата 3 после восстановления, получено %d %v", id, err)
+	}
+	if all, _ := openPersistent(t, dir).GetAll(); len(all) != 3 {
+		t.Errorf("запись после восстановления должна сохраниться, получено %v", all)
+	}
+
+	// Испорченная первая запись, за которой идут целые, — повреждение, а не обрыв
+	data, _ = os.ReadFile(path)
+	data[walHeaderSize] ^= 0xff
+	os.WriteFile(path, data, 0o644)
+	if _, err := OpenMemoryRepository(MemoryPersistence{Dir: dir}); err == nil || !strings.Contains(err.Error(), "повреждён") {
+		t.Errorf("ожидается ошибка повреждения журнала, получено %v", err)
+	}
+}
+
+// TestMemoryPersistenceZeroTail проверяет, что хвост из нулей после сбоя отрезается как оборванная запись
+func TestMemoryPersistenceZeroTail(t *testing.T) {
+	dir := t.TempDir()
+	repo := openPersistent(t, dir)
+	repo.Create(Quote{Author: "A", Text: "первая"})
+	repo.Create(Quote{Author: "B", Text: "вторая"})
+	path := filepath.Join(dir, walFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Файловая система увеличила размер файла, но не успела записать данные
+	padded := append(append([]byte{}, data...), make([]byte, 4096)...)
+	if err := os.WriteFile(path, padded, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	recovered := openPersistent(t, dir)
+	if all, _ := recovered.GetAll(); len(all) != 2 {
+		t.Fatalf("ожидаются две целые цитаты, получено %v", all)
+	}
+	if fi, _ := os.Stat(path); fi.Size() != int64(len(data)) {
+		t.Errorf("нулевой хвост должен отрезаться: размер %d, ожидается %d", fi.Size(), len(data))
+	}
+}
+
+// TestMemoryPersistenceBadLength проверяет, что испорченная длина записи в середине журнала
+// даёт ошибку повреждения и не отрезает целые записи после неё
+func TestMemoryPersistenceBadLength(t *testing.T) {
+	dir := t.TempDir()
+	repo := openPersistent(t, dir)
+	repo.Create(Quote{Author: "A", Text: "первая"})
+	repo.Create(Quote{Author: "B", Text: "вторая"})
+	repo.Create(Quote{Author: "C", Text: "третья"})
+	path := filepath.Join(dir, walFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, first, err := readFrame(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Длина второй записи выводит её за конец журнала, как у оборванной последней записи
+	binary.LittleEndian.PutUint32(data[first:first+4], 1<<20)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = OpenMemoryRepository(MemoryPersistence{Dir: dir})
+	if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("повреждён на смещении %d", first)) {
+		t.Errorf("ожидается ошибка повреждения на смещении %d, получено %v", first, err)
+	}
+	if fi, _ := os.Stat(path); fi.Size() != int64(len(data)) {
+		t.Errorf("повреждённый журнал не должен обрезаться: размер %d, ожидается %d", fi.Size(), len(data))
+	}
+
+	// Нулевой заголовок, за которым идут данные, — тоже повреждение
+	copy(data[first:first+walHeaderSize], make([]byte, walHeaderSize))
+	os.WriteFile(path, data, 0o644)
+	if _, err := OpenMemoryRepository(MemoryPersistence{Dir: dir}); err == nil || !strings.Contains(err.Error(), "повреждён") {
+		t.Errorf("ожидается ошибка повреждения журнала, получено %v", err)
+	}
+}
+
+// TestMemoryPersistenceSnapshotReplay проверяет, что записи, уже вошедшие в снимок, не применяются
+// повторно, если журнал не успели очистить
+func TestMemoryPersistenceSnapshotReplay(t *testing.T) {
+	dir := t.TempDir()
+	repo := openPersistent(t, dir).(*MemoryRepo)
+	repo.Create(Quote{Author: "A", Text: "первая"})
+	old, _ := os.ReadFile(filepath.Join(dir, walFile))
+	if err := repo.Snapshot(); err != nil {
+		t.Fatal(err)
+	}
+	repo.Create(Quote{Author: "B", Text: "вторая"})
+	rest, _ := os.ReadFile(filepath.Join(dir, walFile))
+	// Журнал как после сбоя между записью снимка и очисткой журнала
+	os.WriteFile(filepath.Join(dir, walFile), append(old, rest...), 0o644)
+
+	all, _ := openPersistent(t, dir).GetAll()
+	if len(all) != 2 || all[0].ID != 1 || all[1].ID != 2 {
+		t.Errorf("ожидаются цитаты 1 и 2 без повторов, получено %v", all)
+	}
+
+	os.WriteFile(filepath.Join(dir, snapshotFile), []byte("мусор"), 0o644)
+	if _, err := OpenMemoryRepository(MemoryPersistence{Dir: dir}); err == nil {
+		t.Error("повреждённый снимок должен давать ошибку")
+	}
+	if _, err := OpenMemoryRepository(MemoryPersistence{Dir: t.TempDir(), Fsync: "sometimes"}); err == nil {
+		t.Error("неизвестная политика fsync должна давать ошибку")
+	}
+}
+
+// TestMemoryPersistenceBackground проверяет сброс и снимки по расписанию
+func TestMemoryPersistenceBackground(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := OpenMemoryRepository(MemoryPersistence{Dir: dir, FsyncInterval: time.Millisecond, SnapshotInterval: 5 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	repo.Create(Quote{Author: "A", Text: "первая"})
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		fi, err := os.Stat(filepath.Join(dir, walFile))
+		if _, serr := os.Stat(filepath.Join(dir, snapshotFile)); err == nil && serr == nil && fi.Size() == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("снимок по расписанию не записан")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if all, _ := openPersistent(t, dir).GetAll(); len(all) != 1 {
+		t.Errorf("ожидается цитата из снимка, получено %v", all)
+	}
+}
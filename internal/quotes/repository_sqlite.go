@@ -1,91 +1,670 @@
 package quotes
 
 import (
+	"context"
 	"database/sql"
-	_ "github.com/mattn/go-sqlite3"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"Test_Project_Brand_Scout/internal/sqlitedriver"
 )
 
-// SQLiteRepo реализует хранение цитат в SQLite базе данных.
+// quoteColumns — список столбцов, из которых собирается Quote в scanQuote.
+const quoteColumns = "id, tenant, author, quote, score, likes, rating_sum, rating_count"
+
+// SQLiteRepo реализует хранение цитат одного рабочего пространства в SQLite базе данных.
+// Все запросы к цитатам фильтруются по столбцу tenant.
 type SQLiteRepo struct {
-	db *sql.DB
+	db     *sql.DB
+	driver *sqlitedriver.Driver
+	rnd    Rand
+	tenant string
+	shared *sqliteShared
+	idx    *sqliteIndex
+	// ctx — контекст запроса, в котором выполняются запросы к базе
+	ctx context.Context
+}
+
+// sqliteShared хранит индексы весов всех пространств, открытых из одного подключения.
+type sqliteShared struct {
+	mu      sync.Mutex
+	indexes map[string]*sqliteIndex
+}
+
+// sqliteIndex — индекс весов пространства в памяти процесса.
+// mu защищает индекс; ver — значение quotes_version пространства, которому соответствует индекс.
+// nil в weights означает, что индекс нужно перестроить из таблицы.
+type sqliteIndex struct {
+	mu      sync.Mutex
+	weights *weightIndex
+	ver     int64
+}
+
+func init() {
+	Register("sqlite", openSQLiteDSN)
+}
+
+// sqliteParams сопоставляет параметры адреса sqlite:// параметрам соединения. Имена те же,
+// что у go-sqlite3, поэтому адрес вида sqlite:///var/lib/quotes.db?_journal=WAL не зависит от драйвера.
+var sqliteParams = map[string]string{
+	"_busy_timeout": "busy_timeout", "_timeout": "busy_timeout",
+	"_journal_mode": "journal_mode", "_journal": "journal_mode",
+	"_synchronous": "synchronous", "_sync": "synchronous",
+	"_foreign_keys": "foreign_keys", "_fk": "foreign_keys",
+}
+
+// sqliteURIParams — параметры URI-имени файла SQLite, которые передаются в путь как есть.
+var sqliteURIParams = map[string][]string{
+	"mode":  {"ro", "rw", "rwc", "memory"},
+	"cache": {"shared", "private"},
+}
+
+// openSQLiteDSN открывает базу SQLite по адресу sqlite:///path или sqlite://:memory:.
+// Параметры соединения проверяются здесь и передаются драйверу в его синтаксисе.
+func openSQLiteDSN(dsn DSN, opts ...RepoOption) (Repository, error) {
+	allowed := []string{"mode", "cache"}
+	for name := range sqliteParams {
+		allowed = append(allowed, name)
+	}
+	sort.Strings(allowed)
+	if err := dsn.allow(allowed...); err != nil {
+		return nil, err
+	}
+	if dsn.Path == "" {
+		return nil, errors.New("в адресе нужен путь к базе, например sqlite:///var/lib/quotes.db или sqlite://:memory:")
+	}
+	path := dsn.Path
+	uri := url.Values{}
+	var pragmas []sqlitedriver.Pragma
+	for name, values := range dsn.Params {
+		raw := values[0]
+		if valid, ok := sqliteURIParams[name]; ok {
+			if !slices.Contains(valid, raw) {
+				return nil, fmt.Errorf("параметр %s: неизвестное значение %q (ожидается %s)", name, raw, strings.Join(valid, ", "))
+			}
+			uri.Set(name, raw)
+			continue
+		}
+		p, err := sqlitePragma(sqliteParams[name], raw)
+		if err != nil {
+			return nil, fmt.Errorf("параметр %s: %w", name, err)
+		}
+		pragmas = append(pragmas, p)
+	}
+	// Параметры mode и cache SQLite понимает только в URI-имени файла
+	if len(uri) > 0 {
+		if !strings.HasPrefix(path, "file:") {
+			path = "file:" + path
+		}
+		path += "?" + uri.Encode()
+	}
+	// Порядок параметров в DSN не зависит от порядка обхода map
+	sort.Slice(pragmas, func(i, j int) bool { return pragmas[i].Name < pragmas[j].Name })
+	return openSQLite(path, pragmas, applyRepoOptions(opts))
+}
+
+// sqlitePragma проверяет значение параметра соединения и приводит его к виду, понятному обоим драйверам.
+func sqlitePragma(name, raw string) (sqlitedriver.Pragma, error) {
+	value := strings.ToUpper(raw)
+	switch name {
+	case "busy_timeout":
+		if ms, err := strconv.Atoi(raw); err != nil || ms < 0 {
+			return sqlitedriver.Pragma{}, fmt.Errorf("ожидается неотрицательное число миллисекунд, получено %q", raw)
+		}
+		value = raw
+	case "journal_mode":
+		if !slices.Contains([]string{"DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF"}, value) {
+			return sqlitedriver.Pragma{}, fmt.Errorf("неизвестное значение %q (ожидается DELETE, TRUNCATE, PERSIST, MEMORY, WAL или OFF)", raw)
+		}
+	case "synchronous":
+		if !slices.Contains([]string{"OFF", "NORMAL", "FULL", "EXTRA"}, value) {
+			return sqlitedriver.Pragma{}, fmt.Errorf("неизвестное значение %q (ожидается OFF, NORMAL, FULL или EXTRA)", raw)
+		}
+	case "foreign_keys":
+		on, err := strconv.ParseBool(raw)
+		if err != nil {
+			return sqlitedriver.Pragma{}, fmt.Errorf("ожидается true или false, получено %q", raw)
+		}
+		value = "0"
+		if on {
+			value = "1"
+		}
+	}
+	return sqlitedriver.Pragma{Name: name, Value: value}, nil
+}
+
+// OpenSQLiteRepository открывает SQLite базу по указанному пути, применяет миграции схемы
+// и возвращает репозиторий пространства DefaultTenant.
+func OpenSQLiteRepository(path string, opts ...RepoOption) (Repository, error) {
+	return openSQLite(path, nil, applyRepoOptions(opts))
+}
+
+// openSQLite открывает базу с параметрами соединения pragmas и применяет миграции схемы.
+func openSQLite(path string, pragmas []sqlitedriver.Pragma, o repoOptions) (Repository, error) {
+	db, err := o.driver.Open(path, pragmas...)
+	if err != nil {
+		return nil, err
+	}
+	if err := migrateSQLite(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("миграции базы %s: %w", path, err)
+	}
+	base := &SQLiteRepo{db: db, driver: o.driver, rnd: o.rnd, shared: &sqliteShared{indexes: make(map[string]*sqliteIndex)}, ctx: context.Background()}
+	return base.Tenant(DefaultTenant), nil
+}
+
+// WithSQLDriver задаёт драйвер SQLite, например modernc для сборки без cgo или обёртку
+// для трассировки запросов. По умолчанию используется sqlitedriver.Default.
+func WithSQLDriver(d *sqlitedriver.Driver) RepoOption {
+	return func(o *repoOptions) { o.driver = d }
+}
+
+// Tenant возвращает репозиторий пространства id поверх того же подключения.
+func (r *SQLiteRepo) Tenant(id string) Repository {
+	id = normalizeTenant(id)
+	r.shared.mu.Lock()
+	defer r.shared.mu.Unlock()
+	idx, ok := r.shared.indexes[id]
+	if !ok {
+		idx = &sqliteIndex{}
+		r.shared.indexes[id] = idx
+	}
+	return &SQLiteRepo{db: r.db, driver: r.driver, rnd: r.rnd, tenant: id, shared: r.shared, idx: idx, ctx: r.ctx}
+}
+
+// WithContext возвращает репозиторий того же пространства, выполняющий запросы в контексте ctx.
+func (r *SQLiteRepo) WithContext(ctx context.Context) Repository {
+	c := *r
+	c.ctx = ctx
+	return &c
+}
+
+// queryer — общий интерфейс *sql.DB и *sql.Tx для чтения цитат.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// rowScanner — общий интерфейс *sql.Row и *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
 }
 
-// NewSQLiteRepository открывает SQLite базу по указанному пути, создаёт таблицу при необходимости и возвращает репозиторий.
-func NewSQLiteRepository(path string) Repository {
-	db, err := sql.Open("sqlite3", path)
+// scanQuote собирает Quote из строки со столбцами quoteColumns.
+func scanQuote(row rowScanner) (Quote, error) {
+	var q Quote
+	var ratingSum int
+	if err := row.Scan(&q.ID, &q.Tenant, &q.Author, &q.Text, &q.Score, &q.Likes, &ratingSum, &q.Ratings); err != nil {
+		return Quote{}, err
+	}
+	if q.Ratings > 0 {
+		q.Rating = float64(ratingSum) / float64(q.Ratings)
+	}
+	return q, nil
+}
+
+// scanQuotes выполняет запрос, возвращающий quoteColumns, и собирает результат в список.
+func scanQuotes(ctx context.Context, db queryer, query string, args ...any) ([]Quote, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []Quote
+	for rows.Next() {
+		q, err := scanQuote(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, q)
+	}
+	return list, rows.Err()
+}
+
+// queryQuote читает одну цитату пространства tenant по ID, возвращая ErrNotFound при её отсутствии.
+func queryQuote(ctx context.Context, db queryer, tenant string, id int) (Quote, error) {
+	q, err := scanQuote(db.QueryRowContext(ctx, "SELECT "+quoteColumns+" FROM quotes WHERE id = ? AND tenant = ?", id, tenant))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Quote{}, ErrNotFound
+	}
+	return q, err
+}
+
+// mutate выполняет изменение цитат в транзакции и поддерживает индекс весов в актуальном состоянии.
+// apply возвращает число изменённых строк; если версия данных выросла ровно на это число,
+// изменение было только нашим и индекс обновляется инкрементально через update,
+// иначе базу изменил другой процесс и индекс будет перестроен при следующем выборе.
+// Версия ведётся отдельно для каждого пространства, поэтому чужие изменения индекс не сбрасывают.
+func (r *SQLiteRepo) mutate(apply func(tx *sql.Tx) (int64, error), update func(w *weightIndex)) error {
+	r.idx.mu.Lock()
+	defer r.idx.mu.Unlock()
+	tx, err := r.db.BeginTx(r.ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	n, err := apply(tx)
 	if err != nil {
-		panic(err)
+		return err
 	}
-	stmt := `
-	CREATE TABLE IF NOT EXISTS quotes (
-	    id INTEGER PRIMARY KEY,
-	    author TEXT,
-	    quote TEXT
-	);`
-	if _, err := db.Exec(stmt); err != nil {
-		panic(err)
+	ver, err := r.version(tx)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
 	}
-	return &SQLiteRepo{db: db}
+	if r.idx.weights != nil && ver == r.idx.ver+n {
+		update(r.idx.weights)
+		r.idx.ver = ver
+	} else {
+		r.idx.weights = nil
+	}
+	return nil
+}
+
+// version возвращает счётчик изменений цитат пространства.
+func (r *SQLiteRepo) version(db queryer) (int64, error) {
+	var ver int64
+	err := db.QueryRowContext(r.ctx, "SELECT COALESCE((SELECT version FROM quotes_version WHERE tenant = ?), 0)", r.tenant).Scan(&ver)
+	return ver, err
 }
 
 // Create сохраняет новую цитату в базе, возвращает её ID или ошибку.
 func (r *SQLiteRepo) Create(q Quote) (int, error) {
-	res, err := r.db.Exec("INSERT INTO quotes(author, quote) VALUES(?, ?)", q.Author, q.Text)
+	return r.CreateLimited(q, 0)
+}
+
+// CreateLimited сохраняет цитату, если в пространстве меньше limit цитат.
+// Подсчёт выполняется в том же INSERT, поэтому параллельные вставки не превысят лимит.
+func (r *SQLiteRepo) CreateLimited(q Quote, limit int) (int, error) {
+	q.Tenant = r.tenant
+	err := r.mutate(func(tx *sql.Tx) (int64, error) {
+		res, err := tx.ExecContext(r.ctx, `INSERT INTO quotes(tenant, author, quote, score)
+			SELECT ?1, ?2, ?3, ?4 WHERE ?5 <= 0 OR (SELECT COUNT(*) FROM quotes WHERE tenant = ?1) < ?5`,
+			q.Tenant, q.Author, q.Text, q.Score, limit)
+		if err != nil {
+			return 0, err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return 0, ErrQuotaExceeded
+		}
+		id, _ := res.LastInsertId()
+		q.ID = int(id)
+		return 1, nil
+	}, func(w *weightIndex) {
+		w.set(q.ID, quoteWeight(q))
+	})
 	if err != nil {
 		return 0, err
 	}
-	id, _ := res.LastInsertId()
-	return int(id), nil
+	return q.ID, nil
 }
 
-// GetAll возвращает все цитаты из таблицы quotes.
-func (r *SQLiteRepo) GetAll() ([]Quote, error) {
-	rows, err := r.db.Query("SELECT id, author, quote FROM quotes")
+// QuoteCounts возвращает число цитат во всех непустых пространствах базы.
+func (r *SQLiteRepo) QuoteCounts() (map[string]int, error) {
+	rows, err := r.db.QueryContext(r.ctx, "SELECT tenant, COUNT(*) FROM quotes GROUP BY tenant")
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-
-	var list []Quote
+	counts := make(map[string]int)
 	for rows.Next() {
-		var q Quote
-		if err := rows.Scan(&q.ID, &q.Author, &q.Text); err != nil {
+		var tenant string
+		var n int
+		if err := rows.Scan(&tenant, &n); err != nil {
 			return nil, err
 		}
-		list = append(list, q)
+		counts[tenant] = n
 	}
-	return list, nil
+	return counts, rows.Err()
+}
+
+// DB возвращает подключение к базе, например для статистики пула соединений.
+func (r *SQLiteRepo) DB() *sql.DB {
+	return r.db
+}
+
+// Close закрывает соединения с базой. Запросы, выполняющиеся в этот момент, успевают завершиться.
+func (r *SQLiteRepo) Close() error {
+	return r.db.Close()
+}
+
+// GetAll возвращает все цитаты пространства из таблицы quotes.
+func (r *SQLiteRepo) GetAll() ([]Quote, error) {
+	return scanQuotes(r.ctx, r.db, "SELECT "+quoteColumns+" FROM quotes WHERE tenant = ? ORDER BY id", r.tenant)
+}
+
+// GetByID возвращает цитату по ID или ErrNotFound.
+func (r *SQLiteRepo) GetByID(id int) (Quote, error) {
+	return queryQuote(r.ctx, r.db, r.tenant, id)
 }
 
 // GetRandom возвращает случайную цитату из базы или ошибку, если записей нет.
 func (r *SQLiteRepo) GetRandom() (Quote, error) {
+	return r.GetRandomWith(r.rnd)
+}
+
+// GetRandomWith выбирает номер строки с помощью rnd и читает её по смещению в порядке ID.
+// В отличие от ORDER BY RANDOM() результат воспроизводим при одинаковом источнике случайности.
+// Подсчёт и чтение выполняются в одной транзакции, чтобы удаление между ними не сбило смещение.
+func (r *SQLiteRepo) GetRandomWith(rnd Rand) (Quote, error) {
+	tx, err := r.db.BeginTx(r.ctx, nil)
+	if err != nil {
+		return Quote{}, err
+	}
+	defer tx.Rollback()
+
+	var n int
+	if err := tx.QueryRowContext(r.ctx, "SELECT COUNT(*) FROM quotes WHERE tenant = ?", r.tenant).Scan(&n); err != nil {
+		return Quote{}, err
+	}
+	if n == 0 {
+		return Quote{}, ErrEmpty
+	}
+	return scanQuote(tx.QueryRowContext(r.ctx, "SELECT "+quoteColumns+" FROM quotes WHERE tenant = ? ORDER BY id LIMIT 1 OFFSET ?",
+		r.tenant, rnd.Intn(n)))
+}
+
+// GetWeightedRandomWith возвращает цитату с вероятностью, пропорциональной её весу.
+// Выбор делается по индексу весов в памяти процесса; если версия данных в базе
+// не совпадает с версией индекса, индекс сначала перестраивается из таблицы.
+func (r *SQLiteRepo) GetWeightedRandomWith(rnd Rand) (Quote, error) {
+	r.idx.mu.Lock()
+	defer r.idx.mu.Unlock()
+	tx, err := r.db.BeginTx(r.ctx, nil)
+	if err != nil {
+		return Quote{}, err
+	}
+	defer tx.Rollback()
+
+	ver, err := r.version(tx)
+	if err != nil {
+		return Quote{}, err
+	}
+	if r.idx.weights == nil || ver != r.idx.ver {
+		list, err := scanQuotes(r.ctx, tx, "SELECT "+quoteColumns+" FROM quotes WHERE tenant = ? ORDER BY id", r.tenant)
+		if err != nil {
+			return Quote{}, err
+		}
+		r.idx.weights, r.idx.ver = newWeightIndex(list), ver
+	}
+	id, ok := r.idx.weights.pick(rnd)
+	if !ok {
+		return Quote{}, ErrEmpty
+	}
+	return queryQuote(r.ctx, tx, r.tenant, id)
+}
+
+// SetScore задаёт оценку редактора и обновляет вес цитаты в индексе.
+func (r *SQLiteRepo) SetScore(id, score int) error {
 	var q Quote
-	err := r.db.QueryRow("SELECT id, author, quote FROM quotes ORDER BY RANDOM() LIMIT 1").
-		Scan(&q.ID, &q.Author, &q.Text)
-	return q, err
+	return r.mutate(func(tx *sql.Tx) (int64, error) {
+		if _, err := tx.ExecContext(r.ctx, "UPDATE quotes SET score = ? WHERE id = ? AND tenant = ?", score, id, r.tenant); err != nil {
+			return 0, err
+		}
+		var err error
+		q, err = queryQuote(r.ctx, tx, r.tenant, id)
+		return 1, err
+	}, func(w *weightIndex) {
+		w.set(id, quoteWeight(q))
+	})
 }
 
 // FilterByAuthor возвращает цитаты указанного автора из базы.
 func (r *SQLiteRepo) FilterByAuthor(author string) ([]Quote, error) {
-	rows, err := r.db.Query("SELECT id, author, quote FROM quotes WHERE author = ?", author)
+	return scanQuotes(r.ctx, r.db, "SELECT "+quoteColumns+" FROM quotes WHERE tenant = ? AND author = ? ORDER BY id", r.tenant, author)
+}
+
+// Page читает страницу цитат запросом WHERE id > ? ORDER BY id LIMIT ? по первичному ключу.
+func (r *SQLiteRepo) Page(author string, after, limit int) ([]Quote, error) {
+	query, args := "SELECT "+quoteColumns+" FROM quotes WHERE tenant = ? AND id > ?", []any{r.tenant, after}
+	if author != "" {
+		query += " AND author = ?"
+		args = append(args, author)
+	}
+	query += " ORDER BY id"
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+	return scanQuotes(r.ctx, r.db, query, args...)
+}
+
+// Delete удаляет цитату по ID, возвращает ErrNotFound, если цитаты нет в пространстве.
+// Вместе с цитатой удаляются ссылающиеся на неё записи о цитате дня.
+// Цитата другого пространства не удаляется, как и её реакции и показы.
+func (r *SQLiteRepo) Delete(id int) error {
+	return r.mutate(func(tx *sql.Tx) (int64, error) {
+		res, err := tx.ExecContext(r.ctx, "DELETE FROM quotes WHERE id = ? AND tenant = ?", id, r.tenant)
+		if err != nil {
+			return 0, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		if n == 0 {
+			return 0, ErrNotFound
+		}
+		if _, err := tx.ExecContext(r.ctx, "DELETE FROM daily_quotes WHERE tenant = ? AND quote_id = ?", r.tenant, id); err != nil {
+			return 0, err
+		}
+		if _, err := tx.ExecContext(r.ctx, "DELETE FROM reactions WHERE quote_id = ?", id); err != nil {
+			return 0, err
+		}
+		if _, err := tx.ExecContext(r.ctx, "DELETE FROM quote_views WHERE quote_id = ?", id); err != nil {
+			return 0, err
+		}
+		return n, nil
+	}, func(w *weightIndex) {
+		w.remove(id)
+	})
+}
+
+// SetReaction сохраняет реакцию клиента и пересчитывает агрегаты цитаты.
+// Повторный лайк игнорируется, повторная оценка заменяет значение и время.
+func (r *SQLiteRepo) SetReaction(re Reaction) (Quote, error) {
+	return r.updateReactions(re.QuoteID, func(tx *sql.Tx) error {
+		query := `INSERT INTO reactions(quote_id, kind, client, value, created_at) VALUES(?, ?, ?, ?, ?)
+			ON CONFLICT(quote_id, kind, client) DO UPDATE SET value = excluded.value, created_at = excluded.created_at`
+		if re.Kind == ReactionLike {
+			query = "INSERT OR IGNORE INTO reactions(quote_id, kind, client, value, created_at) VALUES(?, ?, ?, ?, ?)"
+		}
+		_, err := tx.ExecContext(r.ctx, query, re.QuoteID, re.Kind, re.Client, re.Value, re.CreatedAt.Unix())
+		return err
+	})
+}
+
+// DeleteReaction удаляет реакцию клиента и пересчитывает агрегаты цитаты.
+func (r *SQLiteRepo) DeleteReaction(quoteID int, kind, client string) (Quote, error) {
+	return r.updateReactions(quoteID, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(r.ctx, "DELETE FROM reactions WHERE quote_id = ? AND kind = ? AND client = ?", quoteID, kind, client)
+		return err
+	})
+}
+
+// updateReactions применяет изменение реакций и пересчитывает агрегаты цитаты в той же транзакции.
+// Обновление столбца likes увеличивает версию данных, поэтому индекс весов обновляется через mutate.
+func (r *SQLiteRepo) updateReactions(quoteID int, change func(tx *sql.Tx) error) (Quote, error) {
+	var q Quote
+	err := r.mutate(func(tx *sql.Tx) (int64, error) {
+		if _, err := queryQuote(r.ctx, tx, r.tenant, quoteID); err != nil {
+			return 0, err
+		}
+		if err := change(tx); err != nil {
+			return 0, err
+		}
+		_, err := tx.ExecContext(r.ctx, `UPDATE quotes SET
+			likes = (SELECT COUNT(*) FROM reactions WHERE quote_id = ?1 AND kind = 'like'),
+			rating_sum = (SELECT COALESCE(SUM(value), 0) FROM reactions WHERE quote_id = ?1 AND kind = 'rating'),
+			rating_count = (SELECT COUNT(*) FROM reactions WHERE quote_id = ?1 AND kind = 'rating')
+			WHERE id = ?1`, quoteID)
+		if err != nil {
+			return 0, err
+		}
+		q, err = queryQuote(r.ctx, tx, r.tenant, quoteID)
+		return 1, err
+	}, func(w *weightIndex) {
+		w.set(q.ID, quoteWeight(q))
+	})
+	return q, err
+}
+
+// ReactionsSince возвращает реакции, созданные не раньше since.
+func (r *SQLiteRepo) ReactionsSince(since time.Time) ([]Reaction, error) {
+	rows, err := r.db.QueryContext(r.ctx, `SELECT quote_id, kind, client, value, created_at FROM reactions
+		WHERE created_at >= ? AND quote_id IN (SELECT id FROM quotes WHERE tenant = ?)`, since.Unix(), r.tenant)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var list []Quote
+	var list []Reaction
 	for rows.Next() {
-		var q Quote
-		if err := rows.Scan(&q.ID, &q.Author, &q.Text); err != nil {
+		var re Reaction
+		var created int64
+		if err := rows.Scan(&re.QuoteID, &re.Kind, &re.Client, &re.Value, &created); err != nil {
 			return nil, err
 		}
+		re.CreatedAt = time.Unix(created, 0)
+		list = append(list, re)
+	}
+	return list, rows.Err()
+}
+
+// AddViews прибавляет часовые счётчики к сохранённым одной транзакцией.
+// Показы удалённых к этому моменту цитат и цитат других пространств отбрасываются.
+func (r *SQLiteRepo) AddViews(views []ViewBucket, requests []RequestBucket) error {
+	tx, err := r.db.BeginTx(r.ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, v := range views {
+		_, err := tx.ExecContext(r.ctx, `INSERT INTO quote_views(quote_id, hour, views)
+			SELECT id, ?, ? FROM quotes WHERE id = ? AND tenant = ?
+			ON CONFLICT(quote_id, hour) DO UPDATE SET views = views + excluded.views`,
+			v.Hour.Unix(), v.Views, v.QuoteID, r.tenant)
+		if err != nil {
+			return err
+		}
+	}
+	for _, q := range requests {
+		_, err := tx.ExecContext(r.ctx, `INSERT INTO request_counts(tenant, hour, route, requests) VALUES(?, ?, ?, ?)
+			ON CONFLICT(tenant, hour, route) DO UPDATE SET requests = requests + excluded.requests`,
+			r.tenant, q.Hour.Unix(), q.Route, q.Requests)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ViewsSince возвращает часовые счётчики показов начиная с часа since.
+func (r *SQLiteRepo) ViewsSince(since time.Time) ([]ViewBucket, error) {
+	rows, err := r.db.QueryContext(r.ctx, `SELECT quote_id, hour, views FROM quote_views
+		WHERE hour >= ? AND quote_id IN (SELECT id FROM quotes WHERE tenant = ?)`, since.Unix(), r.tenant)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []ViewBucket
+	for rows.Next() {
+		var v ViewBucket
+		var hour int64
+		if err := rows.Scan(&v.QuoteID, &hour, &v.Views); err != nil {
+			return nil, err
+		}
+		v.Hour = time.Unix(hour, 0).UTC()
+		list = append(list, v)
+	}
+	return list, rows.Err()
+}
+
+// RequestsSince возвращает часовые счётчики запросов начиная с часа since.
+func (r *SQLiteRepo) RequestsSince(since time.Time) ([]RequestBucket, error) {
+	rows, err := r.db.QueryContext(r.ctx, "SELECT hour, route, requests FROM request_counts WHERE tenant = ? AND hour >= ?", r.tenant, since.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []RequestBucket
+	for rows.Next() {
+		var q RequestBucket
+		var hour int64
+		if err := rows.Scan(&hour, &q.Route, &q.Requests); err != nil {
+			return nil, err
+		}
+		q.Hour = time.Unix(hour, 0).UTC()
 		list = append(list, q)
 	}
-	return list, nil
+	return list, rows.Err()
 }
 
-// Delete удаляет цитату по ID, возвращает ошибку при неудаче.
-func (r *SQLiteRepo) Delete(id int) error {
-	_, err := r.db.Exec("DELETE FROM quotes WHERE id = ?", id)
+// GetDailyPick возвращает цитату дня для даты или ErrNotFound.
+func (r *SQLiteRepo) GetDailyPick(date string) (DailyPick, error) {
+	var p DailyPick
+	err := r.db.QueryRowContext(r.ctx, "SELECT day, quote_id, pinned FROM daily_quotes WHERE tenant = ? AND day = ?", r.tenant, date).
+		Scan(&p.Date, &p.QuoteID, &p.Pinned)
+	if errors.Is(err, sql.ErrNoRows) {
+		return DailyPick{}, ErrNotFound
+	}
+	return p, err
+}
+
+// SaveDailyPick сохраняет выбор, если для даты ещё нет записи, и возвращает действующую запись.
+// INSERT OR IGNORE гарантирует, что экземпляры с общим файлом базы получат один и тот же выбор.
+func (r *SQLiteRepo) SaveDailyPick(p DailyPick) (DailyPick, error) {
+	_, err := r.db.ExecContext(r.ctx, "INSERT OR IGNORE INTO daily_quotes(tenant, day, quote_id, pinned) VALUES(?, ?, ?, ?)",
+		r.tenant, p.Date, p.QuoteID, p.Pinned)
+	if err != nil {
+		return DailyPick{}, err
+	}
+	return r.GetDailyPick(p.Date)
+}
+
+// PinDailyPick закрепляет цитату за датой, перезаписывая существующую запись.
+func (r *SQLiteRepo) PinDailyPick(p DailyPick) error {
+	_, err := r.db.ExecContext(r.ctx, `INSERT INTO daily_quotes(tenant, day, quote_id, pinned) VALUES(?, ?, ?, 1)
+		ON CONFLICT(tenant, day) DO UPDATE SET quote_id = excluded.quote_id, pinned = 1`, r.tenant, p.Date, p.QuoteID)
+	return err
+}
+
+// DeleteDailyPick удаляет запись о цитате дня для даты.
+func (r *SQLiteRepo) DeleteDailyPick(date string) error {
+	_, err := r.db.ExecContext(r.ctx, "DELETE FROM daily_quotes WHERE tenant = ? AND day = ?", r.tenant, date)
 	return err
 }
+
+// DailyPicksBetween возвращает записи за даты в диапазоне [from, to), упорядоченные по дате.
+func (r *SQLiteRepo) DailyPicksBetween(from, to string) ([]DailyPick, error) {
+	rows, err := r.db.QueryContext(r.ctx, "SELECT day, quote_id, pinned FROM daily_quotes WHERE tenant = ? AND day >= ? AND day < ? ORDER BY day",
+		r.tenant, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []DailyPick
+	for rows.Next() {
+		var p DailyPick
+		if err := rows.Scan(&p.Date, &p.QuoteID, &p.Pinned); err != nil {
+			return nil, err
+		}
+		list = append(list, p)
+	}
+	return list, rows.Err()
+}
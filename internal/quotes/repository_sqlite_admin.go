@@ -0,0 +1,138 @@
+package quotes
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// SQLiteSchemaVersion возвращает версию схемы базы db и номер последней миграции,
+// которую знает эта версия сервиса. База не изменяется.
+func SQLiteSchemaVersion(ctx context.Context, db *sql.DB) (current, latest int, err error) {
+	if err := db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&current); err != nil {
+		return 0, 0, err
+	}
+	return current, len(sqliteMigrations), nil
+}
+
+// MigrateSQLite применяет к базе db недостающие миграции схемы.
+func MigrateSQLite(db *sql.DB) error {
+	return migrateSQLite(db)
+}
+
+// Backup записывает согласованную копию базы в новый файл path через онлайн-API резервного
+// копирования SQLite. Копия делается без остановки записи: страницы читаются в одной транзакции чтения.
+// Копия пишется во временный файл рядом с path и переименовывается, поэтому прерванное копирование
+// не оставляет неполного файла; существующий файл не перезаписывается.
+func (r *SQLiteRepo) Backup(ctx context.Context, path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("файл %s уже существует", path)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	if err := r.driver.Backup(ctx, r.db, tmp.Name()); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// CheckIntegrity проверяет структуру файла базы (PRAGMA integrity_check), версию схемы и согласованность
+// данных: реакции и цитаты дня должны ссылаться на существующие цитаты, а агрегаты реакций цитат —
+// совпадать с самими реакциями. Возвращает список найденных проблем; пустой список — база в порядке.
+func (r *SQLiteRepo) CheckIntegrity(ctx context.Context) ([]string, error) {
+	problems, err := integrityCheck(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.CheckSchema(ctx); err != nil {
+		problems = append(problems, err.Error())
+	}
+	checks := []struct {
+		query, problem string
+	}{
+		{"SELECT COUNT(*) FROM reactions WHERE quote_id NOT IN (SELECT id FROM quotes)",
+			"реакций к удалённым цитатам: %d"},
+		{"SELECT COUNT(*) FROM daily_quotes d WHERE NOT EXISTS (SELECT 1 FROM quotes q WHERE q.id = d.quote_id AND q.tenant = d.tenant)",
+			"цитат дня, ссылающихся на отсутствующие цитаты: %d"},
+		{`SELECT COUNT(*) FROM quotes q WHERE
+			likes != (SELECT COUNT(*) FROM reactions WHERE quote_id = q.id AND kind = 'like') OR
+			rating_sum != (SELECT COALESCE(SUM(value), 0) FROM reactions WHERE quote_id = q.id AND kind = 'rating') OR
+			rating_count != (SELECT COUNT(*) FROM reactions WHERE quote_id = q.id AND kind = 'rating')`,
+			"цитат с агрегатами, не совпадающими с реакциями: %d"},
+	}
+	for _, c := range checks {
+		var n int
+		if err := r.db.QueryRowContext(ctx, c.query).Scan(&n); err != nil {
+			return nil, err
+		}
+		if n > 0 {
+			problems = append(problems, fmt.Sprintf(c.problem, n))
+		}
+	}
+	return problems, nil
+}
+
+// integrityCheck возвращает сообщения PRAGMA integrity_check, кроме единственного "ok".
+func integrityCheck(ctx context.Context, db *sql.DB) ([]string, error) {
+	rows, err := db.QueryContext(ctx, "PRAGMA integrity_check")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var problems []string
+	for rows.Next() {
+		var msg string
+		if err := rows.Scan(&msg); err != nil {
+			return nil, err
+		}
+		if msg != "ok" {
+			problems = append(problems, msg)
+		}
+	}
+	return problems, rows.Err()
+}
+
+// VerifySQLiteFile открывает файл базы только для чтения и проверяет, что это целая база SQLite,
+// схему которой эта версия сервиса умеет открыть. Возвращает версию схемы файла.
+// Из opts учитывается драйвер SQLite.
+func VerifySQLiteFile(ctx context.Context, path string, opts ...RepoOption) (int, error) {
+	if _, err := os.Stat(path); err != nil {
+		return 0, err
+	}
+	db, err := applyRepoOptions(opts).driver.Open("file:" + url.PathEscape(path) + "?mode=ro")
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+	problems, err := integrityCheck(ctx, db)
+	if err != nil {
+		return 0, fmt.Errorf("%s не является базой SQLite: %w", path, err)
+	}
+	if len(problems) > 0 {
+		return 0, fmt.Errorf("база %s повреждена: %s", path, problems[0])
+	}
+	version, latest, err := SQLiteSchemaVersion(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+	if version > latest {
+		return 0, fmt.Errorf("версия схемы %s %d новее поддерживаемой %d", path, version, latest)
+	}
+	var n int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'quotes'").Scan(&n); err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		return 0, errors.New("в базе нет таблицы quotes")
+	}
+	return version, nil
+}
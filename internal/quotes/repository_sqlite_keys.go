@@ -0,0 +1,83 @@
+package quotes
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"Test_Project_Brand_Scout/internal/auth"
+)
+
+// keyColumns — список столбцов, из которых собирается auth.Key в scanKey.
+const keyColumns = "id, name, prefix, hash, scopes, tenant, created_at, revoked_at"
+
+// scanKey собирает auth.Key из строки со столбцами keyColumns.
+func scanKey(row rowScanner) (auth.Key, error) {
+	var k auth.Key
+	var scopes string
+	var created int64
+	var revoked sql.NullInt64
+	if err := row.Scan(&k.ID, &k.Name, &k.Prefix, &k.Hash, &scopes, &k.Tenant, &created, &revoked); err != nil {
+		return auth.Key{}, err
+	}
+	k.Scopes = strings.Fields(scopes)
+	k.CreatedAt = time.Unix(created, 0).UTC()
+	if revoked.Valid {
+		at := time.Unix(revoked.Int64, 0).UTC()
+		k.RevokedAt = &at
+	}
+	return k, nil
+}
+
+// CreateKey сохраняет ключ API и возвращает его с присвоенным ID.
+func (r *SQLiteRepo) CreateKey(k auth.Key) (auth.Key, error) {
+	res, err := r.db.ExecContext(r.ctx, "INSERT INTO api_keys(name, prefix, hash, scopes, tenant, created_at) VALUES(?, ?, ?, ?, ?, ?)",
+		k.Name, k.Prefix, k.Hash, strings.Join(k.Scopes, " "), k.Tenant, k.CreatedAt.Unix())
+	if err != nil {
+		return auth.Key{}, err
+	}
+	id, _ := res.LastInsertId()
+	k.ID = int(id)
+	return k, nil
+}
+
+// KeyByHash возвращает ключ API по хешу или auth.ErrKeyNotFound.
+func (r *SQLiteRepo) KeyByHash(hash string) (auth.Key, error) {
+	k, err := scanKey(r.db.QueryRowContext(r.ctx, "SELECT "+keyColumns+" FROM api_keys WHERE hash = ?", hash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return auth.Key{}, auth.ErrKeyNotFound
+	}
+	return k, err
+}
+
+// ListKeys возвращает все ключи API в порядке выдачи.
+func (r *SQLiteRepo) ListKeys() ([]auth.Key, error) {
+	rows, err := r.db.QueryContext(r.ctx, "SELECT "+keyColumns+" FROM api_keys ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []auth.Key
+	for rows.Next() {
+		k, err := scanKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, k)
+	}
+	return list, rows.Err()
+}
+
+// RevokeKey помечает ключ API отозванным; повторный отзыв сохраняет исходное время.
+func (r *SQLiteRepo) RevokeKey(id int, at time.Time) error {
+	res, err := r.db.ExecContext(r.ctx, "UPDATE api_keys SET revoked_at = COALESCE(revoked_at, ?) WHERE id = ?", at.Unix(), id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return auth.ErrKeyNotFound
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package quotes
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// sqliteMigrations — упорядоченный список миграций схемы SQLite.
+// Номер последней применённой миграции хранится в PRAGMA user_version,
+// поэтому новые изменения схемы добавляются только в конец списка.
+var sqliteMigrations = []string{
+	// 1: исходные таблицы цитат и цитат дня
+	`CREATE TABLE IF NOT EXISTS quotes (
+	    id INTEGER PRIMARY KEY,
+	    author TEXT,
+	    quote TEXT
+	);
+	CREATE TABLE IF NOT EXISTS daily_quotes (
+	    day TEXT PRIMARY KEY,
+	    quote_id INTEGER NOT NULL,
+	    pinned INTEGER NOT NULL DEFAULT 0
+	);`,
+	// 2: оценка редактора и счётчик изменений цитат для индекса весов.
+	// Триггеры увеличивают версию при любом изменении, в том числе из других процессов.
+	`ALTER TABLE quotes ADD COLUMN score INTEGER NOT NULL DEFAULT 0;
+	CREATE TABLE quotes_version (
+	    id INTEGER PRIMARY KEY CHECK (id = 1),
+	    version INTEGER NOT NULL
+	);
+	INSERT INTO quotes_version(id, version) VALUES (1, 0);
+	CREATE TRIGGER quotes_version_insert AFTER INSERT ON quotes
+	BEGIN UPDATE quotes_version SET version = version + 1; END;
+	CREATE TRIGGER quotes_version_delete AFTER DELETE ON quotes
+	BEGIN UPDATE quotes_version SET version = version + 1; END;
+	CREATE TRIGGER quotes_version_score AFTER UPDATE OF score ON quotes
+	BEGIN UPDATE quotes_version SET version = version + 1; END;`,
+	// 3: реакции клиентов и их агрегаты; лайки влияют на вес, поэтому тоже меняют версию
+	`ALTER TABLE quotes ADD COLUMN likes INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE quotes ADD COLUMN rating_sum INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE quotes ADD COLUMN rating_count INTEGER NOT NULL DEFAULT 0;
+	CREATE TABLE reactions (
+	    quote_id INTEGER NOT NULL,
+	    kind TEXT NOT NULL,
+	    client TEXT NOT NULL,
+	    value INTEGER NOT NULL,
+	    created_at INTEGER NOT NULL,
+	    PRIMARY KEY (quote_id, kind, client)
+	);
+	CREATE INDEX reactions_created_at ON reactions(created_at);
+	CREATE TRIGGER quotes_version_likes AFTER UPDATE OF likes ON quotes
+	BEGIN UPDATE quotes_version SET version = version + 1; END;`,
+	// 4: часовые счётчики показов цитат и запросов для аналитики
+	`CREATE TABLE quote_views (
+	    quote_id INTEGER NOT NULL,
+	    hour INTEGER NOT NULL,
+	    views INTEGER NOT NULL,
+	    PRIMARY KEY (quote_id, hour)
+	);
+	CREATE INDEX quote_views_hour ON quote_views(hour);
+	CREATE TABLE request_counts (
+	    hour INTEGER NOT NULL,
+	    route TEXT NOT NULL,
+	    requests INTEGER NOT NULL,
+	    PRIMARY KEY (hour, route)
+	);`,
+	// 5: ключи API; хранится только SHA-256 хеш ключа, области доступа — через пробел
+	`CREATE TABLE api_keys (
+	    id INTEGER PRIMARY KEY,
+	    name TEXT NOT NULL,
+	    prefix TEXT NOT NULL,
+	    hash TEXT NOT NULL UNIQUE,
+	    scopes TEXT NOT NULL,
+	    created_at INTEGER NOT NULL,
+	    revoked_at INTEGER
+	);`,
+	// 6: рабочие пространства. Существующие данные переходят в пространство default,
+	// версия для индекса весов ведётся по пространствам, цитата дня и счётчики запросов
+	// получают пространство в первичном ключе, ключ API может быть привязан к пространству.
+	`ALTER TABLE quotes ADD COLUMN tenant TEXT NOT NULL DEFAULT 'default';
+	CREATE INDEX quotes_tenant ON quotes(tenant, id);
+	DROP TRIGGER quotes_version_insert;
+	DROP TRIGGER quotes_version_delete;
+	DROP TRIGGER quotes_version_score;
+	DROP TRIGGER quotes_version_likes;
+	DROP TABLE quotes_version;
+	CREATE TABLE quotes_version (
+	    tenant TEXT PRIMARY KEY,
+	    version INTEGER NOT NULL
+	);
+	CREATE TRIGGER quotes_version_insert AFTER INSERT ON quotes BEGIN
+	    INSERT OR IGNORE INTO quotes_version(tenant, version) VALUES (NEW.tenant, 0);
+	    UPDATE quotes_version SET version = version + 1 WHERE tenant = NEW.tenant;
+	END;
+	CREATE TRIGGER quotes_version_delete AFTER DELETE ON quotes BEGIN
+	    UPDATE quotes_version SET version = version + 1 WHERE tenant = OLD.tenant;
+	END;
+	CREATE TRIGGER quotes_version_score AFTER UPDATE OF score ON quotes BEGIN
+	    UPDATE quotes_version SET version = version + 1 WHERE tenant = NEW.tenant;
+	END;
+	CREATE TRIGGER quotes_version_likes AFTER UPDATE OF likes ON quotes BEGIN
+	    UPDATE quotes_version SET version = version + 1 WHERE tenant = NEW.tenant;
+	END;
+	CREATE TABLE daily_quotes_v6 (
+	    tenant TEXT NOT NULL,
+	    day TEXT NOT NULL,
+	    quote_id INTEGER NOT NULL,
+	    pinned INTEGER NOT NULL DEFAULT 0,
+	    PRIMARY KEY (tenant, day)
+	);
+	INSERT INTO daily_quotes_v6(tenant, day, quote_id, pinned) SELECT 'default', day, quote_id, pinned FROM daily_quotes;
+	DROP TABLE daily_quotes;
+	ALTER TABLE daily_quotes_v6 RENAME TO daily_quotes;
+	CREATE TABLE request_counts_v6 (
+	    tenant TEXT NOT NULL,
+	    hour INTEGER NOT NULL,
+	    route TEXT NOT NULL,
+	    requests INTEGER NOT NULL,
+	    PRIMARY KEY (tenant, hour, route)
+	);
+	INSERT INTO request_counts_v6(tenant, hour, route, requests) SELECT 'default', hour, route, requests FROM request_counts;
+	DROP TABLE request_counts;
+	ALTER TABLE request_counts_v6 RENAME TO request_counts;
+	ALTER TABLE api_keys ADD COLUMN tenant TEXT NOT NULL DEFAULT '';`,
+}
+
+// migrateSQLite применяет недостающие миграции в одной транзакции.
+// Версия перечитывается внутри транзакции, чтобы параллельный запуск не применил миграцию дважды.
+func migrateSQLite(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var version int
+	if err := tx.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	if version > len(sqliteMigrations) {
+		return fmt.Errorf("версия схемы базы %d новее поддерживаемой %d", version, len(sqliteMigrations))
+	}
+	for i := version; i < len(sqliteMigrations); i++ {
+		if _, err := tx.Exec(sqliteMigrations[i]); err != nil {
+			return fmt.Errorf("миграция %d: %w", i+1, err)
+		}
+	}
+	if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", len(sqliteMigrations))); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Ping проверяет соединение с базой.
+func (r *SQLiteRepo) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+// CheckSchema проверяет, что версия схемы базы совпадает с последней миграцией этой версии сервиса.
+// Расхождение означает, что базу обновил более новый экземпляр или миграции не были применены.
+func (r *SQLiteRepo) CheckSchema(ctx context.Context) error {
+	var version int
+	if err := r.db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	if version != len(sqliteMigrations) {
+		return fmt.Errorf("версия схемы базы %d, ожидается %d", version, len(sqliteMigrations))
+	}
+	return nil
+}
@@ -1,10 +1,50 @@
 package quotes
 
 import (
+	"context"
+	"errors"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"Test_Project_Brand_Scout/internal/sqlitedriver"
 )
 
+// openTestSQLite открывает SQLite-репозиторий и закрывает его по окончании теста
+func openTestSQLite(t *testing.T, path string, opts ...RepoOption) Repository {
+	t.Helper()
+	repo, err := OpenSQLiteRepository(path, opts...)
+	if err != nil {
+		t.Fatalf("ошибка открытия SQLite: %v", err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	return repo
+}
+
+// openTestSQLiteDrivers открывает SQLite-репозиторий на каждом драйвере сборки; ключ — sqlite/<драйвер>
+func openTestSQLiteDrivers(t *testing.T, path string, opts ...RepoOption) map[string]Repository {
+	t.Helper()
+	repos := map[string]Repository{}
+	for _, name := range sqlitedriver.Available() {
+		d, err := sqlitedriver.Lookup(name)
+		if err != nil {
+			t.Fatalf("ошибка выбора драйвера %s: %v", name, err)
+		}
+		repos["sqlite/"+name] = openTestSQLite(t, path, append([]RepoOption{WithSQLDriver(d)}, opts...)...)
+	}
+	return repos
+}
+
+// testBackends возвращает пустые репозитории всех бэкендов: memory, bolt и SQLite на каждом драйвере сборки
+func testBackends(t *testing.T) map[string]Repository {
+	t.Helper()
+	repos := openTestSQLiteDrivers(t, ":memory:")
+	repos["memory"] = NewMemoryRepository()
+	repos["bolt"] = openTestBolt(t)
+	return repos
+}
+
 // Тесты для SQLite-репозитория цитат
 func TestSQLiteRepoCRUD(t *testing.T) {
 	// Создание временного файла для SQLite-базы данных
@@ -17,7 +57,7 @@ func TestSQLiteRepoCRUD(t *testing.T) {
 	defer os.Remove(path)
 
 	// Инициализация репозитория с созданной базой
-	repo := NewSQLiteRepository(path)
+	repo := openTestSQLite(t, path)
 
 	// Проверяем, что база изначально пуста
 	all, err := repo.GetAll()
@@ -65,10 +105,10 @@ func TestSQLiteRepoCRUD(t *testing.T) {
 		t.Errorf("ожидается одна цитата от автора A, получено %v", listA)
 	}
 
-	// Удаление несуществующей цитаты не должно приводить к ошибке
+	// Удаление несуществующей цитаты возвращает ErrNotFound, как и в остальных бэкендах
 	err = repo.Delete(100)
-	if err != nil {
-		t.Errorf("ожидается отсутствие ошибки при удалении несуществующего ID, получено %v", err)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("ожидается ErrNotFound при удалении несуществующего ID, получено %v", err)
 	}
 
 	// Удаление существующей цитаты и проверка оставшегося
@@ -84,10 +124,127 @@ func TestSQLiteRepoCRUD(t *testing.T) {
 
 // TestSQLiteRepoRandomEmpty проверяет, что GetRandom возвращает ошибку при отсутствии записей
 func TestSQLiteRepoRandomEmpty(t *testing.T) {
-	repo := NewSQLiteRepository(":memory:")
+	repo := openTestSQLite(t, ":memory:")
 	// Попытка получения случайной цитаты из пустой базы должна привести к ошибке
 	_, err := repo.GetRandom()
 	if err == nil {
 		t.Errorf("ожидается ошибка при получении случайной цитаты из пустой базы, получен nil")
 	}
 }
+
+// TestSQLiteRepoContext проверяет, что запросы выполняются в контексте, привязанном через WithContext
+func TestSQLiteRepoContext(t *testing.T) {
+	repo := openTestSQLite(t, ":memory:")
+	repo.Create(Quote{Author: "A", Text: "a"})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := BindContext(repo, ctx).GetAll(); !errors.Is(err, context.Canceled) {
+		t.Errorf("ожидается context.Canceled, получено %v", err)
+	}
+	if list, err := BindContext(repo, ctx).Tenant(DefaultTenant).GetAll(); err == nil {
+		t.Errorf("Tenant должен сохранять контекст, получено %v", list)
+	}
+	if list, err := repo.GetAll(); err != nil || len(list) != 1 {
+		t.Errorf("исходный репозиторий не должен зависеть от чужого контекста: %v, %v", list, err)
+	}
+}
+
+// TestSQLiteRepoCheckSchema проверяет обнаружение несовпадающей версии схемы
+func TestSQLiteRepoCheckSchema(t *testing.T) {
+	repo := openTestSQLite(t, ":memory:").(*SQLiteRepo)
+	ctx := context.Background()
+	if err := repo.Ping(ctx); err != nil {
+		t.Fatalf("ошибка Ping: %v", err)
+	}
+	if err := repo.CheckSchema(ctx); err != nil {
+		t.Fatalf("после миграций схема должна быть актуальной: %v", err)
+	}
+	if _, err := repo.DB().Exec("PRAGMA user_version = 1000"); err != nil {
+		t.Fatalf("ошибка изменения версии: %v", err)
+	}
+	if err := repo.CheckSchema(ctx); err == nil {
+		t.Error("ожидается ошибка при чужой версии схемы")
+	}
+}
+
+// TestSQLiteRepoBackupIntegrity проверяет копию базы, её проверку и обнаружение несогласованных данных
+func TestSQLiteRepoBackupIntegrity(t *testing.T) {
+	dir := t.TempDir()
+	repo := openTestSQLite(t, filepath.Join(dir, "quotes.db")).(*SQLiteRepo)
+	defer repo.Close()
+	ctx := context.Background()
+	id, _ := repo.Create(Quote{Author: "A", Text: "first"})
+	if problems, err := repo.CheckIntegrity(ctx); err != nil || len(problems) != 0 {
+		t.Fatalf("ожидается целая база, получено %v, %v", problems, err)
+	}
+
+	backup := filepath.Join(dir, "backup.db")
+	if err := repo.Backup(ctx, backup); err != nil {
+		t.Fatalf("ошибка копирования: %v", err)
+	}
+	if err := repo.Backup(ctx, backup); err == nil {
+		t.Error("копия не должна перезаписывать существующий файл")
+	}
+	if version, err := VerifySQLiteFile(ctx, backup); err != nil || version != len(sqliteMigrations) {
+		t.Errorf("ожидается проверенная копия с версией %d, получено %d, %v", len(sqliteMigrations), version, err)
+	}
+	broken := filepath.Join(dir, "broken.db")
+	os.WriteFile(broken, []byte("not a database"), 0o600)
+	if _, err := VerifySQLiteFile(ctx, broken); err == nil {
+		t.Error("ожидается ошибка для файла, который не является базой")
+	}
+
+	if _, err := repo.DB().Exec("INSERT INTO reactions VALUES (?, 'like', 'c', 1, 0), (999, 'like', 'c', 1, 0)", id); err != nil {
+		t.Fatalf("ошибка вставки реакций: %v", err)
+	}
+	problems, err := repo.CheckIntegrity(ctx)
+	if err != nil || len(problems) != 2 {
+		t.Errorf("ожидаются реакция к удалённой цитате и несовпадающий агрегат, получено %v, %v", problems, err)
+	}
+}
+
+// TestSQLiteRepoDrivers проверяет одинаковое поведение репозитория на каждом драйвере сборки
+// и то, что файл базы и его копию открывает любой из драйверов
+func TestSQLiteRepoDrivers(t *testing.T) {
+	ctx := context.Background()
+	mem := NewMemoryRepository()
+	for _, text := range []string{"a", "b", "c", "d"} {
+		mem.Create(Quote{Author: "A", Text: text})
+	}
+	for _, name := range sqlitedriver.Available() {
+		d, _ := sqlitedriver.Lookup(name)
+		dir := t.TempDir()
+		repo := openTestSQLite(t, filepath.Join(dir, "quotes.db"), WithSQLDriver(d)).(*SQLiteRepo)
+		for _, text := range []string{"a", "b", "c", "d"} {
+			if _, err := repo.Create(Quote{Author: "A", Text: text}); err != nil {
+				t.Fatalf("%s: ошибка создания цитаты: %v", name, err)
+			}
+		}
+		if q, err := repo.SetReaction(Reaction{QuoteID: 2, Kind: ReactionRating, Client: "c", Value: 4, CreatedAt: time.Unix(100, 0)}); err != nil || q.Rating != 4 {
+			t.Errorf("%s: ожидается рейтинг 4, получено %v, %v", name, q, err)
+		}
+		for seed := int64(1); seed <= 5; seed++ {
+			a, _ := mem.GetRandomWith(NewSeededRand(seed))
+			b, err := repo.GetRandomWith(NewSeededRand(seed))
+			if err != nil || a.ID != b.ID {
+				t.Errorf("%s: seed=%d: выбрана цитата %d (%v), в памяти — %d", name, seed, b.ID, err, a.ID)
+			}
+		}
+		backup := filepath.Join(dir, "backup.db")
+		if err := repo.Backup(ctx, backup); err != nil {
+			t.Fatalf("%s: ошибка копирования: %v", name, err)
+		}
+		repo.Close()
+		for _, other := range sqlitedriver.Available() {
+			od, _ := sqlitedriver.Lookup(other)
+			if _, err := VerifySQLiteFile(ctx, backup, WithSQLDriver(od)); err != nil {
+				t.Errorf("копия %s не прошла проверку драйвером %s: %v", name, other, err)
+			}
+			copied := openTestSQLite(t, backup, WithSQLDriver(od))
+			if all, err := copied.GetAll(); err != nil || len(all) != 4 {
+				t.Errorf("копия %s, открытая драйвером %s: получено %v, %v", name, other, all, err)
+			}
+			copied.Close()
+		}
+	}
+}
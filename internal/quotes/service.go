@@ -1,46 +1,152 @@
 package quotes
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"time"
+)
 
 // ErrInvalidInput возвращается, если автор или текст цитаты пустые.
 var ErrInvalidInput = errors.New("Поля не должны быть пустыми (Автор и цитата)")
 
+// ErrInvalidScore возвращается, если оценка редактора отрицательная.
+var ErrInvalidScore = errors.New("оценка не может быть отрицательной")
+
 // Service предоставляет бизнес-логику работы с цитатами, используя репозиторий для хранения данных.
 type Service struct {
-	repo Repository
+	repo        Repository
+	ctx         context.Context // контекст запроса, см. WithContext
+	now         func() time.Time
+	dailyWindow int
+	rnd         Rand
+	halfLife    time.Duration
+	views       *viewCounter
+	// tenant — рабочее пространство сервиса; quota и quotas — лимиты числа цитат
+	tenant string
+	quota  int
+	quotas map[string]int
+}
+
+// Option настраивает необязательные параметры Service.
+type Option func(*Service)
+
+// WithClock задаёт источник текущего времени (используется в тестах).
+func WithClock(now func() time.Time) Option {
+	return func(s *Service) { s.now = now }
+}
+
+// WithDailyWindow задаёт число предыдущих дней, в течение которых цитата дня не повторяется.
+func WithDailyWindow(days int) Option {
+	return func(s *Service) { s.dailyWindow = days }
+}
+
+// WithRand задаёт источник случайности для GetRandom вместо источника репозитория.
+func WithRand(rnd Rand) Option {
+	return func(s *Service) { s.rnd = rnd }
 }
 
-// NewService создаёт новый Service с указанным репозиторием.
-func NewService(r Repository) *Service {
-	return &Service{repo: r}
+// NewService создаёт новый Service с указанным репозиторием для пространства DefaultTenant.
+func NewService(r Repository, opts ...Option) *Service {
+	s := &Service{repo: r, now: time.Now, views: newViewCounter(), tenant: DefaultTenant}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // Create добавляет новую цитату с указанным автором и текстом.
-// Возвращает идентификатор созданной цитаты или ошибку при невалидном вводе.
-func (s *Service) Create(author, text string) (int, error) {
+// Возвращает идентификатор созданной цитаты, ошибку при невалидном вводе
+// или ErrQuotaExceeded, если пространство достигло лимита цитат.
+func (s *Service) Create(author, text string) (id int, err error) {
+	s, end := s.trace("Create")
+	defer func() { end(err) }()
 	if author == "" || text == "" {
 		return 0, ErrInvalidInput
 	}
-	return s.repo.Create(Quote{Author: author, Text: text})
+	return s.repo.CreateLimited(Quote{Author: author, Text: text}, s.limit())
 }
 
 // GetAll возвращает список всех сохранённых цитат или ошибку.
-func (s *Service) GetAll() ([]Quote, error) {
+func (s *Service) GetAll() (list []Quote, err error) {
+	s, end := s.trace("GetAll")
+	defer func() { end(err) }()
 	return s.repo.GetAll()
 }
 
+// RandomParams задаёт способ выбора случайной цитаты.
+type RandomParams struct {
+	Seed     *int64 // фиксированный seed для воспроизводимого выбора
+	Weighted bool   // выбирать пропорционально весу цитаты
+}
+
+// Random возвращает случайную цитату согласно параметрам или ошибку, если цитаты отсутствуют.
+func (s *Service) Random(p RandomParams) (q Quote, err error) {
+	s, end := s.trace("Random")
+	defer func() { end(err) }()
+	rnd := s.rnd
+	if p.Seed != nil {
+		rnd = NewSeededRand(*p.Seed)
+	}
+	if p.Weighted {
+		if rnd == nil {
+			rnd = globalRand{}
+		}
+		return s.repo.GetWeightedRandomWith(rnd)
+	}
+	if rnd != nil {
+		return s.repo.GetRandomWith(rnd)
+	}
+	return s.repo.GetRandom()
+}
+
+// GetByID возвращает цитату по идентификатору или ErrNotFound.
+func (s *Service) GetByID(id int) (q Quote, err error) {
+	s, end := s.trace("GetByID")
+	defer func() { end(err) }()
+	return s.repo.GetByID(id)
+}
+
 // GetRandom возвращает случайную цитату или ошибку, если цитаты отсутствуют.
 func (s *Service) GetRandom() (Quote, error) {
-	return s.repo.GetRandom()
+	return s.Random(RandomParams{})
+}
+
+// GetRandomSeeded возвращает цитату, выбранную генератором с заданным seed.
+// При одинаковых seed и наборе цитат результат одинаков для всех бэкендов.
+func (s *Service) GetRandomSeeded(seed int64) (Quote, error) {
+	return s.Random(RandomParams{Seed: &seed})
+}
+
+// SetScore задаёт оценку редактора, определяющую вес цитаты при взвешенном выборе.
+// Нулевая оценка возвращает цитате вес по умолчанию.
+func (s *Service) SetScore(id, score int) (err error) {
+	s, end := s.trace("SetScore")
+	defer func() { end(err) }()
+	if score < 0 {
+		return ErrInvalidScore
+	}
+	return s.repo.SetScore(id, score)
 }
 
 // FilterByAuthor возвращает все цитаты указанного автора или ошибку.
-func (s *Service) FilterByAuthor(author string) ([]Quote, error) {
+func (s *Service) FilterByAuthor(author string) (list []Quote, err error) {
+	s, end := s.trace("FilterByAuthor")
+	defer func() { end(err) }()
 	return s.repo.FilterByAuthor(author)
 }
 
+// Page возвращает страницу цитат в порядке ID: не больше limit цитат с ID больше after, только автора
+// author, если он задан; limit 0 снимает ограничение.
+func (s *Service) Page(author string, after, limit int) (list []Quote, err error) {
+	s, end := s.trace("Page")
+	defer func() { end(err) }()
+	return s.repo.Page(author, after, limit)
+}
+
 // Delete удаляет цитату по заданному идентификатору.
 // Возвращает ошибку, если цитата не найдена.
-func (s *Service) Delete(id int) error {
+func (s *Service) Delete(id int) (err error) {
+	s, end := s.trace("Delete")
+	defer func() { end(err) }()
 	return s.repo.Delete(id)
 }
@@ -3,6 +3,7 @@ package quotes
 
 import (
 	"errors"
+	"slices"
 	"testing"
 )
 
@@ -89,3 +90,39 @@ func TestServiceCRUD(t *testing.T) {
 		t.Errorf("ожидается, что останется цитата id=2, получено %v", after)
 	}
 }
+
+// TestServicePage проверяет постраничную выборку в хранилище: порядок ID, after, limit, автора и пространство
+func TestServicePage(t *testing.T) {
+	for name, repo := range testBackends(t) {
+		svc := NewService(repo)
+		for i := 0; i < 6; i++ {
+			svc.Create([]string{"A", "B"}[i%2], "q")
+		}
+		repo.Tenant("team-b").Create(Quote{Author: "A", Text: "чужая"})
+		svc.Delete(3)
+
+		for _, tc := range []struct {
+			author       string
+			after, limit int
+			want         []int
+		}{
+			{"", 0, 0, []int{1, 2, 4, 5, 6}},
+			{"", 0, 2, []int{1, 2}},
+			{"", 2, 2, []int{4, 5}},
+			{"", 6, 10, nil},
+			{"A", 0, 0, []int{1, 5}},
+			{"A", 1, 1, []int{5}},
+			{"B", 2, 0, []int{4, 6}},
+			{"X", 0, 0, nil},
+		} {
+			list, err := svc.Page(tc.author, tc.after, tc.limit)
+			var ids []int
+			for _, q := range list {
+				ids = append(ids, q.ID)
+			}
+			if err != nil || !slices.Equal(ids, tc.want) {
+				t.Errorf("%s: Page(%q, %d, %d): ожидаются %v, получено %v %v", name, tc.author, tc.after, tc.limit, tc.want, ids, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package quotes
+
+import (
+	"context"
+	"errors"
+	"regexp"
+)
+
+// DefaultTenant — рабочее пространство для запросов без явного пространства
+// и для данных, созданных до появления пространств.
+const DefaultTenant = "default"
+
+// ErrInvalidTenant возвращается для имени пространства недопустимого формата.
+var ErrInvalidTenant = errors.New("некорректное имя рабочего пространства: допустимы a-z, 0-9, - и _, до 64 символов")
+
+// tenantPattern ограничивает имена пространств, чтобы они безопасно попадали в URL и логи.
+var tenantPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// ValidTenant проверяет имя рабочего пространства.
+func ValidTenant(id string) error {
+	if !tenantPattern.MatchString(id) {
+		return ErrInvalidTenant
+	}
+	return nil
+}
+
+// normalizeTenant заменяет пустое имя пространства на DefaultTenant.
+func normalizeTenant(id string) string {
+	if id == "" {
+		return DefaultTenant
+	}
+	return id
+}
+
+// WithQuotas ограничивает число цитат в рабочем пространстве: limits задаёт лимиты отдельных
+// пространств, def — лимит остальных. Нулевой лимит означает отсутствие ограничения.
+func WithQuotas(def int, limits map[string]int) Option {
+	return func(s *Service) {
+		s.quota = def
+		s.quotas = limits
+	}
+}
+
+// Tenant возвращает сервис, работающий с рабочим пространством id.
+// Сервисы пространств разделяют настройки и буфер счётчиков показов.
+func (s *Service) Tenant(id string) *Service {
+	id = normalizeTenant(id)
+	if id == s.tenant {
+		return s
+	}
+	c := *s
+	c.tenant = id
+	c.repo = s.repo.Tenant(id)
+	return &c
+}
+
+// TenantID возвращает рабочее пространство сервиса.
+func (s *Service) TenantID() string {
+	return s.tenant
+}
+
+// limit возвращает лимит цитат пространства сервиса.
+func (s *Service) limit() int {
+	if n, ok := s.quotas[s.tenant]; ok {
+		return n
+	}
+	return s.quota
+}
+
+// tenantKey — ключ контекста, под которым хранится рабочее пространство запроса.
+type tenantKey struct{}
+
+// WithTenant возвращает контекст с рабочим пространством запроса.
+func WithTenant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// TenantFromContext возвращает рабочее пространство запроса или DefaultTenant.
+func TenantFromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(tenantKey{}).(string); ok {
+		return id
+	}
+	return DefaultTenant
+}
@@ -0,0 +1,212 @@
+// Тесты изоляции рабочих пространств в репозиториях, сервисе и HTTP-маршрутах
+package quotes
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"Test_Project_Brand_Scout/internal/auth"
+)
+
+// TestTenantIsolationBackends проверяет, что пространство не видит, не выбирает и не изменяет чужие цитаты
+func TestTenantIsolationBackends(t *testing.T) {
+	for name, repo := range testBackends(t) {
+		other := repo.Tenant("team-b")
+		own, _ := repo.Create(Quote{Author: "A", Text: "своя"})
+		foreign, _ := other.Create(Quote{Author: "A", Text: "чужая"})
+		if own == foreign {
+			t.Fatalf("%s: ID цитат разных пространств совпали: %d", name, own)
+		}
+
+		list, _ := repo.GetAll()
+		if len(list) != 1 || list[0].ID != own || list[0].Tenant != DefaultTenant {
+			t.Errorf("%s: ожидается одна своя цитата, получено %+v", name, list)
+		}
+		if q, _ := other.GetByID(foreign); q.Tenant != "team-b" {
+			t.Errorf("%s: ожидается пространство team-b, получено %q", name, q.Tenant)
+		}
+		if _, err := repo.GetByID(foreign); !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: чужая цитата не должна находиться, получено %v", name, err)
+		}
+		if byAuthor, _ := other.FilterByAuthor("A"); len(byAuthor) != 1 || byAuthor[0].ID != foreign {
+			t.Errorf("%s: фильтр по автору вышел за пространство: %+v", name, byAuthor)
+		}
+		for seed := int64(0); seed < 20; seed++ {
+			if q, _ := repo.GetRandomWith(NewSeededRand(seed)); q.ID != own {
+				t.Fatalf("%s: случайный выбор вернул чужую цитату %d", name, q.ID)
+			}
+			if q, _ := other.GetWeightedRandomWith(NewSeededRand(seed)); q.ID != foreign {
+				t.Fatalf("%s: взвешенный выбор вернул чужую цитату %d", name, q.ID)
+			}
+		}
+		if _, err := repo.SetReaction(Reaction{QuoteID: foreign, Kind: ReactionLike, Client: "c", Value: 1, CreatedAt: time.Now()}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: лайк чужой цитаты должен давать ErrNotFound, получено %v", name, err)
+		}
+		if err := repo.SetScore(foreign, 5); !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: оценка чужой цитаты должна давать ErrNotFound, получено %v", name, err)
+		}
+		if err := repo.Delete(foreign); !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: удаление чужой цитаты должно давать ErrNotFound, получено %v", name, err)
+		}
+		if _, err := other.GetByID(foreign); err != nil {
+			t.Errorf("%s: удаление из чужого пространства затронуло цитату: %v", name, err)
+		}
+
+		if _, err := repo.Tenant("empty").GetRandom(); !errors.Is(err, ErrEmpty) {
+			t.Errorf("%s: ожидается ErrEmpty в пустом пространстве, получено %v", name, err)
+		}
+	}
+}
+
+// TestTenantDailyAndViews проверяет, что цитата дня и счётчики показов ведутся по пространствам
+func TestTenantDailyAndViews(t *testing.T) {
+	for name, repo := range testBackends(t) {
+		now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+		svc := NewService(repo, WithClock(fixedClock(now)))
+		a := svc.Tenant("team-a")
+		b := svc.Tenant("team-b")
+		idA, _ := a.Create("A", "a")
+		idB, _ := b.Create("B", "b")
+
+		if q, err := a.Daily(time.UTC); err != nil || q.ID != idA {
+			t.Errorf("%s: цитата дня team-a: %+v, %v", name, q, err)
+		}
+		if q, err := b.Daily(time.UTC); err != nil || q.ID != idB {
+			t.Errorf("%s: цитата дня team-b: %+v, %v", name, q, err)
+		}
+		if err := a.PinDaily("2026-03-02", idB); !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: закрепление чужой цитаты должно давать ErrNotFound, получено %v", name, err)
+		}
+
+		a.RecordViews("/quotes", Quote{ID: idA})
+		b.RecordViews("/quotes", Quote{ID: idB}, Quote{ID: idB})
+		report, err := b.Analytics(time.Hour, 10)
+		if err != nil {
+			t.Fatalf("%s: ошибка Analytics: %v", name, err)
+		}
+		if len(report.TopQuotes) != 1 || report.TopQuotes[0].ID != idB || report.TopQuotes[0].Views != 2 {
+			t.Errorf("%s: отчёт team-b содержит чужие показы: %+v", name, report.TopQuotes)
+		}
+		if len(report.Requests) != 1 || report.Requests[0].Total != 1 {
+			t.Errorf("%s: ожидается один запрос team-b, получено %+v", name, report.Requests)
+		}
+	}
+}
+
+// TestTenantQuota проверяет лимит цитат по умолчанию и для отдельного пространства
+func TestTenantQuota(t *testing.T) {
+	for name, repo := range testBackends(t) {
+		svc := NewService(repo, WithQuotas(1, map[string]int{"big": 3}))
+		if _, err := svc.Create("A", "a"); err != nil {
+			t.Fatalf("%s: ошибка создания: %v", name, err)
+		}
+		if _, err := svc.Create("A", "b"); !errors.Is(err, ErrQuotaExceeded) {
+			t.Errorf("%s: ожидается ErrQuotaExceeded, получено %v", name, err)
+		}
+		big := svc.Tenant("big")
+		for i := 0; i < 3; i++ {
+			if _, err := big.Create("A", "a"); err != nil {
+				t.Fatalf("%s: ошибка создания в big: %v", name, err)
+			}
+		}
+		if _, err := big.Create("A", "a"); !errors.Is(err, ErrQuotaExceeded) {
+			t.Errorf("%s: ожидается ErrQuotaExceeded в big, получено %v", name, err)
+		}
+		// После удаления место в квоте освобождается
+		list, _ := repo.GetAll()
+		svc.Delete(list[0].ID)
+		if _, err := svc.Create("A", "c"); err != nil {
+			t.Errorf("%s: ожидается успешное создание после удаления, получено %v", name, err)
+		}
+	}
+}
+
+// TestTenantSQLiteMigration проверяет перенос существующих данных в пространство default
+func TestTenantSQLiteMigration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quotes.db")
+	repo := openTestSQLite(t, path)
+	id, _ := repo.Create(Quote{Author: "A", Text: "a"})
+	repo.SaveDailyPick(DailyPick{Date: "2026-01-01", QuoteID: id})
+
+	reopened := openTestSQLite(t, path)
+	if q, err := reopened.GetByID(id); err != nil || q.Tenant != DefaultTenant {
+		t.Errorf("ожидается цитата пространства default, получено %+v, %v", q, err)
+	}
+	if _, err := reopened.Tenant("team-b").GetDailyPick("2026-01-01"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("цитата дня не должна быть видна другому пространству, получено %v", err)
+	}
+}
+
+// TestTenantHandlers проверяет выбор пространства по префиксу пути и по привязке ключа
+func TestTenantHandlers(t *testing.T) {
+	repo := NewMemoryRepository()
+	keys := auth.NewKeyManager(repo)
+	_, operator, _ := keys.Issue("operator", "", []string{auth.ScopeAdmin})
+	_, teamA, _ := keys.Issue("team-a", "team-a", []string{auth.ScopeRead, auth.ScopeWrite})
+	r := mux.NewRouter()
+	RegisterHandlers(r, NewService(repo, WithQuotas(0, map[string]int{"team-a": 1})), keys)
+
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Ключ команды без префикса работает в своём пространстве
+	if w := do("POST", "/quotes", teamA, `{"author":"A","quote":"a"}`); w.Code != http.StatusCreated {
+		t.Fatalf("ожидается 201, получен %d: %s", w.Code, w.Body)
+	}
+	if w := do("POST", "/quotes", teamA, `{"author":"A","quote":"b"}`); w.Code != http.StatusForbidden {
+		t.Errorf("ожидается 403 при превышении квоты, получен %d", w.Code)
+	}
+	// Оператор видит цитату только через префикс пространства
+	var list []Quote
+	json.NewDecoder(do("GET", "/w/team-a/quotes", operator, "").Body).Decode(&list)
+	if len(list) != 1 || list[0].Tenant != "team-a" {
+		t.Errorf("ожидается цитата team-a, получено %+v", list)
+	}
+	if w := do("GET", "/quotes/random", operator, ""); w.Code != http.StatusNotFound {
+		t.Errorf("ожидается 404 в пустом пространстве default, получен %d", w.Code)
+	}
+	if w := do("GET", "/w/team-b/quotes/random", teamA, ""); w.Code != http.StatusForbidden {
+		t.Errorf("ожидается 403 для чужого пространства, получен %d", w.Code)
+	}
+	if w := do("GET", "/w/Team%20B/quotes", operator, ""); w.Code != http.StatusBadRequest {
+		t.Errorf("ожидается 400 для некорректного имени пространства, получен %d", w.Code)
+	}
+	if w := do("DELETE", "/w/team-b/quotes/"+strconv.Itoa(list[0].ID), operator, ""); w.Code != http.StatusNotFound {
+		t.Errorf("ожидается 404 при удалении цитаты из чужого пространства, получен %d", w.Code)
+	}
+	if w := do("GET", "/w/team-a/quotes/"+strconv.Itoa(list[0].ID), teamA, ""); w.Code != http.StatusOK {
+		t.Errorf("ожидается 200 для своей цитаты по префиксу, получен %d", w.Code)
+	}
+}
+
+// TestQuoteCounts проверяет подсчёт цитат по всем пространствам хранилища
+func TestQuoteCounts(t *testing.T) {
+	for name, repo := range testBackends(t) {
+		repo.Create(Quote{Author: "A", Text: "a"})
+		repo.Tenant("team-b").Create(Quote{Author: "B", Text: "b"})
+		repo.Tenant("team-b").Create(Quote{Author: "B", Text: "c"})
+		repo.Tenant("empty").GetAll()
+
+		counts, err := repo.Tenant("team-b").QuoteCounts()
+		if err != nil {
+			t.Fatalf("%s: ошибка QuoteCounts: %v", name, err)
+		}
+		if len(counts) != 2 || counts[DefaultTenant] != 1 || counts["team-b"] != 2 {
+			t.Errorf("%s: неожиданные счётчики %v", name, counts)
+		}
+	}
+}
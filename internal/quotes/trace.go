@@ -0,0 +1,64 @@
+package quotes
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer возвращает трассировщик операций сервиса. Пока провайдер OpenTelemetry не настроен,
+// спаны ничего не записывают.
+func tracer() trace.Tracer {
+	return otel.Tracer("Test_Project_Brand_Scout/internal/quotes")
+}
+
+// tenantAttr — атрибут спана с рабочим пространством операции.
+const tenantAttr = attribute.Key("quotes.tenant")
+
+// ContextRepository реализуют репозитории, которые передают контекст запроса в хранилище:
+// для трассировки запросов к базе и их отмены вместе с HTTP-запросом.
+type ContextRepository interface {
+	Repository
+	// WithContext возвращает репозиторий того же пространства, работающий в контексте ctx.
+	WithContext(ctx context.Context) Repository
+}
+
+// BindContext привязывает repo к ctx, если репозиторий это поддерживает, иначе возвращает его как есть.
+func BindContext(repo Repository, ctx context.Context) Repository {
+	if r, ok := repo.(ContextRepository); ok {
+		return r.WithContext(ctx)
+	}
+	return repo
+}
+
+// WithContext возвращает сервис, выполняющий операции в контексте запроса ctx.
+func (s *Service) WithContext(ctx context.Context) *Service {
+	c := *s
+	c.ctx = ctx
+	c.repo = BindContext(s.repo, ctx)
+	return &c
+}
+
+// context возвращает контекст запроса сервиса или context.Background.
+func (s *Service) context() context.Context {
+	if s.ctx == nil {
+		return context.Background()
+	}
+	return s.ctx
+}
+
+// trace начинает спан операции name и возвращает сервис, привязанный к контексту спана,
+// чтобы вызовы репозитория стали дочерними спанами, и функцию, завершающую спан с ошибкой операции.
+func (s *Service) trace(name string) (*Service, func(error)) {
+	ctx, span := tracer().Start(s.context(), "Service."+name, trace.WithAttributes(tenantAttr.String(s.tenant)))
+	return s.WithContext(ctx), func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
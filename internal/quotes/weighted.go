@@ -0,0 +1,129 @@
+package quotes
+
+// fenwick — дерево Фенвика над целочисленными весами.
+// Поддерживает добавление элемента, изменение веса и поиск по префиксной сумме за O(log n).
+type fenwick struct {
+	tree []int64 // tree[i-1] хранит сумму отрезка (i - lowbit(i), i]
+}
+
+// lowbit возвращает младший установленный бит i.
+func lowbit(i int) int { return i & -i }
+
+// prefix возвращает сумму весов первых n элементов.
+func (f *fenwick) prefix(n int) int64 {
+	var s int64
+	for i := n; i > 0; i -= lowbit(i) {
+		s += f.tree[i-1]
+	}
+	return s
+}
+
+// push добавляет элемент с весом w в конец.
+func (f *fenwick) push(w int64) {
+	i := len(f.tree) + 1
+	f.tree = append(f.tree, w+f.prefix(i-1)-f.prefix(i-lowbit(i)))
+}
+
+// add прибавляет delta к весу элемента с индексом idx (с нуля).
+func (f *fenwick) add(idx int, delta int64) {
+	for i := idx + 1; i <= len(f.tree); i += lowbit(i) {
+		f.tree[i-1] += delta
+	}
+}
+
+// total возвращает сумму всех весов.
+func (f *fenwick) total() int64 { return f.prefix(len(f.tree)) }
+
+// find возвращает индекс (с нуля) первого элемента, на котором префиксная сумма превышает target.
+// target должен лежать в [0, total()).
+func (f *fenwick) find(target int64) int {
+	pos := 0
+	step := 1
+	for step*2 <= len(f.tree) {
+		step *= 2
+	}
+	for ; step > 0; step /= 2 {
+		if next := pos + step; next <= len(f.tree) && f.tree[next-1] <= target {
+			pos = next
+			target -= f.tree[next-1]
+		}
+	}
+	return pos
+}
+
+// quoteWeight возвращает вес цитаты при взвешенном выборе.
+// Явная оценка редактора имеет приоритет; без неё вес растёт с числом лайков.
+func quoteWeight(q Quote) int64 {
+	if q.Score > 0 {
+		return int64(q.Score)
+	}
+	return 1 + int64(q.Likes)
+}
+
+// weightIndex сопоставляет цитатам слоты дерева Фенвика и обновляется инкрементально.
+// Слоты выдаются в порядке возрастания ID, удалённые цитаты оставляют слот с нулевым весом,
+// поэтому одинаковые данные дают одинаковый выбор независимо от истории изменений.
+type weightIndex struct {
+	tree    fenwick
+	ids     []int       // слот -> ID цитаты (0 для удалённых)
+	weights []int64     // слот -> текущий вес
+	slots   map[int]int // ID цитаты -> слот
+}
+
+// newWeightIndex строит индекс по списку цитат, упорядоченному по ID.
+func newWeightIndex(list []Quote) *weightIndex {
+	w := &weightIndex{slots: make(map[int]int, len(list))}
+	for _, q := range list {
+		w.set(q.ID, quoteWeight(q))
+	}
+	return w
+}
+
+// set задаёт вес цитаты, добавляя её в конец, если она ещё не проиндексирована.
+func (w *weightIndex) set(id int, weight int64) {
+	if slot, ok := w.slots[id]; ok {
+		w.tree.add(slot, weight-w.weights[slot])
+		w.weights[slot] = weight
+		return
+	}
+	w.slots[id] = len(w.ids)
+	w.ids = append(w.ids, id)
+	w.weights = append(w.weights, weight)
+	w.tree.push(weight)
+}
+
+// remove обнуляет вес цитаты и освобождает её слот.
+// Когда удалённых слотов становится больше половины, индекс перестраивается.
+func (w *weightIndex) remove(id int) {
+	slot, ok := w.slots[id]
+	if !ok {
+		return
+	}
+	w.tree.add(slot, -w.weights[slot])
+	w.weights[slot] = 0
+	w.ids[slot] = 0
+	delete(w.slots, id)
+	if len(w.slots)*2 < len(w.ids) {
+		w.compact()
+	}
+}
+
+// compact перестраивает индекс без удалённых слотов за O(n).
+func (w *weightIndex) compact() {
+	ids, weights := w.ids, w.weights
+	*w = weightIndex{slots: make(map[int]int, len(w.slots))}
+	for i, id := range ids {
+		if id != 0 {
+			w.set(id, weights[i])
+		}
+	}
+}
+
+// pick выбирает ID цитаты с вероятностью, пропорциональной её весу.
+func (w *weightIndex) pick(rnd Rand) (int, bool) {
+	total := w.tree.total()
+	if total <= 0 {
+		return 0, false
+	}
+	return w.ids[w.tree.find(rnd.Int63n(total))], true
+}
@@ -0,0 +1,206 @@
+// Тесты для взвешенного выбора цитат: дерево Фенвика, индекс весов и оба бэкенда
+package quotes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"Test_Project_Brand_Scout/internal/sqlitedriver"
+)
+
+// TestFenwickFind сравнивает поиск по префиксной сумме с наивным перебором
+func TestFenwickFind(t *testing.T) {
+	weights := []int64{3, 0, 1, 5, 2, 0, 7, 1, 4}
+	var f fenwick
+	for _, w := range weights {
+		f.push(w)
+	}
+	f.add(2, 2) // вес элемента 2 становится 3
+	weights[2] = 3
+
+	var total int64
+	for _, w := range weights {
+		total += w
+	}
+	if f.total() != total {
+		t.Fatalf("ожидается сумма %d, получено %d", total, f.total())
+	}
+	for target := int64(0); target < total; target++ {
+		want, acc := 0, int64(0)
+		for i, w := range weights {
+			acc += w
+			if acc > target {
+				want = i
+				break
+			}
+		}
+		if got := f.find(target); got != want {
+			t.Errorf("target=%d: ожидается индекс %d, получен %d", target, want, got)
+		}
+	}
+}
+
+// TestWeightIndexRemoveCompact проверяет удаление и перестроение индекса весов
+func TestWeightIndexRemoveCompact(t *testing.T) {
+	w := newWeightIndex([]Quote{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4, Score: 5}})
+	w.remove(1)
+	w.remove(2)
+	w.remove(3) // удалённых слотов больше половины — индекс перестраивается
+	if len(w.ids) != 1 || w.tree.total() != 5 {
+		t.Fatalf("ожидается один слот с весом 5, получено ids=%v total=%d", w.ids, w.tree.total())
+	}
+	if id, ok := w.pick(NewSeededRand(1)); !ok || id != 4 {
+		t.Errorf("ожидается выбор цитаты 4, получено %d", id)
+	}
+}
+
+// TestWeightedRandomBackends проверяет распределение и совпадение выбора во всех бэкендах
+func TestWeightedRandomBackends(t *testing.T) {
+	repos := testBackends(t)
+	rands := map[string]Rand{}
+	for name, repo := range repos {
+		for _, text := range []string{"a", "b", "c"} {
+			repo.Create(Quote{Author: "A", Text: text})
+		}
+		if err := repo.SetScore(2, 98); err != nil {
+			t.Fatalf("%s: ошибка SetScore: %v", name, err)
+		}
+		if err := repo.SetScore(100, 1); err != ErrNotFound {
+			t.Errorf("%s: ожидается ErrNotFound для несуществующей цитаты, получено %v", name, err)
+		}
+		rands[name] = NewSeededRand(3)
+	}
+
+	mem, rm := repos["memory"], rands["memory"]
+	hits := 0
+	for i := 0; i < 1000; i++ {
+		a, err := mem.GetWeightedRandomWith(rm)
+		if err != nil {
+			t.Fatalf("memory: ошибка взвешенного выбора: %v", err)
+		}
+		for name, repo := range repos {
+			if name == "memory" {
+				continue
+			}
+			if b, err := repo.GetWeightedRandomWith(rands[name]); err != nil || b != a {
+				t.Fatalf("шаг %d: %s выбрал %v (%v), memory — %v", i, name, b, err, a)
+			}
+		}
+		if a.ID == 2 {
+			hits++
+		}
+	}
+	// Вес цитаты 2 — 98 из 100
+	if hits < 950 {
+		t.Errorf("ожидается около 980 выборов цитаты 2, получено %d", hits)
+	}
+
+	// После удаления тяжёлой цитаты она больше не выбирается
+	for name, repo := range repos {
+		repo.Delete(2)
+		for i := 0; i < 50; i++ {
+			if q, _ := repo.GetWeightedRandomWith(rands[name]); q.ID == 2 {
+				t.Fatalf("%s: выбрана удалённая цитата", name)
+			}
+		}
+	}
+	if _, err := NewMemoryRepository().GetWeightedRandomWith(rm); err != ErrEmpty {
+		t.Errorf("ожидается ErrEmpty для пустого репозитория, получено %v", err)
+	}
+}
+
+// TestSQLiteWeightsSharedFile проверяет, что индекс весов замечает изменения другого экземпляра
+func TestSQLiteWeightsSharedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quotes.db")
+	first := openTestSQLite(t, path)
+	second := openTestSQLite(t, path)
+
+	first.Create(Quote{Author: "A", Text: "a"})
+	rnd := NewSeededRand(1)
+	if q, _ := first.GetWeightedRandomWith(rnd); q.ID != 1 {
+		t.Fatalf("ожидается цитата 1, получено %v", q)
+	}
+	// Второй экземпляр добавляет цитату и удаляет первую
+	second.Create(Quote{Author: "B", Text: "b"})
+	second.Delete(1)
+	for i := 0; i < 20; i++ {
+		q, err := first.GetWeightedRandomWith(rnd)
+		if err != nil || q.ID != 2 {
+			t.Fatalf("ожидается цитата 2 после изменений другого экземпляра, получено %v, %v", q, err)
+		}
+	}
+}
+
+// TestSQLiteMigrateLegacy проверяет миграцию базы, созданной до появления оценок
+func TestSQLiteMigrateLegacy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legacy.db")
+	db, err := sqlitedriver.Default().Open(path)
+	if err != nil {
+		t.Fatalf("не удалось открыть базу: %v", err)
+	}
+	_, err = db.Exec(`CREATE TABLE quotes (id INTEGER PRIMARY KEY, author TEXT, quote TEXT);
+		INSERT INTO quotes(author, quote) VALUES ('A', 'старая цитата');`)
+	db.Close()
+	if err != nil {
+		t.Fatalf("не удалось создать старую схему: %v", err)
+	}
+
+	repo := openTestSQLite(t, path)
+	all, err := repo.GetAll()
+	if err != nil || len(all) != 1 || all[0].Text != "старая цитата" {
+		t.Fatalf("ожидается сохранённая цитата после миграции, получено %v, %v", all, err)
+	}
+	if err := repo.SetScore(all[0].ID, 3); err != nil {
+		t.Errorf("ошибка SetScore после миграции: %v", err)
+	}
+	// Повторное открытие не применяет миграции заново и сохраняет данные
+	q, err := openTestSQLite(t, path).GetByID(all[0].ID)
+	if err != nil || q.Score != 3 {
+		t.Errorf("ожидается цитата с оценкой 3 после повторного открытия, получено %v, %v", q, err)
+	}
+}
+
+// TestScoreAndWeightedHandlers проверяет эндпоинт оценки и параметр weighted
+func TestScoreAndWeightedHandlers(t *testing.T) {
+	repo := NewMemoryRepository()
+	repo.Create(Quote{Author: "A", Text: "a"})
+	repo.Create(Quote{Author: "B", Text: "b"})
+	r := mux.NewRouter()
+	RegisterHandlers(r, NewService(repo), nil)
+
+	cases := []struct {
+		body string
+		path string
+		code int
+	}{
+		{`{"score":5}`, "/admin/quotes/1/score", http.StatusNoContent},
+		{`{"score":-1}`, "/admin/quotes/1/score", http.StatusBadRequest},
+		{`{"score":5}`, "/admin/quotes/100/score", http.StatusNotFound},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPut, c.path, strings.NewReader(c.body)))
+		if w.Code != c.code {
+			t.Errorf("%s %s: ожидается %d, получен %d", c.path, c.body, c.code, w.Code)
+		}
+	}
+	if q, _ := repo.GetByID(1); q.Score != 5 {
+		t.Errorf("ожидается оценка 5, получено %d", q.Score)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/quotes/random?weighted=true&seed=1", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("ожидается 200 для взвешенного выбора, получен %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/quotes/random?weighted=maybe", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("ожидается 400 для некорректного weighted, получен %d", w.Code)
+	}
+}
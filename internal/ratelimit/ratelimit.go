@@ -0,0 +1,228 @@
+// Package ratelimit ограничивает частоту запросов клиентов алгоритмом token bucket
+// и отдельно считает дневные квоты запросов.
+package ratelimit
+
+import (
+	"io"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"Test_Project_Brand_Scout/internal/auth"
+)
+
+// DefaultRoute — ключ правила для маршрутов, у которых нет собственного правила.
+const DefaultRoute = "*"
+
+// Rule — ограничения одного маршрута для одного клиента.
+// Rate — скорость пополнения корзины в запросах в секунду, Burst — её ёмкость, то есть
+// сколько запросов можно сделать подряд. Daily — число запросов за календарные сутки UTC.
+// Нулевые Rate и Daily отключают соответствующее ограничение.
+type Rule struct {
+	Rate  float64
+	Burst int
+	Daily int
+}
+
+// Result — решение по запросу и сведения для заголовков RateLimit-*.
+type Result struct {
+	Allowed    bool
+	Limit      int           // ёмкость корзины или дневная квота
+	Remaining  int           // сколько запросов ещё можно сделать сразу
+	Reset      time.Duration // через сколько ограничение полностью восстановится
+	RetryAfter time.Duration // через сколько стоит повторить отклонённый запрос
+}
+
+// Store хранит состояние корзин и дневных счётчиков.
+// Реализация в памяти подходит для одного экземпляра, SQLite — для нескольких экземпляров с общим файлом.
+type Store interface {
+	// Take забирает токен из корзины key с ёмкостью burst и скоростью пополнения rate в секунду.
+	Take(key string, rate float64, burst int, now time.Time) (Result, error)
+	// TakeDaily увеличивает счётчик key за сутки day, если он меньше quota.
+	TakeDaily(key, day string, quota int) (Result, error)
+}
+
+// KeyFunc определяет клиента, для которого считаются ограничения.
+type KeyFunc func(r *http.Request) string
+
+// ByIP ограничивает запросы по IP-адресу клиента.
+func ByIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return "ip:" + r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// ByIdentity ограничивает запросы по ключу API или субъекту токена,
+// а запросы без действительных учётных данных — по IP-адресу.
+// Личность должна быть заранее определена middleware auth.Identify.
+func ByIdentity(r *http.Request) string {
+	if id, ok := auth.FromContext(r.Context()); ok && id.Subject != "" {
+		return "id:" + id.Subject
+	}
+	return ByIP(r)
+}
+
+// ByTenant возвращает KeyFunc, ограничивающий запросы по рабочему пространству: клиент, привязанный
+// к пространству, всегда считается в своём пространстве, даже если обращается к чужому /w/{workspace};
+// непривязанные клиенты — по префиксу /w/{workspace}, иначе fallback.
+func ByTenant(fallback string) KeyFunc {
+	return func(r *http.Request) string {
+		if id, ok := auth.FromContext(r.Context()); ok && id.Tenant != "" {
+			return "tenant:" + id.Tenant
+		}
+		if ws := mux.Vars(r)["workspace"]; ws != "" {
+			return "tenant:" + ws
+		}
+		return "tenant:" + fallback
+	}
+}
+
+// Limiter применяет правила маршрутов к запросам.
+type Limiter struct {
+	store Store
+	rules atomic.Pointer[map[string]Rule]
+	key   KeyFunc
+	now   func() time.Time
+}
+
+// New создаёт Limiter. Правила задаются по маршрутам вида "POST /quotes" или "/quotes/{id}"
+// (для любого метода); правило DefaultRoute применяется к остальным маршрутам.
+func New(store Store, rules map[string]Rule, key KeyFunc) *Limiter {
+	l := &Limiter{store: store, key: key, now: time.Now}
+	l.SetRules(rules)
+	return l
+}
+
+// SetRules заменяет правила без сброса накопленных корзин и дневных счётчиков.
+// Без правил Limiter пропускает все запросы.
+func (l *Limiter) SetRules(rules map[string]Rule) {
+	l.rules.Store(&rules)
+}
+
+// Close закрывает хранилище счётчиков, если оно держит ресурсы, например соединение с базой.
+func (l *Limiter) Close() error {
+	if c, ok := l.store.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// workspacePrefix — префикс маршрутов рабочих пространств; такие маршруты подчиняются
+// тем же правилам, что и маршруты без префикса.
+const workspacePrefix = "/w/{workspace}"
+
+// rule находит правило запроса и имя, под которым ведутся его корзины.
+func (l *Limiter) rule(r *http.Request) (string, Rule, bool) {
+	route := r.URL.Path
+	if cur := mux.CurrentRoute(r); cur != nil {
+		if tpl, err := cur.GetPathTemplate(); err == nil {
+			route = tpl
+		}
+	}
+	route = strings.TrimPrefix(route, workspacePrefix)
+	rules := *l.rules.Load()
+	for _, name := range []string{r.Method + " " + route, route, DefaultRoute} {
+		if rule, ok := rules[name]; ok {
+			return name, rule, true
+		}
+	}
+	return "", Rule{}, false
+}
+
+// Middleware отклоняет запросы сверх ограничений с кодом 429 и заголовком Retry-After.
+// Ответы получают заголовки RateLimit-Limit, RateLimit-Remaining и RateLimit-Reset по более строгому
+// из ограничений: корзине или дневной квоте. Дневная квота расходуется только запросами, прошедшими корзину.
+// При сбое хранилища запрос пропускается: недоступность лимитера не должна останавливать API.
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, rule, ok := l.rule(r)
+		if !ok || (rule.Rate <= 0 && rule.Daily <= 0) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		key := name + "|" + l.key(r)
+		now := l.now()
+
+		res := Result{Allowed: true, Remaining: math.MaxInt}
+		if rule.Rate > 0 {
+			var err error
+			if res, err = l.store.Take(key, rule.Rate, rule.Burst, now); err != nil {
+				log.Printf("Ошибка ограничителя запросов: %v", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+		if res.Allowed && rule.Daily > 0 {
+			daily, err := l.store.TakeDaily(key, now.UTC().Format("2006-01-02"), rule.Daily)
+			if err != nil {
+				log.Printf("Ошибка ограничителя запросов: %v", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			daily.Reset = now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour).Sub(now)
+			if !daily.Allowed {
+				daily.RetryAfter = daily.Reset
+			}
+			if !daily.Allowed || daily.Remaining < res.Remaining {
+				res = daily
+			}
+		}
+
+		writeHeaders(w, res)
+		if !res.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(seconds(res.RetryAfter)))
+			http.Error(w, "слишком много запросов, повторите позже", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeHeaders записывает заголовки RateLimit-* по результату res.
+func writeHeaders(w http.ResponseWriter, res Result) {
+	h := w.Header()
+	h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(seconds(res.Reset)))
+}
+
+// seconds округляет длительность вверх до целых секунд, как требуют заголовки.
+func seconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int(math.Ceil(d.Seconds()))
+}
+
+// refill пополняет корзину за время elapsed и забирает токен, если он есть.
+func refill(tokens float64, elapsed time.Duration, rate float64, burst int) (float64, Result) {
+	tokens = math.Min(float64(burst), tokens+elapsed.Seconds()*rate)
+	allowed := tokens >= 1
+	if allowed {
+		tokens--
+	}
+	return tokens, bucketResult(tokens, allowed, rate, burst)
+}
+
+// bucketResult описывает состояние корзины после решения по запросу; общая часть хранилищ.
+func bucketResult(tokens float64, allowed bool, rate float64, burst int) Result {
+	res := Result{
+		Allowed:   allowed,
+		Limit:     burst,
+		Remaining: int(tokens),
+		Reset:     time.Duration((float64(burst) - tokens) / rate * float64(time.Second)),
+	}
+	if !allowed {
+		res.RetryAfter = time.Duration((1 - tokens) / rate * float64(time.Second))
+	}
+	return res
+}
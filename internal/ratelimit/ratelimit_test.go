@@ -0,0 +1,154 @@
+// Тесты middleware ограничения запросов
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"Test_Project_Brand_Scout/internal/auth"
+)
+
+// newTestRouter создаёт маршрутизатор с ограничителем и фиксированными часами
+func newTestRouter(rules map[string]Rule, key KeyFunc, now *time.Time) *mux.Router {
+	l := New(NewMemoryStore(), rules, key)
+	l.now = func() time.Time { return *now }
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	r := mux.NewRouter()
+	r.Use(l.Middleware)
+	r.Handle("/quotes", ok).Methods("POST", "GET")
+	r.Handle("/w/{workspace}/quotes", ok).Methods("POST", "GET")
+	r.Handle("/quotes/{id}", ok).Methods("GET")
+	return r
+}
+
+// send выполняет запрос от клиента с адресом addr
+func send(r http.Handler, method, path, addr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = addr
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// TestMiddlewareBurst проверяет ёмкость корзины, заголовки и пополнение со временем
+func TestMiddlewareBurst(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	r := newTestRouter(map[string]Rule{"POST /quotes": {Rate: 1, Burst: 2}}, ByIP, &now)
+
+	for i := 0; i < 2; i++ {
+		if w := send(r, "POST", "/quotes", "10.0.0.1:1"); w.Code != http.StatusOK {
+			t.Fatalf("запрос %d: ожидается 200, получен %d", i, w.Code)
+		}
+	}
+	w := send(r, "POST", "/quotes", "10.0.0.1:1")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("ожидается 429 после исчерпания корзины, получен %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") != "1" || w.Header().Get("RateLimit-Remaining") != "0" || w.Header().Get("RateLimit-Limit") != "2" {
+		t.Errorf("неожиданные заголовки: %v", w.Header())
+	}
+	// Другой клиент и другой маршрут не затронуты
+	if w := send(r, "POST", "/quotes", "10.0.0.2:1"); w.Code != http.StatusOK {
+		t.Errorf("другой IP не должен ограничиваться, получен %d", w.Code)
+	}
+	if w := send(r, "GET", "/quotes", "10.0.0.1:1"); w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "" {
+		t.Errorf("GET /quotes без правила не должен ограничиваться: %d %v", w.Code, w.Header())
+	}
+
+	now = now.Add(time.Second)
+	if w := send(r, "POST", "/quotes", "10.0.0.1:1"); w.Code != http.StatusOK {
+		t.Errorf("ожидается 200 после пополнения корзины, получен %d", w.Code)
+	}
+	// Маршрут рабочего пространства подчиняется тому же правилу и той же корзине
+	if w := send(r, "POST", "/w/team-a/quotes", "10.0.0.1:1"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("ожидается 429 для маршрута пространства, получен %d", w.Code)
+	}
+}
+
+// TestMiddlewareDaily проверяет дневную квоту отдельно от корзины
+func TestMiddlewareDaily(t *testing.T) {
+	now := time.Date(2026, 5, 1, 23, 0, 0, 0, time.UTC)
+	r := newTestRouter(map[string]Rule{
+		"/quotes/{id}": {Daily: 2},
+		DefaultRoute:   {Rate: 100, Burst: 100, Daily: 1000},
+	}, ByIP, &now)
+
+	for i := 0; i < 2; i++ {
+		if w := send(r, "GET", "/quotes/1", "10.0.0.1:1"); w.Code != http.StatusOK {
+			t.Fatalf("запрос %d: ожидается 200, получен %d", i, w.Code)
+		}
+	}
+	w := send(r, "GET", "/quotes/2", "10.0.0.1:1")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "3600" {
+		t.Errorf("ожидается 429 до полуночи UTC, получено %d, Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	// Правило по умолчанию применяется к остальным маршрутам, заголовки — по более строгому ограничению
+	w = send(r, "GET", "/quotes", "10.0.0.1:1")
+	if w.Code != http.StatusOK || w.Header().Get("RateLimit-Remaining") != "99" {
+		t.Errorf("ожидается 200 и остаток корзины 99, получено %d %v", w.Code, w.Header())
+	}
+
+	now = now.Add(time.Hour)
+	if w := send(r, "GET", "/quotes/1", "10.0.0.1:1"); w.Code != http.StatusOK {
+		t.Errorf("квота должна обнулиться в новые сутки, получен %d", w.Code)
+	}
+}
+
+// TestKeyFuncs проверяет определение клиента по личности и рабочему пространству
+func TestKeyFuncs(t *testing.T) {
+	req := httptest.NewRequest("GET", "/quotes", nil)
+	req.RemoteAddr = "10.0.0.1:5000"
+	if k := ByIdentity(req); k != "ip:10.0.0.1" {
+		t.Errorf("без личности ожидается ключ по IP, получено %q", k)
+	}
+	req = req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{Subject: "key:3", Tenant: "team-a"}))
+	if k := ByIdentity(req); k != "id:key:3" {
+		t.Errorf("ожидается ключ по личности, получено %q", k)
+	}
+	if k := ByTenant("default")(req); k != "tenant:team-a" {
+		t.Errorf("ожидается пространство клиента, получено %q", k)
+	}
+	// Привязанный клиент расходует квоту своего пространства, даже обращаясь к чужому по пути
+	req = mux.SetURLVars(req, map[string]string{"workspace": "team-b"})
+	if k := ByTenant("default")(req); k != "tenant:team-a" {
+		t.Errorf("ожидается пространство привязки, а не из пути, получено %q", k)
+	}
+	req = req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{Subject: "key:4"}))
+	if k := ByTenant("default")(req); k != "tenant:team-b" {
+		t.Errorf("для непривязанного клиента ожидается пространство из пути, получено %q", k)
+	}
+	req = mux.SetURLVars(req, nil)
+	if k := ByTenant("default")(req); k != "tenant:default" {
+		t.Errorf("без привязки и пути ожидается пространство по умолчанию, получено %q", k)
+	}
+}
+
+// TestSetRules проверяет замену правил без сброса корзин
+func TestSetRules(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	l := New(NewMemoryStore(), nil, ByIP)
+	l.now = func() time.Time { return now }
+	r := mux.NewRouter()
+	r.Use(l.Middleware)
+	r.Handle("/quotes", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).Methods("POST")
+
+	if w := send(r, "POST", "/quotes", "10.0.0.1:1"); w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "" {
+		t.Fatalf("без правил запросы не ограничиваются: %d %v", w.Code, w.Header())
+	}
+	l.SetRules(map[string]Rule{"POST /quotes": {Rate: 1, Burst: 1}})
+	if w := send(r, "POST", "/quotes", "10.0.0.1:1"); w.Code != http.StatusOK {
+		t.Fatalf("первый запрос после SetRules: ожидается 200, получен %d", w.Code)
+	}
+	if w := send(r, "POST", "/quotes", "10.0.0.1:1"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("второй запрос: ожидается 429, получен %d", w.Code)
+	}
+	// Корзина сохраняется, если правило маршрута осталось прежним
+	l.SetRules(map[string]Rule{"POST /quotes": {Rate: 1, Burst: 1}, DefaultRoute: {Rate: 100, Burst: 100}})
+	if w := send(r, "POST", "/quotes", "10.0.0.1:1"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("после замены правил корзина не должна сбрасываться, получен %d", w.Code)
+	}
+}
@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// memorySweepSize — размер, после которого из памяти удаляются неактуальные записи.
+const memorySweepSize = 10000
+
+// MemoryStore хранит корзины и дневные счётчики в памяти процесса.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*memoryBucket
+	daily   map[string]*memoryDaily
+}
+
+// memoryBucket — корзина клиента: число токенов на момент updated.
+type memoryBucket struct {
+	tokens  float64
+	burst   int
+	rate    float64
+	updated time.Time
+}
+
+// memoryDaily — дневной счётчик клиента.
+type memoryDaily struct {
+	day   string
+	count int
+}
+
+// NewMemoryStore создаёт пустое хранилище в памяти.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*memoryBucket), daily: make(map[string]*memoryDaily)}
+}
+
+// Take забирает токен из корзины key; новая корзина начинается заполненной.
+func (s *MemoryStore) Take(key string, rate float64, burst int, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.buckets[key]
+	if !ok {
+		if len(s.buckets) >= memorySweepSize {
+			s.sweep(now)
+		}
+		b = &memoryBucket{tokens: float64(burst), updated: now}
+		s.buckets[key] = b
+	}
+	var res Result
+	b.tokens, res = refill(b.tokens, now.Sub(b.updated), rate, burst)
+	b.burst, b.rate, b.updated = burst, rate, now
+	return res, nil
+}
+
+// sweep удаляет заполненные корзины: они неотличимы от новых. Вызывается под блокировкой.
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if b.tokens+now.Sub(b.updated).Seconds()*b.rate >= float64(b.burst) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// TakeDaily увеличивает счётчик key за сутки day, если он меньше quota.
+// Счётчик прошлых суток заменяется новым.
+func (s *MemoryStore) TakeDaily(key, day string, quota int) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.daily[key]
+	if !ok || d.day != day {
+		if !ok && len(s.daily) >= memorySweepSize {
+			for k, old := range s.daily {
+				if old.day != day {
+					delete(s.daily, k)
+				}
+			}
+		}
+		d = &memoryDaily{day: day}
+		s.daily[key] = d
+	}
+	res := Result{Limit: quota}
+	if d.count < quota {
+		d.count++
+		res.Allowed = true
+	}
+	res.Remaining = quota - d.count
+	return res, nil
+}
@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	"Test_Project_Brand_Scout/internal/sqlitedriver"
+)
+
+// sqliteSchema создаёт таблицы ограничителя; они живут в том же файле, что и цитаты,
+// но не входят в миграции репозитория, так как ограничитель можно включить отдельно.
+const sqliteSchema = `CREATE TABLE IF NOT EXISTS rate_buckets (
+    key TEXT PRIMARY KEY,
+    tokens REAL NOT NULL,
+    updated REAL NOT NULL,
+    allowed INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS rate_daily (
+    key TEXT NOT NULL,
+    day TEXT NOT NULL,
+    count INTEGER NOT NULL,
+    PRIMARY KEY (key, day)
+);`
+
+// SQLiteStore хранит корзины и дневные счётчики в SQLite, чтобы экземпляры
+// с общим файлом базы соблюдали общие ограничения.
+type SQLiteStore struct {
+	db *sql.DB
+
+	// mu и cleaned — сутки, за которые уже удалены записи прошлых дней
+	mu      sync.Mutex
+	cleaned string
+}
+
+// NewSQLiteStore открывает базу по пути path драйвером d и создаёт таблицы ограничителя.
+func NewSQLiteStore(path string, d *sqlitedriver.Driver) (*SQLiteStore, error) {
+	db, err := d.Open(path, sqlitedriver.Pragma{Name: "busy_timeout", Value: "5000"})
+	if err != nil {
+		return nil, err
+	}
+	// Каждое решение — одна короткая запись; одно соединение избавляет от конкуренции внутри процесса
+	// и делает :memory: одной базой
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+// Take забирает токен из корзины key одним UPSERT, поэтому решение атомарно
+// и для нескольких процессов. Время хранится в секундах Unix с дробной частью.
+func (s *SQLiteStore) Take(key string, rate float64, burst int, now time.Time) (Result, error) {
+	ts := float64(now.UnixNano()) / float64(time.Second)
+	var tokens float64
+	var allowed bool
+	err := s.db.QueryRow(`INSERT INTO rate_buckets(key, tokens, updated, allowed) VALUES(?1, ?2 - 1, ?3, 1)
+		ON CONFLICT(key) DO UPDATE SET
+		    allowed = MIN(?2, tokens + MAX(?3 - updated, 0) * ?4) >= 1,
+		    tokens = MIN(?2, tokens + MAX(?3 - updated, 0) * ?4) - (MIN(?2, tokens + MAX(?3 - updated, 0) * ?4) >= 1),
+		    updated = MAX(?3, updated)
+		RETURNING tokens, allowed`, key, burst, ts, rate).Scan(&tokens, &allowed)
+	if err != nil {
+		return Result{}, err
+	}
+	return bucketResult(tokens, allowed, rate, burst), nil
+}
+
+// TakeDaily увеличивает счётчик key за сутки day, если он меньше quota.
+// При смене суток записи прошлых дней удаляются.
+func (s *SQLiteStore) TakeDaily(key, day string, quota int) (Result, error) {
+	if err := s.cleanup(day); err != nil {
+		return Result{}, err
+	}
+	res := Result{Limit: quota}
+	err := s.db.QueryRow(`INSERT INTO rate_daily(key, day, count) VALUES(?1, ?2, 1)
+		ON CONFLICT(key, day) DO UPDATE SET count = count + 1 WHERE count < ?3
+		RETURNING count`, key, day, quota).Scan(&res.Remaining)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		// Условие WHERE не выполнилось: квота на сутки исчерпана
+		return res, nil
+	case err != nil:
+		return Result{}, err
+	}
+	res.Allowed = true
+	res.Remaining = quota - res.Remaining
+	return res, nil
+}
+
+// cleanup удаляет дневные счётчики прошлых суток и давно не обновлявшиеся корзины
+// один раз за сутки day в этом процессе.
+func (s *SQLiteStore) cleanup(day string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cleaned == day {
+		return nil
+	}
+	if _, err := s.db.Exec("DELETE FROM rate_daily WHERE day < ?", day); err != nil {
+		return err
+	}
+	cutoff := float64(time.Now().Add(-24*time.Hour).UnixNano()) / float64(time.Second)
+	if _, err := s.db.Exec("DELETE FROM rate_buckets WHERE updated < ?", cutoff); err != nil {
+		return err
+	}
+	s.cleaned = day
+	return nil
+}
+
+// Close закрывает соединение с базой.
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
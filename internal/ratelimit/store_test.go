@@ -0,0 +1,83 @@
+// Тесты хранилищ корзин и дневных счётчиков
+package ratelimit
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"Test_Project_Brand_Scout/internal/sqlitedriver"
+)
+
+// TestStores проверяет одинаковое поведение хранилищ в памяти и SQLite на каждом драйвере сборки
+func TestStores(t *testing.T) {
+	stores := map[string]Store{"memory": NewMemoryStore()}
+	for _, name := range sqlitedriver.Available() {
+		d, _ := sqlitedriver.Lookup(name)
+		sq, err := NewSQLiteStore(":memory:", d)
+		if err != nil {
+			t.Fatalf("ошибка NewSQLiteStore: %v", err)
+		}
+		defer sq.Close()
+		stores["sqlite/"+name] = sq
+	}
+	for name, store := range stores {
+		now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+		for i := 0; i < 3; i++ {
+			res, err := store.Take("k", 2, 3, now)
+			if err != nil || !res.Allowed || res.Remaining != 2-i {
+				t.Fatalf("%s: запрос %d: %+v, %v", name, i, res, err)
+			}
+		}
+		res, _ := store.Take("k", 2, 3, now)
+		if res.Allowed || res.RetryAfter != 500*time.Millisecond || res.Reset != 1500*time.Millisecond {
+			t.Errorf("%s: ожидается отказ с ожиданием 0.5s, получено %+v", name, res)
+		}
+		// Отказ не расходует токены: через полсекунды появляется ровно один
+		if res, _ := store.Take("k", 2, 3, now.Add(500*time.Millisecond)); !res.Allowed || res.Remaining != 0 {
+			t.Errorf("%s: ожидается разрешение после пополнения, получено %+v", name, res)
+		}
+		// Корзина не переполняется выше burst
+		if res, _ := store.Take("k", 2, 3, now.Add(time.Hour)); !res.Allowed || res.Remaining != 2 {
+			t.Errorf("%s: ожидается полная корзина, получено %+v", name, res)
+		}
+
+		for i := 0; i < 2; i++ {
+			if res, err := store.TakeDaily("k", "2026-05-01", 2); err != nil || !res.Allowed || res.Remaining != 1-i {
+				t.Fatalf("%s: дневной запрос %d: %+v, %v", name, i, res, err)
+			}
+		}
+		if res, _ := store.TakeDaily("k", "2026-05-01", 2); res.Allowed || res.Remaining != 0 {
+			t.Errorf("%s: ожидается исчерпанная квота, получено %+v", name, res)
+		}
+		if res, _ := store.TakeDaily("k", "2026-05-02", 2); !res.Allowed {
+			t.Errorf("%s: в новые сутки квота должна восстановиться, получено %+v", name, res)
+		}
+	}
+}
+
+// TestSQLiteStoreShared проверяет, что экземпляры с общим файлом делят ограничения
+func TestSQLiteStoreShared(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quotes.db")
+	first, err := NewSQLiteStore(path, sqlitedriver.Default())
+	if err != nil {
+		t.Fatalf("ошибка NewSQLiteStore: %v", err)
+	}
+	defer first.Close()
+	second, err := NewSQLiteStore(path, sqlitedriver.Default())
+	if err != nil {
+		t.Fatalf("ошибка NewSQLiteStore: %v", err)
+	}
+	defer second.Close()
+
+	now := time.Now()
+	first.Take("k", 1, 2, now)
+	first.Take("k", 1, 2, now)
+	if res, _ := second.Take("k", 1, 2, now); res.Allowed {
+		t.Errorf("второй экземпляр должен видеть исчерпанную корзину, получено %+v", res)
+	}
+	first.TakeDaily("k", "2026-05-01", 1)
+	if res, _ := second.TakeDaily("k", "2026-05-01", 1); res.Allowed {
+		t.Errorf("второй экземпляр должен видеть исчерпанную квоту, получено %+v", res)
+	}
+}
@@ -0,0 +1,168 @@
+// Package servertls настраивает TLS сервера: сертификат и ключ из файлов, минимальную версию
+// протокола, набор шифров и необязательную проверку клиентских сертификатов (mTLS).
+// Сертификаты перечитываются без перезапуска — по изменению файлов или по явному вызову Reload.
+package servertls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"Test_Project_Brand_Scout/internal/filewatch"
+)
+
+// Политики набора шифров.
+const (
+	// PolicyModern разрешает только TLS 1.3, где набор шифров фиксирован протоколом.
+	PolicyModern = "modern"
+	// PolicyIntermediate ограничивает TLS 1.2 шифрами ECDHE с AEAD — совместимый с большинством клиентов выбор.
+	PolicyIntermediate = "intermediate"
+	// PolicyDefault оставляет набор шифров стандартной библиотеки Go.
+	PolicyDefault = "default"
+)
+
+// Режимы проверки клиентских сертификатов при заданном ClientCAFile.
+const (
+	// ClientAuthRequire отклоняет соединения без сертификата, подписанного ClientCAFile.
+	ClientAuthRequire = "require"
+	// ClientAuthOptional проверяет сертификат, только если клиент его предъявил.
+	ClientAuthOptional = "optional"
+)
+
+// intermediateCiphers — шифры TLS 1.2 политики intermediate: прямая секретность и AEAD.
+var intermediateCiphers = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+}
+
+// Options задаёт TLS сервера.
+type Options struct {
+	CertFile     string // сертификат сервера в PEM, может содержать цепочку
+	KeyFile      string // закрытый ключ сервера в PEM
+	MinVersion   string // минимальная версия протокола: 1.2 или 1.3
+	CipherPolicy string // набор шифров: modern, intermediate или default
+	ClientCAFile string // корневые сертификаты для проверки клиентов; пусто — mTLS выключен
+	ClientAuth   string // require или optional, учитывается только с ClientCAFile
+}
+
+// Reloader хранит текущие сертификат сервера и пул клиентских CA и подменяет их при перезагрузке.
+// Уже установленные соединения продолжают работать со старым сертификатом.
+type Reloader struct {
+	opts       Options
+	minVersion uint16
+	ciphers    []uint16
+	clientAuth tls.ClientAuthType
+
+	files *filewatch.Watcher
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	clientCA *x509.CertPool
+}
+
+// New проверяет параметры и загружает сертификаты.
+func New(opts Options) (*Reloader, error) {
+	if opts.CertFile == "" || opts.KeyFile == "" {
+		return nil, errors.New("для TLS нужно задать файлы сертификата и ключа")
+	}
+	r := &Reloader{opts: opts, files: filewatch.New(opts.CertFile, opts.KeyFile, opts.ClientCAFile)}
+	switch opts.MinVersion {
+	case "", "1.2":
+		r.minVersion = tls.VersionTLS12
+	case "1.3":
+		r.minVersion = tls.VersionTLS13
+	default:
+		return nil, fmt.Errorf("неизвестная версия TLS %q (ожидается 1.2 или 1.3)", opts.MinVersion)
+	}
+	switch opts.CipherPolicy {
+	case "", PolicyIntermediate:
+		r.ciphers = intermediateCiphers
+	case PolicyModern:
+		r.minVersion = tls.VersionTLS13
+	case PolicyDefault:
+	default:
+		return nil, fmt.Errorf("неизвестная политика шифров %q (ожидается modern, intermediate или default)", opts.CipherPolicy)
+	}
+	if opts.ClientCAFile != "" {
+		switch opts.ClientAuth {
+		case "", ClientAuthRequire:
+			r.clientAuth = tls.RequireAndVerifyClientCert
+		case ClientAuthOptional:
+			r.clientAuth = tls.VerifyClientCertIfGiven
+		default:
+			return nil, fmt.Errorf("неизвестный режим проверки клиентов %q (ожидается require или optional)", opts.ClientAuth)
+		}
+	}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload перечитывает сертификат, ключ и клиентские CA. При ошибке продолжают действовать
+// прежние сертификаты, чтобы неудачная замена файлов не остановила сервер.
+func (r *Reloader) Reload() error {
+	r.files.Seen()
+	cert, err := tls.LoadX509KeyPair(r.opts.CertFile, r.opts.KeyFile)
+	if err != nil {
+		return fmt.Errorf("загрузка сертификата сервера: %w", err)
+	}
+	var pool *x509.CertPool
+	if r.opts.ClientCAFile != "" {
+		data, err := os.ReadFile(r.opts.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("загрузка клиентских CA: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return fmt.Errorf("в %s нет сертификатов в формате PEM", r.opts.ClientCAFile)
+		}
+	}
+	r.mu.Lock()
+	r.cert, r.clientCA = &cert, pool
+	r.mu.Unlock()
+	return nil
+}
+
+// Watch раз в interval проверяет файлы сертификатов и перезагружает их после изменения.
+// Ошибка загрузки пишется в лог и повторяется при следующем изменении файлов. Завершается с отменой ctx.
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	r.files.Watch(ctx, interval, func() {
+		if err := r.Reload(); err != nil {
+			log.Printf("Не удалось перезагрузить сертификаты TLS: %v", err)
+			return
+		}
+		log.Println("Сертификаты TLS перезагружены")
+	})
+}
+
+// Config возвращает конфигурацию TLS для http.Server. Сертификат и клиентские CA берутся
+// при каждом рукопожатии, поэтому перезагрузка действует на новые соединения сразу.
+func (r *Reloader) Config() *tls.Config {
+	base := &tls.Config{
+		MinVersion:   r.minVersion,
+		CipherSuites: r.ciphers,
+		ClientAuth:   r.clientAuth,
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+	base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+		cfg := base.Clone()
+		cfg.GetConfigForClient = nil
+		cfg.Certificates = []tls.Certificate{*r.cert}
+		cfg.ClientCAs = r.clientCA
+		return cfg, nil
+	}
+	return base
+}
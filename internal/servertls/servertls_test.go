@@ -0,0 +1,264 @@
+package servertls
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// issuer — сертификат и ключ, которыми подписываются тестовые сертификаты
+type issuer struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// newCert создаёт сертификат с серийным номером serial, подписанный parent (nil — самоподписанный CA)
+func newCert(t *testing.T, parent *issuer, cn string, serial int64, usage x509.ExtKeyUsage) (*issuer, []byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ошибка генерации ключа: %v", err)
+	}
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn, Organization: []string{"Quotes"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	signer, signerKey := tpl, key
+	if parent == nil {
+		tpl.IsCA, tpl.BasicConstraintsValid = true, true
+		tpl.KeyUsage |= x509.KeyUsageCertSign
+		tpl.ExtKeyUsage = nil
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("ошибка создания сертификата: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	return &issuer{cert: cert, key: key},
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// writeFile записывает файл в каталог dir
+func writeFile(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("ошибка записи %s: %v", name, err)
+	}
+	return path
+}
+
+// pki — набор файлов тестовой инфраструктуры: CA, сертификат сервера и клиента
+type pki struct {
+	ca                *issuer
+	caFile            string
+	certFile, keyFile string
+	client            tls.Certificate
+}
+
+// newPKI создаёт CA, сертификат сервера с номером serial и клиентский сертификат с именем ci
+func newPKI(t *testing.T, serial int64) *pki {
+	t.Helper()
+	dir := t.TempDir()
+	ca, caPEM, _ := newCert(t, nil, "Test CA", 1, 0)
+	_, certPEM, keyPEM := newCert(t, ca, "server", serial, x509.ExtKeyUsageServerAuth)
+	_, clientPEM, clientKey := newCert(t, ca, "ci", 100, x509.ExtKeyUsageClientAuth)
+	client, err := tls.X509KeyPair(clientPEM, clientKey)
+	if err != nil {
+		t.Fatalf("ошибка разбора клиентского сертификата: %v", err)
+	}
+	return &pki{
+		ca:       ca,
+		caFile:   writeFile(t, dir, "ca.pem", caPEM),
+		certFile: writeFile(t, dir, "cert.pem", certPEM),
+		keyFile:  writeFile(t, dir, "key.pem", keyPEM),
+		client:   client,
+	}
+}
+
+// serve запускает HTTPS-сервер с конфигурацией cfg, отвечающий Common Name клиентского сертификата
+func serve(t *testing.T, cfg *tls.Config) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ошибка Listen: %v", err)
+	}
+	srv := &http.Server{TLSConfig: cfg, Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.VerifiedChains) > 0 {
+			w.Write([]byte(r.TLS.VerifiedChains[0][0].Subject.CommonName))
+		}
+	})}
+	go srv.ServeTLS(ln, "", "")
+	t.Cleanup(func() { srv.Close() })
+	return "https://" + ln.Addr().String()
+}
+
+// client создаёт HTTPS-клиента, доверяющего ca и предъявляющего certs
+func client(ca *issuer, certs ...tls.Certificate) *http.Client {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	return &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{RootCAs: pool, Certificates: certs},
+		DisableKeepAlives: true,
+	}}
+}
+
+// serverSerial возвращает серийный номер сертификата, который предъявил сервер
+func serverSerial(t *testing.T, c *http.Client, addr string) int64 {
+	t.Helper()
+	res, err := c.Get(addr)
+	if err != nil {
+		t.Fatalf("ошибка запроса: %v", err)
+	}
+	res.Body.Close()
+	return res.TLS.PeerCertificates[0].SerialNumber.Int64()
+}
+
+// TestReload проверяет замену сертификата без перезапуска и сохранение прежнего при ошибке
+func TestReload(t *testing.T) {
+	p := newPKI(t, 10)
+	r, err := New(Options{CertFile: p.certFile, KeyFile: p.keyFile})
+	if err != nil {
+		t.Fatalf("ошибка New: %v", err)
+	}
+	addr := serve(t, r.Config())
+	c := client(p.ca)
+	if got := serverSerial(t, c, addr); got != 10 {
+		t.Fatalf("ожидается сертификат 10, получен %d", got)
+	}
+
+	_, certPEM, keyPEM := newCert(t, p.ca, "server", 11, x509.ExtKeyUsageServerAuth)
+	writeFile(t, filepath.Dir(p.certFile), "cert.pem", certPEM)
+	writeFile(t, filepath.Dir(p.keyFile), "key.pem", keyPEM)
+	if err := r.Reload(); err != nil {
+		t.Fatalf("ошибка Reload: %v", err)
+	}
+	if got := serverSerial(t, c, addr); got != 11 {
+		t.Errorf("после Reload ожидается сертификат 11, получен %d", got)
+	}
+
+	writeFile(t, filepath.Dir(p.keyFile), "key.pem", []byte("испорчен"))
+	if err := r.Reload(); err == nil {
+		t.Error("ожидается ошибка для испорченного ключа")
+	}
+	if got := serverSerial(t, c, addr); got != 11 {
+		t.Errorf("при ошибке должен остаться прежний сертификат, получен %d", got)
+	}
+}
+
+// TestWatch проверяет перезагрузку после изменения файлов
+func TestWatch(t *testing.T) {
+	p := newPKI(t, 20)
+	r, err := New(Options{CertFile: p.certFile, KeyFile: p.keyFile})
+	if err != nil {
+		t.Fatalf("ошибка New: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Watch(ctx, 10*time.Millisecond)
+	addr := serve(t, r.Config())
+	c := client(p.ca)
+
+	_, certPEM, keyPEM := newCert(t, p.ca, "server", 21, x509.ExtKeyUsageServerAuth)
+	writeFile(t, filepath.Dir(p.keyFile), "key.pem", keyPEM)
+	writeFile(t, filepath.Dir(p.certFile), "cert.pem", certPEM)
+	deadline := time.Now().Add(2 * time.Second)
+	for serverSerial(t, c, addr) != 21 {
+		if time.Now().After(deadline) {
+			t.Fatal("сертификат не перезагружен после изменения файлов")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestMutualTLS проверяет обязательный и необязательный клиентский сертификат
+func TestMutualTLS(t *testing.T) {
+	p := newPKI(t, 30)
+	r, err := New(Options{CertFile: p.certFile, KeyFile: p.keyFile, ClientCAFile: p.caFile})
+	if err != nil {
+		t.Fatalf("ошибка New: %v", err)
+	}
+	addr := serve(t, r.Config())
+	if _, err := client(p.ca).Get(addr); err == nil {
+		t.Error("без клиентского сертификата соединение должно отклоняться")
+	}
+	res, err := client(p.ca, p.client).Get(addr)
+	if err != nil {
+		t.Fatalf("ошибка запроса с сертификатом: %v", err)
+	}
+	defer res.Body.Close()
+	buf := make([]byte, 16)
+	n, _ := res.Body.Read(buf)
+	if string(buf[:n]) != "ci" {
+		t.Errorf("сервер должен видеть проверенный сертификат ci, получено %q", buf[:n])
+	}
+
+	r, err = New(Options{CertFile: p.certFile, KeyFile: p.keyFile, ClientCAFile: p.caFile, ClientAuth: ClientAuthOptional})
+	if err != nil {
+		t.Fatalf("ошибка New: %v", err)
+	}
+	if res, err := client(p.ca).Get(serve(t, r.Config())); err != nil {
+		t.Errorf("в режиме optional сертификат не обязателен: %v", err)
+	} else {
+		res.Body.Close()
+	}
+}
+
+// TestOptions проверяет версии протокола и политики шифров
+func TestOptions(t *testing.T) {
+	p := newPKI(t, 40)
+	base := Options{CertFile: p.certFile, KeyFile: p.keyFile}
+	for _, tc := range []struct {
+		policy, version string
+		min             uint16
+		ciphers         bool
+	}{
+		{"", "", tls.VersionTLS12, true},
+		{PolicyModern, "1.2", tls.VersionTLS13, false},
+		{PolicyDefault, "1.3", tls.VersionTLS13, false},
+	} {
+		opts := base
+		opts.CipherPolicy, opts.MinVersion = tc.policy, tc.version
+		r, err := New(opts)
+		if err != nil {
+			t.Fatalf("%s: ошибка New: %v", tc.policy, err)
+		}
+		cfg := r.Config()
+		if cfg.MinVersion != tc.min || (len(cfg.CipherSuites) > 0) != tc.ciphers {
+			t.Errorf("%s/%s: неожиданная конфигурация %x, %v", tc.policy, tc.version, cfg.MinVersion, cfg.CipherSuites)
+		}
+	}
+
+	for _, opts := range []Options{
+		{CertFile: p.certFile},
+		{CertFile: p.certFile, KeyFile: p.keyFile, MinVersion: "1.0"},
+		{CertFile: p.certFile, KeyFile: p.keyFile, CipherPolicy: "legacy"},
+		{CertFile: p.certFile, KeyFile: p.keyFile, ClientCAFile: p.caFile, ClientAuth: "maybe"},
+		{CertFile: p.certFile, KeyFile: p.keyFile, ClientCAFile: p.keyFile},
+	} {
+		if _, err := New(opts); err == nil {
+			t.Errorf("ожидается ошибка для %+v", opts)
+		}
+	}
+}
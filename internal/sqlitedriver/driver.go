@@ -0,0 +1,174 @@
+// Package sqlitedriver скрывает различия драйверов SQLite для database/sql: go-sqlite3 (mattn), которому
+// нужен cgo, и modernc.org/sqlite на чистом Go. Драйвер выбирается по имени из конфигурации; при сборке
+// с тегом purego или с CGO_ENABLED=0 go-sqlite3 в бинарник не попадает и используется modernc.
+package sqlitedriver
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Имена драйверов в конфигурации.
+const (
+	Mattn   = "mattn"   // github.com/mattn/go-sqlite3, нужен cgo
+	Modernc = "modernc" // modernc.org/sqlite, чистый Go
+	// Auto выбирает go-sqlite3, если он есть в сборке, иначе modernc.
+	Auto = "auto"
+)
+
+// Pragma — параметр соединения SQLite, который драйвер применяет к каждому новому соединению.
+type Pragma struct {
+	Name  string
+	Value string
+}
+
+// Pragmas — параметры соединения, которые понимают оба драйвера.
+var Pragmas = []string{"busy_timeout", "journal_mode", "synchronous", "foreign_keys"}
+
+// Driver описывает драйвер SQLite: имя, под которым он зарегистрирован в database/sql,
+// и то, чем реализации отличаются друг от друга.
+type Driver struct {
+	// Name — имя драйвера в конфигурации: mattn или modernc.
+	Name string
+	// sqlName — имя в database/sql; у обёрток, например трассировки, оно своё.
+	sqlName string
+	// pragma записывает параметр соединения в DSN в синтаксисе драйвера.
+	pragma func(q url.Values, p Pragma)
+	// backup копирует базу соединения src, снятого с обёрток, в файл path.
+	backup func(src any, path string) error
+}
+
+var (
+	mu      sync.Mutex
+	drivers = map[string]*Driver{}
+	wrapped = map[string]*Driver{}
+)
+
+// register добавляет драйвер в список доступных; вызывается из init файлов драйверов.
+func register(d *Driver) {
+	mu.Lock()
+	defer mu.Unlock()
+	drivers[d.Name] = d
+}
+
+// Available возвращает имена драйверов, которые есть в этой сборке, по алфавиту.
+func Available() []string {
+	mu.Lock()
+	defer mu.Unlock()
+	names := make([]string, 0, len(drivers))
+	for name := range drivers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Default возвращает go-sqlite3, если он есть в сборке, иначе modernc.
+func Default() *Driver {
+	mu.Lock()
+	defer mu.Unlock()
+	if d, ok := drivers[Mattn]; ok {
+		return d
+	}
+	return drivers[Modernc]
+}
+
+// Lookup возвращает драйвер по имени из конфигурации; пустое имя и auto означают Default.
+func Lookup(name string) (*Driver, error) {
+	if name == "" || name == Auto {
+		return Default(), nil
+	}
+	mu.Lock()
+	d, ok := drivers[name]
+	mu.Unlock()
+	if ok {
+		return d, nil
+	}
+	if name == Mattn {
+		return nil, fmt.Errorf("драйвер SQLite %s недоступен: сервис собран без cgo или с тегом purego", name)
+	}
+	return nil, fmt.Errorf("неизвестный драйвер SQLite %q (ожидается %s)", name, strings.Join(append([]string{Auto}, Available()...), ", "))
+}
+
+// ValidPragma проверяет, что параметр соединения поддерживают оба драйвера.
+func ValidPragma(name string) error {
+	if !slices.Contains(Pragmas, name) {
+		return fmt.Errorf("неподдерживаемый параметр SQLite %q (ожидается %s)", name, strings.Join(Pragmas, ", "))
+	}
+	return nil
+}
+
+// DSN добавляет к пути базы параметры соединения в синтаксисе драйвера.
+func (d *Driver) DSN(path string, pragmas ...Pragma) string {
+	if len(pragmas) == 0 {
+		return path
+	}
+	q := url.Values{}
+	for _, p := range pragmas {
+		d.pragma(q, p)
+	}
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return path + sep + q.Encode()
+}
+
+// Open открывает базу path; pragmas применяются к каждому соединению пула.
+func (d *Driver) Open(path string, pragmas ...Pragma) (*sql.DB, error) {
+	for _, p := range pragmas {
+		if err := ValidPragma(p.Name); err != nil {
+			return nil, err
+		}
+	}
+	return sql.Open(d.sqlName, d.DSN(path, pragmas...))
+}
+
+// Wrap возвращает драйвер, соединения которого оборачивает wrap, например для трассировки запросов.
+// Обёртка регистрируется в database/sql один раз за процесс под именем с суффиксом suffix.
+func (d *Driver) Wrap(suffix string, wrap func(driver.Driver) driver.Driver) *Driver {
+	name := d.sqlName + "-" + suffix
+	mu.Lock()
+	defer mu.Unlock()
+	if w, ok := wrapped[name]; ok {
+		return w
+	}
+	// sql.Open не подключается к базе, а только находит зарегистрированный драйвер
+	db, _ := sql.Open(d.sqlName, "")
+	sql.Register(name, wrap(db.Driver()))
+	db.Close()
+	w := *d
+	w.sqlName = name
+	wrapped[name] = &w
+	return &w
+}
+
+// Backup записывает согласованную копию базы db в файл path через онлайн-API резервного копирования
+// SQLite. Страницы читаются в одной транзакции чтения, поэтому запись в базу не останавливается.
+func (d *Driver) Backup(ctx context.Context, db *sql.DB, path string) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return conn.Raw(func(raw any) error {
+		return d.backup(unwrap(raw), path)
+	})
+}
+
+// unwrap снимает обёртки драйвера, например трассировки, и возвращает соединение самого драйвера.
+func unwrap(conn any) any {
+	for {
+		c, ok := conn.(interface{ Unwrap() driver.Conn })
+		if !ok {
+			return conn
+		}
+		conn = c.Unwrap()
+	}
+}
@@ -0,0 +1,90 @@
+package sqlitedriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestLookup проверяет выбор драйвера по имени из конфигурации
+func TestLookup(t *testing.T) {
+	if d, err := Lookup(""); err != nil || d != Default() {
+		t.Errorf("пустое имя должно означать драйвер по умолчанию, получено %v, %v", d, err)
+	}
+	if d, err := Lookup(Auto); err != nil || d != Default() {
+		t.Errorf("auto должно означать драйвер по умолчанию, получено %v, %v", d, err)
+	}
+	if d, err := Lookup(Modernc); err != nil || d.Name != Modernc {
+		t.Errorf("драйвер modernc есть в любой сборке, получено %v, %v", d, err)
+	}
+	if _, err := Lookup("cgo"); err == nil || !strings.Contains(err.Error(), "неизвестный") {
+		t.Errorf("ожидается ошибка для неизвестного драйвера, получено %v", err)
+	}
+	if _, err := Default().Open(":memory:", Pragma{Name: "cache_size", Value: "1"}); err == nil {
+		t.Error("ожидается ошибка для неподдерживаемого параметра соединения")
+	}
+}
+
+// TestDrivers проверяет параметры соединения, обёртку и онлайн-копию на каждом драйвере сборки
+func TestDrivers(t *testing.T) {
+	ctx := context.Background()
+	for _, name := range Available() {
+		d, _ := Lookup(name)
+		dir := t.TempDir()
+		db, err := d.Open(filepath.Join(dir, "src.db"), Pragma{Name: "busy_timeout", Value: "5000"}, Pragma{Name: "journal_mode", Value: "WAL"})
+		if err != nil {
+			t.Fatalf("%s: ошибка открытия: %v", name, err)
+		}
+		var timeout int
+		var mode string
+		if err := db.QueryRow("PRAGMA busy_timeout").Scan(&timeout); err != nil || timeout != 5000 {
+			t.Errorf("%s: ожидается busy_timeout 5000, получено %d, %v", name, timeout, err)
+		}
+		if err := db.QueryRow("PRAGMA journal_mode").Scan(&mode); err != nil || mode != "wal" {
+			t.Errorf("%s: ожидается journal_mode wal, получено %q, %v", name, mode, err)
+		}
+		if _, err := db.Exec("CREATE TABLE t (v TEXT); INSERT INTO t VALUES ('x')"); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		db.Close()
+
+		// Обёртка регистрируется один раз, а копия снимает её с соединения
+		wrap := func(inner driver.Driver) driver.Driver { return wrapper{inner} }
+		w := d.Wrap("test", wrap)
+		if d.Wrap("test", wrap) != w || w.Name != name {
+			t.Errorf("%s: повторная обёртка должна вернуть тот же драйвер", name)
+		}
+		db, err = w.Open(filepath.Join(dir, "src.db"))
+		if err != nil {
+			t.Fatalf("%s: ошибка открытия через обёртку: %v", name, err)
+		}
+		backup := filepath.Join(dir, "backup.db")
+		if err := w.Backup(ctx, db, backup); err != nil {
+			t.Fatalf("%s: ошибка копирования: %v", name, err)
+		}
+		db.Close()
+		db, _ = d.Open(backup)
+		var v string
+		if err := db.QueryRow("SELECT v FROM t").Scan(&v); err != nil || v != "x" {
+			t.Errorf("%s: в копии ожидается строка x, получено %q, %v", name, v, err)
+		}
+		db.Close()
+	}
+}
+
+// wrapper — обёртка драйвера, как у трассировки: соединения отдают исходное через Unwrap
+type wrapper struct{ driver.Driver }
+
+func (w wrapper) Open(name string) (driver.Conn, error) {
+	c, err := w.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return wrappedConn{c}, nil
+}
+
+type wrappedConn struct{ driver.Conn }
+
+func (c wrappedConn) Unwrap() driver.Conn { return c.Conn }
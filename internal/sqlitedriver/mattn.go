@@ -0,0 +1,43 @@
+//go:build cgo && !purego
+
+package sqlitedriver
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+func init() {
+	register(&Driver{Name: Mattn, sqlName: "sqlite3", pragma: mattnPragma, backup: mattnBackup})
+}
+
+// mattnPragma записывает параметр как _name=value: go-sqlite3 знает каждый параметр по имени.
+func mattnPragma(q url.Values, p Pragma) {
+	q.Set("_"+p.Name, p.Value)
+}
+
+// mattnBackup открывает файл копии вторым соединением go-sqlite3 и копирует в него страницы src.
+func mattnBackup(src any, path string) error {
+	conn, ok := src.(*sqlite3.SQLiteConn)
+	if !ok {
+		return fmt.Errorf("драйвер %T не поддерживает резервное копирование", src)
+	}
+	dest, err := (&sqlite3.SQLiteDriver{}).Open(path)
+	if err != nil {
+		return err
+	}
+	b, err := dest.(*sqlite3.SQLiteConn).Backup("main", conn, "main")
+	if err == nil {
+		if _, err = b.Step(-1); err != nil {
+			b.Finish()
+		} else {
+			err = b.Finish()
+		}
+	}
+	if cerr := dest.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,36 @@
+package sqlitedriver
+
+import (
+	"fmt"
+	"net/url"
+
+	"modernc.org/sqlite"
+)
+
+func init() {
+	register(&Driver{Name: Modernc, sqlName: "sqlite", pragma: moderncPragma, backup: moderncBackup})
+}
+
+// moderncPragma записывает параметр как _pragma=name(value): modernc выполняет PRAGMA при подключении.
+func moderncPragma(q url.Values, p Pragma) {
+	q.Add("_pragma", p.Name+"("+p.Value+")")
+}
+
+// moderncBackup копирует страницы src в файл path; соединение с копией modernc открывает и закрывает сам.
+func moderncBackup(src any, path string) error {
+	conn, ok := src.(interface {
+		NewBackup(dstURI string) (*sqlite.Backup, error)
+	})
+	if !ok {
+		return fmt.Errorf("драйвер %T не поддерживает резервное копирование", src)
+	}
+	b, err := conn.NewBackup(path)
+	if err != nil {
+		return err
+	}
+	if _, err := b.Step(-1); err != nil {
+		b.Finish()
+		return err
+	}
+	return b.Finish()
+}
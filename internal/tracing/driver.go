@@ -0,0 +1,147 @@
+package tracing
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Driver оборачивает драйвер database/sql и создаёт спан на каждый SQL-запрос с текстом запроса
+// в атрибуте db.query.text. Значения параметров в спаны не попадают. Запросы вне трассы
+// (миграции, фоновые задачи) спанов не создают, чтобы не плодить одиночные трассы.
+func Driver(d driver.Driver, system string) driver.Driver {
+	return &tracedDriver{Driver: d, system: system}
+}
+
+type tracedDriver struct {
+	driver.Driver
+	system string
+}
+
+func (d *tracedDriver) Open(name string) (driver.Conn, error) {
+	c, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedConn{Conn: c, system: d.system}, nil
+}
+
+// tracedConn передаёт вызовы исходному соединению, оборачивая выполнение запросов в спаны.
+type tracedConn struct {
+	driver.Conn
+	system string
+}
+
+// Unwrap возвращает исходное соединение драйвера, например для sql.Conn.Raw.
+func (c *tracedConn) Unwrap() driver.Conn {
+	return c.Conn
+}
+
+// start начинает спан запроса, если в ctx есть трасса.
+func (c *tracedConn) start(ctx context.Context, query string) (context.Context, trace.Span) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx, nil
+	}
+	op, _, _ := strings.Cut(strings.TrimSpace(query), " ")
+	return tracer().Start(ctx, c.system+" "+strings.ToUpper(op),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemKey.String(c.system), semconv.DBQueryText(query)),
+	)
+}
+
+// end завершает спан запроса с ошибкой err. driver.ErrSkip не ошибка: database/sql
+// просто повторит запрос через Prepare.
+func end(span trace.Span, err error) {
+	if span == nil {
+		return
+	}
+	if err != nil && err != driver.ErrSkip {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (c *tracedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	ex, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := c.start(ctx, query)
+	res, err := ex.ExecContext(ctx, query, args)
+	end(span, err)
+	return res, err
+}
+
+func (c *tracedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := c.start(ctx, query)
+	rows, err := q.QueryContext(ctx, query, args)
+	if err != nil || span == nil {
+		end(span, err)
+		return rows, err
+	}
+	// Спан завершается, когда строки результата прочитаны и закрыты
+	return &tracedRows{Rows: rows, span: span}, nil
+}
+
+func (c *tracedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return p.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *tracedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *tracedConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *tracedConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *tracedConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *tracedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if ch, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return ch.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// tracedRows завершает спан запроса при закрытии результата.
+type tracedRows struct {
+	driver.Rows
+	span trace.Span
+}
+
+func (r *tracedRows) Close() error {
+	err := r.Rows.Close()
+	end(r.span, err)
+	return err
+}
@@ -0,0 +1,177 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"Test_Project_Brand_Scout/internal/auth"
+	"Test_Project_Brand_Scout/internal/instrument"
+	"Test_Project_Brand_Scout/internal/quotes"
+)
+
+// Repository оборачивает quotes.Repository и создаёт спан на каждую операцию.
+// Исходный репозиторий привязывается к контексту спана, поэтому спаны SQL-запросов
+// становятся дочерними для спана операции.
+type Repository struct {
+	next    quotes.Repository
+	backend string
+	ctx     context.Context
+}
+
+// NewRepository возвращает репозиторий, трассирующий операции repo; backend попадает в атрибут db.system.
+func NewRepository(repo quotes.Repository, backend string) quotes.Repository {
+	return &Repository{next: repo, backend: backend, ctx: context.Background()}
+}
+
+// call выполняет операцию op в дочернем спане контекста репозитория.
+func call[T any](r *Repository, op string, fn func(repo quotes.Repository) (T, error)) (T, error) {
+	ctx, span := tracer().Start(r.ctx, "Repository."+op, trace.WithAttributes(
+		attribute.String("db.system", r.backend),
+		attribute.String("db.operation.name", op),
+	))
+	defer span.End()
+	res, err := fn(quotes.BindContext(r.next, ctx))
+	if err != nil {
+		span.RecordError(err)
+		if !instrument.Expected(err) {
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+	return res, err
+}
+
+// callErr — call для операций, возвращающих только ошибку.
+func callErr(r *Repository, op string, fn func(repo quotes.Repository) error) error {
+	_, err := call(r, op, func(repo quotes.Repository) (struct{}, error) { return struct{}{}, fn(repo) })
+	return err
+}
+
+// Unwrap возвращает исходный репозиторий.
+func (r *Repository) Unwrap() quotes.Repository {
+	return r.next
+}
+
+// WithContext возвращает репозиторий, создающий спаны в контексте запроса ctx.
+func (r *Repository) WithContext(ctx context.Context) quotes.Repository {
+	return &Repository{next: r.next, backend: r.backend, ctx: ctx}
+}
+
+func (r *Repository) Tenant(id string) quotes.Repository {
+	return &Repository{next: r.next.Tenant(id), backend: r.backend, ctx: r.ctx}
+}
+
+func (r *Repository) Create(q quotes.Quote) (int, error) {
+	return call(r, "Create", func(repo quotes.Repository) (int, error) { return repo.Create(q) })
+}
+
+func (r *Repository) CreateLimited(q quotes.Quote, limit int) (int, error) {
+	return call(r, "CreateLimited", func(repo quotes.Repository) (int, error) { return repo.CreateLimited(q, limit) })
+}
+
+func (r *Repository) Close() error {
+	return r.next.Close()
+}
+
+func (r *Repository) QuoteCounts() (map[string]int, error) {
+	return call(r, "QuoteCounts", quotes.Repository.QuoteCounts)
+}
+
+func (r *Repository) GetAll() ([]quotes.Quote, error) {
+	return call(r, "GetAll", quotes.Repository.GetAll)
+}
+
+func (r *Repository) GetByID(id int) (quotes.Quote, error) {
+	return call(r, "GetByID", func(repo quotes.Repository) (quotes.Quote, error) { return repo.GetByID(id) })
+}
+
+func (r *Repository) GetRandom() (quotes.Quote, error) {
+	return call(r, "GetRandom", quotes.Repository.GetRandom)
+}
+
+func (r *Repository) GetRandomWith(rnd quotes.Rand) (quotes.Quote, error) {
+	return call(r, "GetRandomWith", func(repo quotes.Repository) (quotes.Quote, error) { return repo.GetRandomWith(rnd) })
+}
+
+func (r *Repository) GetWeightedRandomWith(rnd quotes.Rand) (quotes.Quote, error) {
+	return call(r, "GetWeightedRandomWith", func(repo quotes.Repository) (quotes.Quote, error) { return repo.GetWeightedRandomWith(rnd) })
+}
+
+func (r *Repository) SetScore(id, score int) error {
+	return callErr(r, "SetScore", func(repo quotes.Repository) error { return repo.SetScore(id, score) })
+}
+
+func (r *Repository) FilterByAuthor(author string) ([]quotes.Quote, error) {
+	return call(r, "FilterByAuthor", func(repo quotes.Repository) ([]quotes.Quote, error) { return repo.FilterByAuthor(author) })
+}
+
+func (r *Repository) Page(author string, after, limit int) ([]quotes.Quote, error) {
+	return call(r, "Page", func(repo quotes.Repository) ([]quotes.Quote, error) { return repo.Page(author, after, limit) })
+}
+
+func (r *Repository) Delete(id int) error {
+	return callErr(r, "Delete", func(repo quotes.Repository) error { return repo.Delete(id) })
+}
+
+func (r *Repository) SetReaction(re quotes.Reaction) (quotes.Quote, error) {
+	return call(r, "SetReaction", func(repo quotes.Repository) (quotes.Quote, error) { return repo.SetReaction(re) })
+}
+
+func (r *Repository) DeleteReaction(quoteID int, kind, client string) (quotes.Quote, error) {
+	return call(r, "DeleteReaction", func(repo quotes.Repository) (quotes.Quote, error) { return repo.DeleteReaction(quoteID, kind, client) })
+}
+
+func (r *Repository) ReactionsSince(since time.Time) ([]quotes.Reaction, error) {
+	return call(r, "ReactionsSince", func(repo quotes.Repository) ([]quotes.Reaction, error) { return repo.ReactionsSince(since) })
+}
+
+func (r *Repository) AddViews(views []quotes.ViewBucket, requests []quotes.RequestBucket) error {
+	return callErr(r, "AddViews", func(repo quotes.Repository) error { return repo.AddViews(views, requests) })
+}
+
+func (r *Repository) ViewsSince(since time.Time) ([]quotes.ViewBucket, error) {
+	return call(r, "ViewsSince", func(repo quotes.Repository) ([]quotes.ViewBucket, error) { return repo.ViewsSince(since) })
+}
+
+func (r *Repository) RequestsSince(since time.Time) ([]quotes.RequestBucket, error) {
+	return call(r, "RequestsSince", func(repo quotes.Repository) ([]quotes.RequestBucket, error) { return repo.RequestsSince(since) })
+}
+
+func (r *Repository) CreateKey(k auth.Key) (auth.Key, error) {
+	return call(r, "CreateKey", func(repo quotes.Repository) (auth.Key, error) { return repo.CreateKey(k) })
+}
+
+func (r *Repository) KeyByHash(hash string) (auth.Key, error) {
+	return call(r, "KeyByHash", func(repo quotes.Repository) (auth.Key, error) { return repo.KeyByHash(hash) })
+}
+
+func (r *Repository) ListKeys() ([]auth.Key, error) {
+	return call(r, "ListKeys", quotes.Repository.ListKeys)
+}
+
+func (r *Repository) RevokeKey(id int, at time.Time) error {
+	return callErr(r, "RevokeKey", func(repo quotes.Repository) error { return repo.RevokeKey(id, at) })
+}
+
+func (r *Repository) GetDailyPick(date string) (quotes.DailyPick, error) {
+	return call(r, "GetDailyPick", func(repo quotes.Repository) (quotes.DailyPick, error) { return repo.GetDailyPick(date) })
+}
+
+func (r *Repository) SaveDailyPick(p quotes.DailyPick) (quotes.DailyPick, error) {
+	return call(r, "SaveDailyPick", func(repo quotes.Repository) (quotes.DailyPick, error) { return repo.SaveDailyPick(p) })
+}
+
+func (r *Repository) PinDailyPick(p quotes.DailyPick) error {
+	return callErr(r, "PinDailyPick", func(repo quotes.Repository) error { return repo.PinDailyPick(p) })
+}
+
+func (r *Repository) DeleteDailyPick(date string) error {
+	return callErr(r, "DeleteDailyPick", func(repo quotes.Repository) error { return repo.DeleteDailyPick(date) })
+}
+
+func (r *Repository) DailyPicksBetween(from, to string) ([]quotes.DailyPick, error) {
+	return call(r, "DailyPicksBetween", func(repo quotes.Repository) ([]quotes.DailyPick, error) { return repo.DailyPicksBetween(from, to) })
+}
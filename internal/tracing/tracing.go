@@ -0,0 +1,143 @@
+// Package tracing настраивает трассировку OpenTelemetry: спаны HTTP-запросов, операций
+// репозитория и SQL-запросов, распространение контекста W3C traceparent и экспорт спанов.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"Test_Project_Brand_Scout/internal/instrument"
+)
+
+// tracer возвращает трассировщик спанов HTTP-запросов и операций репозитория. Он берётся
+// у текущего глобального провайдера при каждом вызове, поэтому замена провайдера действует сразу.
+func tracer() trace.Tracer {
+	return otel.Tracer("Test_Project_Brand_Scout/internal/tracing")
+}
+
+// unmatchedRoute — имя маршрута для запросов, не попавших ни в один маршрут.
+const unmatchedRoute = "unmatched"
+
+// Options задаёт экспорт спанов.
+type Options struct {
+	// Exporter — куда отправлять спаны: none, otlp, stdout или file.
+	Exporter string
+	// Endpoint — адрес OTLP/HTTP коллектора, например http://localhost:4318.
+	Endpoint string
+	// File — файл, в который дописываются спаны в формате JSON при Exporter=file.
+	File string
+	// SampleRatio — доля трассируемых запросов от 0 до 1 для запросов без решения вышестоящего сервиса.
+	SampleRatio float64
+	// ServiceName — имя сервиса в ресурсе спанов.
+	ServiceName string
+}
+
+// Setup устанавливает глобальный распространитель контекста W3C и, если экспорт включён,
+// провайдер спанов. Возвращаемая функция отправляет оставшиеся спаны и закрывает экспортёр.
+func Setup(opts Options) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exp sdktrace.SpanExporter
+	var closer io.Closer
+	// Локальный вывод пишется сразу, чтобы спаны не терялись при остановке процесса
+	immediate := false
+	switch opts.Exporter {
+	case "", "none":
+		return func(context.Context) error { return nil }, nil
+	case "otlp":
+		var o []otlptracehttp.Option
+		if opts.Endpoint != "" {
+			o = append(o, otlptracehttp.WithEndpointURL(opts.Endpoint))
+		}
+		exp, err = otlptracehttp.New(context.Background(), o...)
+	case "stdout":
+		exp, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		immediate = true
+	case "file":
+		f, ferr := os.OpenFile(opts.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if ferr != nil {
+			return nil, fmt.Errorf("не удалось открыть файл спанов: %w", ferr)
+		}
+		closer = f
+		exp, err = stdouttrace.New(stdouttrace.WithWriter(f))
+		immediate = true
+	default:
+		return nil, fmt.Errorf("неизвестный экспортёр спанов %q (ожидается none, otlp, stdout или file)", opts.Exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	name := opts.ServiceName
+	if name == "" {
+		name = "quotes"
+	}
+	ratio := opts.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+	processor := sdktrace.NewBatchSpanProcessor(exp)
+	if immediate {
+		processor = sdktrace.NewSimpleSpanProcessor(exp)
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(processor),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(name))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(tp)
+	return func(ctx context.Context) error {
+		err := tp.Shutdown(ctx)
+		if closer != nil {
+			if cerr := closer.Close(); err == nil {
+				err = cerr
+			}
+		}
+		return err
+	}, nil
+}
+
+// Middleware продолжает трассу из заголовка traceparent или начинает новую и оборачивает
+// обработку запроса в серверный спан с именем "МЕТОД шаблон-маршрута".
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		route := unmatchedRoute
+		if cur := mux.CurrentRoute(r); cur != nil {
+			if tpl, err := cur.GetPathTemplate(); err == nil {
+				route = tpl
+			}
+		}
+		ctx, span := tracer().Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		sw := instrument.NewResponseWriter(w)
+		next.ServeHTTP(sw, r.WithContext(ctx))
+		status := sw.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
@@ -0,0 +1,141 @@
+package tracing
+
+import (
+	"context"
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"Test_Project_Brand_Scout/internal/quotes"
+	"Test_Project_Brand_Scout/internal/sqlitedriver"
+)
+
+// tracedSQLite — драйвер SQLite сборки, соединения которого создают спаны
+var tracedSQLite = sqlitedriver.Default().Wrap("traced-test", func(d driver.Driver) driver.Driver { return Driver(d, "sqlite") })
+
+// withRecorder устанавливает провайдер, сохраняющий спаны в памяти
+func withRecorder(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	if _, err := Setup(Options{}); err != nil {
+		t.Fatalf("ошибка Setup: %v", err)
+	}
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	t.Cleanup(func() {
+		tp.Shutdown(context.Background())
+		otel.SetTracerProvider(prev)
+	})
+	return exp
+}
+
+// byName находит спан по имени
+func byName(spans tracetest.SpanStubs, name string) (tracetest.SpanStub, bool) {
+	for _, s := range spans {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return tracetest.SpanStub{}, false
+}
+
+// TestSpansAcrossLayers проверяет вложенность спанов HTTP → Service → Repository → SQL
+// и продолжение трассы из заголовка traceparent
+func TestSpansAcrossLayers(t *testing.T) {
+	exp := withRecorder(t)
+	raw, err := quotes.OpenSQLiteRepository(":memory:", quotes.WithSQLDriver(tracedSQLite))
+	if err != nil {
+		t.Fatalf("ошибка открытия SQLite: %v", err)
+	}
+	raw.Create(quotes.Quote{Author: "A", Text: "a"})
+	repo := NewRepository(raw, "sqlite")
+	r := mux.NewRouter()
+	r.Use(Middleware)
+	quotes.RegisterHandlers(r, quotes.NewService(repo), nil)
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/quotes/random?seed=1", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("ожидается 200, получен %d", w.Code)
+	}
+
+	spans := exp.GetSpans()
+	httpSpan, ok := byName(spans, "GET /quotes/random")
+	if !ok {
+		t.Fatalf("нет спана HTTP-запроса среди %d спанов", len(spans))
+	}
+	if httpSpan.SpanContext.TraceID().String() != traceID || httpSpan.Parent.SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("трасса не продолжена из traceparent: %s", httpSpan.SpanContext.TraceID())
+	}
+	svcSpan, ok := byName(spans, "Service.Random")
+	if !ok || svcSpan.Parent.SpanID() != httpSpan.SpanContext.SpanID() {
+		t.Fatalf("спан сервиса должен быть дочерним для HTTP: %+v", svcSpan.Parent)
+	}
+	repoSpan, ok := byName(spans, "Repository.GetRandomWith")
+	if !ok || repoSpan.Parent.SpanID() != svcSpan.SpanContext.SpanID() {
+		t.Fatalf("спан репозитория должен быть дочерним для сервиса: %+v", repoSpan.Parent)
+	}
+	var sqlSpans int
+	for _, s := range spans {
+		if strings.HasPrefix(s.Name, "sqlite ") {
+			if s.Parent.SpanID() != repoSpan.SpanContext.SpanID() {
+				t.Errorf("SQL-спан %q должен быть дочерним для репозитория", s.Name)
+			}
+			for _, a := range s.Attributes {
+				if a.Key == "db.query.text" && !strings.Contains(a.Value.AsString(), "FROM quotes") {
+					t.Errorf("неожиданный текст запроса %q", a.Value.AsString())
+				}
+			}
+			sqlSpans++
+		}
+	}
+	if sqlSpans == 0 {
+		t.Error("ожидаются спаны SQL-запросов")
+	}
+}
+
+// TestSetupFile проверяет экспорт спанов в файл в формате JSON
+func TestSetupFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.json")
+	prev := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(prev)
+	shutdown, err := Setup(Options{Exporter: "file", File: path})
+	if err != nil {
+		t.Fatalf("ошибка Setup: %v", err)
+	}
+	r := mux.NewRouter()
+	r.Use(Middleware)
+	r.HandleFunc("/quotes/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/quotes/1", nil))
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("ошибка shutdown: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ошибка чтения файла спанов: %v", err)
+	}
+	for _, want := range []string{`"Name":"GET /quotes/{id}"`, `"http.route"`, `"Code":"Error"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("в файле спанов нет %s: %s", want, data)
+		}
+	}
+	if _, err := Setup(Options{Exporter: "zipkin"}); err == nil {
+		t.Error("ожидается ошибка для неизвестного экспортёра")
+	}
+}
@@ -1,75 +1,399 @@
 package main
 
 import (
+	"Test_Project_Brand_Scout/internal/auth"
+	"Test_Project_Brand_Scout/internal/backup"
 	"Test_Project_Brand_Scout/internal/config"
+	"Test_Project_Brand_Scout/internal/cors"
+	"Test_Project_Brand_Scout/internal/features"
+	"Test_Project_Brand_Scout/internal/health"
+	"Test_Project_Brand_Scout/internal/httplog"
+	"Test_Project_Brand_Scout/internal/metrics"
 	"Test_Project_Brand_Scout/internal/quotes"
-	"bytes"
+	"Test_Project_Brand_Scout/internal/ratelimit"
+	"Test_Project_Brand_Scout/internal/servertls"
+	"Test_Project_Brand_Scout/internal/sqlitedriver"
+	"Test_Project_Brand_Scout/internal/tracing"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"flag"
+	"fmt"
 	"github.com/gorilla/mux"
-	"io"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+	_ "time/tzdata"
 )
 
+// tracingEnabled сообщает, включён ли экспорт спанов
+func tracingEnabled(cfg config.Config) bool {
+	return cfg.TraceExporter != "" && cfg.TraceExporter != "none"
+}
+
+// sqliteDriver возвращает драйвер SQLite из конфигурации; при включённой трассировке
+// его соединения создают спаны SQL-запросов
+func sqliteDriver(cfg config.Config) *sqlitedriver.Driver {
+	d, err := sqlitedriver.Lookup(cfg.SQLiteDriver)
+	if err != nil {
+		log.Fatalf("Не удалось выбрать драйвер SQLite: %v", err)
+	}
+	if tracingEnabled(cfg) {
+		d = d.Wrap("traced", func(d driver.Driver) driver.Driver { return tracing.Driver(d, "sqlite") })
+	}
+	return d
+}
+
+// openRepository открывает репозиторий цитат бэкенда, выбранного по адресу хранилища из конфигурации
+func openRepository(cfg config.Config) quotes.Repository {
+	repo, err := quotes.Open(cfg.StorageURL(), quotes.WithSQLDriver(sqliteDriver(cfg)))
+	if err != nil {
+		log.Fatalf("Не удалось открыть хранилище: %v", err)
+	}
+	return repo
+}
+
+// app — собранное приложение: маршрутизатор API, обработчик метрик и проверки готовности
+type app struct {
+	router  *mux.Router
+	metrics http.Handler
+	health  *health.Registry
+	// handler — корневой обработчик: пробы /healthz и /readyz и маршрутизатор API
+	handler http.Handler
+
+	repo    quotes.Repository
+	limiter *ratelimit.Limiter
+	// backups создаёт резервные копии базы; nil, если BACKUP_DIR не задан
+	backups *backup.Manager
+	// Компоненты с динамическими параметрами, которые обновляются при перезагрузке конфигурации
+	logger   *httplog.Logger
+	cors     *cors.CORS
+	features *features.Flags
+	// stopFlusher останавливает сброс счётчиков показов, flushed закрывается после последнего сброса
+	stopFlusher chan struct{}
+	flushed     chan struct{}
+}
+
 // newRouter создаёт маршрутизатор с middleware и хендлерами на основе конфигурации
 func newRouter(cfg config.Config) *mux.Router {
-	var repo quotes.Repository
-	if cfg.DBMode == "sqlite" {
-		repo = quotes.NewSQLiteRepository(cfg.DBPath)
-	} else {
-		repo = quotes.NewMemoryRepository()
-	}
-	svc := quotes.NewService(repo)
-	router := mux.NewRouter()
-	router.Use(loggingMiddleware)
-	quotes.RegisterHandlers(router, svc)
-	return router
+	return newApp(cfg).router
 }
 
-func main() {
-	cfg := config.Load()
-	log.Printf("Используется режим хранения: %s", cfg.DBMode)
+// newApp создаёт хранилище, сервис и маршрутизатор. Если служебный адрес METRICS_ADDR не задан,
+// /metrics регистрируется на основном маршрутизаторе.
+func newApp(cfg config.Config) *app {
+	m := metrics.New()
+	raw := openRepository(cfg)
+	if err := m.RegisterQuoteCount(raw); err != nil {
+		log.Fatalf("Не удалось зарегистрировать метрики: %v", err)
+	}
+	if db, ok := raw.(interface{ DB() *sql.DB }); ok {
+		if err := m.RegisterDB(db.DB(), "quotes"); err != nil {
+			log.Fatalf("Не удалось зарегистрировать метрики: %v", err)
+		}
+	}
+	// Адрес хранилища уже определил режим, поэтому он же служит именем бэкенда в метриках и спанах
+	backend := cfg.DBMode
+	if backend == "" {
+		backend = "memory"
+	}
+	var repo quotes.Repository = raw
+	if tracingEnabled(cfg) {
+		repo = tracing.NewRepository(repo, backend)
+	}
+	repo = m.Repository(repo, backend)
+	svc := quotes.NewService(repo,
+		quotes.WithDailyWindow(cfg.DailyWindow),
+		quotes.WithPopularHalfLife(cfg.PopularHalfLife),
+		quotes.WithQuotas(cfg.TenantMaxQuotes, cfg.TenantQuotas),
+	)
+	a := &app{metrics: m.Handler(), repo: repo}
+	// Счётчики показов копятся в памяти и периодически сбрасываются в хранилище
+	if cfg.ViewFlushInterval > 0 {
+		a.stopFlusher = make(chan struct{})
+		a.flushed = make(chan struct{})
+		go func() {
+			defer close(a.flushed)
+			svc.RunViewFlusher(cfg.ViewFlushInterval, a.stopFlusher)
+		}()
+	}
+	router := mux.NewRouter()
+	logger := httplog.New(slog.Default(), logOptions(cfg.Dynamic))
+	a.logger = logger
+	a.features = features.New(cfg.Features)
+	// Спан запроса создаётся первым, чтобы лог содержал trace_id
+	router.Use(tracing.Middleware, logger.Middleware, m.Middleware, a.features.Middleware)
+	// Запросы к несуществующим маршрутам не проходят через middleware mux, поэтому оборачиваются отдельно
+	unmatched := func(h http.Handler) http.Handler {
+		return tracing.Middleware(logger.Middleware(m.Middleware(a.features.Middleware(h))))
+	}
+	router.NotFoundHandler = unmatched(http.NotFoundHandler())
+	router.MethodNotAllowedHandler = unmatched(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+	authn, keys := newAuthenticator(cfg, repo)
+	// Метрики описывают все пространства, поэтому на основном порту доступны только оператору
+	if cfg.MetricsAddr == "" {
+		router.Handle("/metrics", auth.Require(authn, auth.ScopeAdmin, auth.OperatorOnly(m.Handler()))).Methods(http.MethodGet)
+	}
+	// Личность определяется до ограничителя, чтобы лимиты считались по ключу, а не по IP
+	router.Use(func(next http.Handler) http.Handler { return auth.Identify(authn, next) })
+	a.limiter = newLimiter(cfg)
+	router.Use(a.limiter.Middleware)
+	if keys != nil {
+		auth.RegisterKeyHandlers(router, keys, authn)
+	}
+	quotes.RegisterHandlers(router, svc, authn)
+	if src, ok := raw.(backup.Source); ok && cfg.BackupDir != "" {
+		a.backups = backup.New(cfg.BackupDir, src, backup.Retention{Daily: cfg.BackupKeepDaily, Weekly: cfg.BackupKeepWeekly})
+		backup.RegisterHandlers(router, a.backups, authn)
+	}
 
-	router := newRouter(cfg)
+	a.router = router
+	a.health = newHealth(cfg, raw)
+	// Пробы обслуживаются в обход middleware маршрутизатора: их не ограничивает лимит запросов
+	// и они не засоряют лог и метрики. CORS стоит перед маршрутизатором, потому что у preflight-запросов
+	// OPTIONS нет собственных маршрутов.
+	a.cors = cors.New(corsOptions(cfg.Dynamic))
+	root := http.NewServeMux()
+	root.Handle("GET /healthz", health.LiveHandler())
+	root.Handle("GET /readyz", a.health.ReadyHandler())
+	root.Handle("/", a.cors.Middleware(router))
+	a.handler = root
+	return a
+}
 
-	log.Println("Сервер запущен на порту", cfg.Port)
-	log.Fatal(http.ListenAndServe(
-		":"+cfg.Port,
-		router,
-	))
+// newHealth регистрирует проверки готовности хранилища: соединение с SQLite,
+// версию схемы и свободное место на разделе с файлом базы.
+func newHealth(cfg config.Config, repo quotes.Repository) *health.Registry {
+	checks := health.NewRegistry(cfg.HealthTimeout)
+	db, ok := repo.(interface {
+		Ping(ctx context.Context) error
+		CheckSchema(ctx context.Context) error
+	})
+	if !ok {
+		return checks
+	}
+	checks.Register("sqlite", db.Ping)
+	checks.Register("migrations", db.CheckSchema)
+	if cfg.DBPath != ":memory:" && !strings.Contains(cfg.DBPath, "mode=memory") {
+		checks.Register("disk", health.DiskSpace(cfg.DBPath, cfg.HealthMinFreeMB<<20))
+	}
+	return checks
 }
 
-// loggingResponseWriter оборачивает http.ResponseWriter для перехвата статуса и тела ответа и сохранения их для последующего логирования
-type loggingResponseWriter struct {
-	http.ResponseWriter
-	statusCode int
-	body       bytes.Buffer
+// newAuthenticator собирает аутентификатор из режимов AUTH_MODE, перечисленных через запятую.
+// Возвращает nil, если авторизация отключена, и менеджер ключей, если включены ключи API.
+func newAuthenticator(cfg config.Config, repo quotes.Repository) (auth.Authenticator, *auth.KeyManager) {
+	var chain auth.Chain
+	var keys *auth.KeyManager
+	for _, mode := range strings.Split(cfg.AuthMode, ",") {
+		switch strings.TrimSpace(mode) {
+		case "", "none":
+		case "apikey":
+			keys = auth.NewKeyManager(repo)
+			if cfg.AuthBootstrapKey != "" {
+				if err := keys.Ensure("bootstrap", cfg.AuthBootstrapKey, []string{auth.ScopeAdmin}); err != nil {
+					log.Fatalf("Не удалось сохранить AUTH_BOOTSTRAP_KEY: %v", err)
+				}
+			}
+			chain = append(chain, keys)
+		case "jwt":
+			jwt, err := auth.NewJWTAuthenticator(auth.JWTConfig{
+				JWKS:        cfg.JWTJWKS,
+				Issuer:      cfg.JWTIssuer,
+				Audience:    cfg.JWTAudience,
+				RoleClaim:   cfg.JWTRoleClaim,
+				RoleMap:     cfg.JWTRoleMap,
+				Leeway:      cfg.JWTLeeway,
+				TenantClaim: cfg.JWTTenantClaim,
+			})
+			if err != nil {
+				log.Fatalf("Не удалось настроить проверку JWT: %v", err)
+			}
+			chain = append(chain, jwt)
+		case "cert":
+			if cfg.TLSClientCAFile == "" {
+				log.Fatal("Для AUTH_MODE=cert нужно задать TLS_CLIENT_CA_FILE")
+			}
+			cert, err := auth.NewCertAuthenticator(auth.CertConfig{
+				RoleMap:     cfg.TLSClientRoleMap,
+				TenantMap:   cfg.TLSClientTenantMap,
+				DefaultRole: cfg.TLSClientDefaultRole,
+			})
+			if err != nil {
+				log.Fatalf("Не удалось настроить авторизацию по сертификатам: %v", err)
+			}
+			chain = append(chain, cert)
+		default:
+			log.Fatalf("Неизвестный режим авторизации %q в AUTH_MODE (ожидается none, apikey, jwt или cert)", mode)
+		}
+	}
+	if len(chain) == 0 {
+		log.Println("Авторизация отключена (AUTH_MODE=none): любой клиент может изменять и удалять цитаты")
+		return nil, nil
+	}
+	return chain, keys
 }
 
-// WriteHeader сохраняет код статуса и передаёт его оригинальному ResponseWriter
-func (lrw *loggingResponseWriter) WriteHeader(code int) {
-	lrw.statusCode = code
-	lrw.ResponseWriter.WriteHeader(code)
+// newLimiter создаёт ограничитель запросов по правилам RATE_LIMITS и RATE_DAILY_QUOTAS.
+// Ограничитель создаётся и без правил, чтобы их можно было добавить перезагрузкой конфигурации.
+func newLimiter(cfg config.Config) *ratelimit.Limiter {
+	var key ratelimit.KeyFunc
+	switch cfg.RateLimitKey {
+	case "", "key":
+		key = ratelimit.ByIdentity
+	case "ip":
+		key = ratelimit.ByIP
+	case "tenant":
+		key = ratelimit.ByTenant(quotes.DefaultTenant)
+	default:
+		log.Fatalf("Неизвестный ключ ограничения %q в RATE_LIMIT_KEY (ожидается key, ip или tenant)", cfg.RateLimitKey)
+	}
+
+	var store ratelimit.Store
+	switch cfg.RateLimitStore {
+	case "", "memory":
+		store = ratelimit.NewMemoryStore()
+	case "sqlite":
+		sq, err := ratelimit.NewSQLiteStore(cfg.DBPath, sqliteDriver(cfg))
+		if err != nil {
+			log.Fatalf("Не удалось открыть хранилище ограничителя: %v", err)
+		}
+		store = sq
+	default:
+		log.Fatalf("Неизвестное хранилище ограничителя %q в RATE_LIMIT_STORE (ожидается memory или sqlite)", cfg.RateLimitStore)
+	}
+	return ratelimit.New(store, limiterRules(cfg.Dynamic), key)
 }
 
-// Write записывает тело ответа в буфер и отправляет его через оригинальный ResponseWriter
-func (lrw *loggingResponseWriter) Write(b []byte) (int, error) {
-	lrw.body.Write(b)
-	return lrw.ResponseWriter.Write(b)
+// newTLS загружает сертификаты сервера и запускает их перезагрузку по изменению файлов до отмены ctx.
+func newTLS(ctx context.Context, cfg config.Config) *servertls.Reloader {
+	certs, err := servertls.New(servertls.Options{
+		CertFile:     cfg.TLSCertFile,
+		KeyFile:      cfg.TLSKeyFile,
+		MinVersion:   cfg.TLSMinVersion,
+		CipherPolicy: cfg.TLSCipherPolicy,
+		ClientCAFile: cfg.TLSClientCAFile,
+		ClientAuth:   cfg.TLSClientAuth,
+	})
+	if err != nil {
+		log.Fatalf("Не удалось настроить TLS: %v", err)
+	}
+	if cfg.TLSReloadInterval > 0 {
+		go certs.Watch(ctx, cfg.TLSReloadInterval)
+	}
+	return certs
 }
 
-// loggingMiddleware создаёт middleware для логирования каждого HTTP-запроса и ответа
-// Лог содержит метод, URI, тело запроса, статус и тело ответа
-func loggingMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		var reqBody []byte
-		if r.Body != nil {
-			reqBody, _ = io.ReadAll(r.Body)
+func main() {
+	cfg, flags, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Некорректная конфигурация:\n%v\n", err)
+		os.Exit(2)
+	}
+	if flags.PrintConfig {
+		if err := config.Print(os.Stdout, cfg); err != nil {
+			log.Fatal(err)
 		}
-		r.Body = io.NopCloser(bytes.NewBuffer(reqBody))
+		return
+	}
+	// Уровень хранится в LevelVar, чтобы его можно было сменить перезагрузкой конфигурации
+	level := new(slog.LevelVar)
+	lvl, err := httplog.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		log.Fatalf("Некорректные настройки логирования: %v", err)
+	}
+	level.Set(lvl)
+	logger, err := httplog.NewLeveledLogger(os.Stderr, cfg.LogFormat, level)
+	if err != nil {
+		log.Fatalf("Некорректные настройки логирования: %v", err)
+	}
+	// Сообщения пакета log тоже проходят через slog и попадают в тот же формат
+	slog.SetDefault(logger)
 
-		log.Printf("Получен запрос: %s %s, тело: %s", r.Method, r.RequestURI, string(reqBody))
-		lrw := &loggingResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
-		next.ServeHTTP(lrw, r)
-		log.Printf("Отправлен ответ: статус %d, тело: %s", lrw.statusCode, lrw.body.String())
+	shutdownTracing, err := tracing.Setup(tracing.Options{
+		Exporter:    cfg.TraceExporter,
+		Endpoint:    cfg.TraceEndpoint,
+		File:        cfg.TraceFile,
+		SampleRatio: cfg.TraceSampleRatio,
 	})
+	if err != nil {
+		log.Fatalf("Не удалось настроить трассировку: %v", err)
+	}
+	// Ключами API напрямую в хранилище управляет quotesctl; прежняя подкоманда сервера подсказывает замену
+	if len(flags.Args) > 0 && flags.Args[0] == "keys" {
+		fmt.Fprintln(os.Stderr, "подкоманда keys перенесена в quotesctl: quotesctl keys "+strings.Join(flags.Args[1:], " "))
+		os.Exit(2)
+	}
+	log.Printf("Используется режим хранения: %s", cfg.DBMode)
+
+	// Первый SIGINT или SIGTERM запускает плавную остановку, повторный завершает процесс сразу
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// После первого сигнала обработчик снимается, и повторный сигнал получает действие по умолчанию
+	context.AfterFunc(ctx, stop)
+
+	a := newApp(cfg)
+	settings := config.NewReloader(cfg, flags)
+	a.subscribe(settings, level)
+	if cfg.ConfigReloadInterval > 0 {
+		go settings.Watch(ctx, cfg.ConfigReloadInterval)
+	}
+	if a.backups != nil && cfg.BackupInterval > 0 {
+		go a.backups.Run(ctx, cfg.BackupInterval)
+	}
+	ln, err := net.Listen("tcp", ":"+cfg.Port)
+	if err != nil {
+		log.Fatalf("Не удалось открыть порт %s: %v", cfg.Port, err)
+	}
+	srv := newServer(cfg, a.handler)
+	var certs *servertls.Reloader
+	if cfg.TLSCertFile != "" {
+		certs = newTLS(ctx, cfg)
+		srv.TLSConfig = certs.Config()
+	}
+	go reloadOnHangup(ctx, settings, certs)
+	endpoints := []endpoint{{srv: srv, ln: ln}}
+	if cfg.MetricsAddr != "" {
+		admin := http.NewServeMux()
+		admin.Handle("GET /metrics", a.metrics)
+		admin.Handle("GET /healthz", health.LiveHandler())
+		admin.Handle("GET /readyz", a.health.ReadyHandler())
+		aln, err := net.Listen("tcp", cfg.MetricsAddr)
+		if err != nil {
+			log.Fatalf("Не удалось открыть служебный адрес %s: %v", cfg.MetricsAddr, err)
+		}
+		log.Println("Метрики доступны на", cfg.MetricsAddr)
+		endpoints = append(endpoints, endpoint{srv: newServer(cfg, admin), ln: aln})
+	}
+
+	if srv.TLSConfig != nil {
+		log.Println("Сервер запущен на порту", cfg.Port, "(HTTPS)")
+	} else {
+		log.Println("Сервер запущен на порту", cfg.Port)
+	}
+	err = a.serve(ctx, cfg, endpoints...)
+	// Оставшиеся спаны отправляются после остановки серверов, чтобы попали спаны последних запросов
+	tctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if terr := shutdownTracing(tctx); terr != nil {
+		log.Printf("Не удалось отправить спаны: %v", terr)
+	}
+	if err != nil {
+		log.Printf("Сервер остановлен с ошибкой: %v", err)
+		cancel()
+		os.Exit(1)
+	}
+	log.Println("Сервер остановлен")
 }
@@ -2,18 +2,27 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"Test_Project_Brand_Scout/internal/backup"
 	"Test_Project_Brand_Scout/internal/config"
+	"Test_Project_Brand_Scout/internal/quotes"
 )
 
 // TestNewRouterMemory проверяет эндпоинты при хранении в памяти
 func TestNewRouterMemory(t *testing.T) {
-	cfg := config.Config{Port: "8080", DBMode: "memory"}
+	cfg := config.Config{Static: config.Static{Port: "8080", DBMode: "memory"}}
 	r := newRouter(cfg)
 
 	// GET /quotes должен вернуть пустой список
@@ -73,7 +82,7 @@ func TestNewRouterMemory(t *testing.T) {
 // TestNewRouterSQLite проверяет эндпоинты при хранении в SQLite режиме с in-memory базой
 func TestNewRouterSQLite(t *testing.T) {
 	// Используем :memory: чтобы не трогать файл на диске
-	cfg := config.Config{Port: "8080", DBMode: "sqlite", DBPath: ":memory:"}
+	cfg := config.Config{Static: config.Static{Port: "8080", DBMode: "sqlite", DBPath: ":memory:"}}
 	r := newRouter(cfg)
 
 	// GET /quotes должен вернуть пустой список
@@ -113,3 +122,291 @@ func TestNewRouterSQLite(t *testing.T) {
 		t.Errorf("SQLite: ожидается одна цитата от S, получено %v", list)
 	}
 }
+
+// TestNewRouterBolt проверяет, что DB_MODE=bolt сохраняет цитаты в файле bbolt
+func TestNewRouterBolt(t *testing.T) {
+	cfg := config.Config{Static: config.Static{Port: "8080", DBMode: "bolt", DBPath: filepath.Join(t.TempDir(), "quotes.bolt")}}
+	a := newApp(cfg)
+	defer a.Close()
+
+	buf, _ := json.Marshal(map[string]string{"author": "B", "quote": "BoltQ"})
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/quotes", bytes.NewBuffer(buf))
+	req.Header.Set("Content-Type", "application/json")
+	a.router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("bolt: ожидается статус 201 Created, получен %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	a.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/quotes?author=B", nil))
+	var list []map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || len(list) != 1 || list[0]["quote"] != "BoltQ" {
+		t.Errorf("bolt: ожидается одна цитата от B, получено %s (%v)", w.Body.String(), err)
+	}
+}
+
+// TestNewRouterBackup проверяет онлайн-копию базы через POST /admin/backup, в том числе через драйвер
+// с трассировкой, который оборачивает соединения драйвера SQLite
+func TestNewRouterBackup(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.Config{Static: config.Static{Port: "8080", DBMode: "sqlite", DBPath: filepath.Join(dir, "quotes.db"),
+		BackupDir: filepath.Join(dir, "backups"), TraceExporter: "stdout"}}
+	r := newRouter(cfg)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/quotes", strings.NewReader(`{"author":"A","quote":"в копии"}`)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("ожидается статус 201 Created, получен %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/backup", nil))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("ожидается статус 201 Created, получен %d: %s", w.Code, w.Body)
+	}
+	var snap backup.Snapshot
+	if err := json.NewDecoder(w.Body).Decode(&snap); err != nil {
+		t.Fatal(err)
+	}
+	if err := backup.VerifyChecksum(snap.Path); err != nil {
+		t.Errorf("копия не прошла проверку контрольной суммы: %v", err)
+	}
+	if _, err := quotes.VerifySQLiteFile(context.Background(), snap.Path); err != nil {
+		t.Errorf("копия не прошла проверку базы: %v", err)
+	}
+	copied, err := quotes.OpenSQLiteRepository(snap.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer copied.Close()
+	if list, err := copied.GetAll(); err != nil || len(list) != 1 || list[0].Text != "в копии" {
+		t.Errorf("копия должна содержать цитату, получено %v %v", list, err)
+	}
+
+	// Без BACKUP_DIR эндпоинт не регистрируется
+	cfg.BackupDir = ""
+	w = httptest.NewRecorder()
+	newRouter(cfg).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/backup", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("без BACKUP_DIR ожидается 404, получен %d", w.Code)
+	}
+}
+
+// TestNewRouterAPIKey проверяет авторизацию по ключам API с административным ключом из конфигурации
+func TestNewRouterAPIKey(t *testing.T) {
+	admin := "qk_bootstrap-admin-key-for-tests"
+	cfg := config.Config{Static: config.Static{Port: "8080", DBMode: "memory", AuthMode: "apikey", AuthBootstrapKey: admin}}
+	r := newRouter(cfg)
+
+	// Без ключа удаление запрещено
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/quotes/1", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("ожидается 401 без ключа, получен %d", w.Code)
+	}
+
+	// Администратор выдаёт ключ только для чтения
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/admin/keys", bytes.NewBufferString(`{"name":"viewer","scopes":["quotes:read"]}`))
+	req.Header.Set("Authorization", "Bearer "+admin)
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("ожидается 201 при выдаче ключа, получен %d", w.Code)
+	}
+	var issued map[string]any
+	json.Unmarshal(w.Body.Bytes(), &issued)
+	viewer, _ := issued["key"].(string)
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/quotes", nil)
+	req.Header.Set("Authorization", "Bearer "+viewer)
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("ожидается 200 для чтения с ключом viewer, получен %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/quotes", bytes.NewBufferString(`{"author":"A","quote":"Q"}`))
+	req.Header.Set("Authorization", "Bearer "+viewer)
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("ожидается 403 для записи с ключом viewer, получен %d", w.Code)
+	}
+}
+
+// TestNewRouterCert проверяет авторизацию по клиентскому сертификату mTLS
+func TestNewRouterCert(t *testing.T) {
+	cfg := config.Config{Static: config.Static{DBMode: "memory", AuthMode: "cert", TLSClientCAFile: "ca.pem",
+		TLSClientRoleMap: map[string]string{"ci": "editor"}}}
+	r := newRouter(cfg)
+	post := func(state *tls.ConnectionState) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/quotes", bytes.NewBufferString(`{"author":"A","quote":"Q"}`))
+		req.TLS = state
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	if code := post(nil); code != http.StatusUnauthorized {
+		t.Errorf("ожидается 401 без сертификата, получен %d", code)
+	}
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "ci"}}
+	if code := post(&tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}); code != http.StatusCreated {
+		t.Errorf("ожидается 201 для сертификата editor, получен %d", code)
+	}
+
+	// Администратор пространства по сертификату не получает операторских эндпоинтов
+	cfg.TLSClientTenantMap = map[string]string{"team": "team-a"}
+	cfg.TLSClientDefaultRole = "admin"
+	r = newRouter(cfg)
+	cert = &x509.Certificate{Subject: pkix.Name{CommonName: "team"}}
+	if code := post(&tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}); code != http.StatusCreated {
+		t.Errorf("ожидается 201 для сертификата пространства, получен %d", code)
+	}
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("ожидается 403 на /metrics для сертификата пространства, получен %d", w.Code)
+	}
+	cert = &x509.Certificate{Subject: pkix.Name{CommonName: "stranger"}}
+	if code := post(&tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}); code != http.StatusUnauthorized {
+		t.Errorf("сертификат без пространства и своей роли должен отклоняться, получен %d", code)
+	}
+}
+
+// TestNewRouterRateLimit проверяет, что ограничения считаются по ключу, а не по общему IP
+func TestNewRouterRateLimit(t *testing.T) {
+	admin := "qk_bootstrap-admin-key-for-tests"
+	cfg := config.Config{
+		Static: config.Static{
+			Port: "8080", DBMode: "memory", AuthMode: "apikey", AuthBootstrapKey: admin,
+			RateLimitKey: "key", RateLimitStore: "memory",
+		},
+		Dynamic: config.Dynamic{
+			RateLimits: map[string]config.RateLimit{"POST /quotes": {Requests: 1, Per: time.Hour, Burst: 1}},
+		},
+	}
+	r := newRouter(cfg)
+
+	post := func(token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/quotes", bytes.NewBufferString(`{"author":"A","quote":"Q"}`))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+	if w := post(admin); w.Code != http.StatusCreated || w.Header().Get("RateLimit-Limit") != "1" {
+		t.Fatalf("ожидается 201 с заголовками RateLimit, получено %d %v", w.Code, w.Header())
+	}
+	if w := post(admin); w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("ожидается 429 с Retry-After, получено %d %v", w.Code, w.Header())
+	}
+	// Запрос без ключа с того же адреса считается отдельно и доходит до проверки прав
+	if w := post(""); w.Code != http.StatusUnauthorized {
+		t.Errorf("ожидается 401 для запроса без ключа, получен %d", w.Code)
+	}
+}
+
+// TestNewRouterRequestID проверяет, что идентификатор запроса возвращается и для несуществующих маршрутов
+func TestNewRouterRequestID(t *testing.T) {
+	r := newRouter(config.Config{Static: config.Static{DBMode: "memory"}})
+	for path, code := range map[string]int{"/quotes": http.StatusOK, "/missing": http.StatusNotFound} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != code || w.Header().Get("X-Request-ID") == "" {
+			t.Errorf("%s: ожидается %d и X-Request-ID, получено %d %q", path, code, w.Code, w.Header().Get("X-Request-ID"))
+		}
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPatch, "/quotes", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("ожидается 405, получен %d", w.Code)
+	}
+}
+
+// TestNewRouterMetrics проверяет /metrics на основном порту и его отсутствие при отдельном слушателе
+func TestNewRouterMetrics(t *testing.T) {
+	r := newRouter(config.Config{Static: config.Static{DBMode: "memory"}})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/quotes", nil))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK || !bytes.Contains(w.Body.Bytes(), []byte(`quotes_http_requests_total{method="GET",route="/quotes",status="200"} 1`)) {
+		t.Errorf("ожидаются метрики запросов, получено %d %s", w.Code, w.Body)
+	}
+
+	a := newApp(config.Config{Static: config.Static{DBMode: "memory", MetricsAddr: "127.0.0.1:9090"}})
+	w = httptest.NewRecorder()
+	a.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("при METRICS_ADDR /metrics не должен быть на основном порту, получен %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	a.metrics.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("ожидается 200 от служебного обработчика, получен %d", w.Code)
+	}
+
+	// С авторизацией метрики на основном порту доступны только администратору без привязки к пространству
+	admin := "qk_bootstrap-admin-key-for-tests"
+	r = newRouter(config.Config{Static: config.Static{DBMode: "memory", AuthMode: "apikey", AuthBootstrapKey: admin}})
+	issue := func(body string) string {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/admin/keys", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+admin)
+		r.ServeHTTP(w, req)
+		var issued map[string]any
+		json.Unmarshal(w.Body.Bytes(), &issued)
+		key, _ := issued["key"].(string)
+		return key
+	}
+	for _, tc := range []struct {
+		name, key string
+		want      int
+	}{
+		{"без ключа", "", http.StatusUnauthorized},
+		{"ключ для чтения", issue(`{"name":"viewer","scopes":["quotes:read"]}`), http.StatusForbidden},
+		{"администратор пространства", issue(`{"name":"team","scopes":["admin"],"tenant":"team-a"}`), http.StatusForbidden},
+		{"оператор", admin, http.StatusOK},
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if tc.key != "" {
+			req.Header.Set("Authorization", "Bearer "+tc.key)
+		}
+		r.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("%s: ожидается %d для /metrics, получен %d", tc.name, tc.want, w.Code)
+		}
+	}
+}
+
+// TestProbes проверяет /healthz и /readyz, в том числе после начала остановки
+func TestProbes(t *testing.T) {
+	a := newApp(config.Config{Static: config.Static{DBMode: "sqlite", DBPath: ":memory:"}})
+	for _, path := range []string{"/healthz", "/readyz"} {
+		w := httptest.NewRecorder()
+		a.handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: ожидается 200, получен %d %s", path, w.Code, w.Body)
+		}
+	}
+	w := httptest.NewRecorder()
+	a.handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if !bytes.Contains(w.Body.Bytes(), []byte(`"name":"migrations"`)) {
+		t.Errorf("ожидается проверка миграций, получено %s", w.Body)
+	}
+	w = httptest.NewRecorder()
+	a.handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/quotes", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("запросы API должны доходить до маршрутизатора, получен %d", w.Code)
+	}
+
+	a.health.Shutdown()
+	w = httptest.NewRecorder()
+	a.handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("после начала остановки ожидается 503, получен %d", w.Code)
+	}
+}
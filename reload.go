@@ -0,0 +1,99 @@
+package main
+
+import (
+	"Test_Project_Brand_Scout/internal/config"
+	"Test_Project_Brand_Scout/internal/cors"
+	"Test_Project_Brand_Scout/internal/httplog"
+	"Test_Project_Brand_Scout/internal/ratelimit"
+	"Test_Project_Brand_Scout/internal/servertls"
+	"context"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// logOptions возвращает параметры логирования запросов из динамической конфигурации
+func logOptions(d config.Dynamic) httplog.Options {
+	return httplog.Options{Bodies: d.LogBodies, MaxBody: d.LogBodyMax, Redact: d.LogRedact}
+}
+
+// limiterRules собирает правила ограничителя из RATE_LIMITS и RATE_DAILY_QUOTAS
+func limiterRules(d config.Dynamic) map[string]ratelimit.Rule {
+	rules := make(map[string]ratelimit.Rule)
+	for route, l := range d.RateLimits {
+		rule := rules[route]
+		rule.Rate = float64(l.Requests) / l.Per.Seconds()
+		rule.Burst = l.Burst
+		rules[route] = rule
+	}
+	for route, n := range d.RateDaily {
+		rule := rules[route]
+		rule.Daily = n
+		rules[route] = rule
+	}
+	return rules
+}
+
+// corsOptions возвращает правила CORS из динамической конфигурации
+func corsOptions(d config.Dynamic) cors.Options {
+	return cors.Options{Origins: d.CORSOrigins, Methods: d.CORSMethods, Headers: d.CORSHeaders, MaxAge: d.CORSMaxAge}
+}
+
+// subscribe подписывает компоненты приложения на перезагрузку динамических параметров.
+// Уровень логов проверяется первым: при ошибке остальные компоненты не затрагиваются.
+func (a *app) subscribe(r *config.Reloader, level *slog.LevelVar) {
+	r.Subscribe("log_level", func(d config.Dynamic) error {
+		lvl, err := httplog.ParseLevel(d.LogLevel)
+		if err != nil {
+			return err
+		}
+		level.Set(lvl)
+		return nil
+	})
+	r.Subscribe("httplog", func(d config.Dynamic) error {
+		a.logger.SetOptions(logOptions(d))
+		return nil
+	})
+	r.Subscribe("ratelimit", func(d config.Dynamic) error {
+		a.limiter.SetRules(limiterRules(d))
+		return nil
+	})
+	r.Subscribe("cors", func(d config.Dynamic) error {
+		a.cors.Set(corsOptions(d))
+		return nil
+	})
+	r.Subscribe("features", func(d config.Dynamic) error {
+		a.features.Set(d.Features)
+		return nil
+	})
+}
+
+// reloadOnHangup по SIGHUP перезагружает конфигурацию и сертификаты TLS (certs может быть nil)
+// до отмены ctx. Ошибка одной перезагрузки не мешает другой.
+func reloadOnHangup(ctx context.Context, settings *config.Reloader, certs *servertls.Reloader) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+		}
+		if err := settings.Reload(); err != nil {
+			log.Printf("Не удалось перезагрузить конфигурацию, действует прежняя: %v", err)
+		} else {
+			log.Println("Конфигурация перезагружена по SIGHUP")
+		}
+		if certs == nil {
+			continue
+		}
+		if err := certs.Reload(); err != nil {
+			log.Printf("Не удалось перезагрузить сертификаты TLS: %v", err)
+			continue
+		}
+		log.Println("Сертификаты TLS перезагружены по SIGHUP")
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"Test_Project_Brand_Scout/internal/config"
+)
+
+// TestReloadDynamic проверяет, что перезагрузка файла конфигурации меняет поведение работающего
+// приложения, а некорректный файл оставляет прежние параметры
+func TestReloadDynamic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quotes.yaml")
+	write := func(doc string) {
+		if err := os.WriteFile(path, []byte(doc), 0o600); err != nil {
+			t.Fatalf("ошибка записи конфигурации: %v", err)
+		}
+	}
+	write("features: [read_only]\n")
+	cfg, flags, err := config.Load([]string{"--config", path})
+	if err != nil {
+		t.Fatalf("ошибка загрузки конфигурации: %v", err)
+	}
+	// Хранилище задаётся явно: .env рабочего каталога не должен влиять на тест
+	cfg.DBMode, cfg.RateLimitStore = "memory", "memory"
+	a := newApp(cfg)
+	defer a.Close()
+	settings := config.NewReloader(cfg, flags)
+	level := new(slog.LevelVar)
+	a.subscribe(settings, level)
+
+	post := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/quotes", bytes.NewBufferString(`{"author":"A","quote":"Q"}`))
+		req.Header.Set("Origin", "https://app.example.com")
+		a.handler.ServeHTTP(w, req)
+		return w
+	}
+	if w := post(); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("в режиме read_only ожидается 503, получен %d", w.Code)
+	}
+
+	write(`log_level: debug
+cors_allowed_origins: [https://app.example.com]
+rate_limits:
+  "POST /quotes": 1/h
+`)
+	if err := settings.Reload(); err != nil {
+		t.Fatalf("ошибка Reload: %v", err)
+	}
+	if level.Level() != slog.LevelDebug {
+		t.Errorf("ожидается уровень debug, получен %v", level.Level())
+	}
+	w := post()
+	if w.Code != http.StatusCreated || w.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" {
+		t.Errorf("после снятия read_only ожидается 201 с заголовком CORS, получено %d %v", w.Code, w.Header())
+	}
+	if w := post(); w.Code != http.StatusTooManyRequests {
+		t.Errorf("новое ограничение должно действовать сразу, получен %d", w.Code)
+	}
+
+	write("log_level: verbose\nfeatures: [read_only]\n")
+	if err := settings.Reload(); err == nil {
+		t.Fatal("ожидается ошибка для некорректного уровня логов")
+	}
+	if w := post(); w.Code != http.StatusTooManyRequests || level.Level() != slog.LevelDebug {
+		t.Errorf("после ошибки должны действовать прежние параметры, получено %d, %v", w.Code, level.Level())
+	}
+}
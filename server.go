@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"Test_Project_Brand_Scout/internal/config"
+)
+
+// endpoint — HTTP-сервер и слушатель, на котором он принимает соединения.
+type endpoint struct {
+	srv *http.Server
+	ln  net.Listener
+}
+
+// newServer создаёт HTTP-сервер с таймаутами и ограничением размера заголовков из конфигурации.
+func newServer(cfg config.Config, h http.Handler) *http.Server {
+	return &http.Server{
+		Handler:           h,
+		ReadTimeout:       cfg.HTTPReadTimeout,
+		ReadHeaderTimeout: cfg.HTTPReadHeaderTimeout,
+		WriteTimeout:      cfg.HTTPWriteTimeout,
+		IdleTimeout:       cfg.HTTPIdleTimeout,
+		MaxHeaderBytes:    cfg.HTTPMaxHeaderBytes,
+		ErrorLog:          log.Default(),
+	}
+}
+
+// serve обслуживает запросы на endpoints до отмены ctx или ошибки одного из серверов.
+// При остановке /readyz сразу начинает отвечать 503, через SHUTDOWN_DELAY слушатели закрываются,
+// текущие запросы дорабатывают не дольше SHUTDOWN_TIMEOUT, после чего ресурсы приложения освобождаются.
+func (a *app) serve(ctx context.Context, cfg config.Config, endpoints ...endpoint) error {
+	errc := make(chan error, len(endpoints))
+	for _, ep := range endpoints {
+		go func() {
+			serve := func() error { return ep.srv.Serve(ep.ln) }
+			if ep.srv.TLSConfig != nil {
+				serve = func() error { return ep.srv.ServeTLS(ep.ln, "", "") }
+			}
+			if err := serve(); !errors.Is(err, http.ErrServerClosed) {
+				errc <- err
+			}
+		}()
+	}
+
+	var errs []error
+	select {
+	case <-ctx.Done():
+		log.Println("Получен сигнал остановки, завершаем текущие запросы")
+	case err := <-errc:
+		errs = append(errs, err)
+	}
+	a.health.Shutdown()
+	// Балансировщик успевает увидеть неготовность и перестать направлять новые запросы
+	if len(errs) == 0 && cfg.ShutdownDelay > 0 {
+		time.Sleep(cfg.ShutdownDelay)
+	}
+
+	drain, cancel := context.Background(), context.CancelFunc(func() {})
+	if cfg.ShutdownTimeout > 0 {
+		drain, cancel = context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	}
+	defer cancel()
+	// Служебный сервер останавливается последним, чтобы пробы и метрики были доступны во время остановки
+	for _, ep := range endpoints {
+		if err := ep.srv.Shutdown(drain); err != nil {
+			errs = append(errs, fmt.Errorf("не все запросы завершились за %s: %w", cfg.ShutdownTimeout, err))
+			ep.srv.Close()
+		}
+	}
+	errs = append(errs, a.Close())
+	return errors.Join(errs...)
+}
+
+// Close сохраняет накопленные счётчики показов и закрывает хранилища цитат и ограничителя.
+func (a *app) Close() error {
+	if a.stopFlusher != nil {
+		close(a.stopFlusher)
+		<-a.flushed
+	}
+	var errs []error
+	if a.limiter != nil {
+		errs = append(errs, a.limiter.Close())
+	}
+	errs = append(errs, a.repo.Close())
+	return errors.Join(errs...)
+}
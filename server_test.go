@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"Test_Project_Brand_Scout/internal/config"
+)
+
+// startServe запускает a.serve с обработчиком h и возвращает адрес сервера и канал с результатом serve
+func startServe(t *testing.T, ctx context.Context, a *app, cfg config.Config, h http.Handler) (string, <-chan error) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ошибка Listen: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- a.serve(ctx, cfg, endpoint{srv: newServer(cfg, h), ln: ln}) }()
+	return "http://" + ln.Addr().String(), done
+}
+
+// slowHandler задерживает ответ на /slow до закрытия release и передаёт остальные запросы next
+func slowHandler(next http.Handler, started chan<- struct{}, release <-chan struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/slow" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		close(started)
+		<-release
+		w.Write([]byte("ok"))
+	})
+}
+
+// TestServeDrain проверяет, что остановка дожидается текущего запроса, снимает готовность и закрывает хранилище
+func TestServeDrain(t *testing.T) {
+	cfg := config.Config{Static: config.Static{DBMode: "sqlite", DBPath: filepath.Join(t.TempDir(), "quotes.db"), ShutdownTimeout: 5 * time.Second}}
+	a := newApp(cfg)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	started, release := make(chan struct{}), make(chan struct{})
+	addr, done := startServe(t, ctx, a, cfg, slowHandler(a.handler, started, release))
+
+	resp := make(chan *http.Response, 1)
+	go func() {
+		res, err := http.Get(addr + "/slow")
+		if err != nil {
+			t.Errorf("текущий запрос не должен обрываться: %v", err)
+		}
+		resp <- res
+	}()
+	<-started
+	cancel()
+
+	// Пока запрос выполняется, готовность уже снята
+	deadline := time.Now().Add(time.Second)
+	for {
+		w := httptest.NewRecorder()
+		a.handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		if w.Code == http.StatusServiceUnavailable {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("после сигнала /readyz должен отвечать 503, получен %d", w.Code)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	select {
+	case err := <-done:
+		t.Fatalf("serve не должен завершаться до окончания запроса: %v", err)
+	default:
+	}
+
+	close(release)
+	if res := <-resp; res == nil || res.StatusCode != http.StatusOK {
+		t.Fatalf("ожидается 200 для текущего запроса, получено %v", res)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("ожидается остановка без ошибок, получено %v", err)
+	}
+	if _, err := a.repo.GetAll(); err == nil {
+		t.Error("после остановки хранилище должно быть закрыто")
+	}
+}
+
+// TestServeDeadline проверяет, что зависший запрос не задерживает остановку дольше SHUTDOWN_TIMEOUT
+func TestServeDeadline(t *testing.T) {
+	cfg := config.Config{Static: config.Static{DBMode: "memory", ShutdownTimeout: 50 * time.Millisecond}}
+	a := newApp(cfg)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	addr, done := startServe(t, ctx, a, cfg, slowHandler(a.handler, started, release))
+
+	go http.Get(addr + "/slow")
+	<-started
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("ожидается ошибка истечения срока, получено %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("остановка должна завершаться по истечении SHUTDOWN_TIMEOUT")
+	}
+}
+
+// TestNewServerLimits проверяет ограничение размера заголовков
+func TestNewServerLimits(t *testing.T) {
+	cfg := config.Config{Static: config.Static{DBMode: "memory", HTTPReadHeaderTimeout: time.Second, HTTPMaxHeaderBytes: 1024}}
+	a := newApp(cfg)
+	ctx, cancel := context.WithCancel(context.Background())
+	addr, done := startServe(t, ctx, a, cfg, a.handler)
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	req, _ := http.NewRequest(http.MethodGet, addr+"/healthz", nil)
+	req.Header.Set("X-Large", strings.Repeat("a", 16<<10))
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("ошибка запроса: %v", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusRequestHeaderFieldsTooLarge {
+		t.Errorf("ожидается 431 для слишком больших заголовков, получен %d", res.StatusCode)
+	}
+}